@@ -0,0 +1,213 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"linkedin-automation/config"
+)
+
+// ErrUnknownProvider is returned for an ai.provider other than openai or anthropic
+var ErrUnknownProvider = errors.New("unknown AI provider")
+
+// ErrNoteTooLong is returned when the model's note can't be cut to fit the limit
+var ErrNoteTooLong = errors.New("generated note exceeds length limit")
+
+// ErrEmptyNote is returned when the model answered with no usable text
+var ErrEmptyNote = errors.New("generated note is empty")
+
+// DefaultPrompt is used when ai.prompt is empty
+const DefaultPrompt = `Write a short, friendly LinkedIn connection note to the person below.
+Mention one specific detail from their headline or about section.
+No hashtags, no emoji, no sales pitch, no placeholders. Reply with the note only.`
+
+// Provider defaults
+var defaults = map[string]struct{ baseURL, model string }{
+	"openai":    {"https://api.openai.com/v1", "gpt-4o-mini"},
+	"anthropic": {"https://api.anthropic.com/v1", "claude-3-5-haiku-latest"},
+}
+
+// anthropicVersion is the API version header Anthropic requires
+const anthropicVersion = "2023-06-01"
+
+// Profile is what the model gets to see of the recipient
+type Profile struct {
+	Name     string
+	Headline string
+	About    string
+}
+
+// NoteWriter generates connection notes with an LLM
+type NoteWriter struct {
+	Provider string
+	APIKey   string
+	Model    string
+	Prompt   string
+	BaseURL  string
+
+	client *http.Client
+}
+
+// New creates a NoteWriter from the ai config section.
+// It returns nil when no provider is configured.
+func New(cfg *config.Config) (*NoteWriter, error) {
+	c := cfg.AI
+	if c.Provider == "" {
+		return nil, nil
+	}
+	d, ok := defaults[c.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (want openai or anthropic)", ErrUnknownProvider, c.Provider)
+	}
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("ai.api_key is required for provider %q", c.Provider)
+	}
+
+	w := &NoteWriter{
+		Provider: c.Provider,
+		APIKey:   c.APIKey,
+		Model:    c.Model,
+		Prompt:   c.Prompt,
+		BaseURL:  strings.TrimSuffix(c.BaseURL, "/"),
+		client:   &http.Client{Timeout: c.Timeout},
+	}
+	if w.Model == "" {
+		w.Model = d.model
+	}
+	if w.Prompt == "" {
+		w.Prompt = DefaultPrompt
+	}
+	if w.BaseURL == "" {
+		w.BaseURL = d.baseURL
+	}
+	return w, nil
+}
+
+// Note asks the model for a note about p of at most maxLen characters.
+// A longer answer is cut back to its last full sentence; if none fits,
+// ErrNoteTooLong is returned so the caller can fall back to a template.
+func (w *NoteWriter) Note(ctx context.Context, p Profile, maxLen int) (string, error) {
+	prompt := fmt.Sprintf("%s\nKeep it under %d characters.\n\nName: %s\nHeadline: %s\nAbout: %s",
+		w.Prompt, maxLen, p.Name, p.Headline, p.About)
+
+	var (
+		text string
+		err  error
+	)
+	if w.Provider == "anthropic" {
+		text, err = w.anthropic(ctx, prompt)
+	} else {
+		text, err = w.openai(ctx, prompt)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fit(clean(text), maxLen)
+}
+
+func (w *NoteWriter) openai(ctx context.Context, prompt string) (string, error) {
+	req := map[string]any{
+		"model":      w.Model,
+		"max_tokens": 200,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + w.APIKey}
+	if err := w.post(ctx, "/chat/completions", headers, req, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrEmptyNote
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (w *NoteWriter) anthropic(ctx context.Context, prompt string) (string, error) {
+	req := map[string]any{
+		"model":      w.Model,
+		"max_tokens": 200,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+	}
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	headers := map[string]string{"x-api-key": w.APIKey, "anthropic-version": anthropicVersion}
+	if err := w.post(ctx, "/messages", headers, req, &resp); err != nil {
+		return "", err
+	}
+	for _, c := range resp.Content {
+		if c.Type == "text" {
+			return c.Text, nil
+		}
+	}
+	return "", ErrEmptyNote
+}
+
+// post sends body as JSON to the provider and decodes the answer into out
+func (w *NoteWriter) post(ctx context.Context, path string, headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.BaseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s API: unexpected status %s: %s", w.Provider, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s API: invalid response: %w", w.Provider, err)
+	}
+	return nil
+}
+
+// clean strips the quotes and whitespace models like to wrap notes in
+func clean(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.Trim(text, "\"'“”")
+	return strings.TrimSpace(text)
+}
+
+// fit keeps text within maxLen characters, cutting at a sentence end
+func fit(text string, maxLen int) (string, error) {
+	if text == "" {
+		return "", ErrEmptyNote
+	}
+	runes := []rune(text)
+	if maxLen <= 0 || len(runes) <= maxLen {
+		return text, nil
+	}
+	cut := string(runes[:maxLen])
+	if i := strings.LastIndexAny(cut, ".!?"); i > 0 {
+		return strings.TrimSpace(cut[:i+1]), nil
+	}
+	return "", fmt.Errorf("%w: %d > %d characters", ErrNoteTooLong, len(runes), maxLen)
+}
@@ -0,0 +1,451 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linkedin-automation/logger"
+	"linkedin-automation/storage"
+)
+
+// ErrUnknownCommand is returned when a job asks for a workflow the server can't run
+var ErrUnknownCommand = errors.New("command can't be run through the API")
+
+// Job states
+const (
+	Queued    = "queued"
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	Cancelled = "cancelled"
+)
+
+// maxJobs is how many finished jobs are kept for GET /api/jobs
+const maxJobs = 100
+
+// JobRequest asks for one workflow run. Empty search fields keep the values
+// the server was started with; a campaign .yaml file brings its own.
+type JobRequest struct {
+	Command  string   `json:"command"`
+	Campaign string   `json:"campaign,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+
+	Keywords string `json:"keywords,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Company  string `json:"company,omitempty"`
+	Location string `json:"location,omitempty"`
+	Industry string `json:"industry,omitempty"`
+	Network  string `json:"network,omitempty"`
+	Pages    int    `json:"pages,omitempty"`
+}
+
+// Job is a queued, running or finished JobRequest
+type Job struct {
+	ID         int        `json:"id"`
+	Request    JobRequest `json:"request"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  time.Time  `json:"started_at,omitzero"`
+	FinishedAt time.Time  `json:"finished_at,omitzero"`
+}
+
+// Server exposes the bot over HTTP: jobs are queued and run one at a time by
+// Run, on the process's single browser session
+type Server struct {
+	Store *storage.MemoryStore
+	Log   logger.Logger
+	Logs  *LogStream
+
+	// Commands are the workflows jobs may run
+	Commands map[string]bool
+	// Validate, when set, rejects a request before it is queued (bad campaign file...)
+	Validate func(JobRequest) error
+	// Token, when set, must be sent as "Authorization: Bearer <token>" or,
+	// from a browser, as ?token=
+	Token string
+	// Dashboard, when set, is served at /
+	Dashboard http.Handler
+
+	mu     sync.Mutex
+	jobs   []*Job
+	nextID int
+	wake   chan struct{}
+}
+
+// New creates a Server; logs may be nil when log streaming isn't wanted
+func New(store *storage.MemoryStore, l logger.Logger, logs *LogStream, commands map[string]bool) *Server {
+	return &Server{
+		Store:    store,
+		Log:      l,
+		Logs:     logs,
+		Commands: commands,
+		nextID:   1,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Enqueue validates and queues a request
+func (s *Server) Enqueue(r JobRequest) (Job, error) {
+	if !s.Commands[r.Command] {
+		return Job{}, fmt.Errorf("%w: %q", ErrUnknownCommand, r.Command)
+	}
+	if s.Validate != nil {
+		if err := s.Validate(r); err != nil {
+			return Job{}, err
+		}
+	}
+
+	s.mu.Lock()
+	j := &Job{ID: s.nextID, Request: r, Status: Queued, QueuedAt: time.Now()}
+	s.nextID++
+	s.jobs = append(s.jobs, j)
+	s.prune()
+	job := *j
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	s.Log.Info("Job queued", "id", job.ID, "command", r.Command, "campaign", r.Campaign)
+	return job, nil
+}
+
+// prune drops the oldest finished jobs beyond maxJobs; s.mu must be held
+func (s *Server) prune() {
+	excess := len(s.jobs) - maxJobs
+	if excess <= 0 {
+		return
+	}
+	kept := s.jobs[:0]
+	for _, j := range s.jobs {
+		if excess > 0 && j.Status != Queued && j.Status != Running {
+			excess--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	s.jobs = kept
+}
+
+// Jobs returns every known job, newest first
+func (s *Server) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0, len(s.jobs))
+	for i := len(s.jobs) - 1; i >= 0; i-- {
+		out = append(out, *s.jobs[i])
+	}
+	return out
+}
+
+// Job returns the job with the given id
+func (s *Server) Job(id int) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.ID == id {
+			return *j, true
+		}
+	}
+	return Job{}, false
+}
+
+// next marks the oldest queued job as running and returns it
+func (s *Server) next() (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Status == Queued {
+			j.Status = Running
+			j.StartedAt = time.Now()
+			return j, true
+		}
+	}
+	return nil, false
+}
+
+// finish records a job's outcome
+func (s *Server) finish(j *Job, status string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.Status = status
+	j.FinishedAt = time.Now()
+	if err != nil {
+		j.Error = err.Error()
+	}
+}
+
+// Run executes queued jobs one at a time with exec until ctx is cancelled.
+// Jobs still queued then are marked cancelled.
+func (s *Server) Run(ctx context.Context, exec func(JobRequest) error) {
+	for ctx.Err() == nil {
+		j, ok := s.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+			case <-s.wake:
+			}
+			continue
+		}
+
+		s.Log.Info("Running job", "id", j.ID, "command", j.Request.Command)
+		err := exec(j.Request)
+		switch {
+		case err != nil:
+			s.Log.Error("Job failed", "id", j.ID, "command", j.Request.Command, "error", err)
+			s.finish(j, Failed, err)
+		case ctx.Err() != nil:
+			s.finish(j, Cancelled, ctx.Err())
+		default:
+			s.Log.Info("Job finished", "id", j.ID, "command", j.Request.Command)
+			s.finish(j, Succeeded, nil)
+		}
+	}
+
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.Status == Queued {
+			j.Status = Cancelled
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Handler returns the API routes, behind the token check when one is set
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/jobs", s.handleJobs)
+	mux.HandleFunc("GET /api/jobs/{id}", s.handleJob)
+	mux.HandleFunc("POST /api/jobs", s.handleEnqueue(""))
+	mux.HandleFunc("POST /api/campaigns", s.handleEnqueue("connect"))
+	mux.HandleFunc("POST /api/follow-ups", s.handleEnqueue("message"))
+	mux.HandleFunc("GET /api/profiles", s.handleProfiles)
+	mux.HandleFunc("GET /api/logs", s.handleLogs)
+	if s.Dashboard != nil {
+		mux.Handle("GET /{$}", s.Dashboard)
+	}
+	return s.authorize(sameOrigin(mux))
+}
+
+// Serve starts the API in the background
+func (s *Server) Serve(addr string) {
+	go func() {
+		s.Log.Info("API listening", "addr", addr, "token", s.Token != "")
+		if err := http.ListenAndServe(addr, s.Handler()); err != nil {
+			s.Log.Error("API stopped", "error", err)
+		}
+	}()
+}
+
+// NewToken returns a random bearer token for a server started without one
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authorize rejects requests without the bearer token
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.Token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin rejects writes a web page could send cross-site: every POST
+// must be JSON (a content type that needs a CORS preflight the API never
+// grants) and, from a browser, come from the API's own origin
+func sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("requests must be sent as Content-Type: application/json"))
+				return
+			}
+			if origin := r.Header.Get("Origin"); origin != "" {
+				if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+					writeError(w, http.StatusForbidden, fmt.Errorf("cross-origin request from %s refused", origin))
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	queued, running := 0, 0
+	for _, j := range s.Jobs() {
+		switch j.Status {
+		case Queued:
+			queued++
+		case Running:
+			running++
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"stats":        s.Store.Stats(),
+		"jobs_queued":  queued,
+		"jobs_running": running,
+	})
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Jobs())
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job id %q", r.PathValue("id")))
+		return
+	}
+	j, ok := s.Job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+// handleEnqueue queues the posted request; command, when set, is forced
+func (s *Server) handleEnqueue(command string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req JobRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+				return
+			}
+		}
+		if command != "" {
+			req.Command = command
+		}
+		j, err := s.Enqueue(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, j)
+	}
+}
+
+// profileFilters select records for GET /api/profiles?status=...
+var profileFilters = map[string]func(storage.Record) bool{
+	"all":       func(storage.Record) bool { return true },
+	"sent":      func(r storage.Record) bool { return !r.RequestedAt.IsZero() },
+	"pending":   func(r storage.Record) bool { return !r.RequestedAt.IsZero() && r.ConnectedAt.IsZero() },
+	"connected": func(r storage.Record) bool { return !r.ConnectedAt.IsZero() },
+	"messaged":  func(r storage.Record) bool { return !r.MessagedAt.IsZero() },
+	"withdrawn": func(r storage.Record) bool { return !r.WithdrawnAt.IsZero() },
+}
+
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "all"
+	}
+	keep, ok := profileFilters[status]
+	if !ok {
+		names := make([]string, 0, len(profileFilters))
+		for name := range profileFilters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown status %q (want one of %s)", status, strings.Join(names, ", ")))
+		return
+	}
+	campaign := r.URL.Query().Get("campaign")
+
+	records := []storage.Record{}
+	for _, rec := range s.Store.Records() {
+		if keep(rec) && (campaign == "" || slices.Contains(rec.Campaigns, campaign)) {
+			records = append(records, rec)
+		}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// handleLogs streams log lines as server-sent events, starting with the
+// last ?tail= lines (default 100)
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if s.Logs == nil {
+		writeError(w, http.StatusNotFound, errors.New("log streaming is not enabled"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+	tail := 100
+	if v := r.URL.Query().Get("tail"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid tail %q", v))
+			return
+		}
+		tail = n
+	}
+
+	recent, lines, cancel := s.Logs.Subscribe(tail)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for _, line := range recent {
+		fmt.Fprintf(w, "data: %s\n\n", line)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-lines:
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LogStream is an io.Writer for the logger that keeps the latest lines and
+// fans new ones out to subscribers (GET /api/logs)
+type LogStream struct {
+	mu      sync.Mutex
+	lines   []string
+	max     int
+	partial []byte
+	subs    map[chan string]bool
+}
+
+// NewLogStream keeps up to max recent lines
+func NewLogStream(max int) *LogStream {
+	return &LogStream{max: max, subs: make(map[chan string]bool)}
+}
+
+// Write splits p into lines; an unfinished last line waits for the next write
+func (l *LogStream) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data := append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		l.publish(string(data[:i]))
+		data = data[i+1:]
+	}
+	l.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// publish stores a line and hands it to subscribers; slow ones miss lines
+// rather than blocking the bot
+func (l *LogStream) publish(line string) {
+	l.lines = append(l.lines, line)
+	if len(l.lines) > l.max {
+		l.lines = l.lines[len(l.lines)-l.max:]
+	}
+	for ch := range l.subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// Tail returns up to n of the most recent lines, oldest first
+func (l *LogStream) Tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tail(n)
+}
+
+func (l *LogStream) tail(n int) []string {
+	if n > len(l.lines) {
+		n = len(l.lines)
+	}
+	return append([]string(nil), l.lines[len(l.lines)-n:]...)
+}
+
+// Subscribe returns up to tail recent lines and a channel receiving the lines
+// written after them; cancel stops the subscription
+func (l *LogStream) Subscribe(tail int) (recent []string, lines <-chan string, cancel func()) {
+	ch := make(chan string, 64)
+	l.mu.Lock()
+	recent = l.tail(tail)
+	l.subs[ch] = true
+	l.mu.Unlock()
+	return recent, ch, func() {
+		l.mu.Lock()
+		delete(l.subs, ch)
+		l.mu.Unlock()
+	}
+}
@@ -3,17 +3,21 @@ package auth
 import (
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/go-rod/rod/lib/proto"
-
 	"linkedin-automation/browser"
+	"linkedin-automation/checkpoint"
 	"linkedin-automation/config"
 	"linkedin-automation/logger"
 	"linkedin-automation/stealth"
 )
 
+// ErrCheckpoint is returned when LinkedIn shows a security checkpoint or 2FA prompt
+var ErrCheckpoint = errors.New("manual intervention required: 2FA/checkpoint detected")
+
+// reauthSelector matches the mid-session "Verify it's you" password prompt
+const reauthSelector = `div[role="dialog"] input[type="password"], form[action*="reauth"] input[type="password"]`
+
 // Authenticator handles login and session management
 type Authenticator struct {
 	Browser *browser.Browser
@@ -100,28 +104,7 @@ func (a *Authenticator) Login() error {
 	}
 
 	// Password
-	a.Log.Info("Entering password")
-	passField, err := a.Browser.Page.Element("#password")
-	if err != nil {
-		// Fallback
-		passField, err = a.Browser.Page.Element(`input[name="session_password"]`)
-		if err != nil {
-			return errors.New("password field not found")
-		}
-	}
-	if err := passField.WaitVisible(); err != nil {
-		return fmt.Errorf("password field not visible: %w", err)
-	}
-	/*
-		if err := passField.Click(proto.InputMouseButtonLeft, 1); err != nil {
-			return err
-		}
-		if err := a.Browser.HumanType(passField, pass); err != nil {
-			return err
-		}
-	*/
-	// Fallback to reliable input
-	if err := passField.Input(pass); err != nil {
+	if err := a.fillPassword(pass); err != nil {
 		return err
 	}
 
@@ -136,11 +119,7 @@ func (a *Authenticator) Login() error {
 	}
 
 	a.Log.Info("Submitting login form")
-	if err := a.Browser.HumanMove(signInBtn); err != nil {
-		signInBtn.Click(proto.InputMouseButtonLeft, 1)
-	} else {
-		signInBtn.Click(proto.InputMouseButtonLeft, 1)
-	}
+	a.Browser.Click(signInBtn)
 
 	// 4. Verification Check
 	a.Log.Info("Waiting for navigation...")
@@ -159,6 +138,7 @@ func (a *Authenticator) Login() error {
 	// challengeSelector := "#app__container" -- removed unused
 
 	// Simple polling loop for 30 seconds
+	totpTried := false
 	startTime := time.Now()
 	for time.Since(startTime) < 30*time.Second {
 		// Check Success
@@ -179,12 +159,26 @@ func (a *Authenticator) Login() error {
 			return fmt.Errorf("login failed: %s", text)
 		}
 
-		// Check Challenge (Security Checkpoint)
-		// Often checks for "Let's do a quick security check" text
-		if strings.Contains(a.Browser.Page.MustInfo().Title, "Security Verification") ||
-			strings.Contains(a.Browser.Page.MustInfo().Title, "Challenge") {
+		// Check Challenge (Security Checkpoint), a 2FA code prompt is answered once if a TOTP secret is set
+		if a.checkpointDetected() {
+			if !totpTried {
+				totpTried = true
+				if handled, err := a.submitTOTP(); handled {
+					if err != nil {
+						return fmt.Errorf("two-step verification failed: %w", err)
+					}
+					// The challenge URL lingers until LinkedIn redirects
+					a.waitCheckpointCleared(15 * time.Second)
+					continue
+				}
+			}
+			// In headful mode the challenge handler waits for it to be solved by hand
+			if a.Browser.Challenge != nil && a.Browser.Challenge() == nil {
+				startTime = time.Now()
+				continue
+			}
 			a.Log.Warn("Security checkpoint/2FA detection! Manual intervention required.")
-			return errors.New("manual intervention required: 2FA/checkpoint detected")
+			return ErrCheckpoint
 		}
 
 		time.Sleep(500 * time.Millisecond)
@@ -192,3 +186,83 @@ func (a *Authenticator) Login() error {
 
 	return errors.New("timeout waiting for login result")
 }
+
+// HandleReauth detects the mid-session "Verify it's you" password prompt and,
+// if credentials are configured, fills and submits it.
+// Returns true if a prompt was found.
+func (a *Authenticator) HandleReauth() (bool, error) {
+	if has, _, _ := a.Browser.Page.Has(reauthSelector); !has {
+		return false, nil
+	}
+
+	a.Log.Warn("Re-authentication prompt detected")
+	if !a.Config.AutoReauth || a.Config.LinkedIn.Password == "" {
+		return true, errors.New("re-authentication required but auto_reauth is disabled or password missing")
+	}
+
+	passField, err := a.Browser.Page.Element(reauthSelector)
+	if err != nil {
+		return true, err
+	}
+	if err := passField.Input(a.Config.LinkedIn.Password); err != nil {
+		return true, err
+	}
+
+	stealth.SleepContextual(stealth.ActionTypeThink, 0.5)
+
+	submitBtn, err := a.Browser.Page.Element(`div[role="dialog"] button[type="submit"], form[action*="reauth"] button[type="submit"]`)
+	if err != nil {
+		return true, errors.New("re-authentication submit button not found")
+	}
+	a.Browser.Click(submitBtn)
+
+	// Give LinkedIn a moment to either close the prompt or escalate to 2FA
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	if a.checkpointDetected() {
+		if handled, err := a.submitTOTP(); handled && err == nil {
+			stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+		}
+		if a.checkpointDetected() {
+			a.Log.Warn("Re-authentication escalated to a security checkpoint")
+			return true, ErrCheckpoint
+		}
+	}
+	if has, _, _ := a.Browser.Page.Has(reauthSelector); has {
+		return true, errors.New("re-authentication prompt still present after submit")
+	}
+
+	a.Log.Info("Re-authentication successful")
+	return true, nil
+}
+
+// fillPassword locates the login password field and enters pass
+func (a *Authenticator) fillPassword(pass string) error {
+	a.Log.Info("Entering password")
+	passField, err := a.Browser.Page.Element("#password")
+	if err != nil {
+		// Fallback
+		passField, err = a.Browser.Page.Element(`input[name="session_password"]`)
+		if err != nil {
+			return errors.New("password field not found")
+		}
+	}
+	if err := passField.WaitVisible(); err != nil {
+		return fmt.Errorf("password field not visible: %w", err)
+	}
+	// Reliable input instead of HumanType, see username above
+	return passField.Input(pass)
+}
+
+// checkpointDetected reports whether the current page is a security checkpoint
+func (a *Authenticator) checkpointDetected() bool {
+	return checkpoint.Detect(a.Browser.Page) != checkpoint.None
+}
+
+// waitCheckpointCleared waits up to timeout for the page to leave a checkpoint
+func (a *Authenticator) waitCheckpointCleared(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for a.checkpointDetected() && time.Now().Before(deadline) {
+		time.Sleep(500 * time.Millisecond)
+	}
+}
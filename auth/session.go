@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ErrBadSessionKey is returned when a session file can't be decrypted with the configured key
+var ErrBadSessionKey = errors.New("session file could not be decrypted (wrong key or corrupted file)")
+
+// sessionURLs scopes which cookies are exported
+var sessionURLs = []string{"https://www.linkedin.com", "https://linkedin.com"}
+
+// Session files start with sessionMagic and a random salt the key is derived
+// with; files without it are from before the key derivation and use the
+// passphrase's plain SHA-256
+const (
+	sessionMagic      = "LISESS1\n"
+	sessionSaltSize   = 16
+	sessionIterations = 600000
+)
+
+// ExportSession writes the LinkedIn cookies (li_at, JSESSIONID, ...) to path,
+// encrypted with AES-GCM under linkedin.session_key
+func (a *Authenticator) ExportSession(path string) error {
+	cookies, err := a.Browser.Page.Cookies(sessionURLs)
+	if err != nil {
+		return fmt.Errorf("failed to read cookies: %w", err)
+	}
+	plain, err := json.Marshal(cookies)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, sessionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := sessionCipher(a.Config.LinkedIn.SessionKey, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	header := append([]byte(sessionMagic), salt...)
+	sealed := gcm.Seal(append(header, nonce...), nonce, plain, nil)
+
+	if err := os.WriteFile(path, sealed, 0600); err != nil {
+		return err
+	}
+	a.Log.Info("Session exported", "file", path, "cookies", len(cookies))
+	return nil
+}
+
+// ImportSession restores cookies written by ExportSession into the browser
+func (a *Authenticator) ImportSession(path string) error {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var salt []byte
+	if rest, ok := bytes.CutPrefix(sealed, []byte(sessionMagic)); ok {
+		if len(rest) < sessionSaltSize {
+			return ErrBadSessionKey
+		}
+		salt, sealed = rest[:sessionSaltSize], rest[sessionSaltSize:]
+	}
+	gcm, err := sessionCipher(a.Config.LinkedIn.SessionKey, salt)
+	if err != nil {
+		return err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return ErrBadSessionKey
+	}
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return ErrBadSessionKey
+	}
+
+	var cookies []*proto.NetworkCookie
+	if err := json.Unmarshal(plain, &cookies); err != nil {
+		return fmt.Errorf("invalid session file: %w", err)
+	}
+	if err := a.Browser.Page.SetCookies(proto.CookiesToParams(cookies)); err != nil {
+		return fmt.Errorf("failed to set cookies: %w", err)
+	}
+	a.Log.Info("Session imported", "file", path, "cookies", len(cookies))
+	return nil
+}
+
+// sessionCipher derives an AES-256-GCM cipher from the passphrase with
+// PBKDF2 over salt. A nil salt reads files written before key derivation.
+func sessionCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("session key is not set")
+	}
+	var key []byte
+	if salt == nil {
+		legacy := sha256.Sum256([]byte(passphrase))
+		key = legacy[:]
+	} else {
+		var err error
+		if key, err = pbkdf2.Key(sha256.New, passphrase, salt, sessionIterations, 32); err != nil {
+			return nil, err
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
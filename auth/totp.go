@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/stealth"
+)
+
+// totpInputSelector matches the verification code field on LinkedIn's two-step page
+const totpInputSelector = `input[name="pin"], input#input__phone_verification_pin, input[autocomplete="one-time-code"]`
+
+// TOTP returns the RFC 6238 six-digit code for a base32 secret at time t
+func TOTP(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
+
+// submitTOTP fills the two-step verification field with the current code.
+// Returns false if no secret is configured or the field isn't on the page.
+func (a *Authenticator) submitTOTP() (bool, error) {
+	secret := a.Config.LinkedIn.TOTPSecret
+	if secret == "" {
+		return false, nil
+	}
+	field, err := a.Browser.Page.Timeout(5 * time.Second).Element(totpInputSelector)
+	if err != nil {
+		return false, nil
+	}
+
+	// A code about to expire may be rejected by the time it's submitted
+	if time.Now().Unix()%30 > 25 {
+		time.Sleep(time.Duration(30-time.Now().Unix()%30) * time.Second)
+	}
+	code, err := TOTP(secret, time.Now())
+	if err != nil {
+		return true, err
+	}
+
+	a.Log.Info("Entering two-step verification code")
+	if err := a.Browser.HumanType(field, code); err != nil {
+		return true, err
+	}
+	stealth.SleepContextual(stealth.ActionTypeThink, 0.5)
+
+	submitBtn, err := a.Browser.Page.Element(`#two-step-submit-button, form button[type="submit"]`)
+	if err != nil {
+		return true, errors.New("verification submit button not found")
+	}
+	a.Browser.Click(submitBtn)
+	return true, nil
+}
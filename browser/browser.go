@@ -2,16 +2,20 @@ package browser
 
 import (
 	"fmt"
-	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/go-rod/stealth"
 
 	"linkedin-automation/config"
 	"linkedin-automation/logger"
+	"linkedin-automation/selectors"
 	"linkedin-automation/utils"
 )
 
@@ -21,23 +25,108 @@ type Browser struct {
 	Page       *rod.Page
 	Log        logger.Logger
 	Cfg        *config.Config
+	// Sel holds the element fallback chains; nil uses the built-in ones
+	Sel        *selectors.Set
 	LastMouseX float64
 	LastMouseY float64
+
+	// mouseMu serialises idle mouse movements with PauseIdle; idlePaused
+	// counts active pauses, idleStop stops the idle goroutine and idleDone
+	// is closed once it has returned
+	mouseMu    sync.Mutex
+	idlePaused atomic.Int32
+	idleStop   chan struct{}
+	idleDone   chan struct{}
+
+	// Proxies is nil when no proxy is configured
+	Proxies *ProxyManager
+
+	// remote is set when attached to a browser the bot didn't launch; only
+	// the bot's own tab is closed then
+	remote bool
+
+	// Challenge, when set, is called after every navigation to detect and wait
+	// out security challenges; its error is returned by NavigateTo
+	Challenge func() error
 }
 
 // New initializes a new Browser instance with stealth settings
 func New(cfg *config.Config, log logger.Logger) (*Browser, error) {
+	if cfg.Browser.RemoteURL != "" {
+		return attach(cfg, log)
+	}
+
+	proxies := NewProxyManager(cfg, log)
+	proxy := ""
+	if proxies != nil {
+		if _, err := proxies.CheckAll(); err != nil {
+			return nil, err
+		}
+		proxy = proxies.Current()
+	}
+
+	browser, page, err := launch(cfg, log, proxy)
+	if err != nil {
+		return nil, err
+	}
+	b := &Browser{
+		RodBrowser: browser,
+		Page:       page,
+		Log:        log,
+		Cfg:        cfg,
+		Proxies:    proxies,
+	}
+	if cfg.Browser.IdleMouse {
+		b.StartIdle()
+	}
+	return b, nil
+}
+
+// launch starts Chrome through the given proxy (empty for none) and opens the stealth page
+func launch(cfg *config.Config, log logger.Logger, proxy string) (*rod.Browser, *rod.Page, error) {
+	// Persistent fingerprint: the same account always shows the same
+	// screen, platform and user agent
+	fp, created, err := LoadFingerprint(cfg.Browser.FingerprintFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if created {
+		log.Info("Generated browser fingerprint", "file", cfg.Browser.FingerprintFile, "platform", fp.Platform)
+	}
+	if cfg.UserAgent != "" {
+		fp.UserAgent = cfg.UserAgent
+	}
+
 	// 1. Lifecycle Management: Use custom launcher
 	l := launcher.New().
-		Headless(cfg.Headless).
 		Devtools(true) // Open devtools by default for debugging if headful
 
+	// Headful, on a virtual display or new headless, see setDisplay
+	mode, err := setDisplay(l, cfg, log, fp)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if cfg.UserDataDir != "" {
 		l.UserDataDir(cfg.UserDataDir)
 	}
 
-	if cfg.ProxyURL != "" {
-		l.Proxy(cfg.ProxyURL)
+	if proxy != "" {
+		l.Proxy(proxy)
+	}
+
+	if cfg.ChromeBinary != "" {
+		l.Bin(cfg.ChromeBinary)
+	}
+
+	// Extra flags like --no-sandbox or --disable-dev-shm-usage for containers
+	for _, f := range cfg.ChromeFlags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(f, "-"), "=")
+		if hasValue {
+			l.Set(flags.Flag(name), value)
+		} else {
+			l.Set(flags.Flag(name))
+		}
 	}
 
 	// 2. Headful mode is implied if Headless is false in config
@@ -52,59 +141,86 @@ func New(cfg *config.Config, log logger.Logger) (*Browser, error) {
 
 	url, err := l.Launch()
 	if err != nil {
-		return nil, fmt.Errorf("failed to launch browser: %w", err)
+		return nil, nil, fmt.Errorf("failed to launch browser: %w", err)
 	}
 
 	browser := rod.New().ControlURL(url).MustConnect()
 
+	// A user agent claiming another Chrome than the one running is easy to spot
+	if cfg.UserAgent == "" {
+		if v, err := browser.Version(); err != nil {
+			log.Warn("Failed to read the browser version, keeping the fingerprint's", "error", err)
+		} else {
+			fp.matchVersion(v.Product)
+		}
+	}
+	if !fp.hostPlatform() {
+		log.Warn("Fingerprint platform differs from this machine, delete the fingerprint file to generate a matching one", "platform", fp.Platform, "file", cfg.Browser.FingerprintFile)
+	}
+
 	// Create a new page (or use the default one)
 	// We'll use MustPage to get the initial page
 	page := browser.MustPage()
 
-	// 5. Random Viewport
-	// Generate random dimensions between reasonable desktop sizes
-	width := 1024 + rand.Intn(1920-1024)
-	height := 768 + rand.Intn(1080-768)
-
 	// Apply stealth
 	// stealth.JS includes standard stealth scripts
 	// We can also configure specific evasions if needed.
 	// rod-stealth automatically handles navigator.webdriver and other common leaks.
 	page.MustEvalOnNewDocument(stealth.JS)
 
-	// Set User Agent Override if provided, otherwise Stealth might provide a default
-	if cfg.UserAgent != "" {
-		page.MustEvalOnNewDocument(fmt.Sprintf(`Object.defineProperty(navigator, 'userAgent', { get: () => "%s" })`, cfg.UserAgent))
-	} else {
-		// Fallback to a modern UA if none provided to avoid HeadlessChrome UA
-		ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
-		page.MustEvalOnNewDocument(fmt.Sprintf(`Object.defineProperty(navigator, 'userAgent', { get: () => "%s" })`, ua))
-	}
-
-	// Set Viewport
-	err = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
-		Width:             width,
-		Height:            height,
-		DeviceScaleFactor: 1,
-		Mobile:            false,
-	})
-	if err != nil {
+	// The fingerprint script runs after stealth.JS so its WebGL values win
+	if err := fp.apply(page); err != nil {
 		browser.Close()
-		return nil, fmt.Errorf("failed to set viewport: %w", err)
+		return nil, nil, err
 	}
 
-	log.Info("Browser initialized", "width", width, "height", height, "headless", cfg.Headless)
+	log.Info("Browser initialized", "width", fp.ViewportWidth, "height", fp.ViewportHeight, "timezone", fp.Timezone, "display", mode, "proxy", proxy != "")
 
-	return &Browser{
+	return browser, page, nil
+}
+
+// attach connects to the running Chrome at browser.remote_url and opens a tab
+// in it. No stealth scripts, user agent or viewport overrides are applied:
+// the point is to look exactly like that browser.
+func attach(cfg *config.Config, log logger.Logger) (*Browser, error) {
+	if len(cfg.Proxies) > 0 || cfg.ProxyURL != "" {
+		log.Warn("Proxies are ignored when attaching to a running browser")
+	}
+	url, err := launcher.ResolveURL(cfg.Browser.RemoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach browser at %s: %w", cfg.Browser.RemoteURL, err)
+	}
+
+	browser := rod.New().ControlURL(url)
+	if err := browser.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to attach to browser: %w", err)
+	}
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tab: %w", err)
+	}
+
+	log.Info("Attached to running browser", "url", cfg.Browser.RemoteURL)
+	b := &Browser{
 		RodBrowser: browser,
 		Page:       page,
 		Log:        log,
 		Cfg:        cfg,
-	}, nil
+		remote:     true,
+	}
+	if cfg.Browser.IdleMouse {
+		b.StartIdle()
+	}
+	return b, nil
 }
 
-// Close cleans up the browser resources
+// Close cleans up the browser resources. An attached browser keeps running,
+// only the bot's tab is closed.
 func (b *Browser) Close() error {
+	b.StopIdle()
+	if b.remote {
+		return b.Page.Close()
+	}
 	return b.RodBrowser.Close()
 }
 
@@ -118,5 +234,68 @@ func (b *Browser) NavigateTo(url string) error {
 
 	// Retry up to 3 times with 2s initial backoff
 	// 2s -> 4s -> 8s
-	return utils.RetryWithBackoff(op, 3, 2*time.Second, 10*time.Second)
+	err := utils.RetryWithBackoff(op, 3, 2*time.Second, 10*time.Second)
+	if err != nil {
+		if b.proxyFailure("navigation error") {
+			return utils.RetryWithBackoff(op, 3, 2*time.Second, 10*time.Second)
+		}
+		return err
+	}
+	if info, ierr := b.Page.Info(); ierr == nil && strings.Contains(info.URL, "/checkpoint/") {
+		// A new proxy starts on a blank page; go back so the challenge (if
+		// any) is on screen for the handler below
+		if b.proxyFailure("security checkpoint") {
+			if err := utils.RetryWithBackoff(op, 3, 2*time.Second, 10*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+	if b.Challenge != nil {
+		return b.Challenge()
+	}
+	return nil
+}
+
+// proxyFailure reports a failure to the proxy manager and rotates when failures spike.
+// It returns true if the browser now runs through a different proxy.
+func (b *Browser) proxyFailure(reason string) bool {
+	if b.Proxies == nil || !b.Proxies.ReportFailure(reason) {
+		return false
+	}
+	if err := b.RotateProxy(); err != nil {
+		b.Log.Error("Proxy rotation failed", "error", err)
+		return false
+	}
+	return true
+}
+
+// RotateProxy relaunches the browser through the next proxy, carrying the session cookies over
+func (b *Browser) RotateProxy() error {
+	if b.Proxies == nil {
+		return ErrNoHealthyProxy
+	}
+	cookies, err := b.RodBrowser.GetCookies()
+	if err != nil {
+		return fmt.Errorf("failed to read cookies: %w", err)
+	}
+
+	next := b.Proxies.Next()
+	b.Log.Warn("Rotating proxy", "proxy", redactProxy(next))
+	// The idle mouse must not touch the page while it is swapped
+	idle := b.StopIdle()
+	b.RodBrowser.Close()
+
+	browser, page, err := launch(b.Cfg, b.Log, next)
+	if err != nil {
+		return err
+	}
+	b.RodBrowser, b.Page = browser, page
+	b.LastMouseX, b.LastMouseY = 0, 0
+	if idle {
+		b.StartIdle()
+	}
+	if err := browser.SetCookies(proto.CookiesToParams(cookies)); err != nil {
+		return fmt.Errorf("failed to restore cookies: %w", err)
+	}
+	return nil
 }
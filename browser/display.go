@@ -0,0 +1,97 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+)
+
+// ErrNoDisplay is returned when browser.display is "xvfb" but xvfb-run isn't installed
+var ErrNoDisplay = errors.New("xvfb-run not found, install xvfb or set browser.display to auto or headless")
+
+// Display modes, see config Browser.Display
+const (
+	displayHeadful  = "headful"
+	displayXvfb     = "xvfb"
+	displayHeadless = "headless"
+)
+
+// hasDisplay reports whether a headful Chrome has a screen to open on.
+// Only Linux servers and containers lack one.
+func hasDisplay() bool {
+	if runtime.GOOS != "linux" {
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// displayMode picks how Chrome is shown. headless: true and display:
+// headless use Chrome's new headless mode, which runs the full browser
+// rather than the old headless shell. "auto" stays headful when there is
+// a screen, otherwise prefers a virtual one from Xvfb, which keeps the
+// headful rendering stealth relies on, and falls back to new headless.
+func displayMode(cfg *config.Config, log logger.Logger) (string, error) {
+	if cfg.Headless {
+		return displayHeadless, nil
+	}
+	_, xvfbErr := exec.LookPath("xvfb-run")
+	switch cfg.Browser.Display {
+	case "none":
+		return displayHeadful, nil
+	case "headless":
+		return displayHeadless, nil
+	case "xvfb":
+		if xvfbErr != nil {
+			return "", ErrNoDisplay
+		}
+		return displayXvfb, nil
+	}
+	if hasDisplay() {
+		return displayHeadful, nil
+	}
+	if xvfbErr == nil {
+		log.Info("No display found, running Chrome on a virtual display")
+		return displayXvfb, nil
+	}
+	log.Warn("No display and no xvfb-run found, falling back to headless Chrome; install xvfb for headful mode")
+	return displayHeadless, nil
+}
+
+// setDisplay configures the launcher for the display mode and the sandbox
+// setting. The window matches the fingerprint's screen so a virtual display
+// or headless window doesn't show the default 800x600.
+func setDisplay(l *launcher.Launcher, cfg *config.Config, log logger.Logger, fp Fingerprint) (string, error) {
+	mode, err := displayMode(cfg, log)
+	if err != nil {
+		return "", err
+	}
+	switch mode {
+	case displayXvfb:
+		l.Headless(false).Devtools(false).
+			XVFB("--auto-servernum", fmt.Sprintf("--server-args=-screen 0 %dx%dx24", fp.ScreenWidth, fp.ScreenHeight))
+	case displayHeadless:
+		l.HeadlessNew(true).Devtools(false)
+	default:
+		l.Headless(false)
+	}
+	if mode != displayHeadful {
+		l.Set(flags.Flag("window-size"), fmt.Sprintf("%d,%d", fp.ScreenWidth, fp.ScreenHeight))
+		// Containers often have a tiny /dev/shm that crashes tabs
+		l.Set(flags.Flag("disable-dev-shm-usage"))
+	}
+
+	if !cfg.Browser.Sandbox {
+		l.NoSandbox(true)
+	} else if runtime.GOOS == "linux" && os.Geteuid() == 0 {
+		log.Warn("Running as root with the Chrome sandbox on usually fails; set browser.sandbox: false in containers")
+	}
+	return mode, nil
+}
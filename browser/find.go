@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/selectors"
+)
+
+// ErrElementNotFound is returned when no selector of an element's chain matches
+var ErrElementNotFound = errors.New("element not found")
+
+// labelPlaceholder matches {contains:EXPR:ACTION} and {equals:EXPR:ACTION}
+var labelPlaceholder = regexp.MustCompile(`\{(contains|equals):([^:{}]+):([a-z_]+)\}`)
+
+// Selectors returns the expanded fallback chain of a logical element
+func (b *Browser) Selectors(name string) []string {
+	chain := b.Sel.Chain(name)
+	out := make([]string, len(chain))
+	for i, sel := range chain {
+		out[i] = labelPlaceholder.ReplaceAllStringFunc(sel, func(m string) string {
+			parts := labelPlaceholder.FindStringSubmatch(m)
+			labels := b.Cfg.Labels(parts[3])
+			if parts[1] == "equals" {
+				return XPathEqualsAny(parts[2], labels)
+			}
+			return XPathContainsAny(parts[2], labels)
+		})
+	}
+	return out
+}
+
+// Find returns the first element matched by the chain of name, giving each
+// selector up to timeout to appear
+func (b *Browser) Find(name string, timeout time.Duration) (*rod.Element, error) {
+	el, _, err := b.FindWhere(name, timeout, nil)
+	return el, err
+}
+
+// FindWhere is Find skipping elements ok rejects (nil accepts any). It also
+// returns the selector that matched.
+func (b *Browser) FindWhere(name string, timeout time.Duration, ok func(*rod.Element) bool) (*rod.Element, string, error) {
+	for _, sel := range b.Selectors(name) {
+		el, err := b.element(b.Page.Timeout(timeout), sel)
+		if err != nil {
+			continue
+		}
+		if ok == nil || ok(el) {
+			return el, sel, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrElementNotFound, name)
+}
+
+// FindAll returns the elements matched by the first selector of the chain
+// that matches any
+func (b *Browser) FindAll(name string) (rod.Elements, error) {
+	for _, sel := range b.Selectors(name) {
+		var els rod.Elements
+		var err error
+		if selectors.IsXPath(sel) {
+			els, err = b.Page.ElementsX(sel)
+		} else {
+			els, err = b.Page.Elements(sel)
+		}
+		if err == nil && len(els) > 0 {
+			return els, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrElementNotFound, name)
+}
+
+// Has reports whether any selector of the chain of name matches right now
+func (b *Browser) Has(name string) bool {
+	for _, sel := range b.Selectors(name) {
+		var has bool
+		if selectors.IsXPath(sel) {
+			has, _, _ = b.Page.HasX(sel)
+		} else {
+			has, _, _ = b.Page.Has(sel)
+		}
+		if has {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Browser) element(page *rod.Page, sel string) (*rod.Element, error) {
+	if selectors.IsXPath(strings.TrimSpace(sel)) {
+		return page.ElementX(sel)
+	}
+	return page.Element(sel)
+}
@@ -0,0 +1,302 @@
+package browser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Fingerprint is the browser identity shown to LinkedIn. It is generated once
+// per account and reused: a session whose screen or platform changes while
+// its cookies stay the same stands out more than any single value.
+type Fingerprint struct {
+	UserAgent           string    `json:"user_agent"`
+	Platform            string    `json:"platform"`
+	Languages           []string  `json:"languages"`
+	Timezone            string    `json:"timezone,omitempty"`
+	WebGLVendor         string    `json:"webgl_vendor"`
+	WebGLRenderer       string    `json:"webgl_renderer"`
+	ScreenWidth         int       `json:"screen_width"`
+	ScreenHeight        int       `json:"screen_height"`
+	ViewportWidth       int       `json:"viewport_width"`
+	ViewportHeight      int       `json:"viewport_height"`
+	DeviceMemory        int       `json:"device_memory"`
+	HardwareConcurrency int       `json:"hardware_concurrency"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// fullVersion is the launched Chrome's version for the client hints,
+	// set by matchVersion
+	fullVersion string
+}
+
+// osProfile keeps user agent, platform, GPU and screen consistent with each other
+type osProfile struct {
+	uaOS      string
+	platform  string
+	vendors   [][2]string // WebGL vendor, renderer
+	screens   [][2]int
+	uiHeights [2]int // browser chrome + taskbar/menu bar, min and max
+}
+
+// osProfiles are keyed by GOOS: the platform shown is the host's, since
+// fonts, rendering and TCP/TLS signals give the real one away anyway
+var osProfiles = map[string]osProfile{
+	"windows": {
+		uaOS:     "Windows NT 10.0; Win64; x64",
+		platform: "Win32",
+		vendors: [][2]string{
+			{"Google Inc. (NVIDIA)", "ANGLE (NVIDIA, NVIDIA GeForce GTX 1650 Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+			{"Google Inc. (NVIDIA)", "ANGLE (NVIDIA, NVIDIA GeForce RTX 3060 Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+			{"Google Inc. (Intel)", "ANGLE (Intel, Intel(R) UHD Graphics 620 Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+			{"Google Inc. (Intel)", "ANGLE (Intel, Intel(R) Iris(R) Xe Graphics Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+			{"Google Inc. (AMD)", "ANGLE (AMD, AMD Radeon(TM) Graphics Direct3D11 vs_5_0 ps_5_0, D3D11)"},
+		},
+		screens:   [][2]int{{1920, 1080}, {1366, 768}, {1536, 864}, {1440, 900}, {1600, 900}, {2560, 1440}},
+		uiHeights: [2]int{110, 140},
+	},
+	"darwin": {
+		uaOS:     "Macintosh; Intel Mac OS X 10_15_7",
+		platform: "MacIntel",
+		vendors: [][2]string{
+			{"Google Inc. (Apple)", "ANGLE (Apple, ANGLE Metal Renderer: Apple M1, Unspecified Version)"},
+			{"Google Inc. (Apple)", "ANGLE (Apple, ANGLE Metal Renderer: Apple M2, Unspecified Version)"},
+			{"Google Inc. (Intel Inc.)", "ANGLE (Intel Inc., Intel(R) Iris(TM) Plus Graphics OpenGL Engine, OpenGL 4.1)"},
+		},
+		screens:   [][2]int{{1440, 900}, {1512, 982}, {1680, 1050}, {1728, 1117}, {1920, 1080}},
+		uiHeights: [2]int{95, 120},
+	},
+	"linux": {
+		uaOS:     "X11; Linux x86_64",
+		platform: "Linux x86_64",
+		vendors: [][2]string{
+			{"Google Inc. (Intel)", "ANGLE (Intel, Mesa Intel(R) UHD Graphics 620 (KBL GT2), OpenGL 4.6)"},
+			{"Google Inc. (Intel)", "ANGLE (Intel, Mesa Intel(R) Xe Graphics (TGL GT2), OpenGL 4.6)"},
+			{"Google Inc. (AMD)", "ANGLE (AMD, AMD Radeon Graphics (radeonsi, renoir, LLVM 15.0.7), OpenGL 4.6)"},
+			{"Google Inc. (NVIDIA Corporation)", "ANGLE (NVIDIA Corporation, NVIDIA GeForce GTX 1650/PCIe/SSE2, OpenGL 4.5.0)"},
+		},
+		screens:   [][2]int{{1920, 1080}, {1366, 768}, {1440, 900}, {1600, 900}, {2560, 1440}},
+		uiHeights: [2]int{100, 130},
+	},
+}
+
+// hostProfile is the profile of the OS the bot runs on, Linux for others
+func hostProfile() osProfile {
+	if p, ok := osProfiles[runtime.GOOS]; ok {
+		return p
+	}
+	return osProfiles["linux"]
+}
+
+// defaultChromeVersion is written into new user agents until matchVersion
+// replaces it with the launched Chrome's
+const defaultChromeVersion = 126
+
+// chromeVersionPattern reads the major version out of a user agent
+var chromeVersionPattern = regexp.MustCompile(`Chrome/(\d+)`)
+
+// uaVersionPattern is the whole version in a user agent
+var uaVersionPattern = regexp.MustCompile(`Chrome/[\d.]+`)
+
+// GenerateFingerprint creates a random but internally consistent fingerprint
+// for the host's OS
+func GenerateFingerprint() Fingerprint {
+	p := hostProfile()
+	gpu := p.vendors[rand.Intn(len(p.vendors))]
+	screen := p.screens[rand.Intn(len(p.screens))]
+	ui := p.uiHeights[0] + rand.Intn(p.uiHeights[1]-p.uiHeights[0]+1)
+
+	return Fingerprint{
+		UserAgent: fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36",
+			p.uaOS, defaultChromeVersion),
+		Platform:            p.platform,
+		Languages:           []string{"en-US", "en"},
+		Timezone:            hostTimezone(),
+		WebGLVendor:         gpu[0],
+		WebGLRenderer:       gpu[1],
+		ScreenWidth:         screen[0],
+		ScreenHeight:        screen[1],
+		ViewportWidth:       screen[0],
+		ViewportHeight:      screen[1] - ui,
+		DeviceMemory:        []int{4, 8, 8}[rand.Intn(3)],
+		HardwareConcurrency: []int{4, 8, 8, 12, 16}[rand.Intn(5)],
+		CreatedAt:           time.Now(),
+	}
+}
+
+// LoadFingerprint reads the fingerprint saved at path, generating and saving
+// a new one the first time; created reports the latter. An empty path
+// generates one for this session only.
+func LoadFingerprint(path string) (fp Fingerprint, created bool, err error) {
+	if path == "" {
+		return GenerateFingerprint(), false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &fp); err != nil {
+			return Fingerprint{}, false, fmt.Errorf("invalid fingerprint file %s: %w", path, err)
+		}
+		return fp, false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return Fingerprint{}, false, err
+	}
+
+	fp = GenerateFingerprint()
+	data, err = json.MarshalIndent(fp, "", "  ")
+	if err != nil {
+		return Fingerprint{}, false, err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return Fingerprint{}, false, err
+		}
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return Fingerprint{}, false, fmt.Errorf("failed to save fingerprint: %w", err)
+	}
+	return fp, true, nil
+}
+
+// matchVersion puts product's version (as from Browser.GetVersion, e.g.
+// "HeadlessChrome/126.0.6478.126") into the user agent and client hints, so
+// they match the features the browser really has. The UA keeps only the
+// major version, like Chrome's own reduced user agent.
+func (fp *Fingerprint) matchVersion(product string) {
+	_, full, ok := strings.Cut(product, "/")
+	if !ok || full == "" {
+		return
+	}
+	major, _, _ := strings.Cut(full, ".")
+	fp.UserAgent = uaVersionPattern.ReplaceAllString(fp.UserAgent, "Chrome/"+major+".0.0.0")
+	fp.fullVersion = full
+}
+
+// hostPlatform reports whether the fingerprint's platform is the host's
+func (fp Fingerprint) hostPlatform() bool {
+	return fp.Platform == hostProfile().platform
+}
+
+// hostTimezone returns the machine's IANA zone name, "" when unknown. Faking a
+// zone far from the IP the requests come from would be a mismatch of its own.
+func hostTimezone() string {
+	if tz := os.Getenv("TZ"); tz != "" {
+		return strings.TrimPrefix(tz, ":")
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// fingerprintJS patches the navigator and WebGL values Chrome can't override
+// through the protocol. %s is the fingerprint as JSON.
+const fingerprintJS = `(() => {
+	const fp = %s;
+	const def = (obj, prop, value) => Object.defineProperty(obj, prop, { get: () => value, configurable: true });
+	def(Navigator.prototype, 'platform', fp.platform);
+	def(Navigator.prototype, 'languages', Object.freeze([...fp.languages]));
+	def(Navigator.prototype, 'language', fp.languages[0]);
+	def(Navigator.prototype, 'deviceMemory', fp.device_memory);
+	def(Navigator.prototype, 'hardwareConcurrency', fp.hardware_concurrency);
+	for (const ctx of [self.WebGLRenderingContext, self.WebGL2RenderingContext]) {
+		if (!ctx) continue;
+		const getParameter = ctx.prototype.getParameter;
+		ctx.prototype.getParameter = function (p) {
+			if (p === 37445) return fp.webgl_vendor;   // UNMASKED_VENDOR_WEBGL
+			if (p === 37446) return fp.webgl_renderer; // UNMASKED_RENDERER_WEBGL
+			return getParameter.call(this, p);
+		};
+	}
+})();`
+
+// userAgentMetadata returns the client hints (Sec-CH-UA headers and
+// navigator.userAgentData) matching the user agent, which Chrome would
+// otherwise report from the real machine
+func (fp Fingerprint) userAgentMetadata() *proto.EmulationUserAgentMetadata {
+	version := fmt.Sprint(defaultChromeVersion)
+	if m := chromeVersionPattern.FindStringSubmatch(fp.UserAgent); m != nil {
+		version = m[1]
+	}
+	fullVersion := version + ".0.0.0"
+	if strings.HasPrefix(fp.fullVersion, version+".") {
+		fullVersion = fp.fullVersion
+	}
+	platform, platformVersion, arch := "Windows", "10.0.0", "x86"
+	switch fp.Platform {
+	case "MacIntel":
+		platform, platformVersion = "macOS", "10.15.7"
+		if strings.Contains(fp.WebGLRenderer, "Apple M") {
+			arch = "arm"
+		}
+	case "Linux x86_64":
+		platform, platformVersion = "Linux", ""
+	}
+	brands := func(v string) []*proto.EmulationUserAgentBrandVersion {
+		return []*proto.EmulationUserAgentBrandVersion{
+			{Brand: "Not/A)Brand", Version: "8"},
+			{Brand: "Chromium", Version: v},
+			{Brand: "Google Chrome", Version: v},
+		}
+	}
+	return &proto.EmulationUserAgentMetadata{
+		Brands:          brands(version),
+		FullVersionList: brands(fullVersion),
+		Platform:        platform,
+		PlatformVersion: platformVersion,
+		Architecture:    arch,
+		Bitness:         "64",
+		Mobile:          false,
+	}
+}
+
+// apply sets the fingerprint on page; it must run before the first navigation
+func (fp Fingerprint) apply(page *rod.Page) error {
+	err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
+		UserAgent:         fp.UserAgent,
+		AcceptLanguage:    strings.Join(fp.Languages, ","),
+		Platform:          fp.Platform,
+		UserAgentMetadata: fp.userAgentMetadata(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set user agent: %w", err)
+	}
+
+	if fp.Timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: fp.Timezone}).Call(page); err != nil {
+			return fmt.Errorf("failed to set timezone %q: %w", fp.Timezone, err)
+		}
+	}
+
+	data, err := json.Marshal(fp)
+	if err != nil {
+		return err
+	}
+	if _, err := page.EvalOnNewDocument(fmt.Sprintf(fingerprintJS, data)); err != nil {
+		return fmt.Errorf("failed to install fingerprint script: %w", err)
+	}
+
+	err = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             fp.ViewportWidth,
+		Height:            fp.ViewportHeight,
+		DeviceScaleFactor: 1,
+		Mobile:            false,
+		ScreenWidth:       &fp.ScreenWidth,
+		ScreenHeight:      &fp.ScreenHeight,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set viewport: %w", err)
+	}
+	return nil
+}
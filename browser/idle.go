@@ -0,0 +1,161 @@
+package browser
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/stealth"
+)
+
+// Idle mouse behaviour: while the bot reads or waits, a real hand doesn't
+// hold the mouse perfectly still. The idle goroutine drifts it a few pixels
+// along smooth noise and now and then wanders towards the scrollbar or an
+// edge. Precise interactions pause it with PauseIdle.
+
+// idleDriftRadius bounds how far (px) a drift wanders from where it started
+const idleDriftRadius = 18.0
+
+// StartIdle starts the idle mouse goroutine. It is a no-op when it is already
+// running; Close stops it.
+func (b *Browser) StartIdle() {
+	b.mouseMu.Lock()
+	defer b.mouseMu.Unlock()
+	if b.idleStop != nil {
+		return
+	}
+	b.idleStop, b.idleDone = make(chan struct{}), make(chan struct{})
+	go b.idleLoop(b.idleStop, b.idleDone)
+}
+
+// StopIdle stops the idle mouse goroutine and waits for it to return. It
+// reports whether the goroutine was running.
+func (b *Browser) StopIdle() bool {
+	b.mouseMu.Lock()
+	stop, done := b.idleStop, b.idleDone
+	b.idleStop, b.idleDone = nil, nil
+	b.mouseMu.Unlock()
+	if stop == nil {
+		return false
+	}
+	close(stop)
+	<-done
+	return true
+}
+
+// PauseIdle stops idle movements until the returned resume function is
+// called, waiting for a movement in progress to finish. Calls nest.
+func (b *Browser) PauseIdle() (resume func()) {
+	b.idlePaused.Add(1)
+	// A drift step in flight holds the lock, wait for it
+	b.mouseMu.Lock()
+	b.mouseMu.Unlock()
+	return func() { b.idlePaused.Add(-1) }
+}
+
+func (b *Browser) idleLoop(stop, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(stealth.RandomDuration(1500*time.Millisecond, 4*time.Second)):
+		}
+		if b.idlePaused.Load() > 0 {
+			continue
+		}
+		if rand.Float64() < 0.08 {
+			b.wanderToEdge()
+		} else {
+			b.drift(20+rand.Intn(60), func() bool {
+				select {
+				case <-stop:
+					return true
+				default:
+					return b.idlePaused.Load() > 0
+				}
+			})
+		}
+	}
+}
+
+// drift moves the mouse the given number of small steps along 2D value noise
+// around its last position. It stops early when abort (if set) reports true.
+func (b *Browser) drift(steps int, abort func() bool) {
+	nx, ny := newNoise(), newNoise()
+	// Noise is sampled slowly so consecutive points stay close together
+	speed := 0.05 + rand.Float64()*0.1
+	for i := 0; i < steps; i++ {
+		ok := b.withMouse(abort, func() {
+			t := float64(i) * speed
+			x := b.LastMouseX + (nx.at(t)-nx.at(t-speed))*idleDriftRadius
+			y := b.LastMouseY + (ny.at(t)-ny.at(t-speed))*idleDriftRadius
+			b.Page.Mouse.MoveTo(proto.Point{X: x, Y: y})
+			b.LastMouseX, b.LastMouseY = x, y
+		})
+		if !ok {
+			return
+		}
+		time.Sleep(time.Duration(15+rand.Intn(20)) * time.Millisecond)
+	}
+}
+
+// wanderToEdge moves the mouse off the content, to the scrollbar area or the
+// left margin, the way people park it while reading
+func (b *Browser) wanderToEdge() {
+	res, err := b.Page.Eval(`() => [window.innerWidth, window.innerHeight]`)
+	if err != nil {
+		return
+	}
+	size := res.Value.Arr()
+	if len(size) != 2 {
+		return
+	}
+	w, h := size[0].Num(), size[1].Num()
+	x := w - 8 - rand.Float64()*25
+	if rand.Float64() < 0.3 {
+		x = 10 + rand.Float64()*w*0.1
+	}
+	y := h * (0.2 + rand.Float64()*0.6)
+
+	b.withMouse(func() bool { return b.idlePaused.Load() > 0 }, func() {
+		if err := b.moveMouseAlongPath(b.LastMouseX, b.LastMouseY, x, y); err == nil {
+			b.LastMouseX, b.LastMouseY = x, y
+		}
+	})
+}
+
+// withMouse runs move holding the mouse lock unless abort reports true, and
+// reports whether it ran
+func (b *Browser) withMouse(abort func() bool, move func()) bool {
+	b.mouseMu.Lock()
+	defer b.mouseMu.Unlock()
+	if abort != nil && abort() {
+		return false
+	}
+	move()
+	return true
+}
+
+// noise is smooth 1D value noise: random values at integer points with
+// smoothstep interpolation between them, in [-1, 1]
+type noise []float64
+
+func newNoise() noise {
+	n := make(noise, 64)
+	for i := range n {
+		n[i] = rand.Float64()*2 - 1
+	}
+	return n
+}
+
+func (n noise) at(t float64) float64 {
+	t = math.Abs(t)
+	i := int(t)
+	f := t - float64(i)
+	f = f * f * (3 - 2*f)
+	a, c := n[i%len(n)], n[(i+1)%len(n)]
+	return a + (c-a)*f
+}
@@ -1,7 +1,6 @@
 package browser
 
 import (
-	"math/rand"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -13,6 +12,8 @@ import (
 
 // HumanType types text into an element with human-like behavior
 func (b *Browser) HumanType(element *rod.Element, text string) error {
+	defer b.PauseIdle()()
+
 	// Ensure element is focused (optional, but good practice)
 	// element.Focus() // Rod's Input usually handles individual key events well, but let's assume focus is needed or already there.
 
@@ -21,40 +22,43 @@ func (b *Browser) HumanType(element *rod.Element, text string) error {
 		return err
 	}
 
-	// Configuration for typing
-	typoRate := 0.05 // 5% chance of typo per character
+	// Speed and typo rate come from the configured typing profile, adjusted
+	// by the persona. Typos follow the keyboard layout and are noticed and
+	// corrected right away.
+	profile := stealth.Current().Typing(stealth.Typing(b.Cfg.Typing.Profile))
+	layout := b.Cfg.Typing.Layout
 	chars := []rune(text)
 
 	for i := 0; i < len(chars); i++ {
 		char := chars[i]
+		next := rune(0)
+		if i+1 < len(chars) {
+			next = chars[i+1]
+		}
 
-		// Check for typo
-		if rand.Float64() < typoRate {
-			// Simulate a typo
-			wrongChar := pickWrongChar(char)
-
-			// Type the wrong character
-			b.Page.InsertText(string(wrongChar))
-
-			// Realization delay (longer than normal keypress)
-			stealth.SleepWithJitter(time.Millisecond*300, 0.3)
-
-			// Backspace
-			b.Page.Keyboard.Press(input.Backspace)
-
-			// Correction delay
-			stealth.SleepWithJitter(time.Millisecond*150, 0.2)
-
-			// Retry the loop for this character is not needed because we just corrected back to state before typo
-			// We now proceed to type the correct character in the main flow
+		switch profile.NextTypo(layout, char, next) {
+		case stealth.TypoAdjacent:
+			wrong, _ := stealth.AdjacentKey(layout, char)
+			b.Page.InsertText(string(wrong))
+			b.correct(profile, 1)
+		case stealth.TypoDouble:
+			// Typed twice: the first one stays, the extra is deleted below
+			b.Page.InsertText(string(char))
+			time.Sleep(profile.KeystrokeDelay() / 2)
+			b.Page.InsertText(string(char))
+			b.correct(profile, 1)
+			time.Sleep(profile.KeystrokeDelay())
+			continue
+		case stealth.TypoTranspose:
+			b.Page.InsertText(string(next))
+			time.Sleep(profile.KeystrokeDelay())
+			b.Page.InsertText(string(char))
+			b.correct(profile, 2)
 		}
 
 		// Type the correct character
 		b.Page.InsertText(string(char))
-
-		// Calculate delay
-		// Base delay from stealth package
-		stealth.SleepContextual(stealth.ActionTypeType, 1.0)
+		time.Sleep(profile.KeystrokeDelay())
 
 		// Additional rhythm logic
 		if char == ' ' {
@@ -66,23 +70,22 @@ func (b *Browser) HumanType(element *rod.Element, text string) error {
 	return nil
 }
 
-// pickWrongChar helps simulate immediate adjacency errors or random errors
-func pickWrongChar(correct rune) rune {
-	// Simple pool of common chars, in a real app this could be a QWERTY adjacency map
-	// For POC, we just return a random lowercase letter or number if it's alphanumeric
-	const alphanum = "abcdefghijklmnopqrstuvwxyz0123456789"
-
-	// Just pick a random one that isn't the correct one
-	for {
-		r := rune(alphanum[rand.Intn(len(alphanum))])
-		if r != correct {
-			return r
-		}
+// correct notices a typo after a moment and deletes the last n characters
+func (b *Browser) correct(profile stealth.TypingProfile, n int) {
+	// Realization delay (longer than normal keypress)
+	stealth.SleepWithJitter(time.Millisecond*300, 0.3)
+	for k := 0; k < n; k++ {
+		b.Page.Keyboard.Press(input.Backspace)
+		time.Sleep(profile.KeystrokeDelay() / 2)
 	}
+	// Correction delay
+	stealth.SleepWithJitter(time.Millisecond*150, 0.2)
 }
 
 // TypeInto finds an element and types the text using human-like behavior
 func (b *Browser) TypeInto(selector, text string) error {
+	defer b.PauseIdle()()
+
 	el, err := b.Page.Element(selector)
 	if err != nil {
 		return err
@@ -1,16 +1,52 @@
 package browser
 
 import (
+	"errors"
 	"math"
 	"math/rand"
 	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/stealth"
 )
 
+// ErrOccluded is returned by HumanClick when the element stays covered by
+// another one, such as a sticky header, a toast or an overlay
+var ErrOccluded = errors.New("element is covered by another element")
+
+// clickAttempts bounds HumanClick's scroll-and-retry rounds
+const clickAttempts = 4
+
+// clickablePointJS looks for a point of the element that is inside the
+// viewport and on top (elementFromPoint hits it or one of its children):
+// a few random points in the middle 80% first, then the centre. Returns
+// {ok, x, y} plus the element's vertical centre and the viewport height.
+const clickablePointJS = `function(tries) {
+	const r = this.getBoundingClientRect();
+	const vw = window.innerWidth, vh = window.innerHeight;
+	const points = [];
+	for (let i = 0; i < tries; i++) points.push([0.1 + Math.random() * 0.8, 0.1 + Math.random() * 0.8]);
+	points.push([0.5, 0.5]);
+	for (const [fx, fy] of points) {
+		const x = r.left + r.width * fx, y = r.top + r.height * fy;
+		if (x < 0 || y < 0 || x >= vw || y >= vh) continue;
+		const hit = document.elementFromPoint(x, y);
+		if (hit && (hit === this || this.contains(hit))) return {ok: true, x, y, center: r.top + r.height / 2, height: vh};
+	}
+	return {ok: false, x: 0, y: 0, center: r.top + r.height / 2, height: vh};
+}`
+
 // HumanMove moves the mouse to the center of the element with human-like behavior.
 func (b *Browser) HumanMove(element *rod.Element) error {
+	defer b.PauseIdle()()
+
+	// Off-screen targets are scrolled to first, as a person would
+	if err := b.ScrollToElement(element); err != nil {
+		return err
+	}
+
 	// Get element box
 	box, err := element.Shape()
 	if err != nil {
@@ -49,6 +85,65 @@ func (b *Browser) HumanMove(element *rod.Element) error {
 	return err
 }
 
+// HumanClick scrolls the element into view, moves the mouse along a human
+// path to a point of it that nothing covers and clicks there. While the
+// element is covered (sticky headers and footers, toasts) it scrolls a little
+// away from the covered edge and retries with other points, returning
+// ErrOccluded when that doesn't help.
+func (b *Browser) HumanClick(el *rod.Element) error {
+	defer b.PauseIdle()()
+
+	if err := b.ScrollToElement(el); err != nil {
+		return err
+	}
+	for attempt := 0; attempt < clickAttempts; attempt++ {
+		res, err := el.Eval(clickablePointJS, 4)
+		if err != nil {
+			return err
+		}
+		v := res.Value
+		if v.Get("ok").Bool() {
+			x, y := v.Get("x").Num(), v.Get("y").Num()
+			if err := b.moveMouseAlongPath(b.LastMouseX, b.LastMouseY, x, y); err != nil {
+				return err
+			}
+			b.LastMouseX, b.LastMouseY = x, y
+			stealth.SleepContextual(stealth.ActionTypeClick, 0.5)
+			return b.Page.Mouse.Click(proto.InputMouseButtonLeft, 1)
+		}
+
+		// Covered near the top is usually the sticky header: bring the
+		// element further down, and up when near the bottom
+		center, height := v.Get("center").Num(), v.Get("height").Num()
+		delta := 120 + rand.Float64()*80
+		if center < height/2 {
+			delta = -delta
+		}
+		b.Log.Debug("Click target covered, scrolling and retrying", "attempt", attempt+1)
+		b.HumanScroll(delta)
+		stealth.SleepContextual(stealth.ActionTypeScroll, 0.5)
+	}
+	return ErrOccluded
+}
+
+// clickFallbackTimeout bounds the direct click Click tries after HumanClick;
+// rod otherwise waits forever for a covered element to become clickable
+const clickFallbackTimeout = 5 * time.Second
+
+// Click is HumanClick with a direct click as fallback, given up after
+// clickFallbackTimeout. It returns HumanClick's error (ErrOccluded for a
+// covered element) when neither worked.
+func (b *Browser) Click(el *rod.Element) error {
+	err := b.HumanClick(el)
+	if err == nil {
+		return nil
+	}
+	if ferr := el.Timeout(clickFallbackTimeout).Click(proto.InputMouseButtonLeft, 1); ferr != nil {
+		return err
+	}
+	return nil
+}
+
 func (b *Browser) moveMouseAlongPath(startX, startY, endX, endY float64) error {
 	// Bezier Control Points
 	// P0 = (startX, startY)
@@ -126,6 +221,8 @@ func (b *Browser) moveMouseAlongPath(startX, startY, endX, endY float64) error {
 
 // ClickElement moves to the element naturally and clicks
 func (b *Browser) ClickElement(selector string) error {
+	defer b.PauseIdle()()
+
 	el, err := b.Page.Element(selector)
 	if err != nil {
 		return err
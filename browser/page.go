@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/profile"
+)
+
+// companyHeaderSelector matches the top card of company, school and showcase pages
+const companyHeaderSelector = ".org-top-card, .org-top-card-primary-content, [data-test-id='org-top-card'], .hashtag-header"
+
+// chatBubbleSelector matches open (or minimised) conversation overlays
+const chatBubbleSelector = ".msg-overlay-conversation-bubble"
+
+// chatCloseSelector matches the close control in a conversation overlay header
+const chatCloseSelector = `button.msg-overlay-bubble-header__control--close-btn, button[data-control-name="overlay.close_conversation_window"], button[aria-label^="Close your"]`
+
+// OnPersonProfile reports whether the current page is a member profile:
+// the final URL (after redirects) is an /in/ URL, a name heading is present
+// and there is no company/school/hashtag header
+func (b *Browser) OnPersonProfile() bool {
+	info, err := b.Page.Info()
+	if err != nil || !profile.IsProfile(info.URL) {
+		return false
+	}
+
+	if has, _, _ := b.Page.Has(companyHeaderSelector); has {
+		return false
+	}
+
+	if _, err := b.Page.Timeout(5 * time.Second).Element("main h1, h1"); err != nil {
+		return false
+	}
+	return true
+}
+
+// CloseChatBubbles closes conversation overlays. With keepActive the bubble
+// holding focus (or the first one if none has focus) is left open.
+// Returns the number of bubbles closed.
+func (b *Browser) CloseChatBubbles(keepActive bool) (int, error) {
+	bubbles, err := b.Page.Elements(chatBubbleSelector)
+	if err != nil {
+		return 0, err
+	}
+	if len(bubbles) == 0 {
+		return 0, nil
+	}
+
+	keep := -1
+	if keepActive {
+		keep = 0
+		for i, bubble := range bubbles {
+			res, err := bubble.Eval(`function() { return this.contains(document.activeElement) }`)
+			if err == nil && res.Value.Bool() {
+				keep = i
+				break
+			}
+		}
+	}
+
+	closed := 0
+	for i, bubble := range bubbles {
+		if i == keep {
+			continue
+		}
+		closeBtn, err := bubble.Element(chatCloseSelector)
+		if err != nil {
+			continue
+		}
+		if err := b.Click(closeBtn); err != nil {
+			continue
+		}
+		closed++
+		time.Sleep(300 * time.Millisecond)
+	}
+	return closed, nil
+}
+
+// HoverRandom moves the mouse onto a random visible element matching selector
+func (b *Browser) HoverRandom(selector string) error {
+	elements, err := b.Page.Elements(selector)
+	if err != nil {
+		return err
+	}
+	var visible rod.Elements
+	for _, el := range elements {
+		if ok, _ := el.Visible(); ok {
+			visible = append(visible, el)
+		}
+	}
+	if len(visible) == 0 {
+		return fmt.Errorf("no visible element matches %q", selector)
+	}
+	return b.HumanMove(visible[rand.Intn(len(visible))])
+}
@@ -0,0 +1,178 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+)
+
+// ErrNoHealthyProxy is returned when every configured proxy failed its health check
+var ErrNoHealthyProxy = errors.New("no healthy proxy available")
+
+// ProxyStatus is the health check result for one proxy
+type ProxyStatus struct {
+	URL     string
+	Latency time.Duration
+	Err     error
+}
+
+// ProxyManager picks the proxy for a session and decides when to rotate to the next one
+type ProxyManager struct {
+	log      logger.Logger
+	proxies  []string // healthy proxies, fastest first
+	current  int
+	failures []time.Time
+
+	checkURL    string
+	timeout     time.Duration
+	maxLatency  time.Duration
+	rotateAfter int
+	window      time.Duration
+
+	mu sync.Mutex
+}
+
+// NewProxyManager returns a manager for the configured proxy pool, or nil if there is none.
+// proxy_url on its own counts as a pool of one.
+func NewProxyManager(cfg *config.Config, log logger.Logger) *ProxyManager {
+	pool := cfg.Proxies
+	if len(pool) == 0 && cfg.ProxyURL != "" {
+		pool = []string{cfg.ProxyURL}
+	}
+	if len(pool) == 0 {
+		return nil
+	}
+	return &ProxyManager{
+		log:         log,
+		proxies:     append([]string(nil), pool...),
+		checkURL:    cfg.ProxyCheck.URL,
+		timeout:     cfg.ProxyCheck.Timeout,
+		maxLatency:  cfg.ProxyCheck.MaxLatency,
+		rotateAfter: cfg.ProxyCheck.RotateAfter,
+		window:      cfg.ProxyCheck.RotateWindow,
+	}
+}
+
+// CheckAll tests every proxy concurrently and keeps the healthy ones, fastest first
+func (m *ProxyManager) CheckAll() ([]ProxyStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make([]ProxyStatus, len(m.proxies))
+	var wg sync.WaitGroup
+	for i, p := range m.proxies {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			latency, err := m.check(p)
+			results[i] = ProxyStatus{URL: p, Latency: latency, Err: err}
+		}(i, p)
+	}
+	wg.Wait()
+
+	var healthy []ProxyStatus
+	for _, r := range results {
+		if r.Err != nil {
+			m.log.Warn("Proxy failed health check", "proxy", redactProxy(r.URL), "error", r.Err)
+			continue
+		}
+		m.log.Info("Proxy healthy", "proxy", redactProxy(r.URL), "latency", r.Latency)
+		healthy = append(healthy, r)
+	}
+	if len(healthy) == 0 {
+		return results, ErrNoHealthyProxy
+	}
+	sort.SliceStable(healthy, func(i, j int) bool { return healthy[i].Latency < healthy[j].Latency })
+
+	m.proxies = m.proxies[:0]
+	for _, h := range healthy {
+		m.proxies = append(m.proxies, h.URL)
+	}
+	m.current = 0
+	return results, nil
+}
+
+// check fetches the check URL through the proxy. LinkedIn answers flagged IPs
+// with 999, 403 or 429, so those count as a bad reputation.
+func (m *ProxyManager) check(proxy string) (time.Duration, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return 0, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(u)},
+		Timeout:   m.timeout,
+		// A redirect to the login or authwall page still proves the IP is served
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	start := time.Now()
+	resp, err := client.Get(m.checkURL)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	latency := time.Since(start)
+
+	switch resp.StatusCode {
+	case 999, http.StatusForbidden, http.StatusTooManyRequests:
+		return latency, fmt.Errorf("ip flagged by %s (status %d)", u.Hostname(), resp.StatusCode)
+	}
+	if resp.StatusCode >= 500 {
+		return latency, fmt.Errorf("check url returned status %d", resp.StatusCode)
+	}
+	if m.maxLatency > 0 && latency > m.maxLatency {
+		return latency, fmt.Errorf("latency %s above %s", latency.Round(time.Millisecond), m.maxLatency)
+	}
+	return latency, nil
+}
+
+// Current returns the proxy in use
+func (m *ProxyManager) Current() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.proxies[m.current]
+}
+
+// ReportFailure records a navigation error or checkpoint and reports whether
+// enough have happened within the window to rotate
+func (m *ProxyManager) ReportFailure(reason string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	kept := m.failures[:0]
+	for _, t := range m.failures {
+		if now.Sub(t) < m.window {
+			kept = append(kept, t)
+		}
+	}
+	m.failures = append(kept, now)
+	m.log.Warn("Proxy failure", "proxy", redactProxy(m.proxies[m.current]), "reason", reason, "recent", len(m.failures))
+	return len(m.proxies) > 1 && m.rotateAfter > 0 && len(m.failures) >= m.rotateAfter
+}
+
+// Next switches to the next proxy in the pool and clears the failure count
+func (m *ProxyManager) Next() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = (m.current + 1) % len(m.proxies)
+	m.failures = nil
+	return m.proxies[m.current]
+}
+
+// redactProxy hides the credentials of a proxy URL for logging
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return "invalid"
+	}
+	return u.Redacted()
+}
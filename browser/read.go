@@ -0,0 +1,112 @@
+package browser
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/stealth"
+)
+
+// readSectionSelector matches the profile sections a person reads before connecting
+const readSectionSelector = "main section:has(#about), main section:has(#experience)"
+
+// readEntrySelector matches the entries of the Experience section
+const readEntrySelector = "main section:has(#experience) li.artdeco-list__item"
+
+// readFullChars is the About + Experience text length that earns the longest read
+const readFullChars = 3000
+
+// sectionTextJS sums the text length of the sections to read, falling back to main
+const sectionTextJS = `(sel) => {
+	const sections = document.querySelectorAll(sel);
+	let n = 0;
+	sections.forEach(s => n += s.innerText.length);
+	if (n === 0) {
+		const main = document.querySelector("main");
+		n = main ? main.innerText.length / 2 : 0;
+	}
+	return n;
+}`
+
+// ReadProfile reads the open profile the way a person sizing someone up would:
+// scrolling through About and Experience for between min and max, longer the
+// more text they hold, now and then expanding a "see more" and hovering an
+// entry. It returns the time spent, stopping early when ctx is done.
+func (b *Browser) ReadProfile(ctx context.Context, min, max time.Duration) time.Duration {
+	started := time.Now()
+	deadline := started.Add(readDuration(b.profileTextLength(), min, max))
+	done := func() bool { return ctx.Err() != nil || time.Now().After(deadline) }
+
+	sections, _ := b.Page.Elements(readSectionSelector)
+	for _, section := range sections {
+		if done() {
+			break
+		}
+		b.readSection(section, done)
+	}
+
+	// Time left over goes to drifting up and down the page
+	for !done() {
+		delta := 200 + rand.Float64()*400
+		if rand.Float64() < 0.3 {
+			delta = -delta
+		}
+		b.HumanScroll(delta)
+		stealth.SleepContextual(stealth.ActionTypeRead, 0.6+rand.Float64()*0.6)
+	}
+	return time.Since(started)
+}
+
+// readSection scrolls a section into view and reads down it
+func (b *Browser) readSection(section *rod.Element, done func() bool) {
+	if err := b.ScrollToElement(section); err != nil {
+		return
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	if rand.Float64() < 0.35 {
+		if btn, _, err := b.FindWhere("see_more", time.Second, func(el *rod.Element) bool {
+			vis, _ := el.Visible()
+			return vis
+		}); err == nil {
+			b.Log.Debug("Expanding a 'see more' while reading")
+			if err := b.HumanClick(btn); err == nil {
+				stealth.SleepContextual(stealth.ActionTypeRead, 1.5)
+			}
+		}
+	}
+
+	for i, steps := 0, 2+rand.Intn(3); i < steps && !done(); i++ {
+		b.HumanScroll(150 + rand.Float64()*250)
+		stealth.SleepContextual(stealth.ActionTypeRead, 0.8+rand.Float64()*0.6)
+		if rand.Float64() < 0.3 {
+			// No entries (e.g. on About) is fine
+			if b.HoverRandom(readEntrySelector) == nil {
+				stealth.SleepContextual(stealth.ActionTypeThink, 0.8)
+			}
+		}
+	}
+}
+
+// profileTextLength is how much text the open profile has to read
+func (b *Browser) profileTextLength() int {
+	res, err := b.Page.Eval(sectionTextJS, readSectionSelector)
+	if err != nil {
+		return 0
+	}
+	return res.Value.Int()
+}
+
+// readDuration scales lo-hi by text length, with some jitter so equally
+// long profiles don't take exactly as long
+func readDuration(chars int, lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	frac := min(float64(chars)/readFullChars, 1)
+	frac = max(0, min(frac+(rand.Float64()-0.5)*0.3, 1))
+	return lo + time.Duration(frac*float64(hi-lo))
+}
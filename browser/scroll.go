@@ -1,10 +1,13 @@
 package browser
 
 import (
+	"errors"
 	"math"
 	"math/rand"
 	"time"
 
+	"github.com/go-rod/rod"
+
 	"linkedin-automation/stealth"
 )
 
@@ -19,6 +22,10 @@ func (b *Browser) HumanScroll(deltaY float64) error {
 
 	remaining := deltaY
 	currentScroll := 0.0
+	force := stealth.Current().ScrollSpeed
+	if force <= 0 {
+		force = 1
+	}
 
 	// Physics parameters
 	const minStep = 20.0
@@ -28,7 +35,8 @@ func (b *Browser) HumanScroll(deltaY float64) error {
 	for math.Abs(remaining) > 0 {
 		// Determine chunk size for this interaction (e.g. one scroll wheel flick)
 		// Usually around 100-300 pixels
-		chunk := (100.0 + rand.Float64()*200.0) * (deltaY / math.Abs(deltaY))
+		// scaled by how hard the persona scrolls
+		chunk := (100.0 + rand.Float64()*200.0) * force * (deltaY / math.Abs(deltaY))
 
 		// Don't overshoot
 		if math.Abs(chunk) > math.Abs(remaining) {
@@ -80,43 +88,61 @@ func (b *Browser) HumanScroll(deltaY float64) error {
 	return nil
 }
 
-// randomHoverJitter moves the mouse slightly to simulate reading or hand jitter
+// randomHoverJitter nudges the mouse a few pixels, like a hand resting on it
+// while the page scrolls
 func (b *Browser) randomHoverJitter() {
-	// We don't know current mouse position easily without tracking or querying.
-	// For now, we assume we just nudge it relative to its last known position if Rod supports relative moves?
-	// Rod's Move is absolute.
-	// We can try to get current mouse position from JS.
-	// Evaluate mouse position
-
-	// NOTE: This adds overhead. If performance is critical, skip or track locally.
-	// For a POC, let's just do a dummy small movement if we can, or skip if too complex.
-	// Let's rely on a predefined behavior: users often move mouse to the center or sides while scrolling.
-
-	// Let's create a move to a random point within the viewport.
-	// Viewport size?
-	// We can get it from page info.
-
-	// Just a simple random move to a random location in the middle 50% of screen.
-	// This might jump if the mouse was elsewhere.
-	// To do this properly we need 'PreviousMouseX/Y' in struct.
-	// Assuming we started at (0,0) or last HumanMove target.
-	// Let's skip the jumpy move and just sleep (hover implies looking).
-
-	// Better: just sleep. The user asked for "random hover movements".
-	// Maybe we can wiggle?
-	// b.Page.Mouse.Move(x, y) required.
-	// Without state, avoiding jump is hard.
-	// I will add a TODO or implemented best effort if I had state.
-	// I'll skip actual Move for now to avoid artifacts, but simulate the TIMING of a hover.
+	b.drift(3+rand.Intn(5), nil)
 }
 
-// ScrollToElement scrolls until the element is in view with padding
-func (b *Browser) ScrollToElement(elementID string) error {
-	// Logic to find element y position and HumanScroll to it
-	// Rod has element.ScrollIntoView(), but it's instant or smooth-css.
-	// We want our HumanScroll.
+// Viewport band ScrollToElement brings elements into, as fractions of the
+// viewport height; elements already inside it aren't scrolled to
+const (
+	bandTop    = 0.15
+	bandBottom = 0.85
+)
+
+// maxScrollRounds bounds ScrollToElement's measure-and-scroll rounds
+const maxScrollRounds = 8
+
+// elementPositionJS returns the element's vertical centre relative to the viewport and the viewport height
+const elementPositionJS = `function() {
+	const r = this.getBoundingClientRect();
+	return [r.top + r.height / 2, window.innerHeight];
+}`
+
+// ScrollToElement scrolls with HumanScroll until the element's centre is within
+// the padded viewport band, landing at a random point of it. Long scrolls
+// sometimes overshoot a little and correct on the next round. When wheel
+// scrolling doesn't move the element (e.g. inside a scrollable container) it
+// falls back to an instant ScrollIntoView.
+func (b *Browser) ScrollToElement(el *rod.Element) error {
+	last := math.NaN()
+	for i := 0; i < maxScrollRounds; i++ {
+		res, err := el.Eval(elementPositionJS)
+		if err != nil {
+			return err
+		}
+		pos := res.Value.Arr()
+		if len(pos) != 2 {
+			return errors.New("element position unavailable")
+		}
+		center, height := pos[0].Num(), pos[1].Num()
+		if center >= height*bandTop && center <= height*bandBottom {
+			return nil
+		}
+		if center == last {
+			b.Log.Debug("Wheel scrolling doesn't move element, scrolling it into view")
+			return el.ScrollIntoView()
+		}
+		last = center
 
-	// 1. Get Element Y offset relative to viewport
-	// ...
-	return nil // Placeholder for complex logic, but HumanScroll(y) is the building block.
+		target := height * (bandTop + rand.Float64()*(bandBottom-bandTop))
+		delta := center - target
+		if math.Abs(delta) > 400 && rand.Float64() < 0.3 {
+			delta *= 1.08 + rand.Float64()*0.12
+		}
+		b.HumanScroll(delta)
+		stealth.SleepContextual(stealth.ActionTypeScroll, 0.5)
+	}
+	return el.ScrollIntoView()
 }
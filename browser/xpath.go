@@ -0,0 +1,49 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// XPathContainsAny builds a predicate matching when expr contains any of the labels,
+// e.g. XPathContainsAny(".", []string{"Connect", "Invite"}) -> (contains(., "Connect") or contains(., "Invite"))
+func XPathContainsAny(expr string, labels []string) string {
+	return xpathAny(labels, func(lit string) string {
+		return fmt.Sprintf("contains(%s, %s)", expr, lit)
+	})
+}
+
+// XPathEqualsAny builds a predicate matching when expr equals any of the labels
+func XPathEqualsAny(expr string, labels []string) string {
+	return xpathAny(labels, func(lit string) string {
+		return fmt.Sprintf("%s=%s", expr, lit)
+	})
+}
+
+func xpathAny(labels []string, term func(lit string) string) string {
+	if len(labels) == 0 {
+		// Never matches, keeps the surrounding selector valid
+		return "false()"
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, term(xpathLiteral(l)))
+	}
+	return "(" + strings.Join(parts, " or ") + ")"
+}
+
+// xpathLiteral quotes s for use in an XPath expression
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	// Both quote types present: concat("a", '"', "b")
+	parts := strings.Split(s, `"`)
+	for i, p := range parts {
+		parts[i] = `"` + p + `"`
+	}
+	return "concat(" + strings.Join(parts, `, '"', `) + ")"
+}
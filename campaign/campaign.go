@@ -0,0 +1,229 @@
+package campaign
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"linkedin-automation/config"
+	"linkedin-automation/hooks"
+	"linkedin-automation/storage"
+	"linkedin-automation/templates"
+)
+
+// ErrLimitReached is returned when the campaign's daily or weekly limit is used up
+var ErrLimitReached = errors.New("campaign limit reached")
+
+// Campaign bundles the search, templates, limits and tags for one outreach effort.
+// Its actions are tracked in a separate storage partition keyed by Name.
+type Campaign struct {
+	Name string `yaml:"name"`
+
+	Search struct {
+		Keywords string   `yaml:"keywords"`
+		Title    string   `yaml:"title"`
+		Company  string   `yaml:"company"`
+		Location string   `yaml:"location"`
+		Industry string   `yaml:"industry"`
+		Network  []string `yaml:"network"`
+		Pages    int      `yaml:"pages"`
+	} `yaml:"search"`
+
+	NoteTemplate string                `yaml:"note_template"`
+	Messages     []config.SequenceStep `yaml:"messages"`
+
+	// NoteVariants and MessageVariants A/B test the note and the first
+	// follow-up message; when set they replace NoteTemplate and the first
+	// message's template
+	NoteVariants    []Variant `yaml:"note_variants"`
+	MessageVariants []Variant `yaml:"message_variants"`
+
+	// Limits on connection requests, 0 = only the global limits apply
+	Limits struct {
+		Daily  int `yaml:"daily"`
+		Weekly int `yaml:"weekly"`
+	} `yaml:"limits"`
+
+	Tags []string `yaml:"tags"`
+}
+
+// Variant is one version of a template in an A/B test. Profiles get a
+// variant at random in proportion to Weight (default 1) and keep it.
+type Variant struct {
+	Name     string `yaml:"name"`
+	Weight   int    `yaml:"weight"`
+	Template string `yaml:"template"`
+}
+
+// Dir is where campaign files live; campaigns queued through the API must be in it
+const Dir = "campaigns"
+
+// ErrOutsideDir is returned for a campaign file outside the campaigns directory
+var ErrOutsideDir = errors.New("campaign file must be in the campaigns directory")
+
+// InDir resolves a campaign file named relative to dir ("cto.yaml" or
+// "campaigns/cto.yaml"), refusing any path that leads outside it
+func InDir(dir, path string) (string, error) {
+	p := filepath.Clean(path)
+	if !filepath.IsAbs(p) && !strings.HasPrefix(p, filepath.Clean(dir)+string(filepath.Separator)) {
+		p = filepath.Join(dir, p)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideDir, path)
+	}
+	return p, nil
+}
+
+// IsFile reports whether a --campaign value names a campaign file rather than a plain name
+func IsFile(arg string) bool {
+	ext := strings.ToLower(filepath.Ext(arg))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Load reads and validates a campaign file. The name defaults to the file name.
+func Load(path string) (*Campaign, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Campaign{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid campaign file %s: %w", path, err)
+	}
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate lints the campaign's templates and limits
+func (c *Campaign) Validate() error {
+	if c.NoteTemplate != "" {
+		if err := templates.Lint(templates.Rule{Name: c.Name + " note", Kind: templates.KindNote, Text: c.NoteTemplate}); err != nil {
+			return err
+		}
+	}
+	for i, step := range c.Messages {
+		rule := templates.Rule{Name: fmt.Sprintf("%s message %d", c.Name, i+1), Kind: templates.KindMessage, Text: step.Template}
+		if err := templates.Lint(rule); err != nil {
+			return err
+		}
+		if err := step.CheckAttachment(); err != nil {
+			return fmt.Errorf("%s message %d: %w", c.Name, i+1, err)
+		}
+	}
+	for _, set := range []struct {
+		kind     string
+		variants []Variant
+	}{
+		{templates.KindNote, c.NoteVariants},
+		{templates.KindMessage, c.MessageVariants},
+	} {
+		seen := make(map[string]bool)
+		for i := range set.variants {
+			v := &set.variants[i]
+			if v.Name == "" {
+				v.Name = string(rune('A' + i))
+			}
+			if seen[v.Name] {
+				return fmt.Errorf("campaign %q: duplicate %s variant %q", c.Name, set.kind, v.Name)
+			}
+			seen[v.Name] = true
+			if v.Weight < 0 {
+				return fmt.Errorf("campaign %q: %s variant %q has a negative weight", c.Name, set.kind, v.Name)
+			}
+			rule := templates.Rule{Name: fmt.Sprintf("%s %s variant %s", c.Name, set.kind, v.Name), Kind: set.kind, Text: v.Template}
+			if err := templates.Lint(rule); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Limits.Daily < 0 || c.Limits.Weekly < 0 {
+		return fmt.Errorf("campaign %q: limits must not be negative", c.Name)
+	}
+	return nil
+}
+
+// CheckLimits returns ErrLimitReached if the campaign's daily or rolling
+// 7-day connection request limit is used up
+func (c *Campaign) CheckLimits(store storage.DataStore, now time.Time) error {
+	action := string(hooks.ActionConnect)
+	if c.Limits.Daily > 0 {
+		y, m, d := now.Date()
+		midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+		if n := store.CampaignActionsSince(c.Name, action, midnight); n >= c.Limits.Daily {
+			return fmt.Errorf("%w: %d/%d today", ErrLimitReached, n, c.Limits.Daily)
+		}
+	}
+	if c.Limits.Weekly > 0 {
+		if n := store.CampaignActionsSince(c.Name, action, now.Add(-7*24*time.Hour)); n >= c.Limits.Weekly {
+			return fmt.Errorf("%w: %d/%d this week", ErrLimitReached, n, c.Limits.Weekly)
+		}
+	}
+	return nil
+}
+
+// Variants returns the campaign's variants of a template kind
+func (c *Campaign) Variants(kind string) []Variant {
+	if kind == templates.KindNote {
+		return c.NoteVariants
+	}
+	return c.MessageVariants
+}
+
+// PickVariant returns the profile's variant of a template kind, assigning one
+// by weight the first time. ok is false when the campaign has no variants of
+// that kind.
+func (c *Campaign) PickVariant(store storage.DataStore, kind, profileURL string) (v Variant, ok bool, err error) {
+	variants := c.Variants(kind)
+	if len(variants) == 0 {
+		return Variant{}, false, nil
+	}
+	if name := store.VariantOf(c.Name, kind, profileURL); name != "" {
+		for _, v := range variants {
+			if v.Name == name {
+				return v, true, nil
+			}
+		}
+		// The variant was removed from the file, assign a current one
+	}
+
+	total := 0
+	for _, v := range variants {
+		total += weight(v)
+	}
+	n := rand.Intn(total)
+	for _, v = range variants {
+		if n < weight(v) {
+			break
+		}
+		n -= weight(v)
+	}
+	return v, true, store.AssignVariant(c.Name, kind, profileURL, v.Name)
+}
+
+// weight is a variant's share, 1 when unset
+func weight(v Variant) int {
+	if v.Weight == 0 {
+		return 1
+	}
+	return v.Weight
+}
@@ -0,0 +1,150 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/logger"
+)
+
+// ErrChallenge is returned when a challenge can't be waited out: the browser
+// is headless, or nobody solved it within the wait timeout
+var ErrChallenge = errors.New("security challenge requires manual resolution")
+
+// Kind is the type of challenge LinkedIn is showing
+type Kind string
+
+const (
+	None            Kind = ""
+	Captcha         Kind = "captcha"
+	Phone           Kind = "phone_verification"
+	Pin             Kind = "pin_verification"
+	UnusualActivity Kind = "unusual_activity"
+	Other           Kind = "checkpoint"
+)
+
+// Challenge page parts; the puzzle is an Arkose/FunCaptcha or reCAPTCHA iframe.
+// Invisible reCAPTCHA frames sit on normal pages too, so frames only count on
+// a checkpoint page.
+const (
+	captchaSelector = `#captcha-internal`
+	captchaFrames   = `iframe[src*="captcha"], iframe[src*="arkoselabs"], iframe[title*="captcha" i]`
+	phoneSelector   = `input[name="phoneNumber"], input[type="tel"][id*="phone"]`
+	pinSelector     = `input[name="pin"], input#input__email_verification_pin, input#input__phone_verification_pin`
+)
+
+// unusualPhrases appear on the "we've noticed unusual activity" interstitial
+var unusualPhrases = []string{
+	"unusual activity",
+	"let's do a quick security check",
+}
+
+// pollInterval is how often a paused bot checks whether the challenge is gone
+const pollInterval = 5 * time.Second
+
+// Detect reports which challenge the page shows, None if it is a normal page.
+// It only inspects the page as it is and never waits for elements.
+func Detect(page *rod.Page) Kind {
+	info, err := page.Info()
+	if err != nil {
+		return None
+	}
+	onCheckpoint := strings.Contains(info.URL, "/checkpoint/") ||
+		strings.Contains(info.Title, "Security Verification") ||
+		strings.Contains(info.Title, "Challenge")
+
+	switch {
+	case has(page, captchaSelector), onCheckpoint && has(page, captchaFrames):
+		return Captcha
+	case onCheckpoint && has(page, phoneSelector):
+		return Phone
+	case onCheckpoint && has(page, pinSelector):
+		return Pin
+	}
+
+	if !onCheckpoint {
+		return None
+	}
+	if body, err := page.Element("body"); err == nil {
+		text, _ := body.Text()
+		text = strings.ReplaceAll(strings.ToLower(text), "’", "'")
+		for _, p := range unusualPhrases {
+			if strings.Contains(text, p) {
+				return UnusualActivity
+			}
+		}
+	}
+	return Other
+}
+
+// has reports whether selector matches, without waiting
+func has(page *rod.Page, selector string) bool {
+	ok, _, _ := page.Has(selector)
+	return ok
+}
+
+// Service pauses workflows on security challenges until they are resolved by hand
+type Service struct {
+	Browser *browser.Browser
+	Log     logger.Logger
+
+	// WaitTimeout bounds the wait for manual resolution
+	WaitTimeout time.Duration
+
+	// OnDetected is called for every challenge found, e.g. to count it or
+	// send the checkpoint notification
+	OnDetected func(Kind)
+}
+
+// New creates a checkpoint Service configured from the checkpoint config section
+func New(b *browser.Browser, l logger.Logger) *Service {
+	return &Service{
+		Browser:     b,
+		Log:         l,
+		WaitTimeout: b.Cfg.Checkpoint.WaitTimeout,
+	}
+}
+
+// Handle checks the current page for a challenge. With none it returns nil at
+// once. In headful mode it waits until the page no longer shows the challenge,
+// ctx is cancelled or WaitTimeout passes; headless it returns ErrChallenge.
+func (s *Service) Handle(ctx context.Context) error {
+	kind := Detect(s.Browser.Page)
+	if kind == None {
+		return nil
+	}
+	url := ""
+	if info, err := s.Browser.Page.Info(); err == nil {
+		url = info.URL
+	}
+	s.Log.Warn("Security challenge detected", "kind", kind, "url", url)
+	if s.OnDetected != nil {
+		s.OnDetected(kind)
+	}
+
+	if s.Browser.Cfg.Headless {
+		s.Log.Error("Security challenge can't be solved in headless mode, stopping", "kind", kind)
+		return fmt.Errorf("%w: %s", ErrChallenge, kind)
+	}
+
+	s.Log.Warn("Bot paused: solve the challenge in the browser window to resume", "timeout", s.WaitTimeout)
+	deadline := time.Now().Add(s.WaitTimeout)
+	for Detect(s.Browser.Page) != None {
+		if s.WaitTimeout > 0 && time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s not solved within %s", ErrChallenge, kind, s.WaitTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+	s.Log.Info("Security challenge resolved, resuming", "kind", kind)
+	return nil
+}
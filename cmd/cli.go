@@ -0,0 +1,527 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"linkedin-automation/search"
+)
+
+// Options holds every command-line option; each subcommand registers the ones it uses
+type Options struct {
+	Command string
+
+	// Common to every command
+	ConfigFile    string
+	RestoreBackup string
+	Account       string
+
+	// Commands that drive the browser
+	ControlFile  string
+	PauseTimeout time.Duration
+	ServeAddr    string
+	MetricsAddr  string
+	ConfirmSends bool
+	Campaign     string
+	Tags         string
+
+	// Search criteria (connect, view, search, observe)
+	Keywords string
+	Title    string
+	Company  string
+	Location string
+	Industry string
+	Network  string
+	MaxPages int
+	Seed     string
+	Group    string
+	Event    string
+	Post     string
+
+	// CompanyPage is a company URL whose employees are targeted
+	CompanyPage string
+
+	UndoFile string
+	Input    string
+	Sheet    string
+
+	ObserveProfile string
+	ObserveOut     string
+
+	// Overrides for the withdraw config section (zero keeps the config value)
+	MaxAge    time.Duration
+	MaxPerRun int
+
+	// Entries for the exclude command
+	ExcludeProfile string
+	ExcludeCompany string
+	ExcludeKeyword string
+
+	// Profile the audit command shows entries for, empty = all
+	AuditProfile string
+
+	// Conversations the replies and archive-conversations commands scan
+	MaxThreads int
+
+	// Profile archive-conversations reads the one thread with, empty = the inbox
+	ArchiveProfile string
+
+	// Connections export-connections writes, 0 = all
+	MaxConnections int
+
+	// Listen address of the serve command's API (default from config)
+	APIAddr string
+	// Listen address of the dashboard command
+	DashboardAddr string
+
+	// Accounts and browser pool of the parallel command, and the workflow it runs
+	Accounts string
+	Pool     int
+	Wrapped  *Options
+
+	// Output of search, replies, export, export-connections and archive-conversations
+	Format string
+	Out    string
+
+	// set holds the flags given on the command line, for ForwardArgs
+	set map[string]string
+}
+
+// Command describes a subcommand: its help text, its flags and whether it needs a logged-in browser
+type Command struct {
+	Name    string
+	Summary string
+	Offline bool
+	Flags   func(fs *flag.FlagSet, o *Options)
+	// Wraps is set for commands taking another command and its flags after their own
+	Wraps bool
+}
+
+var commands = []Command{
+	{
+		Name:    "connect",
+		Summary: "Search for people and send one connection request",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+			fs.StringVar(&o.UndoFile, "undo-file", ".undo", "Create this file (or press Enter) during the undo grace window to withdraw the just-sent request")
+			fs.StringVar(&o.Input, "input", "", "CSV or Google Sheet of target profile URLs (optional first_name and note variable columns); skips search")
+		},
+	},
+	{
+		Name:    "message",
+		Summary: "Detect new connections and send (or queue) follow-up messages",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "sequence",
+		Summary: "Send the next due step of the drip sequence to each connection",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "flush-messages",
+		Summary: "Send follow-ups queued by defer_messages",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "endorse",
+		Summary: "Endorse 1-3 top skills of connections, up to endorse.daily_limit a day",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "congrats",
+		Summary: "Congratulate connections on new jobs and work anniversaries, up to congrats.daily_limit a day",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "birthdays",
+		Summary: "Wish connections a happy birthday once a year, up to birthdays.daily_limit a day",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "view",
+		Summary: "View search results' profiles without connecting, up to view.daily_limit a day",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+		},
+	},
+	{
+		Name:    "withdraw",
+		Summary: "Withdraw pending invitations older than withdraw.max_age",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			fs.DurationVar(&o.MaxAge, "max-age", 0, "Withdraw invitations older than this (default from config)")
+			fs.IntVar(&o.MaxPerRun, "max-per-run", 0, "Maximum invitations to withdraw (default from config)")
+		},
+	},
+	{
+		Name:    "accepted",
+		Summary: "Record which pending invitations were accepted, for acceptance rates",
+		Flags:   browserFlags,
+	},
+	{
+		Name:    "retry",
+		Summary: "Re-invite withdrawn profiles after withdraw.reeligible_after, with withdraw.retry_note",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			fs.StringVar(&o.UndoFile, "undo-file", ".undo", "Create this file (or press Enter) during the undo grace window to withdraw the just-sent request")
+		},
+	},
+	{
+		Name:    "search",
+		Summary: "Run a search and list the profiles found, without contacting anyone",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+			fs.StringVar(&o.Format, "format", "csv", "Output format: csv or json (JSON Lines)")
+			fs.StringVar(&o.Out, "out", "", "Write results to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "replies",
+		Summary: "List inbox conversations waiting for your answer",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			fs.IntVar(&o.MaxThreads, "max-threads", 20, "Number of recent conversations to scan")
+			fs.StringVar(&o.Format, "format", "csv", "Output format: csv or json (JSON Lines)")
+			fs.StringVar(&o.Out, "out", "", "Write replies to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "archive-conversations",
+		Summary: "Write the full message history of inbox conversations as JSON Lines",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			fs.IntVar(&o.MaxThreads, "max-threads", 50, "Number of recent conversations to archive")
+			fs.StringVar(&o.ArchiveProfile, "profile", "", "Archive only the conversation with this profile URL")
+			fs.StringVar(&o.Out, "out", "", "Write conversations to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "export-connections",
+		Summary: "Export all 1st-degree connections with headline, company and connected-on date",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			fs.IntVar(&o.MaxConnections, "max", 0, "Export only the newest N connections (0 = all)")
+			fs.StringVar(&o.Format, "format", "csv", "Output format: csv or json (JSON Lines)")
+			fs.StringVar(&o.Out, "out", "", "Write connections to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "daemon",
+		Summary: "Stay running and execute the workflows scheduled in daemon.jobs",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+		},
+	},
+	{
+		Name:    "serve",
+		Summary: "Stay running and execute workflows queued through the HTTP API",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+			fs.StringVar(&o.APIAddr, "addr", "", "API listen address (default api.addr, 127.0.0.1:8787)")
+		},
+	},
+	{
+		Name:    "observe",
+		Summary: "Visit key pages and report which selectors resolve, no actions are taken",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+			fs.StringVar(&o.ObserveProfile, "observe-profile", "https://www.linkedin.com/in/me/", "Profile URL to inspect")
+			fs.StringVar(&o.ObserveOut, "observe-out", "observe_report.json", "Where to write the report")
+		},
+	},
+	{
+		Name:    "doctor",
+		Summary: "Check that every element the workflows click still resolves and report the broken ones",
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			browserFlags(fs, o)
+			searchFlags(fs, o)
+			fs.StringVar(&o.ObserveProfile, "profile", "", "Profile you are not connected to (default the first search result)")
+			fs.StringVar(&o.ObserveOut, "out", "doctor_report.json", "Where to write the report")
+		},
+	},
+	{
+		Name:    "parallel",
+		Summary: "Run a workflow for several accounts at once, each in its own browser",
+		Wraps:   true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.Accounts, "accounts", "", "Comma-separated accounts to run (default all)")
+			fs.IntVar(&o.Pool, "pool", 0, "Most accounts running at once (default parallel.pool)")
+		},
+	},
+	{
+		Name:    "status",
+		Summary: "Print counters from the state file",
+		Offline: true,
+	},
+	{
+		Name:    "report",
+		Summary: "Show acceptance and reply rates per A/B template variant",
+		Offline: true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.Campaign, "campaign", "", "Only this campaign (name or .yaml file)")
+			fs.StringVar(&o.Format, "format", "text", "Output format: text or json (JSON Lines)")
+			fs.StringVar(&o.Out, "out", "", "Write to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "audit",
+		Summary: "Show the audit log of actions, for one profile or all",
+		Offline: true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.AuditProfile, "profile", "", "Only entries for this profile URL")
+			fs.StringVar(&o.Format, "format", "text", "Output format: text or json (JSON Lines)")
+			fs.StringVar(&o.Out, "out", "", "Write to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "export",
+		Summary: "Export every stored profile with its timestamps, campaigns and tags",
+		Offline: true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.Format, "format", "csv", "Output format: csv or json (JSON Lines)")
+			fs.StringVar(&o.Out, "out", "", "Write to this file instead of stdout")
+		},
+	},
+	{
+		Name:    "sync-sheet",
+		Summary: "Write invited, accepted, messaged and replied status into a Google Sheet of targets",
+		Offline: true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.Sheet, "sheet", "", "Google Sheet URL of the targets (the tab's gid picks the tab)")
+		},
+	},
+	{
+		Name:    "dashboard",
+		Summary: "Serve a web page with the funnel, campaign progress and recent errors",
+		Offline: true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.DashboardAddr, "addr", "127.0.0.1:8788", "Dashboard listen address")
+		},
+	},
+	{
+		Name:    "exclude",
+		Summary: "Add a profile, company or headline keyword to the exclusion list, or list it",
+		Offline: true,
+		Flags: func(fs *flag.FlagSet, o *Options) {
+			fs.StringVar(&o.ExcludeProfile, "profile", "", "Profile URL never to contact")
+			fs.StringVar(&o.ExcludeCompany, "company", "", "Company whose employees are never contacted")
+			fs.StringVar(&o.ExcludeKeyword, "keyword", "", "Skip profiles whose headline contains this (e.g. recruiter)")
+		},
+	},
+}
+
+// browserFlags registers the options shared by commands that log in and act
+func browserFlags(fs *flag.FlagSet, o *Options) {
+	fs.StringVar(&o.ControlFile, "control-file", ".pause", "Pause the workflow while this file exists")
+	fs.DurationVar(&o.PauseTimeout, "pause-timeout", 2*time.Hour, "Maximum time to stay paused before resuming anyway")
+	fs.StringVar(&o.ServeAddr, "serve", "", "Serve GET /healthz on this address (e.g. :8080)")
+	fs.StringVar(&o.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics at /metrics on this address (e.g. :9090)")
+	fs.BoolVar(&o.ConfirmSends, "confirm-sends", false, "Print each rendered note/message and ask y/n before clicking Send")
+	fs.StringVar(&o.Campaign, "campaign", "", "Campaign name to tag actioned profiles with, or a campaign .yaml file")
+	fs.StringVar(&o.Tags, "tags", "", "Comma-separated tags to attach to actioned profiles")
+}
+
+// searchFlags registers the search criteria
+func searchFlags(fs *flag.FlagSet, o *Options) {
+	fs.StringVar(&o.Keywords, "keywords", "Software Engineer", "General search keywords; separate several searches with ';'")
+	fs.StringVar(&o.Title, "title", "", "Job title to search for")
+	fs.StringVar(&o.Company, "company", "", "Company to search for")
+	fs.StringVar(&o.Location, "location", "", "Location to search for: a place name (see search.geo_urns), geo id or URN")
+	fs.StringVar(&o.Industry, "industry", "", "Industry to filter by: a name (see search.industry_urns), id or URN")
+	fs.StringVar(&o.Network, "network", "", "Comma-separated connection degrees to include: 1st, 2nd, 3rd")
+	fs.IntVar(&o.MaxPages, "pages", 1, "Max search pages to scrape")
+	fs.StringVar(&o.Seed, "seed", "", "Seed profile URL: use its 'People also viewed' sidebar instead of searching")
+	fs.StringVar(&o.Event, "event", "", "Event URL: target its attendees instead of searching, with events.note as the note")
+	fs.StringVar(&o.Post, "post", "", "Post URL: target the people who reacted to or commented on it, with posts.note as the note")
+	fs.StringVar(&o.CompanyPage, "company-page", "", "Company URL: target its employees from the People tab, filtered by --title and --location")
+	fs.StringVar(&o.Group, "group", "", "Group URL: target its members instead of searching (the account must be a member)")
+}
+
+// lookupCommand returns the subcommand with the given name
+func lookupCommand(name string) (Command, bool) {
+	for _, c := range commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// ParseArgs picks the subcommand from args (without the program name) and parses its flags.
+// The older "-mode X" and "-observe" invocations are still accepted.
+func ParseArgs(args []string) (*Options, Command, error) {
+	name, rest := "connect", args
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, rest = args[0], args[1:]
+	} else {
+		name, rest = legacyCommand(args)
+	}
+
+	if name == "help" {
+		printUsage(os.Stdout)
+		return nil, Command{}, flag.ErrHelp
+	}
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		printUsage(os.Stderr)
+		return nil, Command{}, fmt.Errorf("unknown command %q", name)
+	}
+
+	o := &Options{Command: name}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&o.ConfigFile, "config", "config.yaml", "Path to configuration file")
+	fs.StringVar(&o.RestoreBackup, "restore-backup", "", "Restore the state file from this backup before running")
+	fs.StringVar(&o.Account, "account", "", "Account from the accounts config section to use (default the first)")
+	if cmd.Flags != nil {
+		cmd.Flags(fs, o)
+	}
+	usage := "[flags]"
+	if cmd.Wraps {
+		usage = "[flags] <command> [command flags]"
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: linkedin-bot %s %s\n\n%s\n\nFlags:\n", cmd.Name, usage, cmd.Summary)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(rest); err != nil {
+		return nil, cmd, err
+	}
+	if cmd.Wraps {
+		if err := o.parseWrapped(fs.Args()); err != nil {
+			return nil, cmd, err
+		}
+	} else if fs.NArg() > 0 {
+		return nil, cmd, fmt.Errorf("unexpected arguments for %s: %s", name, strings.Join(fs.Args(), " "))
+	}
+	o.set = make(map[string]string)
+	fs.Visit(func(f *flag.Flag) { o.set[f.Name] = f.Value.String() })
+	if _, err := search.NetworkCodes(o.networkDegrees()); err != nil {
+		return nil, cmd, err
+	}
+	switch o.Format {
+	case "", "csv", "json", "jsonl":
+	default:
+		return nil, cmd, fmt.Errorf("unknown output format %q (want csv or json)", o.Format)
+	}
+	return o, cmd, nil
+}
+
+// parseWrapped parses the workflow command and flags given after a wrapping
+// command's own flags into o.Wrapped. The config file is the wrapper's unless
+// the workflow names one.
+func (o *Options) parseWrapped(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("%s needs a command to run, e.g. %s connect --keywords=...", o.Command, o.Command)
+	}
+	if !daemonCommands[args[0]] {
+		return fmt.Errorf("%s can't run %q", o.Command, args[0])
+	}
+	wrapped, _, err := ParseArgs(args)
+	if err != nil {
+		return err
+	}
+	if _, ok := wrapped.set["config"]; !ok {
+		wrapped.ConfigFile = o.ConfigFile
+	}
+	o.Wrapped = wrapped
+	return nil
+}
+
+// ForwardArgs builds the arguments to run command for account in a child
+// process, passing on the flags given to this one that command accepts
+func (o *Options) ForwardArgs(command, account string) []string {
+	cmd, ok := lookupCommand(command)
+	if !ok {
+		return nil
+	}
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	if cmd.Flags != nil {
+		cmd.Flags(fs, &Options{})
+	}
+
+	args := []string{command, "--config=" + o.ConfigFile, "--account=" + account}
+	names := make([]string, 0, len(o.set))
+	for name := range o.set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fs.Lookup(name) != nil {
+			args = append(args, "--"+name+"="+o.set[name])
+		}
+	}
+	return args
+}
+
+// networkDegrees splits the --network flag
+func (o *Options) networkDegrees() []string {
+	if o.Network == "" {
+		return nil
+	}
+	return strings.Split(o.Network, ",")
+}
+
+// Criteria builds the search criteria for one of the keyword searches
+func (o *Options) Criteria(keywords string) search.Criteria {
+	return search.Criteria{
+		Keywords: keywords,
+		Title:    o.Title,
+		Company:  o.Company,
+		Location: o.Location,
+		Industry: o.Industry,
+		Network:  o.networkDegrees(),
+	}
+}
+
+// legacyCommand extracts "-mode X" / "-observe" from flag-only arguments
+func legacyCommand(args []string) (string, []string) {
+	name := "connect"
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		flagName := strings.TrimLeft(arg, "-")
+		switch {
+		case flagName == "mode" && i+1 < len(args):
+			name = args[i+1]
+			i++
+		case strings.HasPrefix(flagName, "mode="):
+			name = strings.TrimPrefix(flagName, "mode=")
+		case flagName == "observe" || flagName == "observe=true":
+			return "observe", append(rest, args[i+1:]...)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return name, rest
+}
+
+// printUsage lists the available subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: linkedin-bot <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-15s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintln(w, "\nRun 'linkedin-bot <command> -h' for the command's flags.")
+}
+
+// exitOnParseError exits 0 for -h/help and 2 for bad usage, like the flag package does
+func exitOnParseError(err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
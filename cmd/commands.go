@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"linkedin-automation/campaign"
+	"linkedin-automation/config"
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+	"linkedin-automation/messaging"
+	"linkedin-automation/notify"
+	"linkedin-automation/search"
+	"linkedin-automation/storage"
+	"linkedin-automation/templates"
+)
+
+// RunOffline runs the commands that only read the state file
+func RunOffline(ctx context.Context, log logger.Logger, opts *Options, cfg *config.Config, store *storage.MemoryStore) error {
+	out, closeOut, err := openOutput(opts.Out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+
+	switch opts.Command {
+	case "status":
+		PrintStatus(out, store.Stats())
+		PrintAcceptance(out, store)
+		return nil
+	case "export":
+		return ExportRecords(out, store.Records(), opts.Format)
+	case "exclude":
+		return RunExclude(out, opts, store)
+	case "report":
+		return RunReport(out, opts, store)
+	case "audit":
+		return RunAudit(out, opts, store)
+	case "sync-sheet":
+		return SyncSheet(ctx, log, cfg, store, opts.Sheet)
+	}
+	return fmt.Errorf("command %q needs a browser", opts.Command)
+}
+
+// RunSearchWorkflow searches for each keyword and lists the profiles found without contacting anyone
+func RunSearchWorkflow(ctx context.Context, log logger.Logger, searcher search.Finder, store storage.DataStore, opts *Options) error {
+	out, closeOut, err := openOutput(opts.Out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+
+	var results []SearchResult
+	if opts.Seed != "" {
+		urls, err := searcher.ScrapeRelated(ctx, opts.Seed)
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			results = append(results, SearchResult{Profile: search.Profile{URL: url}})
+		}
+	} else if found, ok, err := ListTargets(ctx, searcher, opts); ok {
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		for _, p := range found {
+			results = append(results, SearchResult{Profile: p})
+		}
+	} else {
+		for _, k := range SplitKeywords(opts.Keywords) {
+			found, err := searcher.SearchPeople(ctx, opts.Criteria(k), opts.MaxPages)
+			if errors.Is(err, search.ErrNoResults) {
+				log.Warn("Search matched no one", "keyword", k)
+				continue
+			}
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+			for _, p := range found {
+				results = append(results, SearchResult{Profile: p, Keyword: k})
+			}
+			if ctx.Err() != nil {
+				// Still write what was found before the shutdown request
+				break
+			}
+		}
+	}
+
+	for i := range results {
+		r := &results[i]
+		r.State = "new"
+		if store.IsConnected(r.URL) || r.Degree == 1 {
+			r.State = "connected"
+		} else if store.IsRequestSent(r.URL) {
+			r.State = "requested"
+		}
+		if meta, ok := store.GetProfileMeta(r.URL); ok {
+			r.Tags = meta.Tags
+		}
+	}
+	if err := WriteSearchResults(out, results, opts.Format); err != nil {
+		return err
+	}
+	log.Info("Search listed", "profiles", len(results))
+	return nil
+}
+
+// RunRepliesWorkflow lists conversations whose newest messages are from the
+// other party, writes them to --out and sends them to the notification channels
+func RunRepliesWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, notifier *notify.Notifier, opts *Options) error {
+	replies, err := messenger.UnansweredReplies(ctx, opts.MaxThreads)
+	if err != nil {
+		return err
+	}
+
+	out, closeOut, err := openOutput(opts.Out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+	if err := WriteReplies(out, replies, opts.Format); err != nil {
+		return err
+	}
+
+	if len(replies) > 0 {
+		fields := make(map[string]string, len(replies))
+		for _, r := range replies {
+			// Keyed by profile, two contacts can share a name
+			key := r.ProfileURL
+			if key == "" {
+				key = r.ThreadURL
+			}
+			fields[key] = r.Name + ": " + r.Snippet + " (" + r.ThreadURL + ")"
+		}
+		notifier.Notify(notify.Replies, fmt.Sprintf("%d conversations waiting for your answer", len(replies)), fields)
+	}
+	log.Info("Replies listed", "conversations", len(replies))
+	return nil
+}
+
+// RunExportConnectionsWorkflow writes every 1st-degree connection (or the
+// newest --max) as CSV or JSON Lines
+func RunExportConnectionsWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, opts *Options) error {
+	connections, err := messenger.ExportConnections(ctx, opts.MaxConnections)
+	if err != nil {
+		return err
+	}
+
+	out, closeOut, err := openOutput(opts.Out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+	if err := WriteConnections(out, connections, opts.Format); err != nil {
+		return err
+	}
+	log.Info("Connections written", "count", len(connections))
+	return nil
+}
+
+// RunArchiveConversationsWorkflow writes the full history of the newest
+// --max-threads conversations, or just the one with --profile, as JSON Lines.
+// Each conversation is written as soon as it is read.
+func RunArchiveConversationsWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, opts *Options) error {
+	out, closeOut, err := openOutput(opts.Out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+	enc := json.NewEncoder(out)
+
+	if opts.ArchiveProfile != "" {
+		c, err := messenger.GetConversation(ctx, opts.ArchiveProfile)
+		if err != nil {
+			return err
+		}
+		log.Info("Conversation written", "name", c.Name, "messages", len(c.Messages))
+		return enc.Encode(c)
+	}
+	n, err := messenger.ArchiveConversations(ctx, opts.MaxThreads, func(c messaging.Conversation) error {
+		return enc.Encode(c)
+	})
+	log.Info("Conversations written", "count", n)
+	return err
+}
+
+// WriteConnections writes the connections as CSV (with a header row) or JSON Lines
+func WriteConnections(w io.Writer, connections []messaging.Connection, format string) error {
+	switch format {
+	case "json", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, c := range connections {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"profile_url", "name", "headline", "company", "connected_on"})
+		for _, c := range connections {
+			connectedOn := ""
+			if !c.ConnectedOn.IsZero() {
+				connectedOn = c.ConnectedOn.Format("2006-01-02")
+			}
+			cw.Write([]string{c.ProfileURL, c.Name, c.Headline, c.Company, connectedOn})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %q (want csv or json)", format)
+}
+
+// WriteReplies writes the replies as CSV (with a header row) or JSON Lines
+func WriteReplies(w io.Writer, replies []messaging.Reply, format string) error {
+	switch format {
+	case "json", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, r := range replies {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"profile_url", "name", "snippet", "time", "thread_url"})
+		for _, r := range replies {
+			cw.Write([]string{r.ProfileURL, r.Name, r.Snippet, formatTime(r.Time), r.ThreadURL})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %q (want csv or json)", format)
+}
+
+// SearchResult is a search hit with what the store already knows about it
+type SearchResult struct {
+	search.Profile
+	Keyword string   `json:"keyword,omitempty"`
+	State   string   `json:"state"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// WriteSearchResults writes the results as CSV (with a header row) or JSON Lines
+func WriteSearchResults(w io.Writer, results []SearchResult, format string) error {
+	switch format {
+	case "json", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"profile_url", "state", "name", "headline", "company", "location", "mutual_connections", "degree", "keyword", "tags"})
+		for _, r := range results {
+			cw.Write([]string{
+				r.URL,
+				r.State,
+				r.Name,
+				r.Headline,
+				r.Company,
+				r.Location,
+				strconv.Itoa(r.MutualCount),
+				strconv.Itoa(r.Degree),
+				r.Keyword,
+				strings.Join(r.Tags, ";"),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %q (want csv or json)", format)
+}
+
+// PrintStatus writes a human-readable summary of the state counters
+func PrintStatus(w io.Writer, st storage.Stats) {
+	firstRun := "never"
+	if !st.FirstRun.IsZero() {
+		firstRun = st.FirstRun.Format("2006-01-02")
+	}
+	fmt.Fprintf(w, "First run:            %s\n", firstRun)
+	fmt.Fprintf(w, "Requests sent:        %d (today %d, last 7 days %d)\n", st.Requests, st.RequestsToday, st.RequestsThisWeek)
+	fmt.Fprintf(w, "Connections:          %d\n", st.Connections)
+	fmt.Fprintf(w, "Messages sent:        %d (today %d)\n", st.Messages, st.MessagesToday)
+	fmt.Fprintf(w, "Queued messages:      %d\n", st.QueuedMessages)
+	fmt.Fprintf(w, "Targets to resume:    %d\n", st.PendingTargets)
+	fmt.Fprintf(w, "Withdrawn requests:   %d\n", st.Withdrawn)
+	fmt.Fprintf(w, "Endorsed profiles:    %d (today %d)\n", st.Endorsed, st.EndorsedToday)
+	fmt.Fprintf(w, "Viewed profiles:      %d (today %d)\n", st.Viewed, st.ViewedToday)
+	fmt.Fprintf(w, "Login failures today: %d\n", st.LoginFailuresToday)
+
+	names := make([]string, 0, len(st.Campaigns))
+	for name := range st.Campaigns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := st.Campaigns[name]
+		fmt.Fprintf(w, "Campaign %-12s requests %d, messages %d\n", name+":", c[string(hooks.ActionConnect)], c[string(hooks.ActionMessage)])
+	}
+}
+
+// PrintAcceptance writes the acceptance rate of each campaign and note template
+func PrintAcceptance(w io.Writer, store *storage.MemoryStore) {
+	names := store.CampaignNames()
+	notes := store.AcceptanceByNote()
+	if len(names) == 0 && len(notes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nAcceptance:")
+	for _, name := range names {
+		f := store.Funnel(name)
+		fmt.Fprintf(w, "  campaign %-20s %3d/%-3d %s\n", name, f.Accepted, f.Sent, rate(f.Accepted, f.Sent))
+	}
+	for _, n := range notes {
+		text := strings.Join(strings.Fields(n.Template), " ")
+		if r := []rune(text); len(r) > 50 {
+			text = string(r[:47]) + "..."
+		}
+		fmt.Fprintf(w, "  note %s %3d/%-3d %s  %q\n", n.ID, n.Accepted, n.Sent, rate(n.Accepted, n.Sent), text)
+	}
+}
+
+// rate formats part/total as a percentage, "-" when nothing was sent
+func rate(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", part*100/total)
+}
+
+// VariantRow is one line of the A/B report
+type VariantRow struct {
+	Campaign string `json:"campaign"`
+	storage.VariantStats
+}
+
+// RunReport writes the A/B variant results of --campaign, or of every
+// campaign with variants, as text or JSON Lines
+func RunReport(w io.Writer, opts *Options, store *storage.MemoryStore) error {
+	names := store.CampaignNames()
+	if opts.Campaign != "" {
+		name := opts.Campaign
+		if campaign.IsFile(name) {
+			c, err := campaign.Load(name)
+			if err != nil {
+				return err
+			}
+			name = c.Name
+		}
+		names = []string{name}
+	}
+
+	var rows []VariantRow
+	for _, name := range names {
+		for _, v := range store.VariantReport(name) {
+			rows = append(rows, VariantRow{Campaign: name, VariantStats: v})
+		}
+	}
+
+	switch opts.Format {
+	case "json", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, r := range rows {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "", "text":
+		if len(rows) == 0 {
+			fmt.Fprintln(w, "No template variants recorded. Add note_variants or message_variants to a campaign file.")
+			return nil
+		}
+		last := ""
+		for _, r := range rows {
+			if r.Campaign != last {
+				fmt.Fprintf(w, "Campaign %s\n", r.Campaign)
+				last = r.Campaign
+			}
+			outcome, n := "accepted", r.Accepted
+			if r.Kind == "message" {
+				outcome, n = "replied", r.Replied
+			}
+			fmt.Fprintf(w, "  %-7s %-12s assigned %4d  sent %4d  %s %4d (%s)\n", r.Kind, r.Variant, r.Assigned, r.Sent, outcome, n, rate(n, r.Sent))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown report format %q (want text or json)", opts.Format)
+}
+
+// AuditEntryFor turns an action result into an audit entry. The template
+// and connect result come from the result's metadata, the A/B variant from
+// the campaigns the profile is tagged with.
+func AuditEntryFor(store storage.DataStore, account string, r hooks.ActionResult) storage.AuditEntry {
+	e := storage.AuditEntry{
+		Time:       r.Time,
+		Account:    account,
+		Action:     string(r.Action),
+		ProfileURL: r.ProfileURL,
+		Result:     string(r.Outcome),
+		Metadata:   make(map[string]string),
+	}
+	if r.Error != nil {
+		e.Error = r.Error.Error()
+	}
+	for k, v := range r.Metadata {
+		switch k {
+		case "template":
+			e.Template = v
+		case "result":
+			e.Result = v
+		default:
+			e.Metadata[k] = v
+		}
+	}
+
+	kind := ""
+	switch r.Action {
+	case hooks.ActionConnect:
+		kind = templates.KindNote
+	case hooks.ActionMessage:
+		kind = templates.KindMessage
+	}
+	if meta, ok := store.GetProfileMeta(r.ProfileURL); ok && kind != "" {
+		for _, c := range meta.Campaigns {
+			if v := store.VariantOf(c, kind, r.ProfileURL); v != "" {
+				e.Variant = c + "/" + v
+				break
+			}
+		}
+	}
+	return e
+}
+
+// RunAudit prints the audit log, for one profile with --profile
+func RunAudit(w io.Writer, opts *Options, store *storage.MemoryStore) error {
+	entries, err := store.AuditTrail(opts.AuditProfile)
+	if err != nil {
+		return err
+	}
+	switch opts.Format {
+	case "json", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "", "text":
+		if len(entries) == 0 {
+			fmt.Fprintln(w, "No audit entries.")
+			return nil
+		}
+		for _, e := range entries {
+			line := fmt.Sprintf("%s  %-8s %-16s %s", e.Time.Local().Format("2006-01-02 15:04:05"), e.Action, e.Result, e.ProfileURL)
+			if e.Account != "" {
+				line += "  account=" + e.Account
+			}
+			if e.Variant != "" {
+				line += "  variant=" + e.Variant
+			}
+			if e.Error != "" {
+				line += "  error=" + e.Error
+			}
+			fmt.Fprintln(w, line)
+			if e.Template != "" {
+				fmt.Fprintf(w, "    template: %s\n", strings.ReplaceAll(e.Template, "\n", " "))
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown audit format %q (want text or json)", opts.Format)
+}
+
+// RunExclude adds the given entries to the stored exclusion list, or prints
+// the list when none are given. Config's blacklist section is not included.
+func RunExclude(w io.Writer, opts *Options, store storage.DataStore) error {
+	entries := map[string]string{
+		storage.ExcludeProfile: opts.ExcludeProfile,
+		storage.ExcludeCompany: opts.ExcludeCompany,
+		storage.ExcludeKeyword: opts.ExcludeKeyword,
+	}
+	added := 0
+	for _, kind := range []string{storage.ExcludeProfile, storage.ExcludeCompany, storage.ExcludeKeyword} {
+		if entries[kind] == "" {
+			continue
+		}
+		if err := store.Exclude(kind, entries[kind]); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Excluded %s: %s\n", kind, entries[kind])
+		added++
+	}
+	if added > 0 {
+		return nil
+	}
+
+	e := store.Exclusions()
+	for _, l := range []struct {
+		kind   string
+		values []string
+	}{
+		{storage.ExcludeProfile, e.Profiles},
+		{storage.ExcludeCompany, e.Companies},
+		{storage.ExcludeKeyword, e.Keywords},
+	} {
+		for _, v := range l.values {
+			fmt.Fprintf(w, "%-8s %s\n", l.kind, v)
+		}
+	}
+	return nil
+}
+
+// ExportRecords writes the records as CSV (with a header row) or JSON Lines
+func ExportRecords(w io.Writer, records []storage.Record, format string) error {
+	switch format {
+	case "json", "jsonl":
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "", "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"profile_url", "requested_at", "connected_at", "messaged_at", "withdrawn_at", "endorsed_at", "viewed_at", "replied_at", "campaigns", "tags", "keyword"})
+		for _, r := range records {
+			cw.Write([]string{
+				r.ProfileURL,
+				formatTime(r.RequestedAt),
+				formatTime(r.ConnectedAt),
+				formatTime(r.MessagedAt),
+				formatTime(r.WithdrawnAt),
+				formatTime(r.EndorsedAt),
+				formatTime(r.ViewedAt),
+				formatTime(r.RepliedAt),
+				strings.Join(r.Campaigns, ";"),
+				strings.Join(r.Tags, ";"),
+				r.Keyword,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown export format %q (want csv or json)", format)
+}
+
+// formatTime renders a timestamp as RFC 3339, empty for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// openOutput returns the file at path, or stdout when path is empty
+func openOutput(path string) (io.Writer, func(), error) {
+	if path == "" {
+		return os.Stdout, func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+	"linkedin-automation/schedule"
+	"linkedin-automation/stealth"
+)
+
+// daemonCommands are the workflows a daemon job may run
+var daemonCommands = map[string]bool{
+	"connect":        true,
+	"message":        true,
+	"sequence":       true,
+	"flush-messages": true,
+	"withdraw":       true,
+	"retry":          true,
+	"accepted":       true,
+	"endorse":        true,
+	"congrats":       true,
+	"birthdays":      true,
+	"view":           true,
+	"replies":        true,
+}
+
+// engagementCommands are the workflows that still run with
+// preflight.on_warned: engagement, they never invite or message anyone
+var engagementCommands = map[string]bool{
+	"view":    true,
+	"endorse": true,
+}
+
+// errEngagementOnly is returned for outreach jobs while only engagement runs
+var errEngagementOnly = errors.New("account shows warning signs, only view and endorse run")
+
+// scheduledJob is a daemon job with its parsed schedule and next firing time
+type scheduledJob struct {
+	config.DaemonJob
+	sched  *schedule.Schedule
+	slot   time.Time // next slot of the schedule
+	fireAt time.Time // slot with jitter applied
+}
+
+// minJobGap is the shortest wait before a job fires, so slots the jitter
+// pulls into the past can't run a job back to back
+const minJobGap = time.Minute
+
+// advance moves the job to its first slot after now and after the slot it
+// just served, jittered by up to ±jitter. A job fired early by the jitter
+// would otherwise get the same slot again and run twice. A schedule with no
+// slot left leaves the job done.
+func (j *scheduledJob) advance(now time.Time, jitter time.Duration) {
+	from := now
+	if j.slot.After(from) {
+		from = j.slot
+	}
+	j.slot = j.sched.Next(from)
+	if j.done() {
+		return
+	}
+	j.fireAt = j.slot
+	if jitter > 0 {
+		j.fireAt = j.slot.Add(time.Duration(rand.Int63n(int64(2*jitter))) - jitter)
+	}
+	if earliest := now.Add(minJobGap); j.fireAt.Before(earliest) {
+		j.fireAt = earliest
+	}
+}
+
+// done reports whether the job's schedule never fires again
+func (j *scheduledJob) done() bool {
+	return j.slot.IsZero()
+}
+
+// nextJob returns the job due first, nil when none fires again
+func nextJob(jobs []*scheduledJob) *scheduledJob {
+	var next *scheduledJob
+	for _, j := range jobs {
+		if !j.done() && (next == nil || j.fireAt.Before(next.fireAt)) {
+			next = j
+		}
+	}
+	return next
+}
+
+// parseJobs checks the configured jobs and parses their schedules
+func parseJobs(jobs []config.DaemonJob) ([]*scheduledJob, error) {
+	if len(jobs) == 0 {
+		return nil, errors.New("no daemon jobs configured, add 'daemon.jobs' to config")
+	}
+	var out []*scheduledJob
+	for _, j := range jobs {
+		if !daemonCommands[j.Command] {
+			return nil, fmt.Errorf("daemon job command %q can't be scheduled", j.Command)
+		}
+		sched, err := schedule.Parse(j.Cron)
+		if err != nil {
+			return nil, err
+		}
+		if sched.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("daemon job %q: cron %q never fires", j.Command, j.Cron)
+		}
+		out = append(out, &scheduledJob{DaemonJob: j, sched: sched})
+	}
+	return out, nil
+}
+
+// RunDaemon runs the configured jobs on their schedules until ctx is cancelled.
+// session is called before each job and on keep-alive to make sure the browser
+// is still logged in; run executes one workflow. With sessions set, jobs due
+// during a break wait for its end and jobs after active hours are skipped.
+func RunDaemon(ctx context.Context, log logger.Logger, cfg *config.Config, sessions *stealth.SessionManager, session func() error, run func(command string) error) error {
+	jobs, err := parseJobs(cfg.Daemon.Jobs)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		j.advance(time.Now(), cfg.Daemon.Jitter)
+	}
+	log.Info("Daemon started", "jobs", len(jobs), "jitter", cfg.Daemon.Jitter, "business_hours_only", cfg.Daemon.BusinessHoursOnly, "sessions", sessions != nil)
+
+	lastActive := time.Now()
+	for ctx.Err() == nil {
+		next := nextJob(jobs)
+		if next == nil {
+			return errors.New("no daemon job is scheduled to run again")
+		}
+		log.Info("Next scheduled job", "command", next.Command, "at", next.fireAt.Format("Mon 15:04:05"))
+
+		// Idle until the job is due, touching the session every keep_alive
+		for ctx.Err() == nil && time.Now().Before(next.fireAt) {
+			keepAliveAt := lastActive.Add(cfg.Daemon.KeepAlive)
+			if cfg.Daemon.KeepAlive <= 0 || !keepAliveAt.Before(next.fireAt) {
+				sleepCtx(ctx, time.Until(next.fireAt))
+				continue
+			}
+			sleepCtx(ctx, time.Until(keepAliveAt))
+			if ctx.Err() != nil {
+				break
+			}
+			lastActive = time.Now()
+			if cfg.Daemon.BusinessHoursOnly && !stealth.IsBusinessHours() {
+				continue
+			}
+			if sessions != nil && !sessions.Hours.Active(time.Now()) {
+				continue
+			}
+			log.Debug("Keep-alive: checking session")
+			if err := session(); err != nil {
+				log.Warn("Keep-alive session check failed", "error", err)
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		next.advance(time.Now(), cfg.Daemon.Jitter)
+
+		if cfg.Daemon.BusinessHoursOnly && !stealth.IsBusinessHours() {
+			log.Info("Outside business hours, skipping scheduled job", "command", next.Command)
+			continue
+		}
+		if !holdForSession(ctx, log, sessions, next.Command) {
+			continue
+		}
+		if err := session(); err != nil {
+			log.Error("Session unavailable, skipping scheduled job", "command", next.Command, "error", err)
+			continue
+		}
+
+		log.Info("Running scheduled job", "command", next.Command, "cron", next.Cron)
+		if err := run(next.Command); err != nil {
+			log.Error("Scheduled job failed", "command", next.Command, "error", err)
+		}
+		lastActive = time.Now()
+	}
+	return nil
+}
+
+// holdForSession waits out a session break or lunch before a due job. It
+// reports false when the job should be skipped: after active hours or on
+// shutdown.
+func holdForSession(ctx context.Context, log logger.Logger, sessions *stealth.SessionManager, command string) bool {
+	for ctx.Err() == nil {
+		resume, reason := sessions.Next(time.Now())
+		if resume.IsZero() {
+			return true
+		}
+		if reason == stealth.ReasonOffHours {
+			log.Info("Outside active hours, skipping scheduled job", "command", command)
+			return false
+		}
+		log.Info("On a break, holding scheduled job", "command", command, "reason", reason, "until", resume.Format("Mon 15:04"))
+		sleepCtx(ctx, time.Until(resume))
+	}
+	return false
+}
+
+// RunAccountsDaemon runs the daemon schedule for several accounts, taking turns:
+// each job runs for the next account as a child process with its own browser,
+// session and state, so one account's failure never touches another's. With
+// parallel.pool above 1 each job runs for every account, that many at once.
+func RunAccountsDaemon(ctx context.Context, log logger.Logger, cfg *config.Config, opts *Options) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// The schedule follows the top-level business hours; each child also
+	// holds back outside its own account's
+	hours, err := LoadBusinessHours(cfg, stealth.Current())
+	if err != nil {
+		return err
+	}
+	stealth.UseHours(hours)
+	accounts := cfg.AccountNames()
+	// Each command takes its own turns, so alternating jobs still reach every account
+	turns := make(map[string]int)
+	job := func(command string) error {
+		account := accounts[turns[command]%len(accounts)]
+		turns[command]++
+		log.Info("Running job for account", "command", command, "account", account)
+		c := exec.Command(exe, opts.ForwardArgs(command, account)...)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("account %s: %w", account, err)
+		}
+		return nil
+	}
+	if pool := cfg.Parallel.Pool; pool > 1 {
+		job = func(command string) error {
+			return runAccounts(ctx, log, accounts, pool, func(account string) []string {
+				return opts.ForwardArgs(command, account)
+			})
+		}
+	}
+
+	// Sessions live in the child processes, there is no browser to keep alive here
+	rr := *cfg
+	rr.Daemon.KeepAlive = 0
+	log.Info("Multi-account daemon", "accounts", len(accounts), "pool", cfg.Parallel.Pool)
+	return RunDaemon(ctx, log, &rr, NewSessions(cfg), func() error { return nil }, job)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"linkedin-automation/config"
+	"linkedin-automation/schedule"
+	"linkedin-automation/stealth"
+)
+
+// LoadBusinessHours builds the account's business hours from the config,
+// with the persona's active hours where no from/to is set
+func LoadBusinessHours(cfg *config.Config, p stealth.Persona) (*schedule.Hours, error) {
+	bh := cfg.BusinessHours
+	if bh.From == "" {
+		bh.From = fmt.Sprintf("%02d:00", p.ActiveFrom)
+	}
+	if bh.To == "" {
+		bh.To = fmt.Sprintf("%02d:00", p.ActiveTo)
+	}
+	holidays := bh.Holidays
+	if bh.HolidaysFile != "" {
+		more, err := schedule.LoadHolidays(bh.HolidaysFile)
+		if err != nil {
+			return nil, fmt.Errorf("business_hours.holidays_file: %w", err)
+		}
+		holidays = append(append([]string(nil), holidays...), more...)
+	}
+	h, err := schedule.ParseHours(bh.Timezone, bh.From, bh.To, bh.Weekdays, holidays)
+	if err != nil {
+		return nil, fmt.Errorf("business_hours: %w", err)
+	}
+	return h, nil
+}
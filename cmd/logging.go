@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+)
+
+// SetupLogging builds the logger for the config: the console at
+// logging.level, plus the rotating log file and the run's own log file when
+// set, each at its own level
+func SetupLogging(console io.Writer, cfg *config.Config, start time.Time) (logger.Logger, error) {
+	lc := cfg.Logging
+	level, err := logger.ParseLevel(lc.Level)
+	if err != nil {
+		return nil, err
+	}
+	sinks := []logger.Sink{{W: console, Level: level}}
+
+	if lc.File != "" {
+		level, err := logger.ParseLevel(lc.FileLevel)
+		if err != nil {
+			return nil, err
+		}
+		f, err := logger.OpenRotating(lc.File, int64(lc.MaxSizeMB)<<20, lc.MaxBackups, lc.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, logger.Sink{W: f, Level: level, JSON: lc.JSON})
+	}
+	if lc.RunDir != "" {
+		level, err := logger.ParseLevel(lc.RunLevel)
+		if err != nil {
+			return nil, err
+		}
+		f, err := logger.OpenRunFile(lc.RunDir, cfg.Account, start)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, logger.Sink{W: f, Level: level, JSON: lc.JSON})
+	}
+	return logger.NewSinks(sinks...), nil
+}
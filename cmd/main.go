@@ -1,46 +1,95 @@
 package main
 
 import (
-	"flag"
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"linkedin-automation/ai"
+	"linkedin-automation/api"
 	"linkedin-automation/auth"
 	"linkedin-automation/browser"
+	"linkedin-automation/campaign"
+	"linkedin-automation/checkpoint"
 	"linkedin-automation/config"
 	"linkedin-automation/connect"
+	"linkedin-automation/dashboard"
+	"linkedin-automation/diagnostics"
+	"linkedin-automation/endorse"
+	"linkedin-automation/health"
+	"linkedin-automation/hooks"
 	"linkedin-automation/logger"
 	"linkedin-automation/messaging"
+	"linkedin-automation/metrics"
+	"linkedin-automation/notify"
+	"linkedin-automation/observe"
+	"linkedin-automation/preflight"
+	"linkedin-automation/profile"
+	"linkedin-automation/ratelimit"
 	"linkedin-automation/search"
+	"linkedin-automation/selectors"
+	"linkedin-automation/sheets"
+	"linkedin-automation/stealth"
 	"linkedin-automation/storage"
+	"linkedin-automation/summary"
+	"linkedin-automation/targets"
+	"linkedin-automation/templates"
+	"linkedin-automation/view"
+	"linkedin-automation/webhook"
+)
+
+// Built-in templates, used unless a template service provides replacements
+const (
+	defaultNoteTemplate    = "Hi {{name}}, I noticed your profile and would love to connect!"
+	defaultMessageTemplate = "Hi {{firstname}}, great to connect with you! I see we share similar interests in tech."
 )
 
 func main() {
-	// Flags
-	configFile := flag.String("config", "config.yaml", "Path to configuration file")
-	mode := flag.String("mode", "connect", "Mode: 'connect' (search & add) or 'message' (follow-up)")
-	keywords := flag.String("keywords", "Software Engineer", "General search keywords")
-	title := flag.String("title", "", "Job title to search for")
-	company := flag.String("company", "", "Company to search for")
-	location := flag.String("location", "", "Location to search for")
-	maxPages := flag.Int("pages", 1, "Max search pages to scrape")
-	flag.Parse()
+	opts, cmd, err := ParseArgs(os.Args[1:])
+	if err != nil {
+		exitOnParseError(err)
+	}
 
 	// 1. Initialize Logger
-	log := logger.New()
-	log.Info("Starting LinkedIn Automation Bot", "mode", *mode)
-
-	// 0. Stealth Check: Business Hours
-	if !IsBusinessHours() {
-		log.Warn("Outside business hours (9AM-6PM). proceeding cautiously.")
+	started := time.Now()
+	var console io.Writer = os.Stdout
+	if opts.Out == "" && (cmd.Offline || opts.Command == "search" || opts.Command == "replies" || opts.Command == "export-connections" || opts.Command == "archive-conversations") {
+		// Keep stdout clean for the command's output
+		console = os.Stderr
+	}
+	var logs *api.LogStream
+	if opts.Command == "serve" {
+		// Recent lines are kept for GET /api/logs
+		logs = api.NewLogStream(1000)
+		console = io.MultiWriter(os.Stdout, logs)
+	}
+	log := logger.NewWriter(console)
+	// useLogging moves the logger onto the config's sinks (files, levels)
+	useLogging := func(cfg *config.Config) {
+		l, err := SetupLogging(console, cfg, started)
+		if err != nil {
+			log.Error("Configuration error: logging", "error", err)
+			os.Exit(1)
+		}
+		log = l
 	}
+	log.Info("Starting LinkedIn Automation Bot", "command", opts.Command)
 
 	// 2. Load Config
-	cfg, err := config.LoadConfig(*configFile)
+	cfg, err := config.LoadConfig(opts.ConfigFile)
 	if err != nil {
 		// Fallback for demo purposes if file missing, assuming Env vars or defaults
 		log.Warn("Could not load config file, proceeding with defaults/env", "error", err)
@@ -49,55 +98,788 @@ func main() {
 		if cfg.Limits.DailyConnections == 0 {
 			cfg.Limits.DailyConnections = 10
 		}
+		cfg.Storage.Path = "state.json"
+	}
+
+	// With several accounts the daemon only schedules; each job runs as a child
+	// process for the next account in turn
+	if opts.Command == "daemon" && opts.Account == "" && len(cfg.Accounts) > 1 {
+		// The children keep their accounts' logs, this process the schedule's
+		useLogging(cfg)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := RunAccountsDaemon(ctx, log, cfg, opts); err != nil {
+			log.Error("Daemon failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if opts.Command == "parallel" {
+		useLogging(cfg)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := RunParallel(ctx, log, cfg, opts); err != nil {
+			log.Error("Parallel run failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	cfg, err = cfg.ForAccount(opts.Account)
+	if err != nil {
+		log.Error("Configuration error: account", "error", err)
+		os.Exit(1)
+	}
+	useLogging(cfg)
+	if cfg.Account != "" {
+		log.Info("Using account", "account", cfg.Account, "state", cfg.Storage.Path)
+	}
+
+	// The account's persona drives pauses, typing and scrolling; its active
+	// hours are the business hours unless business_hours sets them
+	if !cmd.Offline {
+		persona, created, err := stealth.LoadPersona(cfg.Browser.PersonaFile)
+		if err != nil {
+			log.Error("Failed to load persona", "error", err)
+			os.Exit(1)
+		}
+		if created {
+			log.Info("Generated behaviour persona", "file", cfg.Browser.PersonaFile, "active_from", persona.ActiveFrom, "active_to", persona.ActiveTo)
+		}
+		stealth.Use(persona)
+		hours, err := LoadBusinessHours(cfg, persona)
+		if err != nil {
+			log.Error("Configuration error", "error", err)
+			os.Exit(1)
+		}
+		stealth.UseHours(hours)
+
+		// 0. Stealth Check: Business Hours
+		if !stealth.IsBusinessHours() {
+			log.Warn("Outside business hours, proceeding cautiously", "hours", hours.String(), "next", hours.NextStart(time.Now()).Format("Mon 15:04"))
+		}
+	}
+
+	// Import file columns become template variables, read it before templates are linted
+	var imported []targets.Target
+	if opts.Input != "" {
+		imported, err = LoadTargets(cfg, opts.Input)
+		if err != nil {
+			log.Error("Failed to read targets", "file", opts.Input, "error", err)
+			os.Exit(1)
+		}
+		templates.AllowPlaceholders(targets.VarNames(imported)...)
+	}
+
+	// A campaign file bundles search, templates and limits; load it before anything starts
+	var camp *campaign.Campaign
+	if campaign.IsFile(opts.Campaign) {
+		camp, err = campaign.Load(opts.Campaign)
+		if err != nil {
+			log.Error("Failed to load campaign", "file", opts.Campaign, "error", err)
+			os.Exit(1)
+		}
+		log.Info("Campaign loaded", "name", camp.Name, "messages", len(camp.Messages))
+		applyCampaignSearch(opts, camp)
+		if _, err := search.NetworkCodes(opts.networkDegrees()); err != nil {
+			log.Error("Invalid campaign search", "file", opts.Campaign, "error", err)
+			os.Exit(1)
+		}
 	}
 
 	// Validate essential config for running
-	if cfg.LinkedIn.Username == "" && cfg.UserDataDir == "" {
+	if !cmd.Offline && cfg.LinkedIn.Username == "" && cfg.UserDataDir == "" && cfg.LinkedIn.SessionFile == "" && cfg.Browser.RemoteURL == "" {
 		log.Error("Configuration error: Username or UserDataDir is required.")
 		os.Exit(1)
 	}
+	notes, err := ai.New(cfg)
+	if err != nil {
+		log.Error("Configuration error: AI notes", "error", err)
+		os.Exit(1)
+	}
+	notifier, err := notify.New(cfg, log)
+	if err != nil {
+		log.Error("Configuration error: notifications", "error", err)
+		os.Exit(1)
+	}
+	webhooks, err := webhook.New(cfg, log)
+	if err != nil {
+		log.Error("Configuration error: webhooks", "error", err)
+		os.Exit(1)
+	}
+	// exit ends a failed run; os.Exit skips deferred calls, so queued webhook
+	// deliveries (checkpoints, failures) are flushed first
+	exit := func(code int) {
+		webhooks.Wait()
+		os.Exit(code)
+	}
+
+	for name, id := range cfg.Search.GeoURNs {
+		search.RegisterGeo(name, id)
+	}
+	for name, id := range cfg.Search.IndustryURNs {
+		search.RegisterIndustry(name, id)
+	}
+
+	// The state's account name in a shared Postgres database
+	account := cfg.Account
+	if account == "" {
+		account = "default"
+	}
+
+	// The dashboard reads the state file on every request without locking it,
+	// so it can run next to the bot
+	if opts.Command == "dashboard" {
+		path := cfg.Storage.Path
+		snapshot := func() (*storage.MemoryStore, error) { return storage.Snapshot(path) }
+		if dsn := cfg.Storage.Postgres; dsn != "" {
+			path = "postgres"
+			snapshot = func() (*storage.MemoryStore, error) { return storage.PostgresSnapshot(dsn, account) }
+		}
+		d := dashboard.New(snapshot, cfg.Account, log)
+		log.Info("Dashboard listening", "addr", opts.DashboardAddr, "state", path)
+		if err := http.ListenAndServe(opts.DashboardAddr, d); err != nil {
+			log.Error("Dashboard stopped", "error", err)
+			exit(1)
+		}
+		return
+	}
+
+	// 3. Initialize Storage
+	if opts.RestoreBackup != "" {
+		if err := storage.RestoreBackup(opts.RestoreBackup, cfg.Storage.Path); err != nil {
+			log.Error("Failed to restore backup", "file", opts.RestoreBackup, "error", err)
+			exit(1)
+		}
+		log.Info("State restored from backup", "file", opts.RestoreBackup)
+	}
+
+	var store *storage.MemoryStore
+	if cfg.Storage.Postgres != "" {
+		store, err = storage.OpenPostgres(cfg.Storage.Postgres, account, cfg.Storage.LockWait)
+		if err == nil {
+			log.Info("Using shared Postgres state", "account", account)
+		}
+	} else {
+		store, err = storage.Open(cfg.Storage.Path, cfg.Storage.LockWait)
+	}
+	if errors.Is(err, storage.ErrLocked) {
+		log.Error("Another instance is already running with this state", "path", cfg.Storage.Path, "postgres", cfg.Storage.Postgres != "")
+		exit(1)
+	}
+	if err != nil {
+		log.Error("Failed to initialize storage", "error", err)
+		exit(1)
+	}
+	defer store.Close()
+
+	// SIGINT/SIGTERM cancel ctx: the current action finishes, then storage and the
+	// browser are closed by the deferred calls. A second signal kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Closed before stop on a normal exit, so only a signal logs the shutdown
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		}
+		select {
+		case <-exited:
+			return
+		default:
+		}
+		stop()
+		log.Warn("Shutdown requested, finishing the current action (interrupt again to force)")
+	}()
+
+	if cfg.Storage.AuditFile != "" {
+		store.EnableAudit(cfg.Storage.AuditFile)
+	}
+	// Accounts share one claims file; a shared Postgres database keeps the claims itself
+	if cfg.Account != "" && cfg.Parallel.ClaimsFile != "" && cfg.Storage.Postgres == "" {
+		store.EnableClaims(cfg.Parallel.ClaimsFile, cfg.Account)
+	}
+	if cfg.Storage.BackupDir != "" && cfg.Storage.Postgres == "" {
+		if err := store.EnableBackups(cfg.Storage.BackupDir, cfg.Storage.BackupKeep, cfg.Storage.BackupEvery); err != nil {
+			log.Warn("State backups disabled", "error", err)
+		}
+	}
+
+	// Commands that only read the state file stop here, before the browser starts
+	if cmd.Offline {
+		if err := RunOffline(ctx, log, opts, cfg, store); err != nil {
+			log.Error("Command failed", "command", opts.Command, "error", err)
+			exit(1)
+		}
+		return
+	}
+
+	// Lifecycle webhooks; deliveries still in flight finish before exit
+	if webhooks != nil {
+		store.OnAccepted = func(url string, sent time.Time) {
+			webhooks.Emit(webhook.InviteAccepted, url, map[string]string{
+				"sent_at": sent.UTC().Format(time.RFC3339),
+			})
+		}
+		store.OnReplied = func(url string) { webhooks.Emit(webhook.ReplyDetected, url, nil) }
+		defer webhooks.Wait()
+	}
+
+	// Refuse to hammer a soft-locked account with repeated logins
+	if max := cfg.Limits.MaxLoginFailuresPerDay; max > 0 && store.LoginFailuresToday() >= max {
+		log.Error("Too many failed logins today, refusing to try again until tomorrow",
+			"failures", store.LoginFailuresToday(), "max", max)
+		notifier.Notify(notify.LoginFailed, "Login failure cap reached, not logging in until tomorrow", map[string]string{
+			"failures_today": fmt.Sprint(store.LoginFailuresToday()),
+			"max":            fmt.Sprint(max),
+		})
+		exit(1)
+	}
+
+	// Selector overrides are checked before launching so a broken file fails fast
+	sel, err := selectors.Load(cfg.SelectorsFile)
+	if err != nil {
+		log.Error("Failed to load selectors", "error", err)
+		exit(1)
+	}
+	if overridden := sel.Overridden(); len(overridden) > 0 {
+		log.Info("Using selector overrides", "file", cfg.SelectorsFile, "elements", strings.Join(overridden, ", "))
+		if sel.Version != selectors.Version {
+			log.Warn("Selectors file was written for other built-in selectors, check it still applies",
+				"file_version", sel.Version, "version", selectors.Version)
+		}
+	}
 
-	// 3. Initialize Browser
+	// 4. Initialize Browser
 	log.Info("Initializing Browser...")
 	b, err := browser.New(cfg, log)
 	if err != nil {
 		log.Error("Failed to initialize browser", "error", err)
-		os.Exit(1)
+		exit(1)
 	}
 	defer b.Close()
+	b.Sel = sel
+
+	// Challenges after any navigation pause the run until solved in the window;
+	// one that can't be (headless, timed out) ends the run like a shutdown
+	challenges := checkpoint.New(b, log)
+	var onChallenge []func(checkpoint.Kind)
+	challenges.OnDetected = func(kind checkpoint.Kind) {
+		for _, f := range onChallenge {
+			f(kind)
+		}
+	}
+	onChallenge = append(onChallenge, func(kind checkpoint.Kind) {
+		fields := map[string]string{"kind": string(kind)}
+		if info, err := b.Page.Info(); err == nil {
+			fields["url"] = info.URL
+		}
+		notifier.Notify(notify.Checkpoint, "Security challenge detected", fields)
+		webhooks.Emit(webhook.CheckpointDetected, "", fields)
+	})
+	b.Challenge = func() error {
+		err := challenges.Handle(ctx)
+		if errors.Is(err, checkpoint.ErrChallenge) {
+			stop()
+		}
+		return err
+	}
+
+	// Screenshot, HTML and URL of the page whenever something fails
+	var recorder *diagnostics.Recorder
+	if cfg.Diagnostics.Dir != "" {
+		recorder = diagnostics.New(b, log, cfg.Diagnostics.Dir)
+	}
+	diagnose := func(label string, err error) {
+		store.RecordError(label, "", err.Error())
+		if recorder != nil {
+			recorder.Capture(label, err)
+		}
+	}
 
-	// 4. Initialize Auth & Login
+	// 5. Initialize Auth & Login
 	log.Info("Authenticating...")
 	authenticator := auth.New(b, cfg, log)
+	if path := cfg.LinkedIn.SessionFile; path != "" {
+		if _, err := os.Stat(path); err == nil {
+			if err := authenticator.ImportSession(path); err != nil {
+				log.Warn("Failed to import session, falling back to login", "file", path, "error", err)
+			}
+		}
+	}
 	if err := authenticator.Login(); err != nil {
 		log.Error("Authentication failed", "error", err)
-		// Dump screenshot for debug
-		b.Page.MustScreenshot("login_failed.png")
-		os.Exit(1)
+		if failures, serr := store.RecordLoginFailure(); serr == nil {
+			log.Warn("Login failure recorded", "failures_today", failures, "max", cfg.Limits.MaxLoginFailuresPerDay)
+		}
+		diagnose("login_failed", err)
+		notifier.Notify(notify.LoginFailed, "Login failed", map[string]string{
+			"error":          err.Error(),
+			"failures_today": fmt.Sprint(store.LoginFailuresToday()),
+		})
+		exit(1)
 	}
 
-	// 5. Initialize Storage
-	store, err := storage.NewJSONStore("state.json")
-	if err != nil {
-		log.Error("Failed to initialize storage", "error", err)
-		os.Exit(1)
+	// Refresh the saved session so the next run can skip the login form
+	if path := cfg.LinkedIn.SessionFile; path != "" {
+		if err := authenticator.ExportSession(path); err != nil {
+			log.Warn("Failed to export session", "file", path, "error", err)
+		}
+	}
+
+	// Don't pile actions onto an account that is already flagged
+	engagementOnly := false
+	if cfg.Preflight.Enabled && opts.Command != "observe" && opts.Command != "doctor" {
+		standing, reason, err := preflight.CheckAccountStanding(b)
+		if err != nil {
+			log.Warn("Account standing check failed, continuing", "error", err)
+		} else if standing == preflight.Restricted {
+			log.Error("Account appears restricted, aborting before any outreach", "reason", reason)
+			diagnose("preflight_restricted", errors.New(reason))
+			notifier.Notify(notify.Standing, "Account appears restricted, run aborted", map[string]string{"reason": reason})
+			exit(1)
+		} else if standing == preflight.Warned {
+			switch cfg.Preflight.OnWarned {
+			case "continue":
+				log.Warn("Account shows warning signs, continuing as configured", "reason", reason)
+				notifier.Notify(notify.Standing, "Account shows warning signs, continuing", map[string]string{"reason": reason})
+			case "engagement":
+				if opts.Command != "daemon" && opts.Command != "serve" && !engagementCommands[opts.Command] {
+					log.Error("Account shows warning signs, only engagement runs", "reason", reason, "command", opts.Command)
+					notifier.Notify(notify.Standing, "Account shows warning signs, run aborted", map[string]string{"reason": reason, "command": opts.Command})
+					exit(1)
+				}
+				engagementOnly = true
+				log.Warn("Account shows warning signs, running only view and endorse", "reason", reason)
+				notifier.Notify(notify.Standing, "Account shows warning signs, switched to engagement only", map[string]string{"reason": reason})
+			default:
+				log.Error("Account shows warning signs, aborting before any outreach", "reason", reason)
+				notifier.Notify(notify.Standing, "Account shows warning signs, run aborted", map[string]string{"reason": reason})
+				exit(1)
+			}
+		} else {
+			log.Info("Account standing OK")
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
 	}
-	defer store.Close()
 
 	// 6. Initialize Services
-	searcher := search.New(b, log)
-	connector := connect.New(b, log, cfg.Limits.DailyConnections)
+	searcher := search.New(b, log, store)
+	searcher.Diagnostics = recorder
+	firstRun, err := store.FirstRun()
+	if err != nil {
+		log.Warn("Failed to record first run date", "error", err)
+	}
+	connectLimit := cfg.EffectiveConnectionLimit(firstRun, time.Now())
+	log.Info("Effective daily connection limit", "limit", connectLimit, "ramp", cfg.Ramp.Start > 0, "first_run", firstRun.Format("2006-01-02"))
+
+	connector := connect.New(b, log, store, connectLimit)
 	messenger := messaging.New(b, log, store)
+	endorser := endorse.New(b, log, store)
+	viewer := view.New(b, log, store)
+	viewer.MinDwell, viewer.MaxDwell = cfg.View.MinDwell, cfg.View.MaxDwell
+	connector.Auth = authenticator
+	connector.OnWeeklyLimit = func(reason error) {
+		notifier.Notify(notify.LimitReached, "Weekly connection limit reached", map[string]string{
+			"reason":             reason.Error(),
+			"requests_this_week": fmt.Sprint(store.Stats().RequestsThisWeek),
+		})
+	}
+	connector.Blacklist = storage.Exclusions(cfg.Blacklist)
+	messenger.Blacklist = storage.Exclusions(cfg.Blacklist)
+	viewer.Blacklist = storage.Exclusions(cfg.Blacklist)
+	messenger.Auth = authenticator
 
-	// Executive Switch based on Mode
-	if *mode == "message" {
-		log.Info("Starting Workflow: Check Connections & Message")
-		RunFollowUpWorkflow(log, messenger, cfg, store)
-	} else {
-		log.Info("Starting Workflow: Search & Connect", "keywords", *keywords)
-		RunConnectWorkflow(log, searcher, connector, store, keywords, title, company, location, maxPages, cfg)
+	limiter := ratelimit.New(cfg, store, log)
+	connector.Limiter = limiter
+	messenger.Limiter = limiter
+	endorser.Limiter = limiter
+	viewer.Limiter = limiter
+
+	if notes != nil {
+		connector.Notes = notes
+		log.Info("AI notes enabled", "provider", notes.Provider, "model", notes.Model)
+	}
+
+	if opts.ConfirmSends {
+		if cfg.Headless {
+			log.Warn("--confirm-sends works best in headful mode so you can see the composer")
+		}
+		connector.Confirm = ConsoleConfirm
+		messenger.Confirm = ConsoleConfirm
+	}
+
+	sessions := NewSessions(cfg)
+	pause := PauseControl{Path: opts.ControlFile, Timeout: opts.PauseTimeout, Sessions: sessions}
+
+	// Skips and operator decisions are expected, not worth a capture or an error entry
+	expected := []error{
+		context.Canceled, hooks.ErrDeclined, storage.ErrExcluded, profile.ErrNotAProfile,
+		connect.ErrAlreadyConnected, connect.ErrDuplicateCompany, connect.ErrDailyLimit, connect.ErrWeeklyLimit,
+		connect.ErrAlreadyPending, connect.ErrNeedsAnswer, ratelimit.ErrLimitReached, storage.ErrContactedElsewhere,
+		messaging.ErrReplied, messaging.ErrAlreadyMessaged, messaging.ErrCongratulated, endorse.ErrNoSkills,
+	}
+
+	// Liveness for supervisors: HTTP endpoint and/or heartbeat file
+	var resultHooks []hooks.ResultHook
+	if opts.ServeAddr != "" || cfg.Health.HeartbeatFile != "" {
+		monitor := health.New(b, cfg.Health.Staleness, cfg.Health.HeartbeatFile)
+		if opts.ServeAddr != "" {
+			monitor.Serve(opts.ServeAddr, log)
+		}
+		resultHooks = append(resultHooks, func(hooks.ActionResult) { monitor.Touch() })
+		// A deliberate pause is not a hang
+		pause.OnTick = monitor.Touch
+	}
+
+	// Prometheus counters and limit usage for long-running deployments
+	if opts.MetricsAddr != "" {
+		m := metrics.New()
+		m.Skip = expected
+		m.Usage = func() map[string]metrics.Usage {
+			st := store.Stats()
+			return map[string]metrics.Usage{
+				"connections": {Used: st.RequestsToday, Limit: connectLimit},
+				"messages":    {Used: st.MessagesToday, Limit: cfg.Limits.DailyMessages},
+			}
+		}
+		m.Serve(opts.MetricsAddr, log)
+		onChallenge = append(onChallenge, func(checkpoint.Kind) { m.Checkpoint() })
+		resultHooks = append(resultHooks, m.Observe)
+	}
+	if webhooks != nil {
+		resultHooks = append(resultHooks, webhooks.Observe)
+	}
+	if recorder != nil {
+		recorder.Ignore = expected
+		resultHooks = append(resultHooks, recorder.Observe)
+	}
+	// Every run ends with a summary of what it searched, sent and skipped
+	runs := summary.New(cfg.Account, expected)
+	resultHooks = append(resultHooks, runs.Observe)
+	// Every action goes to the audit log, skips and failures included
+	resultHooks = append(resultHooks, func(r hooks.ActionResult) {
+		if err := store.Audit(AuditEntryFor(store, cfg.Account, r)); err != nil {
+			log.Warn("Failed to write audit entry", "error", err)
+		}
+	})
+	// Unexpected failures are kept in the state file for the dashboard
+	resultHooks = append(resultHooks, func(r hooks.ActionResult) {
+		if r.Error == nil {
+			return
+		}
+		for _, e := range expected {
+			if errors.Is(r.Error, e) {
+				return
+			}
+		}
+		if err := store.RecordError(string(r.Action), r.ProfileURL, r.Error.Error()); err != nil {
+			log.Warn("Failed to record error", "error", err)
+		}
+	})
+	if len(resultHooks) > 0 {
+		onResult := hooks.Chain(resultHooks...)
+		connector.OnResult = onResult
+		messenger.OnResult = onResult
+		searcher.OnResult = onResult
+		endorser.OnResult = onResult
+		viewer.OnResult = onResult
+	}
+	undo := UndoWindow{Path: opts.UndoFile, Grace: cfg.UndoGrace}
+
+	// Templates: remote service (with local cache) or built-in defaults
+	noteRule := templates.Rule{Name: "default", Kind: templates.KindNote, Text: defaultNoteTemplate}
+	msgRule := templates.Rule{Name: "default", Kind: templates.KindMessage, Text: defaultMessageTemplate}
+	if cfg.TemplateSourceURL != "" {
+		rules, err := templates.Fetch(cfg.TemplateSourceURL, cfg.TemplateCache, log)
+		if err != nil {
+			log.Warn("No usable remote templates, using built-in defaults", "error", err)
+		} else {
+			if r, ok := templates.Pick(rules, templates.KindNote); ok {
+				noteRule = r
+			}
+			if r, ok := templates.Pick(rules, templates.KindMessage); ok {
+				msgRule = r
+			}
+		}
+	}
+	// specFor derives a run's segment and templates from its options and campaign
+	specFor := func(o *Options, c *campaign.Campaign) runSpec {
+		sp := runSpec{opts: o, camp: c, noteTemplate: noteRule.Text, msgTemplate: msgRule.Text, msgAttachment: cfg.MessageAttachment}
+		if o.Event != "" && cfg.Events.Note != "" {
+			sp.noteTemplate = cfg.Events.Note
+		}
+		if o.Post != "" && cfg.Posts.Note != "" {
+			sp.noteTemplate = cfg.Posts.Note
+		}
+		sp.segment = Segment{Campaign: o.Campaign, PerCampaignDedup: cfg.CampaignDedup == "campaign"}
+		if o.Tags != "" {
+			sp.segment.Tags = strings.Split(o.Tags, ",")
+		}
+		if c != nil {
+			sp.segment.Campaign = c.Name
+			sp.segment.Tags = append(sp.segment.Tags, c.Tags...)
+			sp.segment.Definition = c
+			if c.NoteTemplate != "" {
+				sp.noteTemplate = c.NoteTemplate
+			}
+			if len(c.Messages) > 0 {
+				sp.msgTemplate = c.Messages[0].Template
+				sp.msgAttachment = c.Messages[0].Attachment
+			}
+		}
+		return sp
+	}
+	base := specFor(opts, camp)
+	if !noteRule.NoFooter {
+		connector.NoteFooter = cfg.NoteFooter
+	}
+	if !msgRule.NoFooter {
+		messenger.Footer = cfg.MessageFooter
+	}
+
+	// Executive Switch based on the subcommand
+	run := func(command string, sp runSpec) error {
+		opts, segment := sp.opts, sp.segment
+		connector.Campaign = segment.Campaign
+		if cfg.WarmUp.Enabled && command != "observe" && command != "doctor" {
+			log.Info("Warming up on the feed before starting")
+			if err := stealth.WarmUp(ctx, b, cfg.WarmUp.MinDuration, cfg.WarmUp.MaxDuration); err != nil && ctx.Err() == nil {
+				log.Warn("Warm-up failed, continuing", "error", err)
+			}
+		}
+		switch command {
+		case "observe":
+			log.Info("Starting Observe Mode: checking selectors, no actions will be taken")
+			criteria := opts.Criteria(SplitKeywords(opts.Keywords)[0])
+			report := observe.Run(b, log, observe.DefaultTargets(opts.ObserveProfile, search.BuildURL(criteria)))
+			if err := observe.WriteReport(report, opts.ObserveOut); err != nil {
+				return fmt.Errorf("failed to write observe report: %w", err)
+			}
+			log.Info("Observe report written", "file", opts.ObserveOut)
+		case "doctor":
+			log.Info("Starting Doctor: checking selectors, no actions will be taken")
+			criteria := opts.Criteria(SplitKeywords(opts.Keywords)[0])
+			d := observe.Doctor(b, log, observe.DoctorPages(opts.ObserveProfile, search.BuildURL(criteria)))
+			d.Print(os.Stdout)
+			if err := observe.WriteReport(d, opts.ObserveOut); err != nil {
+				return fmt.Errorf("failed to write doctor report: %w", err)
+			}
+			log.Info("Doctor report written", "file", opts.ObserveOut)
+			if broken := d.Broken(); len(broken) > 0 {
+				return fmt.Errorf("%d elements broken: %s (see %s, fix them in %s)", len(broken), strings.Join(broken, ", "), opts.ObserveOut, cfg.SelectorsFile)
+			}
+		case "search":
+			log.Info("Starting Workflow: Search Only", "keywords", opts.Keywords)
+			if err := RunSearchWorkflow(ctx, log, searcher, store, opts); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("search failed: %w", err)
+			}
+		case "flush-messages":
+			log.Info("Starting Workflow: Flush Queued Messages")
+			RunFlushMessagesWorkflow(ctx, log, messenger, cfg, store, pause, segment)
+		case "withdraw":
+			if opts.MaxAge > 0 {
+				cfg.Withdraw.MaxAge = opts.MaxAge
+			}
+			if opts.MaxPerRun > 0 {
+				cfg.Withdraw.MaxPerRun = opts.MaxPerRun
+			}
+			log.Info("Starting Workflow: Withdraw Stale Invitations")
+			RunWithdrawWorkflow(ctx, log, connector, cfg, pause)
+		case "export-connections":
+			log.Info("Starting Workflow: Export Connections")
+			if err := RunExportConnectionsWorkflow(ctx, log, messenger, opts); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("connection export failed: %w", err)
+			}
+		case "archive-conversations":
+			log.Info("Starting Workflow: Archive Conversations")
+			if err := RunArchiveConversationsWorkflow(ctx, log, messenger, opts); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("conversation archive failed: %w", err)
+			}
+		case "accepted":
+			log.Info("Starting Workflow: Detect Accepted Invitations")
+			if err := RunAcceptedWorkflow(ctx, log, messenger, store); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("acceptance check failed: %w", err)
+			}
+		case "retry":
+			log.Info("Starting Workflow: Retry Withdrawn Invitations")
+			RunRetryWorkflow(ctx, log, connector, store, cfg, pause, undo, segment, sp.noteTemplate)
+		case "sequence":
+			steps := cfg.Sequence
+			if sp.camp != nil && len(sp.camp.Messages) > 0 {
+				steps = sp.camp.Messages
+			}
+			if len(steps) == 0 {
+				return errors.New("no sequence configured, add 'sequence' to config or 'messages' to the campaign file")
+			}
+			log.Info("Starting Workflow: Advance Message Sequence", "steps", len(steps))
+			RunSequenceWorkflow(ctx, log, messenger, cfg, store, pause, segment, steps)
+		case "replies":
+			log.Info("Starting Workflow: Unanswered Replies")
+			if err := RunRepliesWorkflow(ctx, log, messenger, notifier, opts); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("replies failed: %w", err)
+			}
+		case "endorse":
+			log.Info("Starting Workflow: Endorse Connections' Skills")
+			RunEndorseWorkflow(ctx, log, endorser, cfg, store, pause, segment)
+		case "congrats":
+			log.Info("Starting Workflow: Congratulate Connections")
+			RunCongratsWorkflow(ctx, log, messenger, cfg, store, pause, segment)
+		case "birthdays":
+			log.Info("Starting Workflow: Birthday Greetings")
+			RunBirthdayWorkflow(ctx, log, messenger, cfg, store, pause, segment)
+		case "view":
+			log.Info("Starting Workflow: View Target Profiles", "keywords", opts.Keywords)
+			RunViewWorkflow(ctx, log, searcher, viewer, store, viewer.Exclusions(), opts, cfg, pause, segment)
+		case "message":
+			log.Info("Starting Workflow: Check Connections & Message")
+			RunFollowUpWorkflow(ctx, log, messenger, cfg, store, pause, segment, sp.msgTemplate, sp.msgAttachment)
+		default:
+			if opts.Input != "" {
+				log.Info("Starting Workflow: Connect to Imported Targets", "file", opts.Input)
+				RunImportWorkflow(ctx, log, connector, store, cfg, pause, undo, segment, sp.noteTemplate, imported)
+				if sheets.IsURL(opts.Input) {
+					// Write the statuses back even after a shutdown request
+					if err := SyncSheet(context.WithoutCancel(ctx), log, cfg, store, opts.Input); err != nil {
+						log.Warn("Failed to update the sheet", "error", err)
+					}
+				}
+				return nil
+			}
+			log.Info("Starting Workflow: Search & Connect", "keywords", opts.Keywords)
+			if err := RunConnectWorkflow(ctx, log, searcher, connector, store, opts, cfg, pause, undo, segment, sp.noteTemplate); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("search & connect failed: %w", err)
+			}
+		}
+		return nil
+	}
+
+	// summarize reports a finished run with today's totals and writes its summary
+	summarize := func(command string, started time.Time, err error) {
+		st := store.Stats()
+		status := "completed"
+		if err != nil {
+			status = "failed: " + err.Error()
+		} else if ctx.Err() != nil {
+			status = "stopped by shutdown"
+		}
+		report := runs.Finish(status)
+		report.Print(os.Stderr)
+		if cfg.Summary.Dir != "" {
+			if path, err := report.Write(cfg.Summary.Dir); err != nil {
+				log.Warn("Failed to write run summary", "error", err)
+			} else {
+				log.Info("Run summary written", "file", path)
+			}
+		}
+		notifier.Notify(notify.RunCompleted, fmt.Sprintf("Run %s finished", command), map[string]string{
+			"status":             status,
+			"duration":           time.Since(started).Round(time.Second).String(),
+			"requests_today":     fmt.Sprint(st.RequestsToday),
+			"requests_this_week": fmt.Sprint(st.RequestsThisWeek),
+			"messages_today":     fmt.Sprint(st.MessagesToday),
+			"endorsed_today":     fmt.Sprint(st.EndorsedToday),
+			"viewed_today":       fmt.Sprint(st.ViewedToday),
+			"connections":        fmt.Sprint(st.Connections),
+		})
+	}
+
+	// In long-running modes each job is a fresh run: per-run counters reset and
+	// the ramped limit is recomputed
+	job := func(command string, sp runSpec) error {
+		if engagementOnly && !engagementCommands[command] {
+			log.Warn("Skipping outreach job, the account shows warning signs", "command", command)
+			return errEngagementOnly
+		}
+		connector.NewRun()
+		connector.DailyLimit = cfg.EffectiveConnectionLimit(firstRun, time.Now())
+		runs.Start(command)
+		started := time.Now()
+		err := run(command, sp)
+		if err != nil {
+			diagnose(command, err)
+		}
+		summarize(command, started, err)
+		return err
+	}
+	session := func() error {
+		if max := cfg.Limits.MaxLoginFailuresPerDay; max > 0 && store.LoginFailuresToday() >= max {
+			return fmt.Errorf("%d failed logins today", store.LoginFailuresToday())
+		}
+		if err := authenticator.Login(); err != nil {
+			failures, _ := store.RecordLoginFailure()
+			notifier.Notify(notify.LoginFailed, "Login failed", map[string]string{
+				"error":          err.Error(),
+				"failures_today": fmt.Sprint(failures),
+			})
+			return err
+		}
+		return nil
+	}
+
+	switch opts.Command {
+	case "daemon":
+		err := RunDaemon(ctx, log, cfg, sessions, session, func(command string) error {
+			return job(command, base)
+		})
+		if err != nil {
+			log.Error("Daemon failed", "error", err)
+			exit(1)
+		}
+	case "serve":
+		addr := cfg.API.Addr
+		if opts.APIAddr != "" {
+			addr = opts.APIAddr
+		}
+		if cfg.API.Token == "" {
+			// Never serve without a token, a web page could reach even localhost
+			token, err := api.NewToken()
+			if err != nil {
+				log.Error("Failed to generate an API token", "error", err)
+				exit(1)
+			}
+			cfg.API.Token = token
+			log.Warn("No api.token set, generated one for this run", "token", token)
+		}
+		srv := api.New(store, log, logs, daemonCommands)
+		srv.Token = cfg.API.Token
+		srv.Dashboard = dashboard.New(func() (*storage.MemoryStore, error) { return store, nil }, cfg.Account, log)
+		srv.Validate = func(r api.JobRequest) error {
+			_, _, err := requestOptions(opts, r)
+			return err
+		}
+		srv.Serve(addr)
+		srv.Run(ctx, func(r api.JobRequest) error {
+			o, c, err := requestOptions(opts, r)
+			if err != nil {
+				return err
+			}
+			if err := session(); err != nil {
+				return fmt.Errorf("session unavailable: %w", err)
+			}
+			return job(r.Command, specFor(o, c))
+		})
+	default:
+		runs.Start(opts.Command)
+		started := time.Now()
+		err := run(opts.Command, base)
+		summarize(opts.Command, started, err)
+		if err != nil {
+			log.Error("Command failed", "command", opts.Command, "error", err)
+			diagnose(opts.Command, err)
+			exit(1)
+		}
 	}
 
+	if ctx.Err() != nil {
+		log.Info("Stopped cleanly after shutdown request")
+		return
+	}
 	log.Info("Workflow completed successfully")
 
 	// Wait for user input for demo visibility
@@ -106,16 +888,48 @@ func main() {
 	fmt.Scanln()
 }
 
-func RunFollowUpWorkflow(log logger.Logger, messenger *messaging.Service, cfg *config.Config, store *storage.MemoryStore) {
-	// 1. Detect New Connections
-	connections, err := messenger.DetectNewConnections(20) // Check last 20
-	if err != nil {
-		log.Error("Failed to detect connections", "error", err)
-		return
+func RunFollowUpWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, cfg *config.Config, store *storage.MemoryStore, pause PauseControl, segment Segment, msgTemplate, msgAttachment string) {
+	// 1. Resume the connections an interrupted run didn't get to, or detect
+	// new ones and queue them
+	key := segment.Campaign + "|" + msgTemplate
+	var connections []string
+	if q, ok := store.ResumableQueue(queueMessage, key, cfg.QueueMaxAge); ok {
+		log.Info("Resuming queued follow-ups, not checking connections", "pending", q.Pending(), "queued_at", q.CreatedAt.Format(time.RFC3339))
+		for _, t := range q.Targets {
+			if t.Status == storage.TargetPending {
+				connections = append(connections, t.URL)
+			}
+		}
+	} else {
+		detected, err := messenger.DetectNewConnections(ctx, 20) // Check last 20
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error("Failed to detect connections", "error", err)
+			return
+		}
+		connections = detected
+
+		queued := make([]storage.PendingTarget, 0, len(connections))
+		for _, url := range connections {
+			if !store.IsConnected(url) {
+				store.SaveConnection(url)
+			}
+			queued = append(queued, storage.PendingTarget{URL: url})
+		}
+		if err := store.SetQueue(queueMessage, key, queued); err != nil {
+			log.Warn("Failed to save the work queue", "error", err)
+		}
+	}
+	// mark records how a queued connection was handled
+	mark := func(url, status, reason string) {
+		if err := store.SetTargetStatus(queueMessage, url, status, reason); err != nil {
+			log.Warn("Failed to update the work queue", "url", url, "error", err)
+		}
 	}
 
 	// 2. Iterate and Message
-	msgTemplate := "Hi {{firstname}}, great to connect with you! I see we share similar interests in tech."
 	processed := 0
 
 	for _, url := range connections {
@@ -125,98 +939,1253 @@ func RunFollowUpWorkflow(log logger.Logger, messenger *messaging.Service, cfg *c
 		}
 
 		if store.IsMessaged(url) {
+			mark(url, storage.TargetSkipped, "already messaged")
+			continue
+		}
+
+		if store.HasReplied(url) && cfg.Replies.Policy != "template" {
+			log.Debug("Connection already replied, no follow-up", "url", url)
+			mark(url, storage.TargetSkipped, "already replied")
+			continue
+		}
+
+		if RecentlyVisited(store, cfg, url, hooks.ActionMessage) {
+			log.Info("Profile visited too recently, skipping", "url", url)
+			mark(url, storage.TargetSkipped, "visited too recently")
+			continue
+		}
+
+		if cfg.DeferMessages {
+			if err := messenger.QueueFollowUp(url, segment.Template(log, store, templates.KindMessage, url, msgTemplate)); err != nil {
+				log.Error("Failed to queue message", "url", url, "error", err)
+				mark(url, storage.TargetFailed, err.Error())
+				continue
+			}
+			mark(url, storage.TargetDone, "")
 			continue
 		}
 
+		pause.Wait(ctx, log, messenger.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
+
 		log.Info("Processing follow-up", "url", url)
-		if err := messenger.SendFollowUp(url, msgTemplate); err != nil {
+		tmpl := segment.Template(log, store, templates.KindMessage, url, msgTemplate)
+		if err := messenger.SendFollowUpAttachment(ctx, url, tmpl, msgAttachment); errors.Is(err, messaging.ErrReplied) || errors.Is(err, messaging.ErrAlreadyMessaged) || errors.Is(err, storage.ErrExcluded) {
+			mark(url, storage.TargetSkipped, err.Error())
+			continue
+		} else if errors.Is(err, ratelimit.ErrLimitReached) {
+			log.Info("Rate limit reached, stopping follow-ups", "reason", err)
+			break
+		} else if err != nil && ctx.Err() != nil {
+			// Cut short by a shutdown or checkpoint, the next run retries it
+			break
+		} else if err != nil {
 			log.Error("Failed to send message", "url", url, "error", err)
+			mark(url, storage.TargetFailed, err.Error())
 			continue
 		}
+		mark(url, storage.TargetDone, "")
+
+		segment.Tag(log, store, url, hooks.ActionMessage)
 
 		processed++
 		// Delay
 		delay := time.Duration(20+rand.Intn(40)) * time.Second
 		log.Info("Sleeping before next message", "seconds", delay)
 		PerformRandomStealth(messenger.Browser) // Add random hover
-		time.Sleep(delay)
+		sleepCtx(ctx, delay)
 	}
 }
 
-func RunConnectWorkflow(log logger.Logger, searcher search.Finder, connector *connect.Service, store *storage.MemoryStore, kw, title, company, loc *string, pages *int, cfg *config.Config) {
-	// Step A: Search
-	criteria := search.Criteria{
-		Keywords: *kw,
-		Title:    *title,
-		Company:  *company,
-		Location: *loc,
-	}
-	profiles, err := searcher.SearchPeople(criteria, *pages)
+// RunSequenceWorkflow sends the next due step of the drip sequence to every
+// recorded connection. A reply stops a connection's sequence.
+func RunSequenceWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, cfg *config.Config, store *storage.MemoryStore, pause PauseControl, segment Segment, steps []config.SequenceStep) {
+	// New acceptances join the sequence from when they were first seen
+	connections, err := messenger.DetectNewConnections(ctx, 20)
 	if err != nil {
-		log.Error("Search failed", "error", err)
-		os.Exit(1)
+		log.Warn("Failed to detect new connections", "error", err)
+	}
+	for _, url := range connections {
+		if !store.IsConnected(url) {
+			store.SaveConnection(url)
+		}
 	}
 
-	// Shuffle profiles to randomize order
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(profiles), func(i, j int) { profiles[i], profiles[j] = profiles[j], profiles[i] })
+	name := segment.Campaign
+	if name == "" {
+		name = "default"
+	}
 
-	log.Info("Search complete", "profiles_found", len(profiles))
+	processed := 0
+	for _, r := range store.Records() {
+		url := r.ProfileURL
+		if r.ConnectedAt.IsZero() || store.HasReplied(url) {
+			continue
+		}
+		if segment.Campaign != "" && !store.InCampaign(url, segment.Campaign) {
+			continue
+		}
 
-	// Step B: Filter and Select ONE Random Candidate
-	var candidates []string
-	for _, url := range profiles {
-		if !store.IsRequestSent(url) && !store.IsConnected(url) {
-			candidates = append(candidates, url)
+		progress := store.SequenceProgressFor(name, url)
+		// A follow-up already sent by message mode counts as the first step
+		if progress.Step == 0 && !r.MessagedAt.IsZero() {
+			progress = storage.SequenceProgress{Step: 1, LastSentAt: r.MessagedAt}
+			store.SetSequenceProgress(name, url, progress)
+		}
+		if progress.Step >= len(steps) {
+			continue
 		}
-	}
 
-	if len(candidates) == 0 {
-		log.Info("No new eligible profiles found to connect with.")
-		return
-	}
+		since := r.ConnectedAt
+		if progress.Step > 0 {
+			since = progress.LastSentAt
+		}
+		if time.Since(since) < steps[progress.Step].Delay {
+			continue
+		}
 
-	log.Info("Found eligible profiles", "count", len(candidates))
+		if processed >= cfg.Limits.DailyMessages {
+			log.Warn("Daily message limit reached, remaining steps wait for the next run")
+			break
+		}
+		if RecentlyVisited(store, cfg, url, hooks.ActionMessage) {
+			log.Info("Profile visited too recently, skipping", "url", url)
+			continue
+		}
 
-	// Shuffle candidates
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		pause.Wait(ctx, log, messenger.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
 
-	// Select the first one
-	targetURL := candidates[0]
-	log.Info("Randomly selected profile for connection", "url", targetURL)
+		log.Info("Sending sequence step", "url", url, "sequence", name, "step", progress.Step+1, "of", len(steps))
+		tmpl := steps[progress.Step].Template
+		if progress.Step == 0 {
+			tmpl = segment.Template(log, store, templates.KindMessage, url, tmpl)
+		}
+		if err := messenger.SendSequenceStep(ctx, url, name, progress.Step, tmpl, steps[progress.Step].Attachment); errors.Is(err, messaging.ErrReplied) {
+			log.Info("Connection replied, sequence stopped", "url", url)
+			continue
+		} else if errors.Is(err, storage.ErrExcluded) {
+			continue
+		} else if errors.Is(err, ratelimit.ErrLimitReached) {
+			log.Info("Rate limit reached, stopping sequences", "reason", err)
+			break
+		} else if err != nil {
+			log.Error("Failed to send sequence step", "url", url, "error", err)
+			continue
+		}
 
-	noteTemplate := "Hi {{name}}, I noticed your profile and would love to connect!"
+		segment.Tag(log, store, url, hooks.ActionMessage)
 
-	// Attempt Connection
-	log.Info("Sending connection request...")
-	err = connector.SendConnectionRequest(targetURL, noteTemplate)
-	if err != nil {
-		log.Error("Failed to send connection request", "url", targetURL, "error", err)
-		// We do not exit here, just log. The function returns and demo finishes.
-	} else {
-		// Mark as sent
-		store.SaveRequest(targetURL)
-		log.Info("Connection request sent successfully! Exiting for POC safety.")
+		processed++
+		delay := time.Duration(20+rand.Intn(40)) * time.Second
+		log.Info("Sleeping before next message", "seconds", delay)
+		PerformRandomStealth(messenger.Browser)
+		sleepCtx(ctx, delay)
 	}
+	log.Info("Sequence run complete", "sent", processed)
 }
 
-// IsBusinessHours checks if current time is between 9 AM and 6 PM
-func IsBusinessHours() bool {
-	now := time.Now()
-	hour := now.Hour()
-	return hour >= 9 && hour < 18
-}
+// RunFlushMessagesWorkflow sends queued follow-ups, oldest first, with the usual limits and delays
+func RunFlushMessagesWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, cfg *config.Config, store *storage.MemoryStore, pause PauseControl, segment Segment) {
+	queue := store.QueuedMessages()
+	log.Info("Queued messages", "count", len(queue))
 
-// PerformRandomStealth performs random hover actions
-func PerformRandomStealth(b *browser.Browser) {
-	// Randomly decide to hover over something safe
-	if rand.Float32() > 0.7 { // 30% chance
-		// Find a safe element to hover (e.g., logo, nav)
-		// We try a few generic safe selectors
+	processed := 0
+	for _, qm := range queue {
+		if processed >= cfg.Limits.DailyMessages {
+			log.Warn("Daily message limit reached, leaving the rest queued", "remaining", len(queue)-processed)
+			break
+		}
+
+		if store.IsMessaged(qm.ProfileURL) {
+			store.RemoveQueuedMessage(qm.ProfileURL)
+			continue
+		}
+
+		if RecentlyVisited(store, cfg, qm.ProfileURL, hooks.ActionMessage) {
+			log.Info("Profile visited too recently, leaving it queued", "url", qm.ProfileURL)
+			continue
+		}
+
+		pause.Wait(ctx, log, messenger.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
+
+		log.Info("Sending queued follow-up", "url", qm.ProfileURL, "queued_at", qm.QueuedAt)
+		if err := messenger.SendFollowUpAttachment(ctx, qm.ProfileURL, qm.Template, cfg.MessageAttachment); errors.Is(err, messaging.ErrReplied) || errors.Is(err, messaging.ErrAlreadyMessaged) || errors.Is(err, storage.ErrExcluded) {
+			store.RemoveQueuedMessage(qm.ProfileURL)
+			continue
+		} else if errors.Is(err, ratelimit.ErrLimitReached) {
+			log.Info("Rate limit reached, keeping the rest queued", "reason", err)
+			break
+		} else if err != nil {
+			log.Error("Failed to send queued message, keeping it queued", "url", qm.ProfileURL, "error", err)
+			continue
+		}
+		if err := store.RemoveQueuedMessage(qm.ProfileURL); err != nil {
+			log.Warn("Failed to dequeue message", "url", qm.ProfileURL, "error", err)
+		}
+
+		segment.Tag(log, store, qm.ProfileURL, hooks.ActionMessage)
+
+		processed++
+		delay := time.Duration(20+rand.Intn(40)) * time.Second
+		log.Info("Sleeping before next message", "seconds", delay)
+		PerformRandomStealth(messenger.Browser)
+		sleepCtx(ctx, delay)
+	}
+}
+
+// RunEndorseWorkflow endorses the top skills of recorded connections not yet
+// endorsed, in random order, up to endorse.daily_limit profiles per day
+func RunEndorseWorkflow(ctx context.Context, log logger.Logger, endorser *endorse.Service, cfg *config.Config, store *storage.MemoryStore, pause PauseControl, segment Segment) {
+	var candidates []string
+	for _, r := range store.Records() {
+		if r.ConnectedAt.IsZero() || !r.EndorsedAt.IsZero() {
+			continue
+		}
+		if segment.Campaign != "" && !store.InCampaign(r.ProfileURL, segment.Campaign) {
+			continue
+		}
+		candidates = append(candidates, r.ProfileURL)
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	log.Info("Connections to endorse", "count", len(candidates))
+
+	now := time.Now()
+	y, m, d := now.Date()
+	startOfDay := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	endorsed := 0
+	for _, url := range candidates {
+		if n := store.EndorsementsSince(startOfDay); n >= cfg.Endorse.DailyLimit {
+			log.Info("Daily endorsement limit reached", "endorsed_today", n, "limit", cfg.Endorse.DailyLimit)
+			break
+		}
+		if RecentlyVisited(store, cfg, url, hooks.ActionEndorse) {
+			continue
+		}
+
+		pause.Wait(ctx, log, endorser.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
+
+		if _, err := endorser.Endorse(ctx, url, cfg.Endorse.MaxSkills); errors.Is(err, endorse.ErrNoSkills) {
+			log.Info("No skills to endorse", "url", url)
+			continue
+		} else if errors.Is(err, ratelimit.ErrLimitReached) {
+			log.Info("Rate limit reached, stopping endorsements", "reason", err)
+			break
+		} else if err != nil {
+			log.Error("Failed to endorse", "url", url, "error", err)
+			continue
+		}
+		segment.Tag(log, store, url, hooks.ActionEndorse)
+
+		endorsed++
+		delay := time.Duration(20+rand.Intn(40)) * time.Second
+		log.Info("Sleeping before next endorsement", "seconds", delay)
+		PerformRandomStealth(endorser.Browser)
+		sleepCtx(ctx, delay)
+	}
+	log.Info("Endorse run complete", "endorsed", endorsed)
+}
+
+// RunCongratsWorkflow congratulates connections on the job changes (and work
+// anniversaries unless disabled) in My Network's catch-up, each occasion once,
+// up to congrats.daily_limit a day
+func RunCongratsWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, cfg *config.Config, store *storage.MemoryStore, pause PauseControl, segment Segment) {
+	templateFor := map[string]string{messaging.CatchUpJobChange: cfg.Congrats.JobChange}
+	if cfg.Congrats.Anniversaries {
+		templateFor[messaging.CatchUpAnniversary] = cfg.Congrats.Anniversary
+	}
+	sent := runCatchUps(ctx, log, messenger, store, pause, segment, templateFor, cfg.Congrats.DailyLimit)
+	log.Info("Congrats run complete", "sent", sent)
+}
+
+// RunBirthdayWorkflow greets connections with a birthday in My Network's
+// catch-up, once per connection a year, up to birthdays.daily_limit a day
+func RunBirthdayWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, cfg *config.Config, store *storage.MemoryStore, pause PauseControl, segment Segment) {
+	templateFor := map[string]string{messaging.CatchUpBirthday: cfg.Birthdays.Template}
+	sent := runCatchUps(ctx, log, messenger, store, pause, segment, templateFor, cfg.Birthdays.DailyLimit)
+	log.Info("Birthday run complete", "sent", sent)
+}
+
+// runCatchUps messages the catch-up occasions of each kind in templateFor with
+// its template, until dailyLimit messages on those kinds went out today.
+// Returns how many were sent.
+func runCatchUps(ctx context.Context, log logger.Logger, messenger *messaging.Service, store *storage.MemoryStore, pause PauseControl, segment Segment, templateFor map[string]string, dailyLimit int) int {
+	kinds := make([]string, 0, len(templateFor))
+	for _, kind := range []string{messaging.CatchUpJobChange, messaging.CatchUpAnniversary, messaging.CatchUpBirthday} {
+		if _, ok := templateFor[kind]; ok {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	now := time.Now()
+	y, m, d := now.Date()
+	startOfDay := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	sentToday := func() int {
+		n := 0
+		for _, kind := range kinds {
+			n += store.CongratsSince(kind, startOfDay)
+		}
+		return n
+	}
+
+	sent := 0
+	for _, kind := range kinds {
+		occasions, err := messenger.CatchUps(ctx, kind, 0)
+		if err != nil {
+			log.Error("Failed to read catch-up notifications", "kind", kind, "error", err)
+			continue
+		}
+		for _, c := range occasions {
+			if n := sentToday(); n >= dailyLimit {
+				log.Info("Daily limit reached", "occasion", kind, "sent_today", n, "limit", dailyLimit)
+				return sent
+			}
+			if !store.CongratulatedAt(c.ProfileURL, c.Event()).IsZero() {
+				continue
+			}
+			if segment.Campaign != "" && !store.InCampaign(c.ProfileURL, segment.Campaign) {
+				continue
+			}
+
+			pause.Wait(ctx, log, messenger.Browser)
+			if ctx.Err() != nil {
+				log.Info("Shutdown requested, stopping before the next action")
+				return sent
+			}
+
+			log.Info("Congratulating connection", "url", c.ProfileURL, "occasion", kind, "company", c.Company)
+			if err := messenger.Congratulate(ctx, c, templateFor[kind]); errors.Is(err, messaging.ErrCongratulated) || errors.Is(err, storage.ErrExcluded) {
+				continue
+			} else if errors.Is(err, ratelimit.ErrLimitReached) {
+				log.Info("Rate limit reached, stopping congratulations", "reason", err)
+				return sent
+			} else if err != nil {
+				log.Error("Failed to congratulate", "url", c.ProfileURL, "error", err)
+				continue
+			}
+			segment.Tag(log, store, c.ProfileURL, hooks.ActionMessage)
+
+			sent++
+			delay := time.Duration(30+rand.Intn(60)) * time.Second
+			log.Info("Sleeping before next congratulation", "seconds", delay)
+			PerformRandomStealth(messenger.Browser)
+			sleepCtx(ctx, delay)
+		}
+	}
+	return sent
+}
+
+// RunViewWorkflow views the profiles of search results that haven't been
+// invited or viewed yet, in random order, up to view.daily_limit a day. No
+// connection is sent; a later connect run reaches people who have already
+// seen the account's name in their profile viewers.
+func RunViewWorkflow(ctx context.Context, log logger.Logger, searcher search.Finder, viewer *view.Service, store *storage.MemoryStore, exclusions storage.Exclusions, opts *Options, cfg *config.Config, pause PauseControl, segment Segment) {
+	now := time.Now()
+	y, m, d := now.Date()
+	startOfDay := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	if n := store.ViewsSince(startOfDay); n >= cfg.View.DailyLimit {
+		log.Info("Daily profile view limit reached, not searching", "viewed_today", n, "limit", cfg.View.DailyLimit)
+		return
+	}
+
+	profiles, _, details, err := SearchTargets(ctx, log, searcher, opts)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil && len(profiles) == 0 {
+		log.Error("Search failed, nothing to view", "error", err)
+		return
+	}
+
+	var candidates []string
+	for _, url := range profiles {
+		if reason := exclusions.Match(url, details[url].Company, details[url].Headline); reason != "" {
+			log.Debug("Search result is excluded, skipping", "url", url, "reason", reason)
+			continue
+		}
+		if details[url].Degree == 1 || store.IsConnected(url) || segment.AlreadyContacted(store, url) {
+			continue
+		}
+		if !store.ViewedAt(url).IsZero() || RecentlyVisited(store, cfg, url, hooks.ActionView) {
+			continue
+		}
+		candidates = append(candidates, url)
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	log.Info("Profiles to view", "count", len(candidates))
+
+	viewed := 0
+	for _, url := range candidates {
+		if n := store.ViewsSince(startOfDay); n >= cfg.View.DailyLimit {
+			log.Info("Daily profile view limit reached", "viewed_today", n, "limit", cfg.View.DailyLimit)
+			break
+		}
+
+		pause.Wait(ctx, log, viewer.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
+
+		if err := viewer.View(ctx, url); errors.Is(err, ratelimit.ErrLimitReached) {
+			log.Info("Rate limit reached, stopping profile views", "reason", err)
+			break
+		} else if errors.Is(err, storage.ErrExcluded) {
+			continue
+		} else if err != nil {
+			log.Error("Failed to view profile", "url", url, "error", err)
+			continue
+		}
+		segment.Tag(log, store, url, hooks.ActionView)
+
+		viewed++
+		delay := time.Duration(20+rand.Intn(40)) * time.Second
+		log.Info("Sleeping before next profile view", "seconds", delay)
+		PerformRandomStealth(viewer.Browser)
+		sleepCtx(ctx, delay)
+	}
+	log.Info("View run complete", "viewed", viewed)
+}
+
+// recentConnections is how many of the newest connections an acceptance check reads
+const recentConnections = 40
+
+// RunAcceptedWorkflow reads the newest connections and records the pending
+// invitations among them as accepted, for the acceptance rates of status and
+// the dashboard
+func RunAcceptedWorkflow(ctx context.Context, log logger.Logger, messenger *messaging.Service, store *storage.MemoryStore) error {
+	connections, err := messenger.DetectNewConnections(ctx, recentConnections)
+	if err != nil {
+		return err
+	}
+	accepted := 0
+	for _, url := range connections {
+		if store.IsConnected(url) {
+			continue
+		}
+		if sent := store.RequestTime(url); !sent.IsZero() {
+			log.Info("Invitation accepted", "url", url, "after", time.Since(sent).Round(time.Hour))
+			accepted++
+		}
+		if err := store.SaveConnection(url); err != nil {
+			log.Warn("Failed to record connection", "url", url, "error", err)
+		}
+	}
+	log.Info("Acceptance check complete", "checked", len(connections), "accepted", accepted)
+	return nil
+}
+
+// RunWithdrawWorkflow withdraws pending invitations older than withdraw.max_age
+func RunWithdrawWorkflow(ctx context.Context, log logger.Logger, connector *connect.Service, cfg *config.Config, pause PauseControl) {
+	pause.Wait(ctx, log, connector.Browser)
+	withdrawn, err := connector.WithdrawStale(ctx, cfg.Withdraw.MaxAge, cfg.Withdraw.MaxPerRun)
+	if err != nil && ctx.Err() == nil {
+		log.Error("Failed to withdraw stale invitations", "error", err)
+		return
+	}
+	log.Info("Withdraw run complete", "withdrawn", len(withdrawn))
+}
+
+// WithdrawnBlocked reports whether a request to the profile was withdrawn and
+// it can't be invited again: withdraw.reeligible_after hasn't passed (or is 0,
+// meaning never), LinkedIn's cooldown is still running, or the profile already
+// had withdraw.max_attempts invitations
+func WithdrawnBlocked(store storage.DataStore, cfg *config.Config, url string) bool {
+	at := store.WithdrawnAt(url)
+	if at.IsZero() {
+		return false
+	}
+	after := cfg.Withdraw.ReeligibleAfter
+	if after <= 0 {
+		return true
+	}
+	if after < config.InviteCooldown {
+		after = config.InviteCooldown
+	}
+	if time.Since(at) < after {
+		return true
+	}
+	max := cfg.Withdraw.MaxAttempts
+	return max > 0 && len(store.Attempts(url)) >= max
+}
+
+// NoteFor returns withdraw.retry_note for a profile invited before, so a
+// second attempt doesn't repeat the ignored note, else the profile's note
+// variant of the campaign or noteTemplate
+func NoteFor(log logger.Logger, store storage.DataStore, cfg *config.Config, segment Segment, url, noteTemplate string) string {
+	if cfg.Withdraw.RetryNote != "" && len(store.Attempts(url)) > 0 {
+		return cfg.Withdraw.RetryNote
+	}
+	return segment.Template(log, store, templates.KindNote, url, noteTemplate)
+}
+
+// RunRetryWorkflow re-invites withdrawn profiles whose cooldown is over and
+// that have attempts left, oldest withdrawal first, until the limits are reached
+func RunRetryWorkflow(ctx context.Context, log logger.Logger, connector *connect.Service, store *storage.MemoryStore, cfg *config.Config, pause PauseControl, undo UndoWindow, segment Segment, noteTemplate string) {
+	if cfg.Withdraw.ReeligibleAfter <= 0 {
+		log.Info("withdraw.reeligible_after is not set, withdrawn profiles are never retried")
+		return
+	}
+	exclusions := connector.Exclusions()
+	var due []storage.Record
+	for _, r := range store.Records() {
+		if r.WithdrawnAt.IsZero() || !r.RequestedAt.IsZero() || !r.ConnectedAt.IsZero() {
+			continue
+		}
+		if segment.Campaign != "" && !store.InCampaign(r.ProfileURL, segment.Campaign) {
+			continue
+		}
+		// Company and headline aren't stored, SendConnectionRequest checks
+		// them once the profile is open
+		if WithdrawnBlocked(store, cfg, r.ProfileURL) || exclusions.Match(r.ProfileURL, "", "") != "" {
+			continue
+		}
+		due = append(due, r)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].WithdrawnAt.Before(due[j].WithdrawnAt) })
+	log.Info("Withdrawn profiles due for another attempt", "count", len(due))
+
+	sent := 0
+	for _, r := range due {
+		if err := connector.CheckLimits(); err != nil {
+			log.Info("Connection limit reached, leaving the rest for later", "reason", err)
+			break
+		}
+		if err := segment.CheckLimits(store); err != nil {
+			log.Info("Campaign limit reached, stopping retries", "campaign", segment.Campaign, "reason", err)
+			break
+		}
+		if RecentlyVisited(store, cfg, r.ProfileURL, hooks.ActionConnect) {
+			continue
+		}
+
+		pause.Wait(ctx, log, connector.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
+		log.Info("Retrying invitation", "url", r.ProfileURL, "attempt", len(store.Attempts(r.ProfileURL))+1, "withdrawn_at", r.WithdrawnAt.Format("2006-01-02"))
+		note := NoteFor(log, store, cfg, segment, r.ProfileURL, noteTemplate)
+		res, err := connector.SendConnectionRequest(ctx, r.ProfileURL, note)
+		if !recordConnectResult(ctx, log, connector, store, undo, segment, r.ProfileURL, note, res, err) {
+			continue
+		}
+
+		sent++
+		delay := time.Duration(30+rand.Intn(60)) * time.Second
+		log.Info("Sleeping before next request", "seconds", delay)
+		PerformRandomStealth(connector.Browser)
+		sleepCtx(ctx, delay)
+	}
+	log.Info("Retry run complete", "sent", sent)
+}
+
+// ListTargets lists the group members, event attendees, post engagers or
+// company employees opts name instead of a search; ok is false when they name none
+func ListTargets(ctx context.Context, searcher search.Finder, opts *Options) (found []search.Profile, ok bool, err error) {
+	switch {
+	case opts.Group != "":
+		found, err = searcher.GroupMembers(ctx, opts.Group, opts.MaxPages)
+	case opts.Event != "":
+		found, err = searcher.EventAttendees(ctx, opts.Event, opts.MaxPages)
+	case opts.Post != "":
+		found, err = searcher.PostEngagers(ctx, opts.Post, opts.MaxPages)
+	case opts.CompanyPage != "":
+		filters := search.CompanyFilters{Title: opts.Title, Location: opts.Location}
+		found, err = searcher.CompanyEmployees(ctx, opts.CompanyPage, filters, opts.MaxPages)
+	default:
+		return nil, false, nil
+	}
+	return found, true, err
+}
+
+// SearchTargets runs the search of opts, expands from a seed profile's
+// related sidebar or lists the targets of ListTargets. Several ";"-separated keywords run one search each;
+// sources records which keyword surfaced each profile for per-keyword quotas.
+func SearchTargets(ctx context.Context, log logger.Logger, searcher search.Finder, opts *Options) (profiles []string, sources map[string]string, details map[string]search.Profile, err error) {
+	sources = make(map[string]string)
+	details = make(map[string]search.Profile)
+	if opts.Seed != "" {
+		profiles, err = searcher.ScrapeRelated(ctx, opts.Seed)
+		return profiles, sources, details, err
+	}
+	if found, ok, lerr := ListTargets(ctx, searcher, opts); ok {
+		for _, p := range found {
+			details[p.URL] = p
+			profiles = append(profiles, p.URL)
+		}
+		return profiles, sources, details, lerr
+	}
+	keywords := SplitKeywords(opts.Keywords)
+	empty := 0
+	for _, k := range keywords {
+		found, serr := searcher.SearchPeople(ctx, opts.Criteria(k), opts.MaxPages)
+		if errors.Is(serr, search.ErrNoResults) {
+			log.Warn("Search matched no one", "keyword", k)
+			empty++
+			continue
+		}
+		if serr != nil {
+			err = serr
+			break
+		}
+		for _, p := range found {
+			if _, seen := sources[p.URL]; !seen {
+				sources[p.URL] = k
+				details[p.URL] = p
+				profiles = append(profiles, p.URL)
+			}
+		}
+	}
+	if err == nil && empty == len(keywords) {
+		err = search.ErrNoResults
+	}
+	return profiles, sources, details, err
+}
+
+func RunConnectWorkflow(ctx context.Context, log logger.Logger, searcher search.Finder, connector *connect.Service, store *storage.MemoryStore, opts *Options, cfg *config.Config, pause PauseControl, undo UndoWindow, segment Segment, noteTemplate string) error {
+	// Don't spend a search on a run that can't send anything
+	if err := connector.CheckLimits(); err != nil {
+		log.Info("Connection limit reached, not searching", "reason", err)
+		return nil
+	}
+	if err := segment.CheckLimits(store); err != nil {
+		log.Info("Campaign limit reached, not sending", "campaign", segment.Campaign, "reason", err)
+		return nil
+	}
+
+	// Step A: Resume the candidates an interrupted run left, or search (or
+	// expand from a seed profile's related sidebar) and queue new ones
+	key := connectQueueKey(opts, segment, noteTemplate)
+	if q, ok := store.ResumableQueue(queueConnect, key, cfg.QueueMaxAge); ok {
+		log.Info("Resuming queued candidates, not searching", "pending", q.Pending(), "queued_at", q.CreatedAt.Format(time.RFC3339))
+	} else if queued, err := queueCandidates(ctx, log, searcher, connector, store, opts, cfg, segment, key); !queued {
+		return err
+	}
+
+	// Step B: Take the next queued candidate that is still eligible
+	target, ok := nextConnectTarget(log, store, cfg, segment)
+	if !ok {
+		log.Info("No new eligible profiles found to connect with.")
+		return nil
+	}
+	targetURL := target.URL
+	log.Info("Selected queued profile for connection", "url", targetURL, "name", target.Vars["name"], "keyword", target.Keyword)
+
+	// Attempt Connection
+	pause.Wait(ctx, log, connector.Browser)
+	if ctx.Err() != nil {
+		return nil
+	}
+	log.Info("Sending connection request...")
+	note := NoteFor(log, store, cfg, segment, targetURL, noteTemplate)
+	vars := make(map[string]string)
+	for _, k := range []string{"event", "post"} {
+		if v := target.Vars[k]; v != "" {
+			vars[k] = v
+		}
+	}
+	res, err := connector.SendConnectionRequestVars(ctx, targetURL, note, vars)
+	sent := recordConnectResult(ctx, log, connector, store, undo, segment, targetURL, note, res, err)
+	if status, reason := connectTargetStatus(ctx, res, err); status != storage.TargetPending {
+		if err := store.SetTargetStatus(queueConnect, targetURL, status, reason); err != nil {
+			log.Warn("Failed to update the work queue", "url", targetURL, "error", err)
+		}
+	}
+	if sent {
+		if err := store.SetKeyword(targetURL, target.Keyword); err != nil {
+			log.Warn("Failed to record source keyword", "url", targetURL, "error", err)
+		}
+		log.Info("Connection request sent successfully! Exiting for POC safety.")
+	}
+
+	if opts.Seed == "" && opts.Group == "" && opts.Event == "" && opts.Post == "" && opts.CompanyPage == "" {
+		for _, k := range SplitKeywords(opts.Keywords) {
+			log.Info("Keyword summary", "keyword", k, "sent_today", store.KeywordRequestsToday(k), "limit", cfg.Limits.PerKeywordDailyLimit)
+		}
+	}
+	return nil
+}
+
+// Work queue names of the connect and message workflows
+const (
+	queueConnect = "connect"
+	queueMessage = "message"
+)
+
+// connectQueueKey identifies the search behind the connect queue, so a run
+// with other criteria, another campaign or another note searches afresh
+func connectQueueKey(opts *Options, segment Segment, noteTemplate string) string {
+	return strings.Join([]string{
+		segment.Campaign, noteTemplate, opts.Keywords, opts.Title, opts.Company, opts.Location, opts.Industry,
+		opts.Network, opts.Seed, opts.Group, opts.Event, opts.Post, opts.CompanyPage,
+	}, "|")
+}
+
+// queueCandidates searches, filters the results and queues the eligible
+// profiles in random order. Returns false when there is nothing to queue,
+// with the error of a failed search.
+func queueCandidates(ctx context.Context, log logger.Logger, searcher search.Finder, connector *connect.Service, store *storage.MemoryStore, opts *Options, cfg *config.Config, segment Segment, key string) (bool, error) {
+	profiles, sources, details, err := SearchTargets(ctx, log, searcher, opts)
+	if ctx.Err() != nil {
+		log.Info("Shutdown requested, stopping after search")
+		return false, nil
+	}
+	if errors.Is(err, search.ErrNoResults) {
+		log.Warn("Your search criteria matched no one, try broader keywords or filters")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	log.Info("Search complete", "profiles_found", len(profiles))
+
+	exclusions := connector.Exclusions()
+	var candidates []storage.PendingTarget
+	// companies already queued, for one_per_company_per_run; the check after
+	// the profile visit still catches cards without a company
+	companies := make(map[string]bool)
+	for _, url := range profiles {
+		if reason := exclusions.Match(url, details[url].Company, details[url].Headline); reason != "" {
+			log.Debug("Search result is excluded, skipping", "url", url, "reason", reason)
+			continue
+		}
+		if details[url].Degree == 1 {
+			// The result card already shows a 1st-degree connection, no need to visit
+			log.Debug("Search result is already a connection, skipping", "url", url)
+			if !store.IsConnected(url) {
+				store.SaveConnection(url)
+			}
+			continue
+		}
+		if reason, _ := connectIneligible(store, cfg, segment, url, sources[url]); reason != "" {
+			log.Debug("Search result not eligible, skipping", "url", url, "reason", reason)
+			continue
+		}
+		if company := strings.ToLower(details[url].Company); cfg.OnePerCompanyPerRun && company != "" {
+			if companies[company] || connector.CompanyContacted(company) {
+				log.Debug("Search result's company already contacted this run, skipping", "url", url, "company", details[url].Company)
+				continue
+			}
+			companies[company] = true
+		}
+		t := storage.PendingTarget{URL: url, Keyword: sources[url], Vars: make(map[string]string)}
+		for k, v := range map[string]string{"name": details[url].Name, "event": details[url].Event, "post": details[url].Post} {
+			if v != "" {
+				t.Vars[k] = v
+			}
+		}
+		candidates = append(candidates, t)
+	}
+	if len(candidates) == 0 {
+		log.Info("No new eligible profiles found to connect with.")
+		return false, nil
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	if err := store.SetQueue(queueConnect, key, candidates); err != nil {
+		log.Warn("Failed to save the work queue", "error", err)
+	}
+	log.Info("Found eligible profiles", "count", len(candidates))
+	return true, nil
+}
+
+// connectIneligible returns why a profile can't be invited now, "" if it
+// can, and whether the reason passes with time
+func connectIneligible(store *storage.MemoryStore, cfg *config.Config, segment Segment, url, keyword string) (string, bool) {
+	switch {
+	case segment.AlreadyContacted(store, url) || store.IsConnected(url):
+		return "already contacted", false
+	case store.ClaimedBy(segment.Campaign, url) != "":
+		return "claimed by another account", false
+	case RecentlyVisited(store, cfg, url, hooks.ActionConnect):
+		return "visited too recently", true
+	case WithdrawnBlocked(store, cfg, url):
+		return "withdrawn, not yet re-eligible", false
+	case cfg.Limits.PerKeywordDailyLimit > 0 && keyword != "" && store.KeywordRequestsToday(keyword) >= cfg.Limits.PerKeywordDailyLimit:
+		return "daily quota of keyword reached", true
+	}
+	return "", false
+}
+
+// nextConnectTarget returns the first pending target of the connect queue
+// that is still eligible, marking the ones that no longer are as skipped.
+// Targets held back only for now stay pending for a later run.
+func nextConnectTarget(log logger.Logger, store *storage.MemoryStore, cfg *config.Config, segment Segment) (storage.PendingTarget, bool) {
+	q, _ := store.Queue(queueConnect)
+	for _, t := range q.Targets {
+		if t.Status != storage.TargetPending {
+			continue
+		}
+		reason, temporary := connectIneligible(store, cfg, segment, t.URL, t.Keyword)
+		if reason == "" {
+			return t, true
+		}
+		if temporary {
+			log.Debug("Queued profile not eligible yet, leaving it pending", "url", t.URL, "reason", reason)
+			continue
+		}
+		log.Debug("Queued profile no longer eligible, skipping", "url", t.URL, "reason", reason)
+		if err := store.SetTargetStatus(queueConnect, t.URL, storage.TargetSkipped, reason); err != nil {
+			log.Warn("Failed to update the work queue", "url", t.URL, "error", err)
+		}
+	}
+	return storage.PendingTarget{}, false
+}
+
+// connectTargetStatus maps a connection request's outcome to its queue
+// status. A request cut short by a shutdown, a checkpoint or a limit stays
+// pending for the next run.
+func connectTargetStatus(ctx context.Context, res connect.Result, err error) (string, string) {
+	switch {
+	case ctx.Err() != nil || errors.Is(err, context.Canceled) || res == connect.LimitReached:
+		return storage.TargetPending, ""
+	case err == nil || res == connect.AlreadyPending:
+		return storage.TargetDone, ""
+	case errors.Is(err, connect.ErrNotVerified):
+		return storage.TargetFailed, err.Error()
+	case errors.Is(err, connect.ErrAlreadyConnected), errors.Is(err, hooks.ErrDeclined), errors.Is(err, storage.ErrExcluded),
+		errors.Is(err, connect.ErrDuplicateCompany), errors.Is(err, profile.ErrNotAProfile), errors.Is(err, connect.ErrNeedsAnswer),
+		errors.Is(err, storage.ErrContactedElsewhere):
+		return storage.TargetSkipped, err.Error()
+	}
+	return storage.TargetFailed, err.Error()
+}
+
+// recordConnectResult logs the outcome of a connection request, offers the undo
+// window and records a sent request. Returns true if the request stands.
+func recordConnectResult(ctx context.Context, log logger.Logger, connector *connect.Service, store storage.DataStore, undo UndoWindow, segment Segment, url, note string, res connect.Result, err error) bool {
+	if res == connect.AlreadyPending {
+		// Record it so the profile isn't picked again, but it isn't a new request
+		log.Info("Invitation was already pending, recorded", "url", url)
+		store.SaveRequest(url)
+	} else if res == connect.LimitReached {
+		log.Warn("Connection limit reached, request not sent", "url", url, "reason", err)
+	} else if errors.Is(err, connect.ErrAlreadyConnected) {
+		log.Info("Profile was already a connection, state updated", "url", url)
+	} else if errors.Is(err, hooks.ErrDeclined) {
+		log.Info("Connection request skipped by operator", "url", url)
+	} else if errors.Is(err, storage.ErrExcluded) {
+		log.Info("Skipped excluded profile", "url", url, "reason", err)
+	} else if errors.Is(err, connect.ErrDuplicateCompany) {
+		log.Info("Skipped profile from an already-contacted company", "url", url, "reason", err)
+	} else if errors.Is(err, context.Canceled) {
+		log.Info("Shutdown requested, request not sent", "url", url)
+	} else if errors.Is(err, profile.ErrNotAProfile) {
+		log.Warn("Selected URL was not a person profile, skipped", "url", url)
+	} else if errors.Is(err, connect.ErrNeedsAnswer) {
+		log.Info("Skipped profile asking 'How do you know'", "url", url)
+	} else if errors.Is(err, connect.ErrNotVerified) {
+		log.Warn("Connection request was not confirmed by LinkedIn, not recorded", "url", url)
+	} else if err != nil {
+		log.Error("Failed to send connection request", "url", url, "error", err)
+		// We do not exit here, just log. The function returns and demo finishes.
+	} else if undo.Requested(ctx, log) {
+		if err := connector.WithdrawRequest(url); err != nil {
+			log.Error("Failed to withdraw request, recording it as sent", "url", url, "error", err)
+			store.SaveRequest(url)
+		} else if err := store.UndoInvite(url); err != nil {
+			log.Warn("Failed to record the undone request", "url", url, "error", err)
+		}
+	} else {
+		// Mark as sent
+		store.SaveRequest(url)
+		if connector.Notes != nil {
+			// AI notes differ per profile, compare them as one variant
+			note = "AI note (" + connector.Notes.Model + ")"
+		}
+		if err := store.SetInviteNote(url, note); err != nil {
+			log.Warn("Failed to record note template", "url", url, "error", err)
+		}
+		segment.Tag(log, store, url, hooks.ActionConnect)
+		return true
+	}
+	return false
+}
+
+// RunImportWorkflow sends connection requests to the targets of an import file,
+// in file order, until the limits are reached. Search is skipped entirely.
+func RunImportWorkflow(ctx context.Context, log logger.Logger, connector *connect.Service, store *storage.MemoryStore, cfg *config.Config, pause PauseControl, undo UndoWindow, segment Segment, noteTemplate string, list []targets.Target) {
+	log.Info("Targets loaded", "count", len(list))
+
+	sent := 0
+	for _, t := range list {
+		if err := connector.CheckLimits(); err != nil {
+			log.Info("Connection limit reached, stopping import", "reason", err)
+			break
+		}
+		if segment.AlreadyContacted(store, t.URL) || store.IsConnected(t.URL) {
+			log.Debug("Already contacted, skipping", "url", t.URL)
+			continue
+		}
+		if WithdrawnBlocked(store, cfg, t.URL) || RecentlyVisited(store, cfg, t.URL, hooks.ActionConnect) {
+			log.Debug("Profile not eligible yet, skipping", "url", t.URL)
+			continue
+		}
+		if err := segment.CheckLimits(store); err != nil {
+			log.Info("Campaign limit reached, stopping import", "campaign", segment.Campaign, "reason", err)
+			break
+		}
+
+		pause.Wait(ctx, log, connector.Browser)
+		if ctx.Err() != nil {
+			log.Info("Shutdown requested, stopping before the next action")
+			break
+		}
+		log.Info("Sending connection request to imported target", "url", t.URL)
+		note := NoteFor(log, store, cfg, segment, t.URL, noteTemplate)
+		res, err := connector.SendConnectionRequestVars(ctx, t.URL, note, t.Vars)
+		if !recordConnectResult(ctx, log, connector, store, undo, segment, t.URL, note, res, err) {
+			continue
+		}
+
+		sent++
+		delay := time.Duration(30+rand.Intn(60)) * time.Second
+		log.Info("Sleeping before next request", "seconds", delay)
+		PerformRandomStealth(connector.Browser)
+		sleepCtx(ctx, delay)
+	}
+	log.Info("Import run complete", "sent", sent)
+}
+
+// SplitKeywords splits a ";"-separated keywords flag into individual searches
+func SplitKeywords(kw string) []string {
+	var out []string
+	for _, k := range strings.Split(kw, ";") {
+		if k = strings.TrimSpace(k); k != "" {
+			out = append(out, k)
+		}
+	}
+	if len(out) == 0 {
+		out = []string{""}
+	}
+	return out
+}
+
+// NewSessions builds the session manager for the persona in use, nil when
+// sessions are disabled
+func NewSessions(cfg *config.Config) *stealth.SessionManager {
+	if !cfg.Sessions.Enabled {
+		return nil
+	}
+	m := stealth.NewSessionManager(stealth.BusinessHours())
+	m.MinSession, m.MaxSession = cfg.Sessions.MinSession, cfg.Sessions.MaxSession
+	m.MinBreak, m.MaxBreak = cfg.Sessions.MinBreak, cfg.Sessions.MaxBreak
+	m.LunchAt, _ = cfg.LunchAt() // checked by Validate
+	m.Lunch = cfg.Sessions.Lunch
+	return m
+}
+
+// PerformRandomStealth performs random hover actions
+func PerformRandomStealth(b *browser.Browser) {
+	// Randomly decide to hover over something safe
+	if rand.Float32() > 0.7 { // 30% chance
+		// Find a safe element to hover (e.g., logo, nav)
+		// We try a few generic safe selectors
 		el, err := b.Page.Element("h1, .global-nav__content, img")
 		if err == nil {
 			b.HumanMove(el)
 		}
 	}
 }
+
+// ConsoleConfirm prints the rendered text and asks the operator to approve the send
+func ConsoleConfirm(action hooks.Action, profileURL, text string) bool {
+	if text == "" {
+		text = "(no note)"
+	}
+	fmt.Printf("\n=== Confirm %s to %s ===\n%s\n=== Send? [y/N]: ", action, profileURL, text)
+
+	answer := strings.ToLower(strings.TrimSpace(<-consoleLines()))
+	return answer == "y" || answer == "yes"
+}
+
+// console reads the operator's input lines. One reader serves both the
+// confirm prompt and the undo window so neither swallows the other's input.
+var console struct {
+	once  sync.Once
+	lines chan string
+}
+
+// consoleLines starts reading stdin on first use; the channel is closed at EOF
+func consoleLines() <-chan string {
+	console.once.Do(func() {
+		console.lines = make(chan string)
+		go func() {
+			r := bufio.NewReader(os.Stdin)
+			for {
+				line, err := r.ReadString('\n')
+				if err == nil || line != "" {
+					console.lines <- line
+				}
+				if err != nil {
+					close(console.lines)
+					return
+				}
+			}
+		}()
+	})
+	return console.lines
+}
+
+// interactive reports whether stdin is a terminal an operator can type in
+func interactive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// RecentlyVisited reports whether a profile was visited for this action within min_revisit_interval
+func RecentlyVisited(store storage.DataStore, cfg *config.Config, url string, action hooks.Action) bool {
+	interval := cfg.Limits.MinRevisitInterval
+	if interval <= 0 {
+		return false
+	}
+	last := store.LastVisit(url, string(action))
+	return !last.IsZero() && time.Since(last) < interval
+}
+
+// Segment identifies the campaign and tags a run applies to actioned profiles
+type Segment struct {
+	Campaign         string
+	Tags             []string
+	PerCampaignDedup bool
+
+	// Definition is set when the campaign was loaded from a file
+	Definition *campaign.Campaign
+}
+
+// runSpec is what can differ between the runs of one process: the options,
+// the campaign and the segment and templates derived from them
+type runSpec struct {
+	opts          *Options
+	camp          *campaign.Campaign
+	segment       Segment
+	noteTemplate  string
+	msgTemplate   string
+	msgAttachment string
+}
+
+// applyCampaignSearch lets the campaign's search criteria replace the flag values
+func applyCampaignSearch(opts *Options, c *campaign.Campaign) {
+	if c.Search.Keywords != "" {
+		opts.Keywords = c.Search.Keywords
+	}
+	if c.Search.Title != "" {
+		opts.Title = c.Search.Title
+	}
+	if c.Search.Company != "" {
+		opts.Company = c.Search.Company
+	}
+	if c.Search.Location != "" {
+		opts.Location = c.Search.Location
+	}
+	if c.Search.Industry != "" {
+		opts.Industry = c.Search.Industry
+	}
+	if len(c.Search.Network) > 0 {
+		opts.Network = strings.Join(c.Search.Network, ",")
+	}
+	if c.Search.Pages > 0 {
+		opts.MaxPages = c.Search.Pages
+	}
+}
+
+// CheckLimits applies the campaign file's daily/weekly limits, if any
+func (sg Segment) CheckLimits(store storage.DataStore) error {
+	if sg.Definition == nil {
+		return nil
+	}
+	return sg.Definition.CheckLimits(store, time.Now())
+}
+
+// AlreadyContacted applies the configured dedup policy: globally by default,
+// or only within the current campaign when per-campaign dedup is enabled
+func (sg Segment) AlreadyContacted(store storage.DataStore, url string) bool {
+	if sg.PerCampaignDedup && sg.Campaign != "" {
+		return store.InCampaign(url, sg.Campaign)
+	}
+	return store.IsRequestSent(url)
+}
+
+// Tag records the run's campaign and tags on a profile, and the action in the campaign's partition
+func (sg Segment) Tag(log logger.Logger, store storage.DataStore, url string, action hooks.Action) {
+	if sg.Campaign == "" && len(sg.Tags) == 0 {
+		return
+	}
+	if err := store.TagProfile(url, sg.Campaign, sg.Tags...); err != nil {
+		log.Warn("Failed to tag profile", "url", url, "error", err)
+	}
+	if err := store.RecordCampaignAction(sg.Campaign, string(action), url); err != nil {
+		log.Warn("Failed to record campaign action", "campaign", sg.Campaign, "url", url, "error", err)
+	}
+}
+
+// Template returns the profile's A/B variant of a template kind from the
+// campaign file, or fallback when the campaign defines no variants
+func (sg Segment) Template(log logger.Logger, store storage.DataStore, kind, url, fallback string) string {
+	if sg.Definition == nil {
+		return fallback
+	}
+	v, ok, err := sg.Definition.PickVariant(store, kind, url)
+	if err != nil {
+		log.Warn("Failed to record template variant", "kind", kind, "url", url, "error", err)
+	}
+	if !ok {
+		return fallback
+	}
+	log.Debug("Using template variant", "kind", kind, "variant", v.Name, "url", url)
+	return v.Template
+}
+
+// UndoWindow gives the operator a short grace period after a send to
+// request a withdrawal by creating a control file or, when the run has a
+// terminal, pressing Enter
+type UndoWindow struct {
+	Path  string
+	Grace time.Duration
+}
+
+// Requested waits out the grace window and reports whether the undo file
+// appeared or Enter was pressed. The file is consumed so it doesn't affect
+// the next send. A shutdown request closes the window early.
+func (u UndoWindow) Requested(ctx context.Context, log logger.Logger) bool {
+	keys := u.keys()
+	if u.Grace <= 0 || (u.Path == "" && keys == nil) {
+		return false
+	}
+
+	if keys != nil {
+		log.Info("Undo window open, press Enter or create the undo file to withdraw", "file", u.Path, "grace", u.Grace)
+	} else {
+		log.Info("Undo window open, create the undo file to withdraw", "file", u.Path, "grace", u.Grace)
+	}
+	deadline := time.Now().Add(u.Grace)
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		if u.Path != "" {
+			if _, err := os.Stat(u.Path); err == nil {
+				os.Remove(u.Path)
+				log.Warn("Undo requested by operator")
+				return true
+			}
+		}
+		select {
+		case _, ok := <-keys:
+			if ok {
+				log.Warn("Undo requested by operator")
+				return true
+			}
+			keys = nil
+		case <-tick.C:
+		case <-ctx.Done():
+		}
+	}
+	return false
+}
+
+// keys returns the console's lines with keys pressed before the window
+// discarded, nil when stdin isn't a terminal
+func (u UndoWindow) keys() <-chan string {
+	if u.Grace <= 0 || !interactive() {
+		return nil
+	}
+	keys := consoleLines()
+	for {
+		select {
+		case _, ok := <-keys:
+			if !ok {
+				return nil
+			}
+		default:
+			return keys
+		}
+	}
+}
+
+// PauseControl lets an operator pause a run by creating a control file
+type PauseControl struct {
+	Path    string
+	Timeout time.Duration
+	// OnTick is called on every idle iteration, e.g. to keep liveness fresh
+	OnTick func()
+	// Sessions, when set, holds the workflow through session breaks
+	Sessions *stealth.SessionManager
+}
+
+// Wait blocks through session breaks, then while the control file exists,
+// fidgeting occasionally, until it is removed, the timeout elapses or ctx
+// is cancelled
+func (p PauseControl) Wait(ctx context.Context, log logger.Logger, b *browser.Browser) {
+	p.waitSession(ctx, log)
+	if p.Path == "" {
+		return
+	}
+	if _, err := os.Stat(p.Path); err != nil {
+		return
+	}
+
+	log.Warn("Paused by control file, remove it to resume", "file", p.Path, "timeout", p.Timeout)
+	start := time.Now()
+	for {
+		if _, err := os.Stat(p.Path); err != nil {
+			log.Info("Control file removed, resuming", "paused_for", time.Since(start).Round(time.Second))
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if p.Timeout > 0 && time.Since(start) >= p.Timeout {
+			log.Warn("Pause timeout elapsed, resuming", "file", p.Path)
+			return
+		}
+
+		if p.OnTick != nil {
+			p.OnTick()
+		}
+		PerformRandomStealth(b)
+		sleepCtx(ctx, time.Duration(10+rand.Intn(20))*time.Second)
+	}
+}
+
+// waitSession blocks until the session manager lets activity resume
+func (p PauseControl) waitSession(ctx context.Context, log logger.Logger) {
+	for ctx.Err() == nil {
+		resume, reason := p.Sessions.Next(time.Now())
+		if resume.IsZero() {
+			return
+		}
+		log.Info("Taking a break", "reason", reason, "until", resume.Format("Mon 15:04"))
+		for ctx.Err() == nil && time.Now().Before(resume) {
+			if p.OnTick != nil {
+				p.OnTick()
+			}
+			sleepCtx(ctx, min(time.Until(resume), time.Minute))
+		}
+	}
+}
+
+// sleepCtx sleeps for d or until ctx is cancelled
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+)
+
+// RunParallel runs the wrapped workflow for the chosen accounts at once, at
+// most --pool (or parallel.pool) at a time. Each account is a child process
+// with its own browser, proxy, persona and state, like the daemon's jobs.
+func RunParallel(ctx context.Context, log logger.Logger, cfg *config.Config, opts *Options) error {
+	if len(cfg.Accounts) == 0 {
+		return fmt.Errorf("%s needs accounts configured", opts.Command)
+	}
+	accounts := cfg.AccountNames()
+	if opts.Accounts != "" {
+		accounts = nil
+		for _, name := range strings.Split(opts.Accounts, ",") {
+			name = strings.TrimSpace(name)
+			if !slices.Contains(cfg.AccountNames(), name) {
+				return fmt.Errorf("%w %q (configured: %s)", config.ErrUnknownAccount, name, strings.Join(cfg.AccountNames(), ", "))
+			}
+			accounts = append(accounts, name)
+		}
+	}
+	pool := opts.Pool
+	if pool <= 0 {
+		pool = cfg.Parallel.Pool
+	}
+	if pool <= 0 {
+		pool = len(accounts)
+	}
+	w := opts.Wrapped
+	log.Info("Running accounts in parallel", "command", w.Command, "accounts", len(accounts), "pool", pool)
+	return runAccounts(ctx, log, accounts, pool, func(account string) []string {
+		return w.ForwardArgs(w.Command, account)
+	})
+}
+
+// runAccounts runs a child process with args(account) for each account, at
+// most pool at a time, and waits for all of them. Their output is prefixed
+// with the account. No new child starts once ctx is cancelled.
+func runAccounts(ctx context.Context, log logger.Logger, accounts []string, pool int, args func(account string) []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if pool < 1 {
+		pool = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex // guards failed and the shared output
+		failed []string
+		slots  = make(chan struct{}, pool)
+	)
+	for _, account := range accounts {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			log.Info("Starting account", "account", account)
+			stdout := &prefixWriter{w: os.Stdout, mu: &mu, prefix: "[" + account + "] "}
+			stderr := &prefixWriter{w: os.Stderr, mu: &mu, prefix: "[" + account + "] "}
+			c := exec.Command(exe, args(account)...)
+			c.Stdout, c.Stderr = stdout, stderr
+			err := c.Run()
+			stdout.Flush()
+			stderr.Flush()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Error("Account run failed", "account", account, "error", err)
+				failed = append(failed, account)
+				return
+			}
+			log.Info("Account run finished", "account", account)
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d accounts failed: %s", len(failed), len(accounts), strings.Join(failed, ", "))
+	}
+	return ctx.Err()
+}
+
+// prefixWriter writes each complete line with prefix. Writers sharing mu
+// never interleave within a line.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		p.mu.Lock()
+		io.WriteString(p.w, p.prefix)
+		p.w.Write(p.buf[:i+1])
+		p.mu.Unlock()
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes a last line left without a newline
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.Write([]byte("\n"))
+	}
+}
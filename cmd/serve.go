@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"linkedin-automation/api"
+	"linkedin-automation/campaign"
+	"linkedin-automation/search"
+)
+
+// requestOptions applies an API job request to a copy of the serve command's
+// options and loads the campaign file it names. Empty fields keep the
+// command-line values.
+func requestOptions(base *Options, r api.JobRequest) (*Options, *campaign.Campaign, error) {
+	o := *base
+	o.Command = r.Command
+	if r.Campaign != "" {
+		o.Campaign = r.Campaign
+		// Requests only reach campaign files in the campaigns directory
+		if campaign.IsFile(r.Campaign) {
+			path, err := campaign.InDir(campaign.Dir, r.Campaign)
+			if err != nil {
+				return nil, nil, err
+			}
+			o.Campaign = path
+		}
+	}
+	if len(r.Tags) > 0 {
+		o.Tags = strings.Join(r.Tags, ",")
+	}
+	if r.Keywords != "" {
+		o.Keywords = r.Keywords
+	}
+	if r.Title != "" {
+		o.Title = r.Title
+	}
+	if r.Company != "" {
+		o.Company = r.Company
+	}
+	if r.Location != "" {
+		o.Location = r.Location
+	}
+	if r.Industry != "" {
+		o.Industry = r.Industry
+	}
+	if r.Network != "" {
+		o.Network = r.Network
+	}
+	if r.Pages > 0 {
+		o.MaxPages = r.Pages
+	}
+
+	var c *campaign.Campaign
+	if campaign.IsFile(o.Campaign) {
+		var err error
+		if c, err = campaign.Load(o.Campaign); err != nil {
+			return nil, nil, fmt.Errorf("campaign %s: %w", o.Campaign, err)
+		}
+		applyCampaignSearch(&o, c)
+	}
+	if _, err := search.NetworkCodes(o.networkDegrees()); err != nil {
+		return nil, nil, err
+	}
+	return &o, c, nil
+}
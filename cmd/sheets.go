@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+	"linkedin-automation/sheets"
+	"linkedin-automation/storage"
+	"linkedin-automation/targets"
+)
+
+// sheetStatusColumns are the columns SyncSheet fills, in the order they are
+// added to a sheet missing them
+var sheetStatusColumns = []string{"invited_at", "accepted_at", "messaged_at", "replied"}
+
+// LoadTargets reads --input: a Google Sheet URL or a CSV file
+func LoadTargets(cfg *config.Config, input string) ([]targets.Target, error) {
+	if !sheets.IsURL(input) {
+		return targets.LoadCSV(input)
+	}
+	client, err := sheets.New(cfg.Sheets.CredentialsFile, cfg.Sheets.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := client.Read(context.Background(), input)
+	if err != nil {
+		return nil, err
+	}
+	return targets.FromRows(sheet.Rows)
+}
+
+// SyncSheet writes each target row's invited, accepted, messaged and replied
+// times from the state into the sheet's status columns, adding the columns
+// when missing. Rows the bot never touched get empty cells.
+func SyncSheet(ctx context.Context, log logger.Logger, cfg *config.Config, store *storage.MemoryStore, sheetURL string) error {
+	if sheetURL == "" {
+		return errors.New("--sheet is required")
+	}
+	client, err := sheets.New(cfg.Sheets.CredentialsFile, cfg.Sheets.Timeout)
+	if err != nil {
+		return err
+	}
+	sheet, err := client.Read(ctx, sheetURL)
+	if err != nil {
+		return err
+	}
+	urls, err := targets.RowURLs(sheet.Rows)
+	if err != nil {
+		return err
+	}
+
+	records := make(map[string]storage.Record)
+	for _, r := range store.Records() {
+		records[r.ProfileURL] = r
+	}
+	values := make(map[string][]string, len(sheetStatusColumns))
+	updated := 0
+	for _, url := range urls {
+		r := records[url]
+		values["invited_at"] = append(values["invited_at"], formatTime(r.RequestedAt))
+		values["accepted_at"] = append(values["accepted_at"], formatTime(r.ConnectedAt))
+		values["messaged_at"] = append(values["messaged_at"], formatTime(r.MessagedAt))
+		values["replied"] = append(values["replied"], formatTime(r.RepliedAt))
+		if url != "" && !r.RequestedAt.IsZero() {
+			updated++
+		}
+	}
+	if err := client.WriteColumns(ctx, sheet, sheetStatusColumns, values, targets.HasHeader(sheet.Rows)); err != nil {
+		return err
+	}
+	log.Info("Sheet updated", "tab", sheet.Title, "rows", len(urls), "invited", updated)
+	return nil
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnknownAccount is returned by ForAccount for a name not listed under accounts
+var ErrUnknownAccount = errors.New("unknown account")
+
+// Account is one LinkedIn account of a multi-account setup. Credentials are
+// the account's own; other empty fields inherit the top-level settings, with
+// state, session, fingerprint, persona and browser profile paths namespaced by Name
+// so accounts never share them.
+type Account struct {
+	Name string `yaml:"name"`
+
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	PasswordCommand string `yaml:"password_command"`
+	TOTPSecret      string `yaml:"totp_secret"`
+	SessionFile     string `yaml:"session_file"`
+
+	UserDataDir string   `yaml:"user_data_dir"`
+	ProxyURL    string   `yaml:"proxy_url"`
+	Proxies     []string `yaml:"proxies"`
+
+	// StoragePath is the account's state file, default "<storage.path>" with the
+	// name before the extension (state.json -> state.<name>.json)
+	StoragePath string `yaml:"storage_path"`
+
+	// TypingProfile gives the account its own typing speed ("slow",
+	// "average", "fast"), default the top-level typing.profile
+	TypingProfile string `yaml:"typing_profile"`
+
+	// BusinessHours overrides the top-level business hours field by field,
+	// e.g. an account run from another timezone
+	BusinessHours BusinessHours `yaml:"business_hours"`
+
+	// Limits override the top-level limits when set (0 inherits)
+	Limits struct {
+		DailyConnections  int `yaml:"daily_connections"`
+		WeeklyConnections int `yaml:"weekly_connections"`
+		DailyMessages     int `yaml:"daily_messages"`
+	} `yaml:"limits"`
+}
+
+// AccountNames lists the configured accounts in config order
+func (c *Config) AccountNames() []string {
+	names := make([]string, 0, len(c.Accounts))
+	for _, a := range c.Accounts {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// ForAccount returns a copy of the config with the named account applied.
+// An empty name picks the first account; without accounts c itself is returned.
+// Secrets can come from LINKEDIN_<NAME>_PASSWORD and LINKEDIN_<NAME>_TOTP_SECRET.
+func (c *Config) ForAccount(name string) (*Config, error) {
+	if len(c.Accounts) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("%w %q: no accounts configured", ErrUnknownAccount, name)
+		}
+		return c, nil
+	}
+
+	var acc *Account
+	for i := range c.Accounts {
+		if name == "" || c.Accounts[i].Name == name {
+			acc = &c.Accounts[i]
+			break
+		}
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("%w %q (configured: %s)", ErrUnknownAccount, name, strings.Join(c.AccountNames(), ", "))
+	}
+
+	cfg := *c
+	cfg.Account = acc.Name
+	cfg.Accounts = nil
+
+	cfg.LinkedIn.Username = acc.Username
+	cfg.LinkedIn.Password = acc.Password
+	cfg.LinkedIn.UsernameCommand, cfg.LinkedIn.PasswordCommand = "", ""
+	cfg.LinkedIn.TOTPSecret = acc.TOTPSecret
+	env := "LINKEDIN_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(acc.Name)) + "_"
+	if v := os.Getenv(env + "PASSWORD"); v != "" {
+		cfg.LinkedIn.Password = v
+	}
+	if v := os.Getenv(env + "TOTP_SECRET"); v != "" {
+		cfg.LinkedIn.TOTPSecret = v
+	}
+	if acc.PasswordCommand != "" {
+		v, err := runSecretCommand(acc.PasswordCommand)
+		if err != nil {
+			return nil, fmt.Errorf("accounts %q password_command: %w", acc.Name, err)
+		}
+		cfg.LinkedIn.Password = v
+	}
+
+	cfg.LinkedIn.SessionFile = acc.SessionFile
+	if cfg.LinkedIn.SessionFile == "" && c.LinkedIn.SessionFile != "" {
+		cfg.LinkedIn.SessionFile = namespaced(c.LinkedIn.SessionFile, acc.Name)
+	}
+	cfg.UserDataDir = acc.UserDataDir
+	if cfg.UserDataDir == "" && c.UserDataDir != "" {
+		cfg.UserDataDir = filepath.Join(c.UserDataDir, acc.Name)
+	}
+	cfg.Storage.Path = acc.StoragePath
+	if cfg.Storage.Path == "" {
+		cfg.Storage.Path = namespaced(c.Storage.Path, acc.Name)
+	}
+	if c.Browser.FingerprintFile != "" {
+		cfg.Browser.FingerprintFile = namespaced(c.Browser.FingerprintFile, acc.Name)
+	}
+	if c.Storage.AuditFile != "" {
+		cfg.Storage.AuditFile = namespaced(c.Storage.AuditFile, acc.Name)
+	}
+	if c.Logging.File != "" {
+		cfg.Logging.File = namespaced(c.Logging.File, acc.Name)
+	}
+	if c.Browser.PersonaFile != "" {
+		cfg.Browser.PersonaFile = namespaced(c.Browser.PersonaFile, acc.Name)
+	}
+
+	if acc.TypingProfile != "" {
+		cfg.Typing.Profile = acc.TypingProfile
+	}
+	cfg.BusinessHours = c.BusinessHours.override(acc.BusinessHours)
+	if acc.ProxyURL != "" || len(acc.Proxies) > 0 {
+		cfg.ProxyURL, cfg.Proxies = acc.ProxyURL, acc.Proxies
+	}
+	if acc.Limits.DailyConnections > 0 {
+		cfg.Limits.DailyConnections = acc.Limits.DailyConnections
+	}
+	if acc.Limits.WeeklyConnections > 0 {
+		cfg.Limits.WeeklyConnections = acc.Limits.WeeklyConnections
+	}
+	if acc.Limits.DailyMessages > 0 {
+		cfg.Limits.DailyMessages = acc.Limits.DailyMessages
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("account %q: %w", acc.Name, err)
+	}
+	return &cfg, nil
+}
+
+// namespaced inserts name before the extension: state.json -> state.<name>.json
+func namespaced(path, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + name + ext
+}
+
+// validateAccounts checks account names are usable in paths and unique
+func (c *Config) validateAccounts() error {
+	seen := make(map[string]bool)
+	for i, a := range c.Accounts {
+		if a.Name == "" {
+			return fmt.Errorf("accounts[%d]: name is required", i)
+		}
+		if strings.ContainsAny(a.Name, `/\:`) || strings.HasPrefix(a.Name, ".") {
+			return fmt.Errorf("accounts[%d]: name %q can't be used in file names", i, a.Name)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("accounts[%d]: duplicate name %q", i, a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return nil
+}
@@ -2,12 +2,34 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"os/exec"
+	"runtime"
 	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ErrNotAllowlisted is returned when an action targets a profile outside the safe allowlist
+var ErrNotAllowlisted = errors.New("profile not in safe allowlist")
+
+// InviteCooldown is how long LinkedIn blocks a new invitation to a profile
+// after the previous one was withdrawn
+const InviteCooldown = 21 * 24 * time.Hour
+
+// DefaultButtonLabels are the English labels used when button_labels does not override an action
+var DefaultButtonLabels = map[string][]string{
+	"connect": {"Connect"},
+	"follow":  {"Follow"},
+	"message": {"Message"},
+	"send":    {"Send", "Send now", "Send invitation"},
+	// cta lists primary-styled call-to-action labels that must never be taken for Connect
+	"cta": {"Open to", "Visit", "website", "Hiring", "Providing services", "Book an appointment", "View my"},
+}
+
 // Config holds the application configuration
 type Config struct {
 	Headless     bool   `yaml:"headless"`
@@ -16,15 +38,519 @@ type Config struct {
 	UserDataDir  string `yaml:"user_data_dir"`
 	MonitorIndex int    `yaml:"monitor_index"`
 
+	// Proxies is a pool of proxy URLs. The fastest healthy one is used per session
+	// and the browser rotates to the next when navigation errors or checkpoints spike.
+	// ProxyURL alone is treated as a pool of one.
+	Proxies    []string `yaml:"proxies"`
+	ProxyCheck struct {
+		// URL is fetched through each proxy at startup to measure latency and
+		// catch IPs LinkedIn has flagged
+		URL        string        `yaml:"url"`
+		Timeout    time.Duration `yaml:"timeout"`
+		MaxLatency time.Duration `yaml:"max_latency"`
+		// RotateAfter failures within RotateWindow switch to the next proxy (0 = never)
+		RotateAfter  int           `yaml:"rotate_after"`
+		RotateWindow time.Duration `yaml:"rotate_window"`
+	} `yaml:"proxy_check"`
+
+	Browser struct {
+		// RemoteURL attaches to an already-running Chrome started with
+		// --remote-debugging-port (e.g. "http://127.0.0.1:9222") instead of
+		// launching one. Its own profile and fingerprint are used as they are.
+		RemoteURL string `yaml:"remote_url"`
+		// FingerprintFile keeps the generated browser fingerprint (user agent,
+		// screen, timezone, WebGL...) so every session presents the same one.
+		// Empty generates a fresh fingerprint per launch.
+		FingerprintFile string `yaml:"fingerprint_file"`
+		// PersonaFile keeps the generated behavioural persona (typing speed,
+		// scroll force, reading pace, active hours). Empty generates a fresh
+		// one per run.
+		PersonaFile string `yaml:"persona_file"`
+		// Display is how Chrome is shown when headless is false: "auto" stays
+		// headful with a screen and otherwise uses a virtual display
+		// (xvfb-run) or new headless mode; "xvfb", "headless" and "none"
+		// (always headful) force one
+		Display string `yaml:"display"`
+		// Sandbox keeps Chrome's sandbox on; containers running as root
+		// need it off
+		Sandbox bool `yaml:"sandbox"`
+		// IdleMouse drifts the mouse slightly while the bot reads or waits,
+		// pausing during clicks and typing
+		IdleMouse bool `yaml:"idle_mouse"`
+	} `yaml:"browser"`
+
+	// ChromeBinary overrides the auto-detected browser executable
+	ChromeBinary string `yaml:"chrome_binary"`
+	// ChromeFlags are extra command-line flags, e.g. "--no-sandbox"
+	ChromeFlags []string `yaml:"chrome_flags"`
+
+	// SafeAllowlist restricts all profile actions to URLs containing one of
+	// these substrings. Empty means no restriction.
+	SafeAllowlist []string `yaml:"safe_allowlist"`
+
+	// ButtonLabels maps an action (connect, follow, message, send, cta) to the
+	// button texts LinkedIn may show for it. Missing keys use the defaults.
+	ButtonLabels map[string][]string `yaml:"button_labels"`
+
+	// SelectorsFile overrides the fallback chains of page elements (connect
+	// button, message box...) without rebuilding. A missing file keeps the
+	// built-in selectors.
+	SelectorsFile string `yaml:"selectors_file"`
+
+	Template struct {
+		// Sanitize strips emoji and normalises smart quotes/dashes before typing
+		Sanitize bool `yaml:"sanitize"`
+	} `yaml:"template"`
+
+	// Typing sets how fast and how accurately text is typed. Profile is
+	// "slow", "average" or "fast"; Layout ("qwerty" or "azerty") decides
+	// which neighbouring keys typos hit.
+	Typing struct {
+		Profile string `yaml:"profile"`
+		Layout  string `yaml:"layout"`
+	} `yaml:"typing"`
+
+	// AI generates each connection note with an LLM from the profile's headline
+	// and about section. Provider is "openai" or "anthropic"; empty disables it.
+	// Notes that fail or don't fit in 300 characters fall back to the template.
+	AI struct {
+		Provider string `yaml:"provider"`
+		APIKey   string `yaml:"api_key"`
+		Model    string `yaml:"model"`
+		Prompt   string `yaml:"prompt"`
+		// BaseURL points the provider at a compatible endpoint (e.g. a local server)
+		BaseURL string        `yaml:"base_url"`
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"ai"`
+
+	// TemplateSourceURL is an optional endpoint serving a JSON array of template rules.
+	// The last good response is cached at TemplateCache.
+	TemplateSourceURL string `yaml:"template_source_url"`
+	TemplateCache     string `yaml:"template_cache"`
+
+	// CampaignDedup controls re-contacting across campaigns:
+	// "global" (default) never contacts a profile twice,
+	// "campaign" allows the same profile again in a different campaign.
+	CampaignDedup string `yaml:"campaign_dedup"`
+
+	// HowDoYouKnowPolicy is "skip" (default) to abandon requests that trigger the
+	// "How do you know [Name]?" dialog, or the text of the option to select
+	// (e.g. "We've done business together", "Other").
+	HowDoYouKnowPolicy string `yaml:"how_do_you_know_policy"`
+
+	// UndoGrace holds the storage write after a connect so the request can be
+	// withdrawn via the undo control file or Enter. Zero disables the window.
+	UndoGrace time.Duration `yaml:"undo_grace"`
+
+	// QueueMaxAge is how long the connect and message workflows resume the
+	// candidates queued by an interrupted run before searching again. Zero
+	// resumes them at any age.
+	QueueMaxAge time.Duration `yaml:"queue_max_age"`
+
+	// NoteFooter / MessageFooter are appended to every connection note / message.
+	// Note bodies are truncated to keep the footer within LinkedIn's 300 characters.
+	NoteFooter    string `yaml:"note_footer"`
+	MessageFooter string `yaml:"message_footer"`
+
+	// NameStyle picks how {{name}} is filled in notes: "first" (default), "full",
+	// or "mixed" to vary between first, full and a generic greeting per profile
+	NameStyle string `yaml:"name_style"`
+
+	// DeferMessages makes message mode queue follow-ups instead of sending them;
+	// run the flush-messages command to send the queue
+	DeferMessages bool `yaml:"defer_messages"`
+
+	// MessageAttachment is a file (PDF one-pager, image...) sent with every
+	// message-mode follow-up, queued ones included; empty sends none
+	MessageAttachment string `yaml:"message_attachment"`
+
+	// OnePerCompanyPerRun skips profiles whose current company was already contacted in this run
+	OnePerCompanyPerRun bool `yaml:"one_per_company_per_run"`
+
+	// SendEnableTimeout bounds the wait for a temporarily disabled message Send button
+	SendEnableTimeout time.Duration `yaml:"send_enable_timeout"`
+
+	// Sequence is the drip sequence for the sequence command. Each step is sent
+	// Delay after the previous one, the first Delay after the connection was recorded.
+	Sequence []SequenceStep `yaml:"sequence"`
+
+	// Replies decides what a follow-up does when the connection already wrote in
+	// the thread: "skip" (default) or "template" to send Template instead
+	Replies struct {
+		Policy   string `yaml:"policy"`
+		Template string `yaml:"template"`
+	} `yaml:"replies"`
+
+	// SingleComposer closes leftover chat overlays before each message so text
+	// can't land in the wrong conversation
+	SingleComposer bool `yaml:"single_composer"`
+
+	// AutoReauth fills the mid-session "Verify it's you" password prompt
+	AutoReauth bool `yaml:"auto_reauth"`
+
+	// Accounts lists several LinkedIn accounts; --account picks one (default the
+	// first) and the daemon takes turns between them. Empty uses the linkedin section.
+	Accounts []Account `yaml:"accounts"`
+	// Account is the name of the account ForAccount applied, empty for single-account setups
+	Account string `yaml:"-"`
+
+	// Parallel runs accounts side by side: Pool is the most browsers open at
+	// once for the parallel command (0 = every account) and, when above 1, for
+	// the daemon, which otherwise takes turns. Accounts claim profiles in
+	// ClaimsFile so two never target one person in the same campaign.
+	Parallel struct {
+		Pool       int    `yaml:"pool"`
+		ClaimsFile string `yaml:"claims_file"`
+	} `yaml:"parallel"`
+
 	LinkedIn struct {
 		Username string `yaml:"username"`
 		Password string `yaml:"password"`
+		// UsernameCommand / PasswordCommand are shell commands whose trimmed
+		// output is used instead (e.g. "pass linkedin", "op read op://...")
+		UsernameCommand string `yaml:"username_command"`
+		PasswordCommand string `yaml:"password_command"`
+		// SessionFile holds encrypted session cookies, imported before login and
+		// refreshed after it. SessionKey is the passphrase (prefer LINKEDIN_SESSION_KEY).
+		SessionFile string `yaml:"session_file"`
+		SessionKey  string `yaml:"session_key"`
+		// TOTPSecret is the base32 authenticator-app secret used to answer 2FA prompts
+		TOTPSecret string `yaml:"totp_secret"`
 	} `yaml:"linkedin"`
 
 	Limits struct {
 		DailyConnections int `yaml:"daily_connections"`
-		DailyMessages    int `yaml:"daily_messages"`
+		// WeeklyConnections caps invitations over a rolling 7 days (LinkedIn enforces ~100)
+		WeeklyConnections int `yaml:"weekly_connections"`
+		DailyMessages     int `yaml:"daily_messages"`
+
+		// MinGlobalMessageGap is the minimum time between any two messages, across restarts
+		MinGlobalMessageGap time.Duration `yaml:"min_global_message_gap"`
+
+		// MinRevisitInterval skips profiles visited for the same action more recently than this
+		MinRevisitInterval time.Duration `yaml:"min_revisit_interval"`
+
+		// MaxLoginFailuresPerDay stops login attempts for the rest of the day (0 = unlimited)
+		MaxLoginFailuresPerDay int `yaml:"max_login_failures_per_day"`
+		// PerKeywordDailyLimit caps requests per search keyword per day (0 = unlimited)
+		PerKeywordDailyLimit int `yaml:"per_keyword_daily_limit"`
 	} `yaml:"limits"`
+
+	// RateLimits budgets each action type per rolling hour, calendar day and
+	// rolling week, and paces actions apart. Counters live in the state file so
+	// restarts don't reset them. Connect and message Daily/Weekly default to limits.
+	RateLimits struct {
+		Connect     RateLimit `yaml:"connect"`
+		Message     RateLimit `yaml:"message"`
+		ProfileView RateLimit `yaml:"profile_view"`
+	} `yaml:"rate_limits"`
+
+	Storage struct {
+		Path string `yaml:"path"`
+		// Postgres, a connection string, keeps the state in PostgreSQL
+		// instead of Path so bot instances on several machines share it and
+		// never contact the same person from two accounts. Needs a build
+		// with -tags postgres.
+		Postgres string `yaml:"postgres"`
+		// AuditFile is the append-only audit log of every action (JSON
+		// Lines); Postgres deployments use the bot_audit table. Empty
+		// disables it.
+		AuditFile string `yaml:"audit_file"`
+		// BackupDir enables rotating state backups, keeping the newest BackupKeep.
+		// BackupEvery additionally backs up after every N saves (0 = only on close).
+		BackupDir   string `yaml:"backup_dir"`
+		BackupKeep  int    `yaml:"backup_keep"`
+		BackupEvery int    `yaml:"backup_every"`
+		// LockWait is how long to wait for another instance to release the
+		// state file before giving up (0 = refuse to start immediately)
+		LockWait time.Duration `yaml:"lock_wait"`
+	} `yaml:"storage"`
+
+	// Withdraw configures the withdraw command. Pending invitations older than MaxAge
+	// are withdrawn, at most MaxPerRun per run. Withdrawn profiles become
+	// eligible again after ReeligibleAfter (0 = never, at least InviteCooldown),
+	// up to MaxAttempts invitations in total; later attempts use RetryNote.
+	Withdraw struct {
+		MaxAge          time.Duration `yaml:"max_age"`
+		MaxPerRun       int           `yaml:"max_per_run"`
+		ReeligibleAfter time.Duration `yaml:"reeligible_after"`
+		MaxAttempts     int           `yaml:"max_attempts"`
+		RetryNote       string        `yaml:"retry_note"`
+	} `yaml:"withdraw"`
+
+	// Preflight checks account standing after login. Restricted always aborts;
+	// OnWarned is "abort" (default), "engagement" (only view and endorse
+	// workflows run) or "continue".
+	Preflight struct {
+		Enabled  bool   `yaml:"enabled"`
+		OnWarned string `yaml:"on_warned"`
+	} `yaml:"preflight"`
+
+	// Blacklist lists profiles, companies and headline keywords (e.g. "recruiter")
+	// that are never contacted. Entries added with the exclude command apply too.
+	Blacklist struct {
+		Profiles  []string `yaml:"profiles"`
+		Companies []string `yaml:"companies"`
+		Keywords  []string `yaml:"keywords"`
+	} `yaml:"blacklist"`
+
+	// Checkpoint handles security challenges (CAPTCHA, phone or PIN verification,
+	// "unusual activity") seen after any navigation. Headful runs pause up to
+	// WaitTimeout for them to be solved by hand; headless runs stop.
+	// NotifyURL is the old way to get a JSON POST for each one, it becomes a
+	// notify webhook channel for checkpoint events.
+	Checkpoint struct {
+		WaitTimeout time.Duration `yaml:"wait_timeout"`
+		NotifyURL   string        `yaml:"notify_url"`
+	} `yaml:"checkpoint"`
+
+	// Search extends the place and industry names --location/--industry
+	// understand: name -> id (the number in a search URL's geoUrn/industry facet)
+	Search struct {
+		GeoURNs      map[string]string `yaml:"geo_urns"`
+		IndustryURNs map[string]string `yaml:"industry_urns"`
+	} `yaml:"search"`
+
+	// Events configures --event targeting: Note replaces the default note
+	// template for the event's attendees (a campaign's note still wins)
+	Events struct {
+		Note string `yaml:"note"`
+	} `yaml:"events"`
+
+	// Posts configures --post targeting: Note replaces the default note
+	// template for the post's engagers (a campaign's note still wins)
+	Posts struct {
+		Note string `yaml:"note"`
+	} `yaml:"posts"`
+
+	// Endorse configures the endorse command: at most DailyLimit connections a
+	// day get 1 to MaxSkills of their top skills endorsed
+	Endorse struct {
+		DailyLimit int `yaml:"daily_limit"`
+		MaxSkills  int `yaml:"max_skills"`
+	} `yaml:"endorse"`
+
+	// Congrats configures the congrats command: at most DailyLimit connections
+	// a day get JobChange or Anniversary sent for a catch-up occasion
+	Congrats struct {
+		DailyLimit    int    `yaml:"daily_limit"`
+		JobChange     string `yaml:"job_change"`
+		Anniversary   string `yaml:"anniversary"`
+		Anniversaries bool   `yaml:"anniversaries"`
+	} `yaml:"congrats"`
+
+	// Birthdays configures the birthdays command: at most DailyLimit
+	// connections a day get Template on their birthday, once a year
+	Birthdays struct {
+		DailyLimit int    `yaml:"daily_limit"`
+		Template   string `yaml:"template"`
+	} `yaml:"birthdays"`
+
+	// View configures the view command: at most DailyLimit search results a
+	// day get their profile viewed, for MinDwell-MaxDwell each
+	View struct {
+		DailyLimit int           `yaml:"daily_limit"`
+		MinDwell   time.Duration `yaml:"min_dwell"`
+		MaxDwell   time.Duration `yaml:"max_dwell"`
+	} `yaml:"view"`
+
+	// WarmUp browses the feed for a random MinDuration-MaxDuration before each
+	// workflow, instead of jumping straight from login to outreach
+	WarmUp struct {
+		Enabled     bool          `yaml:"enabled"`
+		MinDuration time.Duration `yaml:"min_duration"`
+		MaxDuration time.Duration `yaml:"max_duration"`
+	} `yaml:"warm_up"`
+
+	// Reading spends MinDuration-MaxDuration on a profile before connecting,
+	// scrolling About and Experience; longer profiles get longer reads
+	Reading struct {
+		Enabled     bool          `yaml:"enabled"`
+		MinDuration time.Duration `yaml:"min_duration"`
+		MaxDuration time.Duration `yaml:"max_duration"`
+	} `yaml:"reading"`
+
+	// BusinessHours is when the account works; the daemon, sessions and the
+	// startup check all consult it. Accounts can override any field.
+	BusinessHours BusinessHours `yaml:"business_hours"`
+
+	// Sessions splits the day into sessions of MinSession-MaxSession with
+	// breaks of MinBreak-MaxBreak between them, a Lunch gap around LunchAt
+	// ("12:30") and nothing outside business hours. Workflows and
+	// the daemon wait out breaks instead of acting at an even pace all day.
+	Sessions struct {
+		Enabled    bool          `yaml:"enabled"`
+		MinSession time.Duration `yaml:"min_session"`
+		MaxSession time.Duration `yaml:"max_session"`
+		MinBreak   time.Duration `yaml:"min_break"`
+		MaxBreak   time.Duration `yaml:"max_break"`
+		LunchAt    string        `yaml:"lunch_at"`
+		Lunch      time.Duration `yaml:"lunch"`
+	} `yaml:"sessions"`
+
+	// Daemon runs workflows on cron schedules (daemon command). Each job fires
+	// within ±Jitter of its slot; jobs falling outside business hours are skipped
+	// when BusinessHoursOnly is set. KeepAlive revisits the feed while idle so
+	// the session stays warm, re-logging in if it expired.
+	Daemon struct {
+		Jobs              []DaemonJob   `yaml:"jobs"`
+		Jitter            time.Duration `yaml:"jitter"`
+		BusinessHoursOnly bool          `yaml:"business_hours_only"`
+		KeepAlive         time.Duration `yaml:"keep_alive"`
+	} `yaml:"daemon"`
+
+	// Notify sends alerts (run summaries, limits, checkpoints, login failures)
+	// to Slack, Telegram, email or a webhook. Events filters them for all
+	// channels, a channel's own events override it; empty means every event.
+	Notify struct {
+		Events   []string        `yaml:"events"`
+		Channels []NotifyChannel `yaml:"channels"`
+		Timeout  time.Duration   `yaml:"timeout"`
+	} `yaml:"notify"`
+
+	// Webhooks POST every lifecycle event (invite_sent, invite_accepted,
+	// message_sent, reply_detected, checkpoint_detected) as signed JSON to
+	// each endpoint, for Zapier, Make or n8n. Failed deliveries are retried
+	// Retries times with a doubling delay.
+	Webhooks struct {
+		Endpoints []WebhookEndpoint `yaml:"endpoints"`
+		Retries   int               `yaml:"retries"`
+		Timeout   time.Duration     `yaml:"timeout"`
+	} `yaml:"webhooks"`
+
+	// Sheets lets --input read targets from a Google Sheet and writes their
+	// status back. CredentialsFile is a service account key (prefer
+	// LINKEDIN_GOOGLE_CREDENTIALS); share the sheet with its client_email.
+	Sheets struct {
+		CredentialsFile string        `yaml:"credentials_file"`
+		Timeout         time.Duration `yaml:"timeout"`
+	} `yaml:"sheets"`
+
+	// Diagnostics saves a screenshot, the page HTML and the URL into Dir
+	// whenever an action or command fails. Empty disables it.
+	Diagnostics struct {
+		Dir string `yaml:"dir"`
+	} `yaml:"diagnostics"`
+
+	// Logging sets the console's level and the logs kept on disk: File is
+	// rotated past MaxSizeMB, keeping MaxBackups rotated files for at most
+	// MaxAge; RunDir gets one file per run named by start time and account.
+	// Each sink has its own level (debug, info, warn, error), and JSON writes
+	// the files as JSON lines. Empty File and RunDir log to the console only.
+	Logging struct {
+		Level      string        `yaml:"level"`
+		File       string        `yaml:"file"`
+		FileLevel  string        `yaml:"file_level"`
+		MaxSizeMB  int           `yaml:"max_size_mb"`
+		MaxBackups int           `yaml:"max_backups"`
+		MaxAge     time.Duration `yaml:"max_age"`
+		RunDir     string        `yaml:"run_dir"`
+		RunLevel   string        `yaml:"run_level"`
+		JSON       bool          `yaml:"json"`
+	} `yaml:"logging"`
+
+	// Summary writes a JSON accounting of every run (searches, sends, skips
+	// by reason, errors, duration) into Dir. Empty only prints it.
+	Summary struct {
+		Dir string `yaml:"dir"`
+	} `yaml:"summary"`
+
+	// API configures the serve command's HTTP API. Addr defaults to
+	// 127.0.0.1:8787. Without a Token (or LINKEDIN_API_TOKEN) a random one
+	// is generated for each run.
+	API struct {
+		Addr  string `yaml:"addr"`
+		Token string `yaml:"token"`
+	} `yaml:"api"`
+
+	Health struct {
+		// Staleness is how long without activity before /healthz reports 503
+		Staleness     time.Duration `yaml:"staleness"`
+		HeartbeatFile string        `yaml:"heartbeat_file"`
+	} `yaml:"health"`
+
+	// Ramp gradually raises the daily connection limit for new accounts.
+	// Opt-in: disabled while Start is 0.
+	Ramp struct {
+		Start           int `yaml:"start"`
+		IncrementPerDay int `yaml:"increment_per_day"`
+		Max             int `yaml:"max"`
+	} `yaml:"ramp"`
+}
+
+// RateLimit is one action type's budget; 0 disables a cap
+type RateLimit struct {
+	Hourly int `yaml:"hourly"`
+	Daily  int `yaml:"daily"`
+	Weekly int `yaml:"weekly"`
+	// MinGap is the least time between two actions of the type, across restarts
+	MinGap time.Duration `yaml:"min_gap"`
+	// Spread, together with Daily, paces actions at least Spread/Daily apart so
+	// the day's budget lasts that long instead of being used up in a burst
+	Spread time.Duration `yaml:"spread"`
+}
+
+// DaemonJob runs Command (e.g. "connect", "message") on a five-field cron schedule
+type DaemonJob struct {
+	Command string `yaml:"command"`
+	Cron    string `yaml:"cron"`
+}
+
+// NotifyChannel is one notification target. Type is "slack" (URL is the
+// incoming webhook), "telegram" (BotToken, ChatID), "email" (SMTP settings)
+// or "webhook" (URL receives the event as JSON).
+type NotifyChannel struct {
+	Type   string   `yaml:"type"`
+	Events []string `yaml:"events"`
+	URL    string   `yaml:"url"`
+
+	BotToken string `yaml:"bot_token"`
+	ChatID   string `yaml:"chat_id"`
+
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// WebhookEndpoint receives lifecycle events as JSON. With a Secret each body
+// is signed with HMAC-SHA256 in the X-Signature-256 header; Events filters
+// them, empty means every event.
+type WebhookEndpoint struct {
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"`
+}
+
+// SequenceStep is one message of a drip sequence
+type SequenceStep struct {
+	Delay    time.Duration `yaml:"delay"`
+	Template string        `yaml:"template"`
+
+	// Attachment is a file sent along with the step, empty for none
+	Attachment string `yaml:"attachment"`
+}
+
+// CheckAttachment reports whether the step's attachment, if any, is a readable file
+func (s SequenceStep) CheckAttachment() error {
+	return checkAttachment(s.Attachment)
+}
+
+// checkAttachment fails for a set path that isn't a regular file
+func checkAttachment(path string) error {
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("attachment: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("attachment %s is not a file", path)
+	}
+	return nil
 }
 
 // LoadConfig reads the config file and applies environment variable overrides
@@ -33,8 +559,88 @@ func LoadConfig(path string) (*Config, error) {
 
 	// Defaults across the board
 	cfg.Headless = true
+	cfg.AutoReauth = true
+	cfg.TemplateCache = "templates_cache.json"
+	cfg.SelectorsFile = "selectors.yaml"
+	cfg.CampaignDedup = "global"
+	cfg.HowDoYouKnowPolicy = "skip"
+	cfg.NameStyle = "first"
+	cfg.SendEnableTimeout = 15 * time.Second
+	cfg.SingleComposer = true
+	cfg.Replies.Policy = "skip"
+	cfg.Preflight.Enabled = true
+	cfg.Withdraw.MaxAge = 21 * 24 * time.Hour
+	cfg.Withdraw.MaxPerRun = 10
+	cfg.Withdraw.MaxAttempts = 2
+	cfg.Preflight.OnWarned = "abort"
 	cfg.Limits.DailyConnections = 20
+	cfg.Limits.WeeklyConnections = 100
 	cfg.Limits.DailyMessages = 20
+	cfg.Limits.MaxLoginFailuresPerDay = 3
+	cfg.Limits.MinRevisitInterval = 12 * time.Hour
+	cfg.RateLimits.Connect = RateLimit{Hourly: 10, MinGap: 30 * time.Second}
+	cfg.RateLimits.Message = RateLimit{Hourly: 10, MinGap: 30 * time.Second}
+	cfg.RateLimits.ProfileView = RateLimit{Hourly: 40, Daily: 150, MinGap: 15 * time.Second}
+	cfg.Storage.Path = "state.json"
+	cfg.Storage.AuditFile = "audit.jsonl"
+	cfg.Browser.FingerprintFile = "fingerprint.json"
+	cfg.Browser.PersonaFile = "persona.json"
+	cfg.Browser.IdleMouse = true
+	cfg.Browser.Display = "auto"
+	cfg.Browser.Sandbox = true
+	cfg.Typing.Profile = "average"
+	cfg.Typing.Layout = "qwerty"
+	cfg.Storage.BackupKeep = 10
+	cfg.Health.Staleness = 30 * time.Minute
+	cfg.Events.Note = "Hi {{firstname}}, I saw we're both attending {{event}}. Would be great to connect!"
+	cfg.Posts.Note = "Hi {{firstname}}, saw you engaging with the post \"{{post}}\". I'm into the same topic, would be great to connect!"
+	cfg.Endorse.DailyLimit = 10
+	cfg.Endorse.MaxSkills = 3
+	cfg.Congrats.DailyLimit = 10
+	cfg.Congrats.JobChange = "Congrats on the new role as {{newtitle}} at {{newcompany}}, {{firstname}}! Wishing you a great start."
+	cfg.Congrats.Anniversary = "Happy {{years}}-year work anniversary at {{newcompany}}, {{firstname}}!"
+	cfg.Congrats.Anniversaries = true
+	cfg.Birthdays.DailyLimit = 10
+	cfg.Birthdays.Template = "Happy birthday, {{firstname}}! Hope you have a great day."
+	cfg.View.DailyLimit = 25
+	cfg.View.MinDwell = 20 * time.Second
+	cfg.View.MaxDwell = time.Minute
+	cfg.WarmUp.Enabled = true
+	cfg.WarmUp.MinDuration = time.Minute
+	cfg.WarmUp.MaxDuration = 3 * time.Minute
+	cfg.Reading.Enabled = true
+	cfg.Reading.MinDuration = 15 * time.Second
+	cfg.Reading.MaxDuration = time.Minute
+	cfg.Sessions.MinSession = 10 * time.Minute
+	cfg.Sessions.MaxSession = 30 * time.Minute
+	cfg.Sessions.MinBreak = 30 * time.Minute
+	cfg.Sessions.MaxBreak = 2 * time.Hour
+	cfg.Sessions.LunchAt = "12:30"
+	cfg.Sessions.Lunch = 45 * time.Minute
+	cfg.Daemon.Jitter = 10 * time.Minute
+	cfg.Daemon.BusinessHoursOnly = true
+	cfg.Daemon.KeepAlive = 45 * time.Minute
+	cfg.Diagnostics.Dir = "debug"
+	cfg.Summary.Dir = "runs"
+	cfg.Logging.Level = "debug"
+	cfg.Logging.FileLevel = "info"
+	cfg.Logging.MaxSizeMB = 50
+	cfg.Logging.MaxBackups = 5
+	cfg.Logging.MaxAge = 30 * 24 * time.Hour
+	cfg.Logging.RunLevel = "debug"
+	cfg.Checkpoint.WaitTimeout = 15 * time.Minute
+	cfg.AI.Timeout = 20 * time.Second
+	cfg.Notify.Timeout = 15 * time.Second
+	cfg.Webhooks.Retries = 3
+	cfg.Webhooks.Timeout = 10 * time.Second
+	cfg.Sheets.Timeout = 30 * time.Second
+	cfg.QueueMaxAge = 72 * time.Hour
+	cfg.Parallel.ClaimsFile = "claims.json"
+	cfg.API.Addr = "127.0.0.1:8787"
+	cfg.ProxyCheck.URL = "https://www.linkedin.com/"
+	cfg.ProxyCheck.Timeout = 15 * time.Second
+	cfg.ProxyCheck.RotateAfter = 3
+	cfg.ProxyCheck.RotateWindow = 10 * time.Minute
 
 	// 1. Read YAML file
 	if path != "" {
@@ -64,15 +670,111 @@ func LoadConfig(path string) (*Config, error) {
 	if v := os.Getenv("LINKEDIN_PROXY"); v != "" {
 		cfg.ProxyURL = v
 	}
+	if v := os.Getenv("LINKEDIN_PROXIES"); v != "" {
+		cfg.Proxies = nil
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.Proxies = append(cfg.Proxies, p)
+			}
+		}
+	}
 	if v := os.Getenv("LINKEDIN_USER_DATA"); v != "" {
 		cfg.UserDataDir = v
 	}
+	if v := os.Getenv("LINKEDIN_REMOTE_URL"); v != "" {
+		cfg.Browser.RemoteURL = v
+	}
+	if v := os.Getenv("LINKEDIN_FINGERPRINT_FILE"); v != "" {
+		cfg.Browser.FingerprintFile = v
+	}
+	if v := os.Getenv("LINKEDIN_POSTGRES_DSN"); v != "" {
+		cfg.Storage.Postgres = v
+	}
+	if v := os.Getenv("LINKEDIN_TIMEZONE"); v != "" {
+		cfg.BusinessHours.Timezone = v
+	}
+	if v := os.Getenv("LINKEDIN_LOG_LEVEL"); v != "" {
+		cfg.Logging.Level = v
+	}
+	if v := os.Getenv("LINKEDIN_LOG_FILE"); v != "" {
+		cfg.Logging.File = v
+	}
+	if v := os.Getenv("LINKEDIN_SELECTORS_FILE"); v != "" {
+		cfg.SelectorsFile = v
+	}
+	if v := os.Getenv("LINKEDIN_PERSONA_FILE"); v != "" {
+		cfg.Browser.PersonaFile = v
+	}
+	if v := os.Getenv("LINKEDIN_TYPING_PROFILE"); v != "" {
+		cfg.Typing.Profile = v
+	}
+	if v := os.Getenv("LINKEDIN_DISPLAY"); v != "" {
+		cfg.Browser.Display = v
+	}
+	if v := os.Getenv("LINKEDIN_SANDBOX"); v != "" {
+		cfg.Browser.Sandbox = (v == "true" || v == "1")
+	}
+	if v := os.Getenv("LINKEDIN_IDLE_MOUSE"); v != "" {
+		cfg.Browser.IdleMouse = (v == "true" || v == "1")
+	}
+	if v := os.Getenv("LINKEDIN_CHROME_BINARY"); v != "" {
+		cfg.ChromeBinary = v
+	}
 	if v := os.Getenv("LINKEDIN_USERNAME"); v != "" {
 		cfg.LinkedIn.Username = v
 	}
 	if v := os.Getenv("LINKEDIN_PASSWORD"); v != "" {
 		cfg.LinkedIn.Password = v
 	}
+	if v := os.Getenv("LINKEDIN_SESSION_FILE"); v != "" {
+		cfg.LinkedIn.SessionFile = v
+	}
+	if v := os.Getenv("LINKEDIN_SESSION_KEY"); v != "" {
+		cfg.LinkedIn.SessionKey = v
+	}
+	if v := os.Getenv("LINKEDIN_TOTP_SECRET"); v != "" {
+		cfg.LinkedIn.TOTPSecret = v
+	}
+	if v := os.Getenv("LINKEDIN_USERNAME_COMMAND"); v != "" {
+		cfg.LinkedIn.UsernameCommand = v
+	}
+	if v := os.Getenv("LINKEDIN_PASSWORD_COMMAND"); v != "" {
+		cfg.LinkedIn.PasswordCommand = v
+	}
+
+	if v := os.Getenv("LINKEDIN_AI_API_KEY"); v != "" {
+		cfg.AI.APIKey = v
+	}
+	// Notification secrets fill channels that leave them empty
+	if v := os.Getenv("LINKEDIN_API_TOKEN"); v != "" {
+		cfg.API.Token = v
+	}
+	for i := range cfg.Notify.Channels {
+		c := &cfg.Notify.Channels[i]
+		if v := os.Getenv("LINKEDIN_TELEGRAM_TOKEN"); v != "" && c.Type == "telegram" && c.BotToken == "" {
+			c.BotToken = v
+		}
+		if v := os.Getenv("LINKEDIN_SMTP_PASSWORD"); v != "" && c.Type == "email" && c.Password == "" {
+			c.Password = v
+		}
+	}
+	if u := cfg.Checkpoint.NotifyURL; u != "" {
+		cfg.Notify.Channels = append(cfg.Notify.Channels, NotifyChannel{Type: "webhook", URL: u, Events: []string{"checkpoint"}})
+	}
+	for i := range cfg.Webhooks.Endpoints {
+		if v := os.Getenv("LINKEDIN_WEBHOOK_SECRET"); v != "" && cfg.Webhooks.Endpoints[i].Secret == "" {
+			cfg.Webhooks.Endpoints[i].Secret = v
+		}
+	}
+	if v := os.Getenv("LINKEDIN_GOOGLE_CREDENTIALS"); v != "" {
+		cfg.Sheets.CredentialsFile = v
+	}
+	if v := os.Getenv("LINKEDIN_TEMPLATE_URL"); v != "" {
+		cfg.TemplateSourceURL = v
+	}
+	if v := os.Getenv("LINKEDIN_SAFE_ALLOWLIST"); v != "" {
+		cfg.SafeAllowlist = strings.Split(v, ",")
+	}
 
 	if v := os.Getenv("LINKEDIN_LIMIT_CONNECT"); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -80,7 +782,23 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
-	// 3. Validation
+	// 3. Secrets from commands (password managers), these win over file/env
+	if cmd := cfg.LinkedIn.UsernameCommand; cmd != "" {
+		v, err := runSecretCommand(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("username_command: %w", err)
+		}
+		cfg.LinkedIn.Username = v
+	}
+	if cmd := cfg.LinkedIn.PasswordCommand; cmd != "" {
+		v, err := runSecretCommand(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("password_command: %w", err)
+		}
+		cfg.LinkedIn.Password = v
+	}
+
+	// 4. Validation
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -88,15 +806,224 @@ func LoadConfig(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// runSecretCommand runs cmd through the platform shell and returns its trimmed stdout
+func runSecretCommand(cmd string) (string, error) {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", cmd)
+	} else {
+		c = exec.Command("sh", "-c", cmd)
+	}
+	c.Stderr = os.Stderr
+
+	out, err := c.Output()
+	if err != nil {
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+	v := strings.TrimSpace(string(out))
+	if v == "" {
+		return "", errors.New("command returned an empty value")
+	}
+	return v, nil
+}
+
 // Validate checks for required fields
 func (c *Config) Validate() error {
-	if c.LinkedIn.Username == "" || c.LinkedIn.Password == "" {
+	if len(c.Accounts) > 0 {
+		// Credentials are checked per account by ForAccount
+		if err := c.validateAccounts(); err != nil {
+			return err
+		}
+	} else if c.LinkedIn.Username == "" || c.LinkedIn.Password == "" {
 		// If UserDataDir is set, maybe we don't need credentials (session reuse)?
 		// But for now let's warn or strict check?
 		// We'll allow empty creds IF UserDataDir is set (session might be valid)
-		if c.UserDataDir == "" {
-			return errors.New("linkedin credentials (username/password) or user_data_dir are required")
+		// An attached browser is usually logged in already
+		if c.UserDataDir == "" && c.LinkedIn.SessionFile == "" && c.Browser.RemoteURL == "" {
+			return errors.New("linkedin credentials (username/password), user_data_dir, session_file or browser.remote_url are required")
+		}
+	}
+	if c.LinkedIn.SessionFile != "" && c.LinkedIn.SessionKey == "" {
+		return errors.New("linkedin.session_file requires a session key (LINKEDIN_SESSION_KEY)")
+	}
+	switch c.NameStyle {
+	case "", "first", "full", "mixed":
+	default:
+		return errors.New("name_style must be 'first', 'full' or 'mixed'")
+	}
+	if c.ChromeBinary != "" {
+		if _, err := os.Stat(c.ChromeBinary); err != nil {
+			return fmt.Errorf("chrome_binary not usable: %w", err)
+		}
+	}
+	if err := checkAttachment(c.MessageAttachment); err != nil {
+		return fmt.Errorf("message_attachment: %w", err)
+	}
+	for i, step := range c.Sequence {
+		if err := step.CheckAttachment(); err != nil {
+			return fmt.Errorf("sequence[%d]: %w", i, err)
+		}
+	}
+	if c.CampaignDedup != "" && c.CampaignDedup != "global" && c.CampaignDedup != "campaign" {
+		return errors.New("campaign_dedup must be 'global' or 'campaign'")
+	}
+	switch c.Replies.Policy {
+	case "", "skip":
+	case "template":
+		if c.Replies.Template == "" {
+			return errors.New("replies.policy 'template' requires replies.template")
+		}
+	default:
+		return errors.New("replies.policy must be 'skip' or 'template'")
+	}
+	switch c.Preflight.OnWarned {
+	case "", "abort", "engagement", "continue":
+	default:
+		return errors.New("preflight.on_warned must be 'abort', 'engagement' or 'continue'")
+	}
+	switch c.Browser.Display {
+	case "", "auto", "xvfb", "headless", "none":
+	default:
+		return errors.New("browser.display must be 'auto', 'xvfb', 'headless' or 'none'")
+	}
+	switch c.Typing.Profile {
+	case "", "slow", "average", "fast":
+	default:
+		return errors.New("typing.profile must be 'slow', 'average' or 'fast'")
+	}
+	if c.Typing.Layout != "" && c.Typing.Layout != "qwerty" && c.Typing.Layout != "azerty" {
+		return errors.New("typing.layout must be 'qwerty' or 'azerty'")
+	}
+	if c.Withdraw.MaxAttempts < 0 {
+		return errors.New("withdraw.max_attempts can't be negative")
+	}
+	if c.View.MinDwell < 0 || c.View.MaxDwell < c.View.MinDwell {
+		return errors.New("view.max_dwell must be at least view.min_dwell")
+	}
+	if c.Reading.MinDuration < 0 || c.Reading.MaxDuration < c.Reading.MinDuration {
+		return errors.New("reading.max_duration must be at least reading.min_duration")
+	}
+	for name, level := range map[string]string{"level": c.Logging.Level, "file_level": c.Logging.FileLevel, "run_level": c.Logging.RunLevel} {
+		switch strings.ToLower(level) {
+		case "", "debug", "info", "warn", "error":
+		default:
+			return fmt.Errorf("logging.%s: unknown level %q (want debug, info, warn or error)", name, level)
+		}
+	}
+	if c.Logging.MaxSizeMB < 0 || c.Logging.MaxBackups < 0 || c.Logging.MaxAge < 0 {
+		return errors.New("logging: max_size_mb, max_backups and max_age can't be negative")
+	}
+	if c.Sessions.Enabled {
+		if c.Sessions.MinSession <= 0 || c.Sessions.MaxSession < c.Sessions.MinSession {
+			return errors.New("sessions.max_session must be at least sessions.min_session, which must be positive")
+		}
+		if c.Sessions.MinBreak < 0 || c.Sessions.MaxBreak < c.Sessions.MinBreak {
+			return errors.New("sessions.max_break must be at least sessions.min_break")
+		}
+		if _, err := c.LunchAt(); err != nil {
+			return err
+		}
+	}
+	for name, r := range map[string]RateLimit{"connect": c.RateLimits.Connect, "message": c.RateLimits.Message, "profile_view": c.RateLimits.ProfileView} {
+		if r.Hourly < 0 || r.Daily < 0 || r.Weekly < 0 || r.MinGap < 0 || r.Spread < 0 {
+			return fmt.Errorf("rate_limits.%s: values can't be negative", name)
 		}
 	}
 	return nil
 }
+
+// CheckAllowed returns ErrNotAllowlisted if the safe allowlist is set and
+// profileURL does not match any of its entries
+func (c *Config) CheckAllowed(profileURL string) error {
+	if c == nil || len(c.SafeAllowlist) == 0 {
+		return nil
+	}
+	for _, pattern := range c.SafeAllowlist {
+		if pattern = strings.TrimSpace(pattern); pattern != "" && strings.Contains(profileURL, pattern) {
+			return nil
+		}
+	}
+	return ErrNotAllowlisted
+}
+
+// Labels returns the button label synonyms for an action, falling back to
+// DefaultButtonLabels when none are configured
+func (c *Config) Labels(action string) []string {
+	if c != nil {
+		if labels := c.ButtonLabels[action]; len(labels) > 0 {
+			return labels
+		}
+	}
+	return DefaultButtonLabels[action]
+}
+
+// BusinessHours is when an account works. Empty fields use the persona's
+// active hours, the machine's timezone, every day and no holidays.
+type BusinessHours struct {
+	// Timezone is an IANA name, e.g. "Europe/Berlin"
+	Timezone string `yaml:"timezone"`
+	// From and To are "HH:MM" in Timezone
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// Weekdays is a cron day-of-week field, e.g. "mon-fri"
+	Weekdays string `yaml:"weekdays"`
+	// Holidays are "YYYY-MM-DD" dates off; HolidaysFile adds more, one per
+	// line or an iCalendar (.ics) export
+	Holidays     []string `yaml:"holidays"`
+	HolidaysFile string   `yaml:"holidays_file"`
+}
+
+// override returns b with o's non-empty fields applied
+func (b BusinessHours) override(o BusinessHours) BusinessHours {
+	if o.Timezone != "" {
+		b.Timezone = o.Timezone
+	}
+	if o.From != "" {
+		b.From = o.From
+	}
+	if o.To != "" {
+		b.To = o.To
+	}
+	if o.Weekdays != "" {
+		b.Weekdays = o.Weekdays
+	}
+	if len(o.Holidays) > 0 {
+		b.Holidays = o.Holidays
+	}
+	if o.HolidaysFile != "" {
+		b.HolidaysFile = o.HolidaysFile
+	}
+	return b
+}
+
+// LunchAt returns sessions.lunch_at as the time since midnight
+func (c *Config) LunchAt() (time.Duration, error) {
+	t, err := time.Parse("15:04", c.Sessions.LunchAt)
+	if err != nil {
+		return 0, fmt.Errorf("sessions.lunch_at %q: want HH:MM", c.Sessions.LunchAt)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// EffectiveConnectionLimit returns today's connection limit, applying the ramp
+// schedule counted from the account's first run when it is enabled
+func (c *Config) EffectiveConnectionLimit(firstRun, now time.Time) int {
+	if c.Ramp.Start <= 0 {
+		return c.Limits.DailyConnections
+	}
+
+	days := int(now.Sub(firstRun).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+
+	limit := c.Ramp.Start + days*c.Ramp.IncrementPerDay
+	max := c.Ramp.Max
+	if max <= 0 {
+		max = c.Limits.DailyConnections
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit
+}
@@ -1,6 +1,7 @@
 package connect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,39 +11,274 @@ import (
 	"github.com/go-rod/rod/lib/input"
 	"github.com/go-rod/rod/lib/proto"
 
+	"linkedin-automation/ai"
+	"linkedin-automation/auth"
 	"linkedin-automation/browser"
+	"linkedin-automation/hooks"
 	"linkedin-automation/logger"
+	"linkedin-automation/personalize"
+	"linkedin-automation/profile"
+	"linkedin-automation/ratelimit"
 	"linkedin-automation/stealth"
+	"linkedin-automation/storage"
+	"linkedin-automation/templates"
 )
 
+// ErrEmailRequired is returned when connecting needs the member's email and no fallback worked
+var ErrEmailRequired = errors.New("connection requires email and no fallback was available")
+
+// ErrDuplicateCompany is returned when one_per_company_per_run is set and the
+// profile's company was already contacted in this run
+var ErrDuplicateCompany = errors.New("company already contacted in this run")
+
+// ErrAlreadyConnected is returned when the target profile is already a 1st-degree connection
+var ErrAlreadyConnected = errors.New("profile is already a 1st-degree connection")
+
+// ErrDailyLimit is returned when today's invitations reached the daily limit
+var ErrDailyLimit = errors.New("daily connection limit reached")
+
+// ErrWeeklyLimit is returned when the last 7 days' invitations reached limits.weekly_connections
+var ErrWeeklyLimit = errors.New("weekly connection limit reached")
+
 // Service handles connection requests
 type Service struct {
 	Browser    *browser.Browser
 	Log        logger.Logger
+	Store      storage.DataStore
 	DailyLimit int
 	sentCount  int
+
+	// limited is set once LinkedIn refuses invitations for the week
+	limited bool
+	// limitReported keeps OnWeeklyLimit to once per run
+	limitReported bool
+
+	// companies contacted in this run, for one_per_company_per_run
+	companies map[string]bool
+
+	// NoteFooter is appended to every connection note, empty disables it
+	NoteFooter string
+
+	// Blacklist is config's exclusion list, checked together with the stored one
+	Blacklist storage.Exclusions
+
+	// Campaign is the current run's campaign, the scope of profile claims
+	Campaign string
+
+	// Notes, when set, writes each note with an LLM; the template is the fallback
+	Notes *ai.NoteWriter
+
+	// Confirm, when set, must approve each note/message before Send is clicked
+	Confirm hooks.ConfirmFunc
+
+	// Auth handles mid-session re-authentication prompts, nil disables it
+	Auth *auth.Authenticator
+
+	// Limiter paces connects and profile views within their budgets, nil disables it
+	Limiter *ratelimit.Limiter
+
+	// OnWeeklyLimit, when set, is called once per run when the weekly limit stops requests
+	OnWeeklyLimit func(reason error)
+
+	// OnResult is invoked after every connect/follow/message attempt
+	OnResult hooks.ResultHook
+	action   hooks.Action
+
+	// vars are the caller's template variables for the current request
+	vars map[string]string
 }
 
 // New creates a new Connect Service
-func New(b *browser.Browser, l logger.Logger, limit int) *Service {
+func New(b *browser.Browser, l logger.Logger, store storage.DataStore, limit int) *Service {
 	return &Service{
 		Browser:    b,
 		Log:        l,
+		Store:      store,
 		DailyLimit: limit,
 		sentCount:  0,
+		companies:  make(map[string]bool),
+		OnResult:   hooks.Noop,
+	}
+}
+
+// SendConnectionRequest visits a profile and sends a request with a note.
+// The Result is only Sent once LinkedIn confirmed the invitation; the error
+// explains any other Result. A cancelled ctx stops it before it starts; a
+// started request is always finished.
+func (s *Service) SendConnectionRequest(ctx context.Context, profileURL string, messageTemplate string) (Result, error) {
+	return s.SendConnectionRequestVars(ctx, profileURL, messageTemplate, nil)
+}
+
+// SendConnectionRequestVars is SendConnectionRequest with extra template variables
+// (e.g. from an import file); they override the ones scraped from the profile
+func (s *Service) SendConnectionRequestVars(ctx context.Context, profileURL string, messageTemplate string, vars map[string]string) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Failed, err
+	}
+	s.action = hooks.ActionConnect
+	s.vars = vars
+	defer func() { s.vars = nil }()
+	err := s.sendConnectionRequest(ctx, profileURL, messageTemplate)
+
+	// A mid-session re-auth prompt silently breaks the action, retry once after handling it
+	if s.Auth != nil {
+		if handled, rerr := s.Auth.HandleReauth(); handled {
+			if rerr != nil {
+				err = rerr
+			} else {
+				s.Log.Info("Retrying connection request after re-authentication")
+				s.action = hooks.ActionConnect
+				err = s.sendConnectionRequest(ctx, profileURL, messageTemplate)
+			}
+		}
+	}
+
+	result := hooks.NewResult(profileURL, s.action, err)
+	result.Metadata["sent_count"] = fmt.Sprint(s.sentCount)
+	result.Metadata["result"] = string(ResultOf(err))
+	if messageTemplate != "" {
+		result.Metadata["template"] = messageTemplate
+	}
+	if s.action != hooks.ActionConnect {
+		result.Metadata["fallback"] = string(s.action)
+	}
+	s.OnResult(result)
+
+	return ResultOf(err), err
+}
+
+// templateVars overlays the caller's variables on the scraped ones
+func (s *Service) templateVars(scraped map[string]string) map[string]string {
+	for k, v := range s.vars {
+		scraped[k] = v
 	}
+	return scraped
 }
 
-// SendConnectionRequest visits a profile and sends a request with a note
-func (s *Service) SendConnectionRequest(profileURL string, messageTemplate string) error {
+// writeNote asks s.Notes for a note about the open profile, footer included.
+// It returns "" when AI notes are off or failed, so the template is used.
+func (s *Service) writeNote(ctx context.Context, profileURL, name string) string {
+	if s.Notes == nil {
+		return ""
+	}
+	p := ai.Profile{
+		Name:     name,
+		Headline: personalize.Headline(s.Browser.Page),
+		About:    personalize.About(s.Browser.Page),
+	}
+	budget := templates.MaxNoteLength - len([]rune(templates.AppendFooter("", s.NoteFooter, 0)))
+	body, err := s.Notes.Note(ctx, p, budget)
+	if err != nil {
+		s.Log.Warn("AI note failed, using template", "url", profileURL, "error", err)
+		return ""
+	}
+	s.Log.Debug("AI note generated", "url", profileURL, "length", len([]rune(body)))
+	return templates.AppendFooter(body, s.NoteFooter, templates.MaxNoteLength)
+}
+
+// Exclusions is the configured blacklist plus the stored exclusions
+func (s *Service) Exclusions() storage.Exclusions {
+	if s.Store == nil {
+		return s.Blacklist
+	}
+	return s.Blacklist.Merge(s.Store.Exclusions())
+}
+
+// NewRun resets the per-run counters, for long-running processes that run
+// several workflows (daemon mode)
+func (s *Service) NewRun() {
+	s.sentCount = 0
+	s.limited = false
+	s.limitReported = false
+	s.companies = make(map[string]bool)
+}
+
+// CompanyContacted reports whether someone at company was invited in this run
+func (s *Service) CompanyContacted(company string) bool {
+	return company != "" && s.companies[strings.ToLower(company)]
+}
+
+// CheckLimits refuses early when this run's count or the persisted daily /
+// rolling weekly invitation counts have reached their limits
+func (s *Service) CheckLimits() error {
+	if s.limited {
+		return s.weeklyLimit(fmt.Errorf("%w (refused by LinkedIn)", ErrWeeklyLimit))
+	}
 	if s.sentCount >= s.DailyLimit {
-		return fmt.Errorf("daily connection limit reached (%d)", s.DailyLimit)
+		return fmt.Errorf("%w (%d this run)", ErrDailyLimit, s.DailyLimit)
+	}
+
+	now := time.Now()
+	y, m, d := now.Date()
+	if n := s.Store.InvitesSince(time.Date(y, m, d, 0, 0, 0, 0, now.Location())); n >= s.DailyLimit {
+		return fmt.Errorf("%w (%d/%d today)", ErrDailyLimit, n, s.DailyLimit)
+	}
+	if weekly := s.Browser.Cfg.Limits.WeeklyConnections; weekly > 0 {
+		if n := s.Store.InvitesSince(now.Add(-7 * 24 * time.Hour)); n >= weekly {
+			return s.weeklyLimit(fmt.Errorf("%w (%d/%d in the last 7 days)", ErrWeeklyLimit, n, weekly))
+		}
+	}
+	return s.Limiter.Check(ratelimit.Connect, ratelimit.ProfileView)
+}
+
+// weeklyLimit reports err to OnWeeklyLimit the first time in a run and returns it
+func (s *Service) weeklyLimit(err error) error {
+	if s.OnWeeklyLimit != nil && !s.limitReported {
+		s.limitReported = true
+		s.OnWeeklyLimit(err)
+	}
+	return err
+}
+
+func (s *Service) sendConnectionRequest(ctx context.Context, profileURL string, messageTemplate string) (err error) {
+	if err := s.CheckLimits(); err != nil {
+		return err
+	}
+
+	if _, err := profile.Parse(profileURL); err != nil {
+		return fmt.Errorf("%w: %s", err, profileURL)
+	}
+
+	// Hard safety rail: never touch profiles outside the allowlist
+	if err := s.Browser.Cfg.CheckAllowed(profileURL); err != nil {
+		s.Log.Error("Refusing to act on profile outside safe allowlist", "url", profileURL)
+		return fmt.Errorf("%w: %s", err, profileURL)
+	}
+	exclusions := s.Exclusions()
+	if reason := exclusions.Match(profileURL, "", ""); reason != "" {
+		s.Log.Info("Profile is excluded, skipping", "url", profileURL, "reason", reason)
+		return fmt.Errorf("%w: %s", storage.ErrExcluded, reason)
+	}
+	// Accounts running side by side split each campaign's profiles between
+	// them; accounts sharing a Postgres database never invite the same person twice
+	other, cerr := s.Store.Claim(s.Campaign, profileURL)
+	switch {
+	case cerr != nil:
+		s.Log.Warn("Failed to claim profile, continuing", "url", profileURL, "error", cerr)
+	case other != "":
+		s.Log.Info("Profile claimed by another account in this campaign, skipping", "url", profileURL, "account", other)
+		return fmt.Errorf("%w: %s", storage.ErrContactedElsewhere, other)
+	default:
+		// An attempt that contacts no one leaves the profile to the other accounts
+		defer func() {
+			if err == nil {
+				return
+			}
+			if rerr := s.Store.Release(s.Campaign, profileURL); rerr != nil {
+				s.Log.Warn("Failed to release profile claim", "url", profileURL, "error", rerr)
+			}
+		}()
+	}
+
+	if err := s.Limiter.Wait(ctx, ratelimit.Connect, ratelimit.ProfileView); err != nil {
+		return err
 	}
 
 	s.Log.Info("Visiting profile for connection", "url", profileURL)
 	if err := s.Browser.NavigateTo(profileURL); err != nil {
 		return err
 	}
+	s.recordVisit(profileURL)
 
 	// Wait for profile to load
 	s.Log.Info("Waiting for profile content to load...")
@@ -52,14 +288,58 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 		s.Log.Warn("Main profile content not found in time, trying to proceed anyway...")
 	}
 
+	// Redirects and mis-scraped links can land on company/school/hashtag pages
+	if !s.Browser.OnPersonProfile() {
+		s.Log.Warn("Page is not a person profile, skipping", "url", profileURL)
+		return fmt.Errorf("%w: %s", profile.ErrNotAProfile, profileURL)
+	}
+
 	// Extra wait for dynamic buttons
 	stealth.SleepContextual(stealth.ActionTypeRead, 2.0)
 	s.Browser.HumanScroll(300)
 
-	// 0. Check for "Pending" status (already sent)
-	if has, _, _ := s.Browser.Page.HasX(`//button[contains(., "Pending")]`); has {
+	// 0. Refuse 1st-degree connections (search sometimes mislabels degrees)
+	if s.isFirstDegree() {
+		s.Log.Info("Profile is already a 1st-degree connection, recording and skipping", "url", profileURL)
+		if s.Store != nil {
+			if err := s.Store.SaveConnection(profileURL); err != nil {
+				s.Log.Warn("Failed to record connection", "error", err)
+			}
+		}
+		return ErrAlreadyConnected
+	}
+
+	company := ""
+	if s.Browser.Cfg.OnePerCompanyPerRun || len(exclusions.Companies) > 0 {
+		company = s.currentCompany()
+	}
+	if reason := exclusions.Match(profileURL, company, personalize.Headline(s.Browser.Page)); reason != "" {
+		s.Log.Info("Profile is excluded, skipping", "url", profileURL, "reason", reason)
+		return fmt.Errorf("%w: %s", storage.ErrExcluded, reason)
+	}
+
+	// Diversify: at most one person per company in a run
+	if s.Browser.Cfg.OnePerCompanyPerRun {
+		if s.CompanyContacted(company) {
+			s.Log.Info("Skipping profile, company already contacted this run", "company", company, "url", profileURL)
+			return fmt.Errorf("%w: %s", ErrDuplicateCompany, company)
+		}
+	}
+
+	// Check for "Pending" status (already sent)
+	if s.Browser.Has("pending_button") {
 		s.Log.Info("Connection already pending, skipping")
-		return nil
+		return ErrAlreadyPending
+	}
+
+	// Read the profile first; clicking Connect seconds after landing is a bot tell
+	if r := s.Browser.Cfg.Reading; r.Enabled {
+		s.Log.Info("Reading profile before connecting")
+		read := s.Browser.ReadProfile(ctx, r.MinDuration, r.MaxDuration)
+		s.Log.Debug("Finished reading profile", "duration", read.Round(time.Second))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 	}
 
 	// 1. Attempt to find "Connect" button
@@ -68,7 +348,6 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 	// B. "More" actions menu -> Connect option
 
 	var connectBtn *rod.Element
-	var err error
 
 	// Try finding the primary Connect button first
 	// We use a broader search first, then filter, or specific reliable selectors
@@ -80,61 +359,36 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 
 	// 1. Attempt to find "Connect" button directly (Primary Action)
 	// We only look for buttons that are strictly visible and main actions
-	directConnectSelectors := []string{
-		`//main//button[contains(@class, "artdeco-button--primary")][contains(., "Connect")]`,
-		`//button[contains(@aria-label, "Connect")][not(contains(@aria-label, "Invite"))]`, // basic connect
-	}
+	// Labels are configurable since LinkedIn A/B tests the button text
+	connectLabels := s.Browser.Cfg.Labels("connect")
 
 	s.Log.Debug("Checking for Direct Connect button...")
-	for _, sel := range directConnectSelectors {
-		btn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(sel)
-		if err == nil {
-			if visible, _ := btn.Visible(); visible {
-				connectBtn = btn
-				s.Log.Info("Found Direct Connect button", "selector", sel)
-				break
-			}
-		}
+	if btn, sel, err := s.Browser.FindWhere("connect_button", 2*time.Second, func(btn *rod.Element) bool {
+		visible, _ := btn.Visible()
+		return visible && s.isConnectButton(btn, connectLabels)
+	}); err == nil {
+		connectBtn = btn
+		s.Log.Info("Found Direct Connect button", "selector", sel)
 	}
 
 	// 2. If not found, Check "More" Menu for "Connect", "Add", or "Invite"
 	if connectBtn == nil {
 		s.Log.Debug("Direct Connect not found, checking 'More' menu")
 
-		// Find More button
-		// usually aria-label="More actions" within the top card
-		moreBtn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(`//main//button[contains(@aria-label, "More actions")]`)
-		if err != nil {
-			// Fallback generic
-			moreBtn, err = s.Browser.Page.Timeout(2 * time.Second).Element(`button[aria-label="More actions"]`)
-		}
-
+		// Find More button, usually aria-label="More actions" within the top card
+		moreBtn, err := s.Browser.Find("more_actions", 2*time.Second)
 		if err == nil {
 			s.Log.Info("Opening 'More' menu...")
-			s.Browser.HumanMove(moreBtn)
-			moreBtn.Click(proto.InputMouseButtonLeft, 1)
+			s.Browser.Click(moreBtn)
 			stealth.SleepWithJitter(time.Second, 0.2)
 
-			// Look for options INSIDE the menu
-			// We look for text specifically because aria-labels might be complex
-			menuOptions := []string{
-				`//div[contains(@class, "artdeco-dropdown")]//span[text()="Connect"]`,
-				`//div[contains(@class, "artdeco-dropdown")]//span[text()="Add"]`, // The screenshot showed "Add"
-				`//div[contains(@class, "artdeco-dropdown")]//span[contains(text(), "Invite")]`,
-				// Fallback generic role=button
-				`//div[@role="button"]//span[text()="Connect"]`,
-				`//div[@role="button"]//span[text()="Add"]`,
-			}
-
-			for _, sel := range menuOptions {
-				if opt, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(sel); err == nil {
-					// It should be visible now
-					if vis, _ := opt.Visible(); vis {
-						connectBtn = opt
-						s.Log.Info("Found Connect/Add option in More menu", "selector", sel)
-						break
-					}
-				}
+			// Look for options INSIDE the menu; it should be visible now
+			if opt, sel, err := s.Browser.FindWhere("more_menu_connect", 2*time.Second, func(opt *rod.Element) bool {
+				vis, _ := opt.Visible()
+				return vis
+			}); err == nil {
+				connectBtn = opt
+				s.Log.Info("Found Connect/Add option in More menu", "selector", sel)
 			}
 		} else {
 			s.Log.Warn("Could not find 'More' button")
@@ -150,10 +404,8 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 	s.Log.Info("Clicking Connect button")
 	// If it was found via span text, we might need to click its parent button?
 	// Rod clicks the center of the element, so clicking the text span usually works if it captures events.
-	if err := s.Browser.HumanMove(connectBtn); err != nil {
-		connectBtn.Click(proto.InputMouseButtonLeft, 1)
-	} else {
-		connectBtn.Click(proto.InputMouseButtonLeft, 1)
+	if err := s.Browser.Click(connectBtn); err != nil {
+		return fmt.Errorf("connect button: %w", err)
 	}
 
 	// 2. Handle Modal "You can customize this invitation"
@@ -161,30 +413,39 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 
 	// Check for "Weekly Limit Reached" or "Email Required"
 	// Weekly limit modal text: "You've reached the weekly limit for connection requests"
-	// Rod Page doesn't have Text(), check body
-	pageText, _ := s.Browser.Page.MustElement("body").Text()
-	if strings.Contains(pageText, "weekly limit") {
+	if s.limitShown() {
 		s.Log.Error("Weekly connection limit reached! Stopping.")
-		return fmt.Errorf("weekly connection limit reached")
+		s.limited = true
+		s.Browser.Page.Keyboard.Press(input.Escape)
+		return s.weeklyLimit(fmt.Errorf("%w (refused by LinkedIn)", ErrWeeklyLimit))
 	}
 
+	// Premium/email gate: connecting needs the member's email, try Follow/Message instead
 	if hasEmail, _, _ := s.Browser.Page.HasX(`//label[contains(., "Email")]`); hasEmail {
-		s.Log.Warn("Email required for connection, skipping")
+		s.Log.Warn("Email required for connection, routing to fallback")
 		s.Browser.Page.Keyboard.Press(input.Escape)
+		stealth.SleepWithJitter(time.Millisecond*500, 0.2)
+		if err := s.tryFallbacks(profileURL, messageTemplate); err != nil {
+			return fmt.Errorf("%w (%v)", ErrEmailRequired, err)
+		}
 		return nil
 	}
 
 	// Check if the "Send" logic is blocked by "How do you know [Name]?"
+	// Rod Page doesn't have Text(), check body
+	pageText, _ := s.Browser.Page.MustElement("body").Text()
 	if strings.Contains(pageText, "How do you know") {
-		s.Log.Warn("LinkedIn is asking 'How do you know this person', skipping strict verification")
-		s.Browser.Page.Keyboard.Press(input.Escape)
-		return nil
+		if !s.answerHowDoYouKnow() {
+			s.Browser.Page.Keyboard.Press(input.Escape)
+			return ErrNeedsAnswer
+		}
 	}
 
 	// 3. Add Note vs Direct Send
 	// Look for "Add a note" button
 	// We check for aria-label OR text content
-	addNoteBtn, err := s.Browser.Page.ElementX(`//button[contains(@aria-label, "Add a note") or contains(., "Add a note")]`)
+	note := ""
+	addNoteBtn, err := s.Browser.Find("add_note", 5*time.Second)
 	if err == nil {
 		s.Log.Info("Adding personalized note")
 		addNoteBtn.Click(proto.InputMouseButtonLeft, 1)
@@ -192,22 +453,32 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 
 		// Customize template
 		nameEl, err := s.Browser.Page.Element("h1")
-		name := "there"
+		rawName := ""
 		if err == nil {
-			name = nameEl.MustText()
+			rawName = nameEl.MustText()
 		}
-		// First name only
-		nameParts := strings.Split(name, " ")
-		if len(nameParts) > 0 {
-			name = nameParts[0]
+		// name_style decides between first name, full name or a mix (with "there" fallback)
+		name := templates.DisplayName(rawName, s.Browser.Cfg.NameStyle)
+		firstName, _ := templates.Names(rawName)
+		if firstName == "" {
+			firstName = templates.GenericName
 		}
 
-		msg := strings.ReplaceAll(messageTemplate, "{{name}}", name)
+		// Company, title, location etc. from the top card; missing ones use fallbacks
+		vars := personalize.Scrape(s.Browser.Page)
+		vars["name"], vars["firstname"] = name, firstName
+		note = s.writeNote(ctx, profileURL, rawName)
+		if note == "" {
+			note = templates.Render(messageTemplate, s.templateVars(vars), s.NoteFooter, templates.MaxNoteLength)
+		}
+		if s.Browser.Cfg.Template.Sanitize {
+			note = templates.Sanitize(note)
+		}
 
 		// Type message
-		textArea, err := s.Browser.Page.Element("textarea[name='message']")
+		textArea, err := s.Browser.Find("note_textarea", 5*time.Second)
 		if err == nil {
-			s.Browser.HumanType(textArea, msg)
+			s.Browser.HumanType(textArea, note)
 		}
 	} else {
 		s.Log.Info("Add a note button not found, checking if we can just Send")
@@ -218,58 +489,200 @@ func (s *Service) SendConnectionRequest(profileURL string, messageTemplate strin
 	// Or "Send without a note" if we skipped note
 	// We look for the primary action button in the modal dialog
 
-	sendBtn, err := s.Browser.Page.Element(`button[aria-label="Send now"]`)
+	sendBtn, err := s.Browser.Find("send_in_modal", 5*time.Second)
 	if err != nil {
-		// Try generic text "Send" inside the dialog
-		// Dialog class usually .artdeco-modal or role="dialog"
-		sendBtn, err = s.Browser.Page.ElementX(`//div[@role="dialog"]//button[contains(., "Send")]`)
-		if err != nil {
-			return errors.New("send button not found in dialog")
-		}
+		return errors.New("send button not found in dialog")
+	}
+
+	if s.Confirm != nil && !s.Confirm(hooks.ActionConnect, profileURL, note) {
+		s.Log.Info("Connection request declined by operator", "url", profileURL)
+		s.Browser.Page.Keyboard.Press(input.Escape)
+		return hooks.ErrDeclined
 	}
 
 	s.Log.Info("Sending connection request")
 	stealth.SleepContextual(stealth.ActionTypeThink, 0.5)
 
-	if err := s.Browser.HumanMove(sendBtn); err != nil {
-		sendBtn.Click(proto.InputMouseButtonLeft, 1)
-	} else {
-		sendBtn.Click(proto.InputMouseButtonLeft, 1)
+	if err := s.Browser.Click(sendBtn); err != nil {
+		s.Browser.Page.Keyboard.Press(input.Escape)
+		return fmt.Errorf("send button: %w", err)
+	}
+
+	// Only count the invitation once LinkedIn confirms it
+	if err := s.verifySent(); err != nil {
+		s.Log.Warn("Connection request not confirmed", "url", profileURL, "error", err)
+		return err
+	}
+	s.recordSent(company)
+
+	return nil
+}
+
+func (s *Service) recordVisit(profileURL string) {
+	s.Limiter.Record(ratelimit.ProfileView)
+	if s.Store == nil {
+		return
+	}
+	if err := s.Store.RecordVisit(profileURL, string(hooks.ActionConnect)); err != nil {
+		s.Log.Warn("Failed to record visit", "url", profileURL, "error", err)
+	}
+}
+
+// currentCompany reads the member's current company from the top card.
+// Returns "" if it can't be determined.
+func (s *Service) currentCompany() string {
+	// aria-label reads "Current company: Acme Corp. Click to skip to experience card"
+	if btn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(`//main//button[starts-with(@aria-label, "Current company")]`); err == nil {
+		if aria, err := btn.Attribute("aria-label"); err == nil && aria != nil {
+			if _, rest, ok := strings.Cut(*aria, ":"); ok {
+				name, _, _ := strings.Cut(rest, ". Click")
+				if name = strings.TrimSpace(name); name != "" {
+					return name
+				}
+			}
+		}
+	}
+
+	// Fallback: headline "Engineer at Acme Corp"
+	if el, err := s.Browser.Page.Element("main .text-body-medium"); err == nil {
+		if text, err := el.Text(); err == nil {
+			if i := strings.LastIndex(text, " at "); i >= 0 {
+				return strings.TrimSpace(text[i+4:])
+			}
+		}
+	}
+	return ""
+}
+
+// isConnectButton verifies a matched button really is a connect action: its visible
+// text must be one of the synonyms (aria-labels read "Invite Jane to connect"),
+// and it must not carry a CTA label
+func (s *Service) isConnectButton(btn *rod.Element, labels []string) bool {
+	text, _ := btn.Text()
+	text = strings.TrimSpace(text)
+	aria := ""
+	if a, err := btn.Attribute("aria-label"); err == nil && a != nil {
+		aria = *a
+	}
+
+	for _, cta := range s.Browser.Cfg.Labels("cta") {
+		if strings.Contains(aria, cta) || strings.Contains(text, cta) {
+			s.Log.Debug("Ignoring CTA button", "text", text, "aria", aria)
+			return false
+		}
 	}
 
-	// Wait for modal to close to ensure it was sent
-	time.Sleep(1 * time.Second)
+	for _, l := range labels {
+		if strings.EqualFold(text, l) {
+			return true
+		}
+		if text == "" && strings.Contains(aria, l) {
+			return true
+		}
+	}
+	// aria-label like "Invite Jane Doe to connect"
+	if strings.HasPrefix(aria, "Invite") && strings.HasSuffix(aria, "to connect") {
+		return true
+	}
+
+	s.Log.Debug("Matched button is not a connect synonym", "text", text, "aria", aria)
+	return false
+}
 
-	s.sentCount++
-	s.Log.Info("Connection request sent", "count", s.sentCount, "limit", s.DailyLimit)
+// WithdrawRequest withdraws a pending invitation from the profile page
+func (s *Service) WithdrawRequest(profileURL string) error {
+	s.Log.Info("Withdrawing connection request", "url", profileURL)
+	if err := s.Browser.NavigateTo(profileURL); err != nil {
+		return err
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
 
+	pendingBtn, err := s.Browser.Find("pending_button", 10*time.Second)
+	if err != nil {
+		return errors.New("pending button not found, nothing to withdraw")
+	}
+	s.Browser.Click(pendingBtn)
+	stealth.SleepContextual(stealth.ActionTypeThink, 0.5)
+
+	confirmBtn, err := s.Browser.Find("withdraw_confirm", 5*time.Second)
+	if err != nil {
+		return errors.New("withdraw confirmation not found")
+	}
+	s.Browser.Click(confirmBtn)
+	stealth.SleepWithJitter(time.Second, 0.2)
+
+	if s.sentCount > 0 {
+		s.sentCount--
+	}
+	s.Log.Info("Connection request withdrawn", "url", profileURL)
 	return nil
 }
 
+// answerHowDoYouKnow applies how_do_you_know_policy to the "How do you know [Name]?"
+// dialog. Returns true if an option was selected and the send can continue.
+func (s *Service) answerHowDoYouKnow() bool {
+	policy := s.Browser.Cfg.HowDoYouKnowPolicy
+	if policy == "" || policy == "skip" {
+		s.Log.Warn("LinkedIn is asking 'How do you know this person', skipping strict verification")
+		return false
+	}
+
+	s.Log.Info("Answering 'How do you know' verification", "option", policy)
+	option, err := s.Browser.Page.Timeout(3 * time.Second).ElementX(
+		`//div[@role="dialog"]//label[` + browser.XPathContainsAny(".", []string{policy}) + `] | ` +
+			`//div[@role="dialog"]//button[` + browser.XPathContainsAny(".", []string{policy}) + `]`)
+	if err != nil {
+		s.Log.Warn("Configured 'How do you know' option not found, skipping", "option", policy)
+		return false
+	}
+	s.Browser.Click(option)
+	stealth.SleepContextual(stealth.ActionTypeClick, 1.0)
+
+	// Some options ask for extra details (company, school, email), we don't fill those
+	if has, el, _ := s.Browser.Page.HasX(`//div[@role="dialog"]//select | //div[@role="dialog"]//input[@type="text" or @type="email"]`); has {
+		if vis, _ := el.Visible(); vis {
+			s.Log.Warn("Selected option requires extra details, skipping", "option", policy)
+			return false
+		}
+	}
+
+	// Confirm the choice to move on to the invitation step
+	if btn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(
+		`//div[@role="dialog"]//button[contains(@class, "artdeco-button--primary")][not(@disabled)]` +
+			`[` + browser.XPathContainsAny(".", append(s.Browser.Cfg.Labels("connect"), "Next", "Continue")) + `]`); err == nil {
+		s.Browser.Click(btn)
+		stealth.SleepContextual(stealth.ActionTypeThink, 0.8)
+	}
+
+	return true
+}
+
+// isFirstDegree checks the distance badge and the primary action button.
+// 1st-degree profiles show "1st" and have "Message" as the primary action.
+func (s *Service) isFirstDegree() bool {
+	if has, _, _ := s.Browser.Page.HasX(`//main//span[contains(@class, "dist-value")][contains(., "1st")]`); has {
+		return true
+	}
+	primaryMessage := `//main//button[contains(@class, "artdeco-button--primary")][` + browser.XPathContainsAny(".", s.Browser.Cfg.Labels("message")) + `]`
+	if btn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(primaryMessage); err == nil {
+		if vis, _ := btn.Visible(); vis {
+			return true
+		}
+	}
+	return false
+}
+
 // tryFallbacks attempts to Follow or Message if Connect fails
 func (s *Service) tryFallbacks(url, msg string) error {
 	// 1. Try FOLLOW
 	s.Log.Info("Fallback: Checking for Follow button...")
 
-	// Selectors for Follow (Direct OR Menu Item)
-	followSelectors := []string{
-		`//button[contains(@aria-label, "Follow")]`,
-		`//button//span[text()="Follow"]`,
-		// If inside the More menu (which might be open)
-		`//div[contains(@class, "artdeco-dropdown")]//span[text()="Follow"]`,
-		`//div[@role="button"]//span[text()="Follow"]`,
-	}
-
-	var followBtn *rod.Element
-	// Direct check
-	for _, sel := range followSelectors {
-		if btn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(sel); err == nil {
-			if vis, _ := btn.Visible(); vis {
-				followBtn = btn
-				break
-			}
-		}
+	// Direct button or an item of the More menu (which might be open)
+	visible := func(btn *rod.Element) bool {
+		vis, _ := btn.Visible()
+		return vis
 	}
+	followBtn, _, _ := s.Browser.FindWhere("follow_button", 2*time.Second, visible)
 
 	// More Menu Check (if not found directly)
 	if followBtn == nil {
@@ -286,53 +699,58 @@ func (s *Service) tryFallbacks(url, msg string) error {
 	}
 
 	if followBtn != nil {
-		s.Log.Info("Clicking Follow button")
-		s.Browser.HumanMove(followBtn)
-		followBtn.Click(proto.InputMouseButtonLeft, 1)
+		s.Log.Info("Clicking Follow button", "fallback", "follow")
+		s.action = hooks.ActionFollow
+		if err := s.Browser.Click(followBtn); err != nil {
+			return fmt.Errorf("follow button: %w", err)
+		}
 		s.sentCount++ // Count as an interaction
 		return nil
 	}
 
 	// 2. Try MESSAGE
 	s.Log.Info("Fallback: Checking for Message button...")
-	// Selectors for Message
-	msgSelectors := []string{
-		`//button[contains(@aria-label, "Message")]`,
-		`//main//button[contains(., "Message")]`,
-	}
-
-	var msgBtn *rod.Element
-	for _, sel := range msgSelectors {
-		if btn, err := s.Browser.Page.Timeout(2 * time.Second).ElementX(sel); err == nil {
-			if vis, _ := btn.Visible(); vis {
-				msgBtn = btn
-				break
-			}
-		}
-	}
+	msgBtn, _, _ := s.Browser.FindWhere("message_button", 2*time.Second, visible)
 
 	if msgBtn != nil {
-		s.Log.Info("Clicking Message button")
-		s.Browser.HumanMove(msgBtn)
-		msgBtn.Click(proto.InputMouseButtonLeft, 1)
+		s.Log.Info("Clicking Message button", "fallback", "message")
+		s.action = hooks.ActionMessage
+		if s.Browser.Cfg.SingleComposer {
+			s.Browser.CloseChatBubbles(false)
+		}
+		s.Browser.Click(msgBtn)
 
 		// Wait for Chat Window
 		// usually div[role="textbox"] or .msg-form__contenteditable
 		s.Log.Info("Waiting for chat window...")
-		textBox, err := s.Browser.Page.Timeout(5 * time.Second).ElementX(`//div[@role="textbox"][@contenteditable="true"]`)
+		textBox, err := s.Browser.Find("message_box", 5*time.Second)
+		if err == nil && s.Browser.Cfg.SingleComposer {
+			if closed, _ := s.Browser.CloseChatBubbles(true); closed > 0 {
+				s.Log.Info("Closed stray chat overlays", "count", closed)
+				textBox, err = s.Browser.Find("message_box", 5*time.Second)
+			}
+		}
 		if err == nil {
 			s.Log.Info("Sending message via Message button")
 
 			// Customize name
 			// (Simplified for fallback)
-			cleanMsg := strings.ReplaceAll(msg, "{{name}}", "there")
+			cleanMsg := templates.Render(msg, s.templateVars(map[string]string{"name": "there", "firstname": "there"}), s.NoteFooter, 0)
+			if s.Browser.Cfg.Template.Sanitize {
+				cleanMsg = templates.Sanitize(cleanMsg)
+			}
 
 			s.Browser.HumanType(textBox, cleanMsg)
 			stealth.SleepWithJitter(time.Second, 0.5)
 
+			if s.Confirm != nil && !s.Confirm(hooks.ActionMessage, url, cleanMsg) {
+				s.Log.Info("Fallback message declined by operator", "url", url)
+				return hooks.ErrDeclined
+			}
+
 			// Click Send
 			// usually button[type="submit"] in the form
-			if sendBtn, err := s.Browser.Page.Element(`button[type="submit"]`); err == nil {
+			if sendBtn, err := s.Browser.Find("message_send", 5*time.Second); err == nil {
 				sendBtn.Click(proto.InputMouseButtonLeft, 1)
 				s.sentCount++
 				return nil
@@ -0,0 +1,107 @@
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/ratelimit"
+)
+
+// ErrAlreadyPending is returned when the profile already has a pending invitation
+var ErrAlreadyPending = errors.New("connection request already pending")
+
+// ErrNotVerified is returned when Send was clicked but neither the
+// "Invitation sent" toast nor the Pending button showed up
+var ErrNotVerified = errors.New("connection request could not be verified")
+
+// ErrNeedsAnswer is returned when LinkedIn asks "How do you know ..." and
+// how_do_you_know_policy doesn't answer it
+var ErrNeedsAnswer = errors.New("connection request needs a 'How do you know' answer")
+
+// Result is the verified outcome of a connection request
+type Result string
+
+const (
+	// Sent means LinkedIn confirmed the invitation (or the Follow/Message fallback went through)
+	Sent Result = "sent"
+	// AlreadyPending means an earlier invitation is still waiting for an answer
+	AlreadyPending Result = "already_pending"
+	// LimitReached means our limits or LinkedIn's stopped the request
+	LimitReached Result = "limit_reached"
+	// Failed means nothing was sent; the error says why
+	Failed Result = "failed"
+)
+
+// ResultOf classifies the error of a connection attempt
+func ResultOf(err error) Result {
+	switch {
+	case err == nil:
+		return Sent
+	case errors.Is(err, ErrAlreadyPending):
+		return AlreadyPending
+	case errors.Is(err, ErrDailyLimit), errors.Is(err, ErrWeeklyLimit), errors.Is(err, ratelimit.ErrLimitReached):
+		return LimitReached
+	}
+	return Failed
+}
+
+// verifyTimeout bounds the wait for LinkedIn to confirm an invitation
+const verifyTimeout = 8 * time.Second
+
+// sentToastXPath matches the "Your invitation to Jane was sent." toast
+const sentToastXPath = `//*[contains(@class, "artdeco-toast-item")][contains(., "nvitation") and contains(., "sent")]`
+
+// limitPhrases appear in the modals LinkedIn shows instead of sending
+var limitPhrases = []string{"weekly limit", "reached the limit", "invitation limit"}
+
+// verifySent waits for proof the invitation went out: the success toast or
+// the Connect button flipping to Pending. A limit modal ends the wait early.
+func (s *Service) verifySent() error {
+	deadline := time.Now().Add(verifyTimeout)
+	for {
+		if has, _, _ := s.Browser.Page.HasX(sentToastXPath); has {
+			return nil
+		}
+		if s.Browser.Has("pending_button") {
+			return nil
+		}
+		if s.limitShown() {
+			s.limited = true
+			return s.weeklyLimit(fmt.Errorf("%w (refused by LinkedIn)", ErrWeeklyLimit))
+		}
+		if time.Now().After(deadline) {
+			return ErrNotVerified
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// limitShown reports whether LinkedIn says the invitation limit was reached
+func (s *Service) limitShown() bool {
+	dialog := `//div[@role="dialog" or @role="alertdialog"][` + browser.XPathContainsAny(".", limitPhrases) + `]`
+	if has, _, _ := s.Browser.Page.HasX(dialog); has {
+		return true
+	}
+	// Rod Page doesn't have Text(), check body
+	if body, err := s.Browser.Page.Element("body"); err == nil {
+		text, _ := body.Text()
+		return strings.Contains(text, "weekly limit")
+	}
+	return false
+}
+
+// recordSent updates the counters for a verified invitation
+func (s *Service) recordSent(company string) {
+	s.sentCount++
+	s.Limiter.Record(ratelimit.Connect)
+	if err := s.Store.RecordInvite(); err != nil {
+		s.Log.Warn("Failed to record invitation for limits", "error", err)
+	}
+	if company != "" {
+		s.companies[strings.ToLower(company)] = true
+	}
+	s.Log.Info("Connection request sent", "count", s.sentCount, "limit", s.DailyLimit)
+}
@@ -0,0 +1,182 @@
+package connect
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+
+	"linkedin-automation/profile"
+	"linkedin-automation/stealth"
+)
+
+// sentInvitationsURL lists the account's pending outgoing invitations
+const sentInvitationsURL = "https://www.linkedin.com/mynetwork/invitation-manager/sent/"
+
+// invitationCardSelector matches one pending invitation in the sent list
+const invitationCardSelector = "li.invitation-card, .invitation-card, li.mn-invitation-list__item"
+
+// withdrawButtonXPath matches a card's Withdraw button
+const withdrawButtonXPath = `.//button[contains(., "Withdraw") or contains(@aria-label, "Withdraw")]`
+
+// sentAgoPattern matches the card's "Sent 3 weeks ago" badge
+var sentAgoPattern = regexp.MustCompile(`(?i)sent\s+(today|yesterday|(\d+)\s+(minute|hour|day|week|month|year)s?\s+ago)`)
+
+// WithdrawStale withdraws pending invitations older than maxAge from the Sent
+// Invitations page, at most limit of them. The age comes from the stored request
+// time when known, otherwise from the card's "Sent ... ago" badge.
+// Cancelling ctx stops after the withdrawal in progress. Returns the profiles withdrawn.
+func (s *Service) WithdrawStale(ctx context.Context, maxAge time.Duration, limit int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.Log.Info("Opening sent invitations", "max_age", maxAge, "limit", limit)
+	if err := s.Browser.NavigateTo(sentInvitationsURL); err != nil {
+		return nil, err
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	// Older invitations are further down and lazy-loaded
+	for i := 0; i < 5; i++ {
+		s.Browser.HumanScroll(600)
+		stealth.SleepRandom(500*time.Millisecond, 1200*time.Millisecond)
+	}
+
+	var withdrawn []string
+	tried := make(map[string]bool)
+
+	// Cards re-render after every withdrawal, so re-query each round
+	for len(withdrawn) < limit && ctx.Err() == nil {
+		cards, err := s.Browser.Page.Elements(invitationCardSelector)
+		if err != nil || len(cards) == 0 {
+			if len(withdrawn) == 0 {
+				s.Log.Info("No pending invitations found")
+			}
+			break
+		}
+
+		found := false
+		for _, card := range cards {
+			link, err := card.Element("a[href*='/in/']")
+			if err != nil {
+				continue
+			}
+			href, err := link.Attribute("href")
+			if err != nil || href == nil {
+				continue
+			}
+			p, err := profile.Parse(*href)
+			if err != nil || tried[p.String()] {
+				continue
+			}
+			url := p.String()
+			tried[url] = true
+
+			text, err := card.Text()
+			if err != nil {
+				continue
+			}
+			age, ok := s.invitationAge(url, text)
+			if !ok || age < maxAge {
+				continue
+			}
+
+			btn, err := card.ElementX(withdrawButtonXPath)
+			if err != nil {
+				s.Log.Warn("Withdraw button not found on invitation", "url", url)
+				continue
+			}
+
+			s.Log.Info("Withdrawing stale invitation", "url", url, "age", age.Round(time.Hour))
+			if err := s.Browser.Click(btn); err != nil {
+				s.Log.Warn("Failed to click withdraw", "url", url, "error", err)
+				continue
+			}
+			stealth.SleepContextual(stealth.ActionTypeThink, 0.5)
+
+			confirmBtn, err := s.Browser.Find("withdraw_confirm", 5*time.Second)
+			if err != nil {
+				s.Log.Warn("Withdraw confirmation not found", "url", url)
+				s.Browser.Page.Keyboard.Press(input.Escape)
+				continue
+			}
+			if err := s.Browser.Click(confirmBtn); err != nil || !s.withdrawConfirmed(card) {
+				s.Log.Warn("Withdrawal not confirmed, not recorded", "url", url, "error", err)
+				s.Browser.Page.Keyboard.Press(input.Escape)
+				continue
+			}
+
+			if err := s.Store.MarkWithdrawn(url); err != nil {
+				s.Log.Warn("Failed to record withdrawal", "url", url, "error", err)
+			}
+			withdrawn = append(withdrawn, url)
+			found = true
+
+			// Human-like pacing between withdrawals
+			stealth.SleepRandom(4*time.Second, 12*time.Second)
+			break
+		}
+		if !found {
+			break
+		}
+	}
+
+	s.Log.Info("Stale invitations withdrawn", "count", len(withdrawn))
+	return withdrawn, nil
+}
+
+// withdrawConfirmed waits for the confirmation dialog to close and the card
+// to lose its Withdraw button, which LinkedIn does once the invitation is gone
+func (s *Service) withdrawConfirmed(card *rod.Element) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if !s.Browser.Has("withdraw_confirm") {
+			// A card removed from the list errors, which counts as gone
+			if has, _, err := card.HasX(withdrawButtonXPath); err != nil || !has {
+				return true
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return false
+}
+
+// invitationAge prefers the stored request time and falls back to the card badge
+func (s *Service) invitationAge(url, cardText string) (time.Duration, bool) {
+	if sent := s.Store.RequestTime(url); !sent.IsZero() {
+		return time.Since(sent), true
+	}
+	return parseSentAgo(cardText)
+}
+
+// parseSentAgo converts "Sent 3 weeks ago" style text into an approximate age
+func parseSentAgo(text string) (time.Duration, bool) {
+	m := sentAgoPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	switch strings.ToLower(m[1]) {
+	case "today":
+		return 0, true
+	case "yesterday":
+		return 24 * time.Hour, true
+	}
+
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return 0, false
+	}
+	unit := map[string]time.Duration{
+		"minute": time.Minute,
+		"hour":   time.Hour,
+		"day":    24 * time.Hour,
+		"week":   7 * 24 * time.Hour,
+		"month":  30 * 24 * time.Hour,
+		"year":   365 * 24 * time.Hour,
+	}[strings.ToLower(m[3])]
+	return time.Duration(n) * unit, true
+}
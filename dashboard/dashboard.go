@@ -0,0 +1,118 @@
+package dashboard
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+	"linkedin-automation/storage"
+)
+
+//go:embed templates/*.html
+var files embed.FS
+
+// page is the single dashboard template
+var page = template.Must(template.New("index.html").Funcs(template.FuncMap{
+	"percent": percent,
+	"ago":     ago,
+}).ParseFS(files, "templates/index.html"))
+
+// recentErrors is how many failures the page lists
+const recentErrors = 20
+
+// Source returns the store to render; called on every request so a
+// standalone dashboard always shows the current state file
+type Source func() (*storage.MemoryStore, error)
+
+// Campaign is one row of the per-campaign table
+type Campaign struct {
+	Name string
+	storage.Funnel
+	Messages int
+}
+
+// View is the data the template renders
+type View struct {
+	Account   string
+	Generated time.Time
+	Stats     storage.Stats
+	Funnel    storage.Funnel
+	Campaigns []Campaign
+	Notes     []storage.NoteAcceptance
+	Errors    []storage.ErrorEntry
+}
+
+// Dashboard serves the HTML overview of the state file
+type Dashboard struct {
+	Source  Source
+	Account string
+	Log     logger.Logger
+}
+
+// New creates a Dashboard reading from source
+func New(source Source, account string, l logger.Logger) *Dashboard {
+	return &Dashboard{Source: source, Account: account, Log: l}
+}
+
+// Build collects the view from the store
+func (d *Dashboard) Build(store *storage.MemoryStore) View {
+	v := View{
+		Account:   d.Account,
+		Generated: time.Now(),
+		Stats:     store.Stats(),
+		Funnel:    store.Funnel(""),
+		Notes:     store.AcceptanceByNote(),
+		Errors:    store.RecentErrors(recentErrors),
+	}
+	for _, name := range store.CampaignNames() {
+		v.Campaigns = append(v.Campaigns, Campaign{
+			Name:     name,
+			Funnel:   store.Funnel(name),
+			Messages: v.Stats.Campaigns[name][string(hooks.ActionMessage)],
+		})
+	}
+	return v
+}
+
+// ServeHTTP renders the dashboard at /
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	store, err := d.Source()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read state: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, d.Build(store)); err != nil {
+		d.Log.Warn("Failed to render dashboard", "error", err)
+	}
+}
+
+// percent is part of total as a whole percentage, 0 when total is 0
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
+
+// ago renders how long ago t was, rounded for reading
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return t.Format("Jan 2 15:04")
+}
@@ -0,0 +1,94 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+)
+
+// unsafeChars are replaced in labels used as file names
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Recorder saves what the browser showed when something went wrong:
+// a screenshot, the page HTML and a text file with the URL and error
+type Recorder struct {
+	Browser *browser.Browser
+	Log     logger.Logger
+	Dir     string
+
+	// Ignore lists expected outcomes (skips, declines) that aren't worth a capture
+	Ignore []error
+}
+
+// New creates a Recorder writing into dir
+func New(b *browser.Browser, l logger.Logger, dir string) *Recorder {
+	return &Recorder{Browser: b, Log: l, Dir: dir}
+}
+
+// Observe is a hooks.ResultHook capturing every failed action
+func (r *Recorder) Observe(res hooks.ActionResult) {
+	if res.Outcome != hooks.OutcomeFailed || r.ignored(res.Error) {
+		return
+	}
+	r.Capture(string(res.Action), res.Error)
+}
+
+func (r *Recorder) ignored(err error) bool {
+	for _, e := range r.Ignore {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Capture saves the current page as <time>_<label>.png/.html/.txt and logs
+// the paths. It returns the common path prefix. Partial captures are kept.
+func (r *Recorder) Capture(label string, cause error) (string, error) {
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		r.Log.Warn("Failed to create diagnostics directory", "dir", r.Dir, "error", err)
+		return "", err
+	}
+	label = strings.Trim(unsafeChars.ReplaceAllString(label, "_"), "_")
+	base := filepath.Join(r.Dir, time.Now().Format("20060102-150405.000")+"_"+label)
+
+	page := r.Browser.Page
+	url := ""
+	if info, err := page.Info(); err == nil {
+		url = info.URL
+	}
+	var errs []error
+
+	note := fmt.Sprintf("time: %s\nurl: %s\n", time.Now().Format(time.RFC3339), url)
+	if cause != nil {
+		note += fmt.Sprintf("error: %v\n", cause)
+	}
+	errs = append(errs, os.WriteFile(base+".txt", []byte(note), 0644))
+
+	if png, err := page.Screenshot(true, nil); err != nil {
+		errs = append(errs, fmt.Errorf("screenshot: %w", err))
+	} else {
+		errs = append(errs, os.WriteFile(base+".png", png, 0644))
+	}
+
+	if html, err := page.HTML(); err != nil {
+		errs = append(errs, fmt.Errorf("html: %w", err))
+	} else {
+		errs = append(errs, os.WriteFile(base+".html", []byte(html), 0644))
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		r.Log.Warn("Diagnostics capture incomplete", "path", base, "error", err)
+	}
+	r.Log.Info("Diagnostics saved", "screenshot", base+".png", "html", base+".html", "url", url)
+	return base, err
+}
@@ -0,0 +1,146 @@
+package endorse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+	"linkedin-automation/profile"
+	"linkedin-automation/ratelimit"
+	"linkedin-automation/stealth"
+	"linkedin-automation/storage"
+)
+
+// ErrNoSkills is returned when the profile shows no skill that can be endorsed
+var ErrNoSkills = errors.New("no endorsable skills on profile")
+
+// skillsSectionXPath is the profile's Skills card, anchored by its #skills div
+const skillsSectionXPath = `//section[.//div[@id="skills"]]`
+
+// endorseButtonXPath matches the not-yet-pressed Endorse buttons inside the Skills card
+const endorseButtonXPath = skillsSectionXPath + `//button[starts-with(normalize-space(.), "Endorse") and not(contains(., "Endorsed")) and not(@aria-pressed="true")]`
+
+// topSkills is how many of the first skills are considered; they are the ones
+// shown on the profile itself
+const topSkills = 3
+
+// Service endorses skills of 1st-degree connections
+type Service struct {
+	Browser *browser.Browser
+	Log     logger.Logger
+	Store   storage.DataStore
+
+	// Limiter paces profile views within their budget, nil disables it
+	Limiter *ratelimit.Limiter
+
+	// OnResult is invoked after every endorsement attempt
+	OnResult hooks.ResultHook
+}
+
+// New creates a new Endorse Service
+func New(b *browser.Browser, l logger.Logger, store storage.DataStore) *Service {
+	return &Service{
+		Browser:  b,
+		Log:      l,
+		Store:    store,
+		OnResult: hooks.Noop,
+	}
+}
+
+// Endorse visits a connection's profile, scrolls to the Skills section and
+// endorses 1 to maxSkills of the top skills. Returns how many were endorsed.
+func (s *Service) Endorse(ctx context.Context, profileURL string, maxSkills int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := s.endorse(ctx, profileURL, maxSkills)
+
+	result := hooks.NewResult(profileURL, hooks.ActionEndorse, err)
+	result.Metadata["skills"] = fmt.Sprint(n)
+	s.OnResult(result)
+	return n, err
+}
+
+func (s *Service) endorse(ctx context.Context, profileURL string, maxSkills int) (int, error) {
+	if _, err := profile.Parse(profileURL); err != nil {
+		return 0, fmt.Errorf("%w: %s", err, profileURL)
+	}
+	// Hard safety rail: never touch profiles outside the allowlist
+	if err := s.Browser.Cfg.CheckAllowed(profileURL); err != nil {
+		s.Log.Error("Refusing to endorse profile outside safe allowlist", "url", profileURL)
+		return 0, fmt.Errorf("%w: %s", err, profileURL)
+	}
+
+	if err := s.Limiter.Wait(ctx, ratelimit.ProfileView); err != nil {
+		return 0, err
+	}
+
+	s.Log.Info("Visiting profile to endorse", "url", profileURL)
+	if err := s.Browser.NavigateTo(profileURL); err != nil {
+		return 0, err
+	}
+	s.Limiter.Record(ratelimit.ProfileView)
+	if err := s.Store.RecordVisit(profileURL, string(hooks.ActionEndorse)); err != nil {
+		s.Log.Warn("Failed to record visit", "url", profileURL, "error", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	if !s.scrollToSkills() {
+		return 0, ErrNoSkills
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 0.8)
+
+	buttons, err := s.Browser.Page.ElementsX(endorseButtonXPath)
+	if err != nil || len(buttons) == 0 {
+		return 0, ErrNoSkills
+	}
+	if len(buttons) > topSkills {
+		buttons = buttons[:topSkills]
+	}
+	if maxSkills < 1 {
+		maxSkills = 1
+	}
+	want := 1 + rand.Intn(maxSkills)
+	if want > len(buttons) {
+		want = len(buttons)
+	}
+
+	endorsed := 0
+	for _, btn := range buttons[:want] {
+		if err := s.Browser.Click(btn); err != nil {
+			s.Log.Warn("Failed to click Endorse", "url", profileURL, "error", err)
+			continue
+		}
+		endorsed++
+		stealth.SleepContextual(stealth.ActionTypeThink, 0.8)
+	}
+	if endorsed == 0 {
+		return 0, fmt.Errorf("no endorsement went through on %s", profileURL)
+	}
+
+	if err := s.Store.MarkEndorsed(profileURL); err != nil {
+		s.Log.Warn("Failed to record endorsement", "url", profileURL, "error", err)
+	}
+	s.Log.Info("Skills endorsed", "url", profileURL, "skills", endorsed)
+	return endorsed, nil
+}
+
+// scrollToSkills scrolls down in human-sized steps until the lazy-loaded Skills
+// section renders, then brings it into view
+func (s *Service) scrollToSkills() bool {
+	for i := 0; i < 12; i++ {
+		if section, err := s.Browser.Page.Timeout(time.Second).ElementX(skillsSectionXPath); err == nil {
+			if visible, _ := section.Visible(); visible {
+				return s.Browser.ScrollToElement(section) == nil
+			}
+		}
+		s.Browser.HumanScroll(400 + rand.Float64()*300)
+		stealth.SleepContextual(stealth.ActionTypeScroll, 1.0)
+	}
+	return false
+}
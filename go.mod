@@ -6,6 +6,7 @@ require (
 	github.com/go-rod/rod v0.116.2
 	github.com/go-rod/stealth v0.4.9
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.12.3
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -0,0 +1,87 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/logger"
+)
+
+// Monitor tracks liveness for supervised deployments
+type Monitor struct {
+	Browser       *browser.Browser
+	Staleness     time.Duration
+	HeartbeatFile string
+
+	mu         sync.RWMutex
+	lastAction time.Time
+}
+
+// New creates a Monitor; the run counts as active from creation
+func New(b *browser.Browser, staleness time.Duration, heartbeatFile string) *Monitor {
+	m := &Monitor{
+		Browser:       b,
+		Staleness:     staleness,
+		HeartbeatFile: heartbeatFile,
+	}
+	m.Touch()
+	return m
+}
+
+// Touch records activity and refreshes the heartbeat file
+func (m *Monitor) Touch() {
+	now := time.Now()
+
+	m.mu.Lock()
+	m.lastAction = now
+	m.mu.Unlock()
+
+	if m.HeartbeatFile != "" {
+		// Best-effort, supervisors treat a stale file as unhealthy anyway
+		os.WriteFile(m.HeartbeatFile, []byte(now.Format(time.RFC3339)+"\n"), 0644)
+	}
+}
+
+// Healthy reports whether the browser responds and the last action is recent enough
+func (m *Monitor) Healthy() (bool, string) {
+	if _, err := (proto.BrowserGetVersion{}).Call(m.Browser.RodBrowser); err != nil {
+		return false, fmt.Sprintf("browser not connected: %v", err)
+	}
+
+	m.mu.RLock()
+	since := time.Since(m.lastAction)
+	m.mu.RUnlock()
+
+	if m.Staleness > 0 && since > m.Staleness {
+		return false, fmt.Sprintf("no activity for %s", since.Round(time.Second))
+	}
+	return true, fmt.Sprintf("last activity %s ago", since.Round(time.Second))
+}
+
+// ServeHTTP implements GET /healthz
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ok, detail := m.Healthy()
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	fmt.Fprintln(w, detail)
+}
+
+// Serve starts the health endpoint in the background
+func (m *Monitor) Serve(addr string, log logger.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", m)
+
+	go func() {
+		log.Info("Health endpoint listening", "addr", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Error("Health endpoint stopped", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,74 @@
+package hooks
+
+import (
+	"errors"
+	"time"
+)
+
+// Action identifies the kind of interaction performed on a profile
+type Action string
+
+const (
+	ActionConnect Action = "connect"
+	ActionFollow  Action = "follow"
+	ActionMessage Action = "message"
+	ActionView    Action = "view"
+	ActionSearch  Action = "search"
+	ActionEndorse Action = "endorse"
+)
+
+// Outcome summarises how an action ended
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailed  Outcome = "failed"
+)
+
+// ActionResult describes a single completed action for integrators
+type ActionResult struct {
+	ProfileURL string
+	Action     Action
+	Outcome    Outcome
+	Error      error
+	Metadata   map[string]string
+	Time       time.Time
+}
+
+// ResultHook is invoked after every profile action
+type ResultHook func(result ActionResult)
+
+// Noop is the default hook and does nothing
+func Noop(ActionResult) {}
+
+// Chain returns a hook calling each of hooks in order
+func Chain(hooks ...ResultHook) ResultHook {
+	return func(result ActionResult) {
+		for _, h := range hooks {
+			h(result)
+		}
+	}
+}
+
+// NewResult builds an ActionResult, deriving the outcome from err
+func NewResult(profileURL string, action Action, err error) ActionResult {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailed
+	}
+	return ActionResult{
+		ProfileURL: profileURL,
+		Action:     action,
+		Outcome:    outcome,
+		Error:      err,
+		Metadata:   make(map[string]string),
+		Time:       time.Now(),
+	}
+}
+
+// ErrDeclined is returned when the operator rejects a send in confirm mode
+var ErrDeclined = errors.New("send declined by operator")
+
+// ConfirmFunc asks the operator to approve the rendered text before sending.
+// Returning false skips the send.
+type ConfirmFunc func(action Action, profileURL, text string) bool
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTime is the timestamp rotated files carry: app.log -> app-<time>.log
+const backupTime = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a log file that is rotated once it grows past MaxSize
+// bytes. Rotated files keep the name with a timestamp before the extension;
+// only the newest MaxBackups are kept, none older than MaxAge (0 = no limit).
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+	MaxAge     time.Duration
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotating opens (or creates) path for appending, creating its directory
+func OpenRotating(path string, maxSize int64, maxBackups int, maxAge time.Duration) (*RotatingFile, error) {
+	r := &RotatingFile{Path: path, MaxSize: maxSize, MaxBackups: maxBackups, MaxAge: maxAge}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.prune()
+	return r, nil
+}
+
+// Write appends p, rotating first when p would take the file past MaxSize.
+// Handlers write a record per call, so records never straddle two files.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// rotate moves the current file aside and starts a new one
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+	base, ext := r.split()
+	if err := os.Rename(r.Path, base+"-"+time.Now().Format(backupTime)+ext); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// prune removes rotated files beyond MaxBackups or older than MaxAge
+func (r *RotatingFile) prune() {
+	if r.MaxBackups <= 0 && r.MaxAge <= 0 {
+		return
+	}
+	base, ext := r.split()
+	backups, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return
+	}
+	// The timestamps sort by name, newest first after reversing
+	slices.Sort(backups)
+	slices.Reverse(backups)
+	kept := 0
+	for _, path := range backups {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(path, base+"-"), ext)
+		t, err := time.ParseInLocation(backupTime, stamp, time.Local)
+		if err != nil {
+			// Not one of ours
+			continue
+		}
+		if (r.MaxBackups > 0 && kept >= r.MaxBackups) || (r.MaxAge > 0 && time.Since(t) > r.MaxAge) {
+			os.Remove(path)
+			continue
+		}
+		kept++
+	}
+}
+
+func (r *RotatingFile) split() (base, ext string) {
+	ext = filepath.Ext(r.Path)
+	return strings.TrimSuffix(r.Path, ext), ext
+}
+
+// OpenRunFile creates the log file of one run in dir, named by its start
+// time and account: 20060102-150405_sales.log
+func OpenRunFile(dir, account string, start time.Time) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := start.Format("20060102-150405")
+	if account != "" {
+		name += "_" + account
+	}
+	f, err := os.OpenFile(filepath.Join(dir, name+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("run log: %w", err)
+	}
+	return f, nil
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -21,11 +22,16 @@ type SlogAdapter struct {
 // New creates a new structured logger
 // Defaults to generic text handler (time=... level=INFO msg=... key=val)
 func New() Logger {
+	return NewWriter(os.Stdout)
+}
+
+// NewWriter is New writing to w, e.g. stderr when stdout carries command output
+func NewWriter(w io.Writer) Logger {
 	opts := &slog.HandlerOptions{
 		Level: slog.LevelDebug, // Default to debug for development
 	}
 	// Use TextHandler for structured but human-readable output
-	handler := slog.NewTextHandler(os.Stdout, opts)
+	handler := slog.NewTextHandler(w, opts)
 
 	return &SlogAdapter{
 		logger: slog.New(handler),
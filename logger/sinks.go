@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Sink is one destination of a multi-sink logger, with its own minimum level
+type Sink struct {
+	W     io.Writer
+	Level slog.Level
+	// JSON writes JSON lines instead of text
+	JSON bool
+}
+
+// NewSinks creates a logger writing each record to every sink whose level it meets
+func NewSinks(sinks ...Sink) Logger {
+	handlers := make(fanout, 0, len(sinks))
+	for _, s := range sinks {
+		opts := &slog.HandlerOptions{Level: s.Level}
+		if s.JSON {
+			handlers = append(handlers, slog.NewJSONHandler(s.W, opts))
+		} else {
+			handlers = append(handlers, slog.NewTextHandler(s.W, opts))
+		}
+	}
+	return &SlogAdapter{logger: slog.New(handlers)}
+}
+
+// ParseLevel reads debug, info, warn or error; empty is debug
+func ParseLevel(s string) (slog.Level, error) {
+	if s == "" {
+		return slog.LevelDebug, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToLower(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+// fanout hands records to several handlers
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+
+	"linkedin-automation/stealth"
+)
+
+// ErrAttachmentTooLarge is returned for files over LinkedIn's attachment size limit
+var ErrAttachmentTooLarge = errors.New("attachment exceeds LinkedIn's 20 MB limit")
+
+// maxAttachmentSize is LinkedIn's limit for files sent in messages
+const maxAttachmentSize = 20 << 20
+
+// Composer attachment parts: the paperclip button opens a file chooser backed by a hidden input
+const (
+	attachButtonSelector  = `.msg-form button[aria-label*="Attach a file"], .msg-form button[aria-label*="attach" i]`
+	attachInputSelector   = `.msg-form input[type="file"]`
+	attachPreviewSelector = `.msg-form__attachment-container, .msg-form__attachments, .msg-attachment-preview`
+)
+
+// attach adds the file at path to the open composer, through the attach
+// button's file chooser or, failing that, the composer's file input, and
+// waits for the upload to show
+func (s *Service) attach(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("attachment: %w", err)
+	}
+	if info.Size() > maxAttachmentSize {
+		return fmt.Errorf("%w: %s", ErrAttachmentTooLarge, path)
+	}
+
+	s.Log.Info("Attaching file", "file", filepath.Base(path))
+	if err := s.chooseFile(path); err != nil {
+		s.Log.Debug("File chooser not intercepted, using the file input", "error", err)
+		input, ierr := s.Browser.Page.Timeout(5 * time.Second).Element(attachInputSelector)
+		if ierr != nil {
+			return fmt.Errorf("attach button and file input not found: %w", err)
+		}
+		if err := input.SetFiles([]string{path}); err != nil {
+			return fmt.Errorf("failed to attach %s: %w", path, err)
+		}
+	}
+
+	// Uploads take a while for a large deck
+	if _, err := s.Browser.Page.Timeout(60 * time.Second).Element(attachPreviewSelector); err != nil {
+		return fmt.Errorf("attachment didn't upload: %w", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeThink, 0.8)
+	return nil
+}
+
+// chooseFile clicks the attach button and answers the file chooser it opens with path
+func (s *Service) chooseFile(path string) error {
+	btn, err := s.Browser.Page.Timeout(5 * time.Second).Element(attachButtonSelector)
+	if err != nil {
+		return err
+	}
+	page := s.Browser.Page.Timeout(10 * time.Second)
+	choose, err := page.HandleFileDialog()
+	if err != nil {
+		return err
+	}
+	s.Browser.Click(btn)
+	if err := choose([]string{path}); err != nil {
+		// Don't leave later file choosers intercepted
+		proto.PageSetInterceptFileChooserDialog{Enabled: false}.Call(s.Browser.Page)
+		return err
+	}
+	return nil
+}
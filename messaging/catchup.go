@@ -0,0 +1,190 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/profile"
+	"linkedin-automation/stealth"
+)
+
+// ErrCongratulated is returned when the connection was already congratulated on the occasion
+var ErrCongratulated = errors.New("already congratulated on this occasion")
+
+// Kinds of catch-up occasions
+const (
+	CatchUpJobChange   = "job_change"
+	CatchUpAnniversary = "anniversary"
+	CatchUpBirthday    = "birthday"
+)
+
+// catchUpURLs are the "Catch up" tabs of My Network, per occasion
+var catchUpURLs = map[string]string{
+	CatchUpJobChange:   "https://www.linkedin.com/mynetwork/catch-up/job_changes/",
+	CatchUpAnniversary: "https://www.linkedin.com/mynetwork/catch-up/work_anniversaries/",
+	CatchUpBirthday:    "https://www.linkedin.com/mynetwork/catch-up/birthdays/",
+}
+
+// Catch-up card parts
+const (
+	catchUpCardSelector = "li.mn-catch-up-card, div[data-view-name='catch-up-card'], .catch-up-card"
+	catchUpLinkSelector = "a[href*='/in/']"
+	catchUpNameSelector = ".mn-catch-up-card__name, .artdeco-entity-lockup__title, span[dir='ltr']"
+)
+
+// Card wording: "Started a new position as Product Manager at Acme",
+// "Celebrating 5 years at Acme" and "Celebrating a birthday today"
+var (
+	newPositionPattern = regexp.MustCompile(`(?i)new (?:position|role|job) as (.+?) at (.+?)(?:[.!]|$)`)
+	anniversaryPattern = regexp.MustCompile(`(?i)(\d+) years? at (.+?)(?:[.!]|$)`)
+	birthdayPattern    = regexp.MustCompile(`(?i)birthday`)
+)
+
+// CatchUp is a connection's job change, work anniversary or birthday from My Network's catch-up
+type CatchUp struct {
+	Kind       string `json:"kind"`
+	ProfileURL string `json:"profile_url"`
+	Name       string `json:"name"`
+	Title      string `json:"title,omitempty"`
+	Company    string `json:"company,omitempty"`
+	Years      string `json:"years,omitempty"`
+}
+
+// Event is the storage key of the occasion, so a later job change gets its
+// own congratulation and a birthday one greeting a year
+func (c CatchUp) Event() string {
+	switch c.Kind {
+	case CatchUpBirthday:
+		return c.Kind + ":" + fmt.Sprint(time.Now().Year())
+	case CatchUpAnniversary:
+		return c.Kind + ":" + strings.ToLower(c.Company) + ":" + c.Years
+	default:
+		return c.Kind + ":" + strings.ToLower(c.Company)
+	}
+}
+
+// Vars are the template placeholders the occasion fills in
+func (c CatchUp) Vars() map[string]string {
+	vars := map[string]string{"newcompany": c.Company, "newtitle": c.Title, "years": c.Years}
+	for k, v := range vars {
+		if v == "" {
+			delete(vars, k)
+		}
+	}
+	return vars
+}
+
+// CatchUps lists up to max (0 for all loaded) occasions of kind from the
+// catch-up page, skipping cards whose wording isn't recognised
+func (s *Service) CatchUps(ctx context.Context, kind string, max int) ([]CatchUp, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	url, ok := catchUpURLs[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown catch-up kind %q", kind)
+	}
+	s.Log.Info("Opening catch-up notifications", "kind", kind)
+	if err := s.Browser.NavigateTo(url); err != nil {
+		return nil, err
+	}
+	if _, err := s.Browser.Page.Timeout(15 * time.Second).Element(catchUpCardSelector); err != nil {
+		s.Log.Warn("No catch-up cards found or selector changed", "kind", kind, "error", err)
+		return nil, nil
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+	s.Browser.HumanScroll(400)
+	stealth.SleepContextual(stealth.ActionTypeScroll, 1.0)
+
+	cards, err := s.Browser.Page.Elements(catchUpCardSelector)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var out []CatchUp
+	for _, card := range cards {
+		if max > 0 && len(out) >= max {
+			break
+		}
+		link, err := card.Element(catchUpLinkSelector)
+		if err != nil {
+			continue
+		}
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		p, err := profile.Parse(absoluteURL(*href))
+		if err != nil || seen[p.String()] {
+			continue
+		}
+		text, err := card.Text()
+		if err != nil {
+			continue
+		}
+		c, ok := parseCatchUp(kind, text)
+		if !ok {
+			s.Log.Debug("Unrecognised catch-up card", "url", p.String())
+			continue
+		}
+		c.ProfileURL, c.Name = p.String(), childText(card, catchUpNameSelector)
+		seen[c.ProfileURL] = true
+		out = append(out, c)
+	}
+	s.Log.Info("Catch-up occasions found", "kind", kind, "count", len(out))
+	return out, nil
+}
+
+// parseCatchUp reads the occasion from a card's text
+func parseCatchUp(kind, text string) (CatchUp, bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	c := CatchUp{Kind: kind}
+	switch kind {
+	case CatchUpJobChange:
+		m := newPositionPattern.FindStringSubmatch(text)
+		if m == nil {
+			return c, false
+		}
+		c.Title, c.Company = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+	case CatchUpAnniversary:
+		m := anniversaryPattern.FindStringSubmatch(text)
+		if m == nil {
+			return c, false
+		}
+		c.Years, c.Company = m[1], strings.TrimSpace(m[2])
+	case CatchUpBirthday:
+		return c, birthdayPattern.MatchString(text)
+	}
+	return c, c.Company != ""
+}
+
+// Congratulate messages a connection on the occasion with template, which
+// may use {{newcompany}}, {{newtitle}} and {{years}}. Each occasion is only
+// congratulated once; an earlier conversation doesn't prevent it.
+// Birthdays are greeted the same way, once a year.
+func (s *Service) Congratulate(ctx context.Context, c CatchUp, template string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !s.Store.CongratulatedAt(c.ProfileURL, c.Event()).IsZero() {
+		return ErrCongratulated
+	}
+	meta := map[string]string{"occasion": c.Kind}
+	if c.Company != "" {
+		meta["company"] = c.Company
+	}
+	err := s.withReauth(c.ProfileURL, template, meta, func() error {
+		return s.deliver(ctx, c.ProfileURL, delivery{template: template, vars: c.Vars(), ignoreReplies: true})
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.Store.MarkCongratulated(c.ProfileURL, c.Event()); err != nil {
+		s.Log.Warn("Failed to record congratulation", "url", c.ProfileURL, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package messaging
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/profile"
+	"linkedin-automation/search"
+	"linkedin-automation/stealth"
+)
+
+// connectionsURL lists every 1st-degree connection, newest first
+const connectionsURL = "https://www.linkedin.com/mynetwork/invite-connect/connections/"
+
+// Connections page parts
+const (
+	connectionCardSelector     = "li.mn-connection-card"
+	connectionLinkSelector     = "a.mn-connection-card__link"
+	connectionNameSelector     = ".mn-connection-card__name"
+	connectionHeadlineSelector = ".mn-connection-card__occupation"
+	connectionTimeSelector     = "time"
+	loadMoreSelector           = "button.scaffold-finite-scroll__load-button"
+)
+
+// staleRounds is how many scrolls in a row may load nothing before the list
+// is taken as complete
+const staleRounds = 3
+
+// connectedPattern matches the card's "Connected on March 3, 2024" or
+// "Connected 2 weeks ago" badge
+var connectedPattern = regexp.MustCompile(`(?i)connected\s+(?:on\s+(.+)|(\d+)\s+(minute|hour|day|week|month|year)s?\s+ago|(today|yesterday))`)
+
+// Connection is a 1st-degree connection as listed on the connections page
+type Connection struct {
+	ProfileURL  string    `json:"profile_url"`
+	Name        string    `json:"name"`
+	Headline    string    `json:"headline"`
+	Company     string    `json:"company,omitempty"`
+	ConnectedOn time.Time `json:"connected_on,omitzero"`
+}
+
+// ExportConnections scrolls through the connections page until every
+// connection is loaded, or max of them when max > 0, and returns them newest
+// first. It only reads: existing connections aren't written to the outreach
+// state, which would start sequences and acceptance hooks for them.
+func (s *Service) ExportConnections(ctx context.Context, max int) ([]Connection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.Log.Info("Opening connections list", "max", max)
+	if err := s.Browser.NavigateTo(connectionsURL); err != nil {
+		return nil, err
+	}
+	if _, err := s.Browser.Page.Timeout(15 * time.Second).Element(connectionCardSelector); err != nil {
+		s.Log.Warn("No connections found or selector changed", "error", err)
+		return nil, nil
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	loaded, stale := 0, 0
+	for ctx.Err() == nil && stale < staleRounds && (max <= 0 || loaded < max) {
+		cards, err := s.Browser.Page.Elements(connectionCardSelector)
+		if err != nil || len(cards) == 0 {
+			break
+		}
+		if len(cards) > loaded {
+			loaded, stale = len(cards), 0
+			s.Log.Info("Connections loaded", "count", loaded)
+		} else {
+			stale++
+		}
+		s.loadMore(cards[len(cards)-1])
+	}
+
+	cards, err := s.Browser.Page.Elements(connectionCardSelector)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var out []Connection
+	for _, card := range cards {
+		if max > 0 && len(out) >= max {
+			break
+		}
+		link, err := card.Element(connectionLinkSelector)
+		if err != nil {
+			continue
+		}
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		p, err := profile.Parse(absoluteURL(*href))
+		if err != nil {
+			continue
+		}
+		c := Connection{
+			ProfileURL:  p.String(),
+			Name:        childText(card, connectionNameSelector),
+			Headline:    childText(card, connectionHeadlineSelector),
+			ConnectedOn: parseConnectedOn(childText(card, connectionTimeSelector), now),
+		}
+		c.Company = search.CompanyFromHeadline(c.Headline)
+		out = append(out, c)
+	}
+	s.Log.Info("Connections exported", "count", len(out))
+	return out, nil
+}
+
+// loadMore scrolls to the last card so the next page lazy-loads, and presses
+// "Show more results" when the list stops at a button instead
+func (s *Service) loadMore(last *rod.Element) {
+	if btn, err := s.Browser.Page.Timeout(time.Second).Element(loadMoreSelector); err == nil {
+		if visible, _ := btn.Visible(); visible {
+			if err := s.Browser.HumanClick(btn); err == nil {
+				stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+				return
+			}
+		}
+	}
+	last.ScrollIntoView()
+	s.Browser.HumanScroll(300)
+	stealth.SleepContextual(stealth.ActionTypeScroll, 1.5)
+}
+
+// parseConnectedOn reads the card's connected badge; relative ages are
+// approximate and unknown formats give the zero time
+func parseConnectedOn(text string, now time.Time) time.Time {
+	m := connectedPattern.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}
+	}
+	y, mo, d := now.Date()
+	today := time.Date(y, mo, d, 0, 0, 0, 0, now.Location())
+	switch {
+	case m[1] != "":
+		t, err := time.ParseInLocation("January 2, 2006", strings.TrimSpace(m[1]), now.Location())
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	case strings.EqualFold(m[4], "today"):
+		return today
+	case strings.EqualFold(m[4], "yesterday"):
+		return today.AddDate(0, 0, -1)
+	}
+
+	n, _ := strconv.Atoi(m[2])
+	switch strings.ToLower(m[3]) {
+	case "minute", "hour":
+		return today
+	case "day":
+		return today.AddDate(0, 0, -n)
+	case "week":
+		return today.AddDate(0, 0, -7*n)
+	case "month":
+		return today.AddDate(0, -n, 0)
+	}
+	return today.AddDate(-n, 0, 0)
+}
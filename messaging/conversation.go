@@ -0,0 +1,245 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/profile"
+	"linkedin-automation/stealth"
+	"linkedin-automation/templates"
+)
+
+// Thread history parts: a day heading starts each day, a group header (sender
+// and time) each run of messages from one sender
+const (
+	messageListSelector = ".msg-s-message-list, .msg-s-message-list-content"
+	dayHeadingSelector  = "time.msg-s-message-list__time-heading"
+	groupTimeSelector   = "time.msg-s-message-group__timestamp"
+	historyLoadSelector = ".msg-s-message-list__loader"
+)
+
+// historyScrollRounds caps the scrolls up a long thread's history
+const historyScrollRounds = 30
+
+// meSender is the Sender of the account's own messages
+const meSender = "me"
+
+// Message is one message of a conversation
+type Message struct {
+	Sender string    `json:"sender"`
+	FromMe bool      `json:"from_me"`
+	Text   string    `json:"text"`
+	Time   time.Time `json:"time,omitzero"`
+}
+
+// Conversation is a thread's full message history, oldest first
+type Conversation struct {
+	ProfileURL string    `json:"profile_url"`
+	Name       string    `json:"name"`
+	ThreadURL  string    `json:"thread_url,omitempty"`
+	Messages   []Message `json:"messages"`
+}
+
+// Replied reports whether the other party wrote anything in the thread
+func (c Conversation) Replied() bool {
+	for _, m := range c.Messages {
+		if !m.FromMe {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConversation opens the thread with a connection from their profile's
+// Message button and returns its full history. A connection who wrote back
+// is recorded as replied.
+func (s *Service) GetConversation(ctx context.Context, profileURL string) (Conversation, error) {
+	if err := ctx.Err(); err != nil {
+		return Conversation{}, err
+	}
+	p, err := profile.Parse(profileURL)
+	if err != nil {
+		return Conversation{}, fmt.Errorf("%w: %s", err, profileURL)
+	}
+	if err := s.Browser.NavigateTo(p.String()); err != nil {
+		return Conversation{}, err
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	msgBtn, err := s.Browser.Find("message_button", 5*time.Second)
+	if err != nil {
+		return Conversation{}, fmt.Errorf("message button not found (not connected?): %w", err)
+	}
+	s.closeStrayChats()
+	s.Browser.Click(msgBtn)
+	stealth.SleepContextual(stealth.ActionTypeThink, 1.0)
+
+	c := Conversation{ProfileURL: p.String()}
+	if el, err := s.Browser.Page.Element("h1"); err == nil {
+		if text, err := el.Text(); err == nil {
+			_, c.Name = templates.Names(text)
+		}
+	}
+	c.Messages, err = s.readHistory(ctx)
+	s.closeStrayChats()
+	if err == nil {
+		s.recordReply(c)
+	}
+	return c, err
+}
+
+// ArchiveConversations reads the full history of the newest maxThreads
+// conversations in the inbox, calling save with each. Senders who ever wrote
+// back are recorded as replied, which ends their follow-ups.
+func (s *Service) ArchiveConversations(ctx context.Context, maxThreads int, save func(Conversation) error) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.Log.Info("Opening inbox to archive conversations", "max", maxThreads)
+	if err := s.Browser.NavigateTo(inboxURL); err != nil {
+		return 0, err
+	}
+	if _, err := s.Browser.Page.Timeout(15 * time.Second).Element(threadItemSelector); err != nil {
+		s.Log.Warn("No conversations found or selector changed", "error", err)
+		return 0, nil
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	threads := s.listThreads(maxThreads)
+	s.Log.Info("Conversations listed", "count", len(threads))
+
+	archived := 0
+	for _, t := range threads {
+		if err := ctx.Err(); err != nil {
+			return archived, err
+		}
+		if err := s.openThread(t.url); err != nil {
+			s.Log.Warn("Failed to open conversation", "thread", t.url, "error", err)
+			continue
+		}
+		c := Conversation{Name: t.name, ThreadURL: t.url}
+		if el, err := s.Browser.Page.Element(threadProfileSelector); err == nil {
+			if href, err := el.Attribute("href"); err == nil && href != nil {
+				if p, err := profile.Parse(absoluteURL(*href)); err == nil {
+					c.ProfileURL = p.String()
+				}
+			}
+		}
+		messages, err := s.readHistory(ctx)
+		if err != nil {
+			s.Log.Warn("Failed to read conversation", "thread", t.url, "error", err)
+			continue
+		}
+		c.Messages = messages
+
+		if err := save(c); err != nil {
+			return archived, err
+		}
+		archived++
+		s.recordReply(c)
+		s.Log.Info("Conversation archived", "name", c.Name, "messages", len(c.Messages))
+		stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+	}
+	return archived, nil
+}
+
+// recordReply marks the other party as replied if they ever wrote back,
+// which ends their follow-ups
+func (s *Service) recordReply(c Conversation) {
+	if c.ProfileURL == "" || !c.Replied() || s.Store.HasReplied(c.ProfileURL) {
+		return
+	}
+	if err := s.Store.MarkReplied(c.ProfileURL); err != nil {
+		s.Log.Warn("Failed to record reply", "url", c.ProfileURL, "error", err)
+	}
+}
+
+// readHistory scrolls the open thread up until its oldest message has loaded
+// and reads every message
+func (s *Service) readHistory(ctx context.Context) ([]Message, error) {
+	list, err := s.Browser.Page.Timeout(10 * time.Second).Element(messageListSelector)
+	if err != nil {
+		return nil, fmt.Errorf("conversation messages not found: %w", err)
+	}
+
+	// Older messages lazy-load when the top of the list is reached
+	loaded := 0
+	for range historyScrollRounds {
+		if ctx.Err() != nil {
+			break
+		}
+		events, err := s.Browser.Page.Elements(threadEventSelector)
+		if err != nil || len(events) == 0 || len(events) == loaded {
+			if has, _, _ := s.Browser.Page.Has(historyLoadSelector); !has {
+				break
+			}
+		}
+		loaded = len(events)
+		list.Eval(`() => { this.scrollTop = 0 }`)
+		stealth.SleepContextual(stealth.ActionTypeScroll, 1.0)
+	}
+
+	events, err := s.Browser.Page.Elements(threadEventSelector)
+	if err != nil {
+		return nil, err
+	}
+	return readEvents(events, time.Now()), nil
+}
+
+// readEvents turns the thread's list items into messages. Sender and time
+// carry over from the last group header and day heading.
+func readEvents(events rod.Elements, now time.Time) []Message {
+	var out []Message
+	var day time.Time
+	sender, clock := "", ""
+	for _, ev := range events {
+		if heading := childText(ev, dayHeadingSelector); heading != "" {
+			day = parseDayHeading(heading, now)
+		}
+		if name := childText(ev, senderNameSelector); name != "" {
+			sender = name
+		}
+		if t := childText(ev, groupTimeSelector); t != "" {
+			clock = t
+		}
+		text := childText(ev, messageBodySelector)
+		if text == "" {
+			continue
+		}
+		fromMe := true
+		if has, _, _ := ev.Has(otherMessageSelector); has {
+			fromMe = false
+		}
+		m := Message{Sender: sender, FromMe: fromMe, Text: text, Time: atClock(day, clock)}
+		if fromMe {
+			m.Sender = meSender
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// parseDayHeading reads a thread's day heading: "Today" or any inbox timestamp format
+func parseDayHeading(text string, now time.Time) time.Time {
+	if strings.EqualFold(strings.TrimSpace(text), "Today") {
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	}
+	return parseListTime(text, now)
+}
+
+// atClock sets day's time of day from a "3:04 PM" group timestamp; zero without a day
+func atClock(day time.Time, clock string) time.Time {
+	if day.IsZero() {
+		return time.Time{}
+	}
+	t, err := time.Parse("3:04 PM", strings.TrimSpace(clock))
+	if err != nil {
+		return day
+	}
+	return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+}
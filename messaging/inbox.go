@@ -0,0 +1,244 @@
+package messaging
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"linkedin-automation/profile"
+	"linkedin-automation/stealth"
+)
+
+// inboxURL is the messaging page listing the most recent conversations first
+const inboxURL = "https://www.linkedin.com/messaging/"
+
+// Inbox page parts
+const (
+	threadItemSelector    = "li.msg-conversation-listitem"
+	threadLinkSelector    = "a.msg-conversation-listitem__link"
+	threadNameSelector    = ".msg-conversation-listitem__participant-names"
+	threadSnippetSelector = ".msg-conversation-card__message-snippet"
+	threadTimeSelector    = ".msg-conversation-listitem__time-stamp"
+	threadEventSelector   = "li.msg-s-message-list__event"
+	messageBodySelector   = ".msg-s-event-listitem__body"
+	threadProfileSelector = "a.msg-thread__link-to-profile, .msg-s-message-group__profile-link"
+)
+
+// maxSnippetLength caps the exported message text
+const maxSnippetLength = 280
+
+// Reply is an incoming message the account hasn't answered yet
+type Reply struct {
+	ProfileURL string    `json:"profile_url"`
+	Name       string    `json:"name"`
+	Snippet    string    `json:"snippet"`
+	Time       time.Time `json:"time,omitzero"`
+	ThreadURL  string    `json:"thread_url"`
+}
+
+// thread is a conversation as listed in the inbox
+type thread struct {
+	url, name, snippet, when string
+}
+
+// UnansweredReplies scans the newest maxThreads conversations and returns the
+// ones whose latest messages came from the other party, i.e. arrived after our
+// last message. Each sender is recorded as replied, which ends their follow-ups.
+func (s *Service) UnansweredReplies(ctx context.Context, maxThreads int) ([]Reply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.Log.Info("Checking inbox for unanswered replies...")
+	if err := s.Browser.NavigateTo(inboxURL); err != nil {
+		return nil, err
+	}
+	if _, err := s.Browser.Page.Timeout(15 * time.Second).Element(threadItemSelector); err != nil {
+		s.Log.Warn("No conversations found or selector changed", "error", err)
+		return nil, nil
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	threads := s.listThreads(maxThreads)
+	s.Log.Info("Conversations listed", "count", len(threads))
+
+	var replies []Reply
+	now := time.Now()
+	for _, t := range threads {
+		// Our own last message shows as "You: ..." in the list, no need to open it
+		if strings.HasPrefix(t.snippet, "You:") {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		r, ok := s.readThread(t)
+		if !ok {
+			continue
+		}
+		r.Time = parseListTime(t.when, now)
+		replies = append(replies, r)
+		if r.ProfileURL != "" {
+			if err := s.Store.MarkReplied(r.ProfileURL); err != nil {
+				s.Log.Warn("Failed to record reply", "url", r.ProfileURL, "error", err)
+			}
+		}
+		stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+	}
+
+	s.Log.Info("Unanswered replies found", "count", len(replies))
+	return replies, nil
+}
+
+// listThreads reads the conversation list, scrolling until maxThreads are loaded
+func (s *Service) listThreads(maxThreads int) []thread {
+	var items rod.Elements
+	for range 5 {
+		items, _ = s.Browser.Page.Elements(threadItemSelector)
+		if len(items) >= maxThreads || len(items) == 0 {
+			break
+		}
+		// The list is its own scroll container and lazy-loads further threads
+		if err := s.Browser.ScrollToElement(items[len(items)-1]); err != nil {
+			break
+		}
+		stealth.SleepContextual(stealth.ActionTypeScroll, 1.0)
+	}
+
+	var threads []thread
+	for _, item := range items {
+		if len(threads) >= maxThreads {
+			break
+		}
+		link, err := item.Element(threadLinkSelector)
+		if err != nil {
+			continue
+		}
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		threads = append(threads, thread{
+			url:     absoluteURL(*href),
+			name:    childText(item, threadNameSelector),
+			snippet: childText(item, threadSnippetSelector),
+			when:    childText(item, threadTimeSelector),
+		})
+	}
+	return threads
+}
+
+// readThread opens a conversation and collects the messages after our last
+// one. ok is false when the thread ends with our own message.
+func (s *Service) readThread(t thread) (Reply, bool) {
+	if err := s.openThread(t.url); err != nil {
+		s.Log.Warn("Failed to open conversation", "thread", t.url, "error", err)
+		return Reply{}, false
+	}
+	events, err := s.Browser.Page.Timeout(10 * time.Second).Elements(threadEventSelector)
+	if err != nil || len(events) == 0 {
+		s.Log.Warn("Conversation messages not found", "thread", t.url)
+		return Reply{}, false
+	}
+
+	// Walk back from the newest message until we reach one of ours
+	var incoming []string
+	found := false
+	for i := len(events) - 1; i >= 0; i-- {
+		if has, _, _ := events[i].Has(otherMessageSelector); !has {
+			break
+		}
+		found = true
+		if text := childText(events[i], messageBodySelector); text != "" {
+			incoming = append([]string{text}, incoming...)
+		}
+	}
+	if !found {
+		return Reply{}, false
+	}
+
+	r := Reply{Name: t.name, ThreadURL: t.url, Snippet: truncate(strings.Join(incoming, " / "), maxSnippetLength)}
+	if el, err := s.Browser.Page.Element(threadProfileSelector); err == nil {
+		if href, err := el.Attribute("href"); err == nil && href != nil {
+			if p, err := profile.Parse(absoluteURL(*href)); err == nil {
+				r.ProfileURL = p.String()
+			}
+		}
+	}
+	s.Log.Info("Unanswered reply", "name", r.Name, "url", r.ProfileURL)
+	return r, true
+}
+
+// openThread clicks the conversation in the list, or navigates to it when the
+// list item can't be found
+func (s *Service) openThread(url string) error {
+	path := strings.TrimPrefix(url, "https://www.linkedin.com")
+	if link, err := s.Browser.Page.Timeout(2 * time.Second).Element(`a[href="` + path + `"]`); err == nil {
+		if err := s.Browser.HumanClick(link); err == nil {
+			stealth.SleepContextual(stealth.ActionTypeClick, 1.0)
+			return nil
+		}
+	}
+	return s.Browser.NavigateTo(url)
+}
+
+// childText returns the trimmed text of the first match of selector inside el
+func childText(el *rod.Element, selector string) string {
+	child, err := el.Element(selector)
+	if err != nil {
+		return ""
+	}
+	text, _ := child.Text()
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// absoluteURL prefixes site-relative links
+func absoluteURL(href string) string {
+	if strings.HasPrefix(href, "/") {
+		return "https://www.linkedin.com" + href
+	}
+	return href
+}
+
+// truncate cuts text to maxLen characters, marking the cut with "…"
+func truncate(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:maxLen-1])) + "…"
+}
+
+// parseListTime reads the inbox timestamp: "3:04 PM" today, "Yesterday", a
+// weekday within the last week, "Jan 2" this year or "Jan 2, 2006".
+// Unknown formats give the zero time.
+func parseListTime(text string, now time.Time) time.Time {
+	text = strings.TrimSpace(text)
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	if t, err := time.ParseInLocation("3:04 PM", text, now.Location()); err == nil {
+		return today.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+	if strings.EqualFold(text, "Yesterday") {
+		return today.AddDate(0, 0, -1)
+	}
+	for i := 1; i <= 7; i++ {
+		day := today.AddDate(0, 0, -i)
+		if strings.EqualFold(text, day.Weekday().String()[:3]) || strings.EqualFold(text, day.Weekday().String()) {
+			return day
+		}
+	}
+	if t, err := time.ParseInLocation("Jan 2, 2006", text, now.Location()); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("Jan 2", text, now.Location()); err == nil {
+		t = t.AddDate(y, 0, 0)
+		if t.After(now) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t
+	}
+	return time.Time{}
+}
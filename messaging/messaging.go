@@ -1,16 +1,38 @@
 package messaging
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
 
+	"linkedin-automation/auth"
 	"linkedin-automation/browser"
+	"linkedin-automation/hooks"
 	"linkedin-automation/logger"
+	"linkedin-automation/personalize"
+	"linkedin-automation/profile"
+	"linkedin-automation/ratelimit"
 	"linkedin-automation/stealth"
 	"linkedin-automation/storage"
+	"linkedin-automation/templates"
+)
+
+// ErrReplied is returned when the connection already replied and replies.policy is "skip"
+var ErrReplied = errors.New("connection already replied, follow-up skipped")
+
+// ErrAlreadyMessaged is returned when the follow-up was already sent to the profile
+var ErrAlreadyMessaged = errors.New("profile already messaged, follow-up skipped")
+
+// Conversation thread parts: messages from the other party carry the --other modifier
+const (
+	otherMessageSelector = ".msg-s-event-listitem--other"
+	senderNameSelector   = ".msg-s-message-group__name, .msg-s-message-group__profile-link"
 )
 
 // Service handles messaging operations
@@ -18,19 +40,41 @@ type Service struct {
 	Browser *browser.Browser
 	Log     logger.Logger
 	Store   storage.DataStore // Use the interface from storage
+
+	// Footer is appended to every message, empty disables it
+	Footer string
+
+	// Blacklist is config's exclusion list, checked together with the stored one
+	Blacklist storage.Exclusions
+
+	// Confirm, when set, must approve each message before Send is clicked
+	Confirm hooks.ConfirmFunc
+
+	// Auth handles mid-session re-authentication prompts, nil disables it
+	Auth *auth.Authenticator
+
+	// Limiter paces messages and profile views within their budgets, nil disables it
+	Limiter *ratelimit.Limiter
+
+	// OnResult is invoked after every follow-up attempt
+	OnResult hooks.ResultHook
 }
 
 // New creates a new Messaging Service
 func New(b *browser.Browser, l logger.Logger, s storage.DataStore) *Service {
 	return &Service{
-		Browser: b,
-		Log:     l,
-		Store:   s,
+		Browser:  b,
+		Log:      l,
+		Store:    s,
+		OnResult: hooks.Noop,
 	}
 }
 
 // DetectNewConnections scans the detailed connections page for recently added connections
-func (s *Service) DetectNewConnections(maxToCheck int) ([]string, error) {
+func (s *Service) DetectNewConnections(ctx context.Context, maxToCheck int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	s.Log.Info("Checking for new connections...")
 	url := "https://www.linkedin.com/mynetwork/invite-connect/connections/"
 	if err := s.Browser.NavigateTo(url); err != nil {
@@ -45,12 +89,11 @@ func (s *Service) DetectNewConnections(maxToCheck int) ([]string, error) {
 
 	// Select connection cards
 	// Usually a list `ul` with items `li` containing links to profiles
-	// Selector for link: .mn-connection-card__link
 
 	var newConnections []string
 
 	// Wait for elements
-	elements, err := s.Browser.Page.Elements(".mn-connection-card__link")
+	elements, err := s.Browser.FindAll("connection_link")
 	if err != nil {
 		s.Log.Warn("No connections found or selector changed", "error", err)
 		return nil, nil // Return empty, not error
@@ -64,13 +107,9 @@ func (s *Service) DetectNewConnections(maxToCheck int) ([]string, error) {
 
 		href, err := el.Attribute("href")
 		if err == nil && href != nil {
-			val := *href
 			// Clean URL
-			if strings.Contains(val, "/in/") {
-				clean := strings.Split(val, "?")[0]
-				if !strings.HasPrefix(clean, "http") {
-					clean = "https://www.linkedin.com" + clean
-				}
+			if p, err := profile.Parse(*href); err == nil {
+				clean := p.String()
 
 				// Check if we already messaged this person (skip effectively?)
 				// Or we just return all recent connections and let the caller decide
@@ -86,36 +125,180 @@ func (s *Service) DetectNewConnections(maxToCheck int) ([]string, error) {
 	return newConnections, nil
 }
 
-// SendFollowUp sends a message to a connection if not already sent
-// SendFollowUp sends a message to a connection if not already sent
-func (s *Service) SendFollowUp(profileURL string, template string) error {
+// SendFollowUp sends a message to a connection, or returns ErrAlreadyMessaged
+// if one was already sent.
+// A cancelled ctx stops it before it starts; a started message is always finished.
+func (s *Service) SendFollowUp(ctx context.Context, profileURL string, template string) error {
+	return s.SendFollowUpAttachment(ctx, profileURL, template, "")
+}
+
+// SendFollowUpAttachment is SendFollowUp with the file at attachment (a PDF
+// one-pager, an image...) sent along, "" for none
+func (s *Service) SendFollowUpAttachment(ctx context.Context, profileURL, template, attachment string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.withReauth(profileURL, template, attachmentMeta(attachment), func() error {
+		return s.sendFollowUp(ctx, profileURL, template, attachment)
+	})
+}
+
+// attachmentMeta reports the attachment's file name with the result
+func attachmentMeta(attachment string) map[string]string {
+	if attachment == "" {
+		return nil
+	}
+	return map[string]string{"attachment": filepath.Base(attachment)}
+}
+
+// SendSequenceStep sends step (0-based) of a drip sequence, with the file at
+// attachment unless it's "", and records the progress. A reply from the
+// connection always stops the sequence with ErrReplied.
+func (s *Service) SendSequenceStep(ctx context.Context, profileURL, sequence string, step int, template, attachment string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := s.withReauth(profileURL, template, attachmentMeta(attachment), func() error {
+		return s.deliver(ctx, profileURL, delivery{template: template, attachment: attachment, stopOnReply: true})
+	})
+	if err != nil {
+		return err
+	}
+	progress := storage.SequenceProgress{Step: step + 1, LastSentAt: time.Now()}
+	if err := s.Store.SetSequenceProgress(sequence, profileURL, progress); err != nil {
+		s.Log.Warn("Failed to record sequence progress", "url", profileURL, "error", err)
+	}
+	return nil
+}
+
+// withReauth runs send and, if a mid-session re-auth prompt silently broke it,
+// handles the prompt and retries once. template and meta are reported with the result.
+func (s *Service) withReauth(profileURL, template string, meta map[string]string, send func() error) error {
+	err := send()
+
+	if s.Auth != nil {
+		if handled, rerr := s.Auth.HandleReauth(); handled {
+			if rerr != nil {
+				err = rerr
+			} else {
+				s.Log.Info("Retrying message after re-authentication")
+				err = send()
+			}
+		}
+	}
+	result := hooks.NewResult(profileURL, hooks.ActionMessage, err)
+	result.Metadata["template"] = template
+	for k, v := range meta {
+		result.Metadata[k] = v
+	}
+	s.OnResult(result)
+	return err
+}
+
+// QueueFollowUp defers a follow-up to a later flush-messages run
+func (s *Service) QueueFollowUp(profileURL string, template string) error {
 	if s.Store.IsMessaged(profileURL) {
-		s.Log.Info("Already messaged this profile, skipping", "url", profileURL)
+		s.Log.Debug("Already messaged this profile, not queueing", "url", profileURL)
 		return nil
 	}
+	if _, err := profile.Parse(profileURL); err != nil {
+		return fmt.Errorf("%w: %s", err, profileURL)
+	}
+
+	if err := s.Store.QueueMessage(profileURL, template); err != nil {
+		return err
+	}
+	s.Log.Info("Follow-up queued", "url", profileURL)
+	return nil
+}
+
+func (s *Service) sendFollowUp(ctx context.Context, profileURL, template, attachment string) error {
+	if s.Store.IsMessaged(profileURL) {
+		s.Log.Info("Already messaged this profile, skipping", "url", profileURL)
+		return ErrAlreadyMessaged
+	}
+	return s.deliver(ctx, profileURL, delivery{template: template, attachment: attachment})
+}
+
+// delivery is what deliver sends and how it treats an ongoing conversation
+type delivery struct {
+	template string
+
+	// vars are added to the placeholders scraped from the profile
+	vars map[string]string
+
+	// attachment is a file sent along with the message, "" for none
+	attachment string
+
+	// stopOnReply skips the send on any reply regardless of replies.policy,
+	// ignoreReplies sends regardless of replies
+	stopOnReply   bool
+	ignoreReplies bool
+}
+
+// Exclusions returns the blacklist merged with the state file's exclusions
+func (s *Service) Exclusions() storage.Exclusions {
+	if s.Store == nil {
+		return s.Blacklist
+	}
+	return s.Blacklist.Merge(s.Store.Exclusions())
+}
+
+// deliver opens the conversation and sends the rendered template
+func (s *Service) deliver(ctx context.Context, profileURL string, d delivery) error {
+	template := d.template
+
+	if _, err := profile.Parse(profileURL); err != nil {
+		return fmt.Errorf("%w: %s", err, profileURL)
+	}
+
+	// Hard safety rail: never message profiles outside the allowlist
+	if err := s.Browser.Cfg.CheckAllowed(profileURL); err != nil {
+		s.Log.Error("Refusing to message profile outside safe allowlist", "url", profileURL)
+		return fmt.Errorf("%w: %s", err, profileURL)
+	}
+	exclusions := s.Exclusions()
+	if reason := exclusions.Match(profileURL, "", ""); reason != "" {
+		s.Log.Info("Profile is excluded, not messaging", "url", profileURL, "reason", reason)
+		return fmt.Errorf("%w: %s", storage.ErrExcluded, reason)
+	}
+
+	if err := s.Limiter.Wait(ctx, ratelimit.Message, ratelimit.ProfileView); err != nil {
+		return err
+	}
+	s.waitForGlobalGap()
 
 	s.Log.Info("Visiting profile to message", "url", profileURL)
 	if err := s.Browser.NavigateTo(profileURL); err != nil {
 		return err
 	}
+	s.Limiter.Record(ratelimit.ProfileView)
+	if err := s.Store.RecordVisit(profileURL, string(hooks.ActionMessage)); err != nil {
+		s.Log.Warn("Failed to record visit", "url", profileURL, "error", err)
+	}
 
 	// Wait for load
 	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
 
+	// Company and headline are only known once the profile is open
+	if reason := exclusions.Match(profileURL, personalize.Scrape(s.Browser.Page)["company"], personalize.Headline(s.Browser.Page)); reason != "" {
+		s.Log.Info("Profile is excluded, not messaging", "url", profileURL, "reason", reason)
+		return fmt.Errorf("%w: %s", storage.ErrExcluded, reason)
+	}
+
 	// Check for "Message" button
 	// Primary button usually "Message" for 1st degree connections
-	msgBtn, err := s.Browser.Page.ElementX(`//button[contains(., "Message")]`)
+	msgBtn, err := s.Browser.Find("message_button", 5*time.Second)
 	if err != nil {
 		// Possibly in "More" menu? Or not connected.
 		return fmt.Errorf("message button not found (not connected?): %w", err)
 	}
 
+	// Close leftovers first so the Message click opens the only composer
+	s.closeStrayChats()
+
 	s.Log.Info("Clicking Message button")
-	if err := s.Browser.HumanMove(msgBtn); err != nil {
-		msgBtn.Click(proto.InputMouseButtonLeft, 1)
-	} else {
-		msgBtn.Click(proto.InputMouseButtonLeft, 1)
-	}
+	s.Browser.Click(msgBtn)
 
 	// This usually opens a chat box (overlay) or goes to messaging page
 	// We wait for the chat input area
@@ -123,17 +306,15 @@ func (s *Service) SendFollowUp(profileURL string, template string) error {
 
 	stealth.SleepContextual(stealth.ActionTypeThink, 1.0)
 
+	if err := s.EnsureSingleComposer(); err != nil {
+		s.Log.Warn("Failed to tidy chat overlays", "error", err)
+	}
+
 	// Focus the text box
 	// We look for the active message text box. It is usually an editable div.
-	selector := `div[role="textbox"][aria-label^="Write a message"]`
-	inputBox, err := s.Browser.Page.Element(selector)
+	inputBox, err := s.Browser.Find("message_box", 5*time.Second)
 	if err != nil {
-		// Try generic contenteditable
-		selector = `.msg-form__contenteditable`
-		inputBox, err = s.Browser.Page.Element(selector)
-		if err != nil {
-			return fmt.Errorf("message input box not found: %w", err)
-		}
+		return fmt.Errorf("message input box not found: %w", err)
 	}
 
 	// Check history again (maybe scrape chat content?)
@@ -142,33 +323,77 @@ func (s *Service) SendFollowUp(profileURL string, template string) error {
 	// Prepare Message
 	// Extract basic info for template
 	nameEl, err := s.Browser.Page.Element("h1")
-	name := "there"
+	rawName := ""
 	if err == nil {
-		name = nameEl.MustText()
+		rawName = nameEl.MustText()
+	}
+	// Strip titles/emoji and split out the first name
+	firstName, name := templates.Names(rawName)
+	if firstName == "" {
+		firstName, name = templates.GenericName, templates.GenericName
 	}
-	// Split full name to get first name
-	firstName := strings.Split(name, " ")[0]
 
-	msg := strings.ReplaceAll(template, "{{firstname}}", firstName)
-	msg = strings.ReplaceAll(msg, "{{name}}", name)
+	// Don't barge into a conversation the connection has already joined
+	if !d.ignoreReplies && s.hasReplied(name) {
+		if err := s.Store.MarkReplied(profileURL); err != nil {
+			s.Log.Warn("Failed to record reply", "url", profileURL, "error", err)
+		}
+		if d.stopOnReply || s.Browser.Cfg.Replies.Policy != "template" {
+			s.Log.Info("Connection already replied, skipping follow-up", "url", profileURL)
+			return ErrReplied
+		}
+		s.Log.Info("Connection already replied, using the reply template", "url", profileURL)
+		template = s.Browser.Cfg.Replies.Template
+	}
+
+	vars := personalize.Scrape(s.Browser.Page)
+	for k, v := range d.vars {
+		vars[k] = v
+	}
+	vars["firstname"], vars["name"] = firstName, name
+	msg := templates.Render(template, vars, s.Footer, 0)
+	if s.Browser.Cfg.Template.Sanitize {
+		msg = templates.Sanitize(msg)
+	}
 
 	s.Log.Info("Typing message")
 	if err := s.Browser.HumanType(inputBox, msg); err != nil {
 		return err
 	}
 
+	if d.attachment != "" {
+		if err := s.attach(d.attachment); err != nil {
+			s.discardDraft(inputBox)
+			return err
+		}
+	}
+
 	// Verify content? (skip for now)
 
 	// Send
 	// Usually invalid to just hit Enter in some cases (adds newline), checking for "Send" button is safer.
 	// Button type=submit usually
-	sendBtn, err := s.Browser.Page.Element(`button[type="submit"]`)
-	if err != nil || !sendBtn.MustVisible() {
-		// Try finding by text "Send" within the message form
-		sendBtn, err = s.Browser.Page.ElementX(`//button[contains(., "Send")]`)
-		if err != nil {
-			return errors.New("send message button not found")
-		}
+	sendBtn, _, err := s.Browser.FindWhere("message_send", 5*time.Second, func(btn *rod.Element) bool {
+		visible, _ := btn.Visible()
+		return visible
+	})
+	if err != nil {
+		return errors.New("send message button not found")
+	}
+
+	preview := msg
+	if d.attachment != "" {
+		preview += "\n[attachment: " + filepath.Base(d.attachment) + "]"
+	}
+	if s.Confirm != nil && !s.Confirm(hooks.ActionMessage, profileURL, preview) {
+		s.Log.Info("Message declined by operator", "url", profileURL)
+		s.discardDraft(inputBox)
+		return hooks.ErrDeclined
+	}
+
+	// Some accounts get a brief anti-spam delay where Send is disabled after typing
+	if err := s.waitUntilEnabled(sendBtn); err != nil {
+		return err
 	}
 
 	stealth.SleepContextual(stealth.ActionTypeThink, 0.5)
@@ -178,15 +403,129 @@ func (s *Service) SendFollowUp(profileURL string, template string) error {
 	// Rod Element finding finds first match. If multiple chats open?
 	// We assume we just opened one.
 
-	if err := s.Browser.HumanMove(sendBtn); err != nil {
-		sendBtn.Click(proto.InputMouseButtonLeft, 1)
-	} else {
-		sendBtn.Click(proto.InputMouseButtonLeft, 1)
+	if err := s.Browser.Click(sendBtn); err != nil {
+		return fmt.Errorf("send button: %w", err)
 	}
 
 	// Mark as sent
 	s.Store.SaveMessage(profileURL)
+	s.Limiter.Record(ratelimit.Message)
 	s.Log.Info("Message sent successfully")
 
 	return nil
 }
+
+// hasReplied scans the open conversation thread for a message from the other party,
+// either marked as such or sent under their name
+func (s *Service) hasReplied(name string) bool {
+	// The thread history loads after the composer
+	s.Browser.Page.Timeout(3 * time.Second).Element(".msg-s-message-list, .msg-s-message-list-content")
+
+	if has, _, _ := s.Browser.Page.Has(otherMessageSelector); has {
+		return true
+	}
+	if name == "" || name == templates.GenericName {
+		return false
+	}
+	senders, err := s.Browser.Page.Elements(senderNameSelector)
+	if err != nil {
+		return false
+	}
+	for _, el := range senders {
+		text, err := el.Text()
+		if err == nil && strings.EqualFold(strings.TrimSpace(text), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureSingleComposer closes every chat overlay except the one just opened,
+// so typing can't land in a leftover conversation
+func (s *Service) EnsureSingleComposer() error {
+	if !s.Browser.Cfg.SingleComposer {
+		return nil
+	}
+	closed, err := s.Browser.CloseChatBubbles(true)
+	if closed > 0 {
+		s.Log.Info("Closed stray chat overlays", "count", closed)
+	}
+	return err
+}
+
+// closeStrayChats closes open chat overlays before a new conversation is started
+func (s *Service) closeStrayChats() {
+	if !s.Browser.Cfg.SingleComposer {
+		return
+	}
+	if closed, err := s.Browser.CloseChatBubbles(false); err != nil {
+		s.Log.Debug("Could not inspect chat overlays", "error", err)
+	} else if closed > 0 {
+		s.Log.Info("Closed leftover chat overlays", "count", closed)
+	}
+}
+
+// discardDraft empties the composer so an unsent message doesn't linger as a
+// LinkedIn draft or get prefixed to the next message in this conversation
+func (s *Service) discardDraft(box *rod.Element) {
+	if err := box.Focus(); err != nil {
+		s.Log.Warn("Failed to focus composer to clear draft", "error", err)
+		return
+	}
+	err := s.Browser.Page.KeyActions().
+		Press(input.ControlLeft).Type(input.KeyA).Release(input.ControlLeft).
+		Type(input.Backspace).Do()
+	if err != nil {
+		s.Log.Warn("Failed to clear draft", "error", err)
+	}
+}
+
+// waitForGlobalGap sleeps until min_global_message_gap has passed since the
+// last message to anyone, so cadence holds across restarts and out-of-loop sends
+func (s *Service) waitForGlobalGap() {
+	gap := s.Browser.Cfg.Limits.MinGlobalMessageGap
+	if gap <= 0 {
+		return
+	}
+
+	last := s.Store.LastMessageTime()
+	if last.IsZero() {
+		return
+	}
+
+	if remaining := gap - time.Since(last); remaining > 0 {
+		s.Log.Info("Waiting for global message gap", "remaining", remaining.Round(time.Second))
+		stealth.SleepWithJitter(remaining, 0.1)
+		// Jitter may undershoot, top up so the gap is never violated
+		if left := gap - time.Since(last); left > 0 {
+			time.Sleep(left)
+		}
+	}
+}
+
+// waitUntilEnabled polls the send button until it is enabled or send_enable_timeout elapses
+func (s *Service) waitUntilEnabled(btn *rod.Element) error {
+	timeout := s.Browser.Cfg.SendEnableTimeout
+	deadline := time.Now().Add(timeout)
+	waited := false
+
+	for {
+		disabled, _ := btn.Attribute("disabled")
+		ariaDisabled, _ := btn.Attribute("aria-disabled")
+		if disabled == nil && (ariaDisabled == nil || *ariaDisabled != "true") {
+			if waited {
+				s.Log.Info("Send button enabled")
+			}
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("send button still disabled after %s", timeout)
+		}
+		if !waited {
+			s.Log.Info("Send button disabled, waiting for it to enable", "timeout", timeout)
+			waited = true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
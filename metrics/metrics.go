@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+)
+
+// Usage is how much of a daily limit has been used
+type Usage struct {
+	Used  int
+	Limit int
+}
+
+// Metrics counts the bot's actions and serves them in the Prometheus text format
+type Metrics struct {
+	// Usage reports current daily limit usage keyed by limit name
+	// ("connections", "messages"); it is read on every scrape
+	Usage func() map[string]Usage
+
+	// Skip lists expected outcomes; failures matching one are neither sends nor errors
+	Skip []error
+
+	mu          sync.Mutex
+	connections int
+	messages    int
+	searches    int
+	checkpoints int
+	errors      map[string]int // by action
+}
+
+// New creates an empty Metrics
+func New() *Metrics {
+	return &Metrics{errors: make(map[string]int)}
+}
+
+// Observe is a hooks.ResultHook counting sends, searches and failures
+func (m *Metrics) Observe(r hooks.ActionResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.Outcome == hooks.OutcomeFailed {
+		for _, e := range m.Skip {
+			if errors.Is(r.Error, e) {
+				return
+			}
+		}
+		m.errors[string(r.Action)]++
+		return
+	}
+	switch r.Action {
+	case hooks.ActionConnect:
+		m.connections++
+	case hooks.ActionMessage:
+		m.messages++
+	case hooks.ActionSearch:
+		m.searches++
+	}
+}
+
+// Checkpoint counts a security checkpoint detection
+func (m *Metrics) Checkpoint() {
+	m.mu.Lock()
+	m.checkpoints++
+	m.mu.Unlock()
+}
+
+// Render writes all metrics in the Prometheus text exposition format
+func (m *Metrics) Render(w io.Writer) {
+	m.mu.Lock()
+	counter(w, "linkedin_connections_sent_total", "Connection requests sent.", m.connections)
+	counter(w, "linkedin_messages_sent_total", "Messages sent.", m.messages)
+	counter(w, "linkedin_searches_total", "People searches performed.", m.searches)
+	counter(w, "linkedin_checkpoints_total", "Security checkpoints detected.", m.checkpoints)
+
+	fmt.Fprintln(w, "# HELP linkedin_errors_total Failed actions.")
+	fmt.Fprintln(w, "# TYPE linkedin_errors_total counter")
+	for _, action := range sortedKeys(m.errors) {
+		fmt.Fprintf(w, "linkedin_errors_total{action=%q} %d\n", action, m.errors[action])
+	}
+	m.mu.Unlock()
+
+	if m.Usage == nil {
+		return
+	}
+	usage := m.Usage()
+	names := sortedKeys(usage)
+	fmt.Fprintln(w, "# HELP linkedin_daily_limit_used Actions counted against today's limit.")
+	fmt.Fprintln(w, "# TYPE linkedin_daily_limit_used gauge")
+	for _, name := range names {
+		fmt.Fprintf(w, "linkedin_daily_limit_used{limit=%q} %d\n", name, usage[name].Used)
+	}
+	fmt.Fprintln(w, "# HELP linkedin_daily_limit Today's limit.")
+	fmt.Fprintln(w, "# TYPE linkedin_daily_limit gauge")
+	for _, name := range names {
+		fmt.Fprintf(w, "linkedin_daily_limit{limit=%q} %d\n", name, usage[name].Limit)
+	}
+	fmt.Fprintln(w, "# HELP linkedin_daily_limit_utilization Fraction of today's limit used.")
+	fmt.Fprintln(w, "# TYPE linkedin_daily_limit_utilization gauge")
+	for _, name := range names {
+		u := usage[name]
+		ratio := 0.0
+		if u.Limit > 0 {
+			ratio = float64(u.Used) / float64(u.Limit)
+		}
+		fmt.Fprintf(w, "linkedin_daily_limit_utilization{limit=%q} %g\n", name, ratio)
+	}
+}
+
+// ServeHTTP implements GET /metrics
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.Render(w)
+}
+
+// Serve starts the metrics endpoint in the background
+func (m *Metrics) Serve(addr string, log logger.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+
+	go func() {
+		log.Info("Metrics endpoint listening", "addr", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Error("Metrics endpoint stopped", "error", err)
+		}
+	}()
+}
+
+// counter writes a single unlabelled counter with its help and type lines
+func counter(w io.Writer, name, help string, v int) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
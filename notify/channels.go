@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+)
+
+// telegramAPI is the Bot API base; a channel url points at a self-hosted Bot API server
+const telegramAPI = "https://api.telegram.org"
+
+// postJSON sends body to url and treats any non-2xx answer as an error
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slack posts to an incoming webhook
+type slack struct {
+	url    string
+	client *http.Client
+}
+
+func newSlack(c config.NotifyChannel, client *http.Client) (Channel, error) {
+	if c.URL == "" {
+		return nil, errors.New("url (incoming webhook) is required")
+	}
+	return &slack{url: c.URL, client: client}, nil
+}
+
+func (s *slack) Send(ctx context.Context, e Event) error {
+	return postJSON(ctx, s.client, s.url, map[string]string{"text": e.Text()})
+}
+
+// telegram sends a message through a bot to one chat
+type telegram struct {
+	url    string
+	chatID string
+	client *http.Client
+}
+
+func newTelegram(c config.NotifyChannel, client *http.Client) (Channel, error) {
+	if c.BotToken == "" || c.ChatID == "" {
+		return nil, errors.New("bot_token and chat_id are required")
+	}
+	base := strings.TrimSuffix(c.URL, "/")
+	if base == "" {
+		base = telegramAPI
+	}
+	return &telegram{url: base + "/bot" + c.BotToken + "/sendMessage", chatID: c.ChatID, client: client}, nil
+}
+
+func (t *telegram) Send(ctx context.Context, e Event) error {
+	return postJSON(ctx, t.client, t.url, map[string]string{"chat_id": t.chatID, "text": e.Text()})
+}
+
+// email sends a plain-text mail over SMTP (STARTTLS when the server offers it)
+type email struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func newEmail(c config.NotifyChannel, client *http.Client) (Channel, error) {
+	if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+		return nil, errors.New("smtp_host, from and to are required")
+	}
+	port := c.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	return &email{
+		addr:     net.JoinHostPort(c.SMTPHost, strconv.Itoa(port)),
+		host:     c.SMTPHost,
+		username: c.Username,
+		password: c.Password,
+		from:     c.From,
+		to:       c.To,
+	}, nil
+}
+
+func (m *email) Send(ctx context.Context, e Event) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: [linkedin-bot] %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		m.from, strings.Join(m.to, ", "), e.Title, e.Time.Format(time.RFC1123Z), strings.ReplaceAll(e.Text(), "\n", "\r\n"))
+
+	// smtp.SendMail has no timeout, run it so ctx can give up on a hung server
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(m.addr, auth, m.from, m.to, []byte(msg)) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// webhook posts the event as JSON for custom integrations
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhook(c config.NotifyChannel, client *http.Client) (Channel, error) {
+	if c.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	return &webhook{url: c.URL, client: client}, nil
+}
+
+func (w *webhook) Send(ctx context.Context, e Event) error {
+	return postJSON(ctx, w.client, w.url, map[string]any{
+		"event":  e.Kind,
+		"title":  e.Title,
+		"fields": e.Fields,
+		"time":   e.Time.Format(time.RFC3339),
+	})
+}
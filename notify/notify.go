@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+)
+
+// ErrUnknownChannel is returned for a notify channel type nobody registered
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// Event kinds
+const (
+	RunCompleted = "run_completed"
+	LimitReached = "limit_reached"
+	Checkpoint   = "checkpoint"
+	LoginFailed  = "login_failed"
+	Replies      = "replies"
+	Standing     = "account_standing"
+)
+
+// Kinds are the events channels can subscribe to
+var Kinds = []string{RunCompleted, LimitReached, Checkpoint, LoginFailed, Replies, Standing}
+
+// Event is one alert
+type Event struct {
+	Kind   string
+	Title  string
+	Fields map[string]string
+	Time   time.Time
+}
+
+// Text renders the event as plain text: the title, then one "key: value" line per field
+func (e Event) Text() string {
+	var b strings.Builder
+	b.WriteString(e.Title)
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", k, e.Fields[k])
+	}
+	return b.String()
+}
+
+// Channel delivers events somewhere a human will see them
+type Channel interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// Builder creates a Channel from its config entry
+type Builder func(c config.NotifyChannel, client *http.Client) (Channel, error)
+
+// builders are the channel types by name; Register adds more
+var builders = map[string]Builder{
+	"slack":    newSlack,
+	"telegram": newTelegram,
+	"email":    newEmail,
+	"webhook":  newWebhook,
+}
+
+// Register makes a channel type available to notify.channels
+func Register(kind string, b Builder) {
+	builders[kind] = b
+}
+
+// subscription is a channel with the events it wants
+type subscription struct {
+	kind    string
+	channel Channel
+	events  []string
+}
+
+// Notifier sends events to the configured channels. A nil Notifier does nothing.
+type Notifier struct {
+	Log     logger.Logger
+	Timeout time.Duration
+	// Account, when set, is added to every event so multi-account alerts can be told apart
+	Account string
+
+	subs []subscription
+}
+
+// New builds a Notifier from the notify config section.
+// It returns nil when no channels are configured.
+func New(cfg *config.Config, l logger.Logger) (*Notifier, error) {
+	if len(cfg.Notify.Channels) == 0 {
+		return nil, nil
+	}
+	client := &http.Client{Timeout: cfg.Notify.Timeout}
+	n := &Notifier{Log: l, Timeout: cfg.Notify.Timeout, Account: cfg.Account}
+	for i, c := range cfg.Notify.Channels {
+		build, ok := builders[c.Type]
+		if !ok {
+			return nil, fmt.Errorf("notify.channels[%d]: %w %q", i, ErrUnknownChannel, c.Type)
+		}
+		events := c.Events
+		if len(events) == 0 {
+			events = cfg.Notify.Events
+		}
+		for _, e := range events {
+			if !slices.Contains(Kinds, e) {
+				return nil, fmt.Errorf("notify.channels[%d]: unknown event %q (want one of %s)", i, e, strings.Join(Kinds, ", "))
+			}
+		}
+		ch, err := build(c, client)
+		if err != nil {
+			return nil, fmt.Errorf("notify.channels[%d] (%s): %w", i, c.Type, err)
+		}
+		n.subs = append(n.subs, subscription{kind: c.Type, channel: ch, events: events})
+	}
+	return n, nil
+}
+
+// Notify sends an event of the given kind to every channel subscribed to it.
+// Delivery failures are only logged; alerts must never stop a run.
+func (n *Notifier) Notify(kind, title string, fields map[string]string) {
+	if n == nil {
+		return
+	}
+	if n.Account != "" {
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields["account"] = n.Account
+	}
+	e := Event{Kind: kind, Title: title, Fields: fields, Time: time.Now()}
+	for _, s := range n.subs {
+		if len(s.events) > 0 && !slices.Contains(s.events, kind) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), n.Timeout)
+		err := s.channel.Send(ctx, e)
+		cancel()
+		if err != nil {
+			n.Log.Warn("Notification failed", "channel", s.kind, "event", kind, "error", err)
+			continue
+		}
+		n.Log.Debug("Notification sent", "channel", s.kind, "event", kind)
+	}
+}
@@ -0,0 +1,244 @@
+package observe
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/logger"
+	"linkedin-automation/profile"
+	"linkedin-automation/selectors"
+	"linkedin-automation/stealth"
+)
+
+// Element health in a doctor report
+const (
+	StatusOK       = "ok"
+	StatusFallback = "fallback" // only a later selector of the chain matched
+	StatusBroken   = "broken"
+	StatusAbsent   = "absent"     // not shown, but another element of its group is
+	StatusSkipped  = "not probed" // only shown after an action, which the doctor doesn't take
+)
+
+// Probe is a logical element the workflows need on a page. Elements lists
+// selector chains of which any may match; Selector is used for parts of the
+// page that have no chain. Probes sharing a Group are alternatives (a profile
+// shows Connect, Pending or Message depending on the relationship): the group
+// is broken only when none of them is found.
+type Probe struct {
+	Name     string
+	Elements []string
+	Selector string
+	Group    string
+}
+
+// Unprobed are the elements that only appear once a dialog or composer is
+// opened, so a read-only check can't see them
+var Unprobed = []string{"more_menu_connect", "add_note", "note_textarea", "send_in_modal", "withdraw_confirm", "message_box", "message_send"}
+
+// DoctorPage is a page the doctor visits and the elements it needs there
+type DoctorPage struct {
+	Name   string
+	URL    string
+	Probes []Probe
+}
+
+// ElementHealth is the result of one probe
+type ElementHealth struct {
+	Page     string `json:"page"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Element  string `json:"element,omitempty"`
+	Selector string `json:"selector,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Diagnosis is the doctor's report
+type Diagnosis struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Elements    []ElementHealth `json:"elements"`
+}
+
+// Broken lists the elements no selector resolved, as page/name
+func (d Diagnosis) Broken() []string {
+	var out []string
+	for _, e := range d.Elements {
+		if e.Status == StatusBroken {
+			out = append(out, e.Page+"/"+e.Name)
+		}
+	}
+	return out
+}
+
+// Print writes the report as a table
+func (d Diagnosis) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\n=== Selector health ===\n")
+	fmt.Fprintf(tw, "PAGE\tELEMENT\tSTATUS\tDETAIL\n")
+	for _, e := range d.Elements {
+		detail := e.Selector
+		if e.Element != "" && e.Element != e.Name {
+			detail = e.Element + ": " + detail
+		}
+		if e.Error != "" {
+			detail = e.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Page, e.Name, strings.ToUpper(e.Status), detail)
+	}
+	tw.Flush()
+}
+
+// DoctorPages are the pages every workflow passes through. The profile should
+// be someone you are not connected to, so the connect flow's elements show;
+// empty uses the first search result.
+func DoctorPages(profileURL, searchURL string) []DoctorPage {
+	return []DoctorPage{
+		{
+			Name: "feed",
+			URL:  "https://www.linkedin.com/feed/",
+			Probes: []Probe{
+				{Name: "global_nav", Selector: ".global-nav__content"},
+				{Name: "feed_updates", Selector: "main .feed-shared-update-v2, main [data-urn*='urn:li:activity']"},
+			},
+		},
+		{
+			Name: "search",
+			URL:  searchURL,
+			Probes: []Probe{
+				{Name: "result_cards", Selector: "li.reusable-search__result-container, div.entity-result, [data-chameleon-result-urn]"},
+				{Name: "result_profile_links", Selector: "main a[href*='/in/']"},
+			},
+		},
+		{
+			Name: "profile",
+			URL:  profileURL,
+			Probes: []Probe{
+				{Name: "name_heading", Selector: "main h1"},
+				{Name: "connect_button", Elements: []string{"connect_button"}, Group: "primary_action"},
+				{Name: "pending_button", Elements: []string{"pending_button"}, Group: "primary_action"},
+				{Name: "message_button", Elements: []string{"message_button"}, Group: "primary_action"},
+				{Name: "follow_button", Elements: []string{"follow_button"}, Group: "primary_action"},
+				{Name: "more_actions", Elements: []string{"more_actions"}},
+			},
+		},
+		{
+			Name: "connections",
+			URL:  "https://www.linkedin.com/mynetwork/invite-connect/connections/",
+			Probes: []Probe{
+				{Name: "connection_link", Elements: []string{"connection_link"}},
+			},
+		},
+	}
+}
+
+// Doctor visits each page and reports which logical elements resolve and
+// which are broken. It takes no actions, so the Unprobed elements are listed
+// as not probed.
+func Doctor(b *browser.Browser, log logger.Logger, pages []DoctorPage) Diagnosis {
+	d := Diagnosis{GeneratedAt: time.Now()}
+
+	for _, p := range pages {
+		if p.URL == "" {
+			p.URL = firstProfileLink(b)
+		}
+		log.Info("Checking page", "page", p.Name, "url", p.URL)
+		if p.URL == "" {
+			for _, probe := range p.Probes {
+				d.Elements = append(d.Elements, ElementHealth{Page: p.Name, Name: probe.Name, Status: StatusBroken, Error: "no profile to check, pass one"})
+			}
+			continue
+		}
+		if err := b.NavigateTo(p.URL); err != nil {
+			for _, probe := range p.Probes {
+				d.Elements = append(d.Elements, ElementHealth{Page: p.Name, Name: probe.Name, Status: StatusBroken, Error: "page failed to load: " + err.Error()})
+			}
+			continue
+		}
+		// Let lazy sections render before probing
+		b.Page.Timeout(10 * time.Second).Element("main")
+		stealth.SleepContextual(stealth.ActionTypeRead, 1.5)
+		b.HumanScroll(400)
+
+		results := make([]ElementHealth, len(p.Probes))
+		found := make(map[string]bool)
+		for i, probe := range p.Probes {
+			results[i] = probeElement(b, probe)
+			results[i].Page = p.Name
+			if results[i].Status != StatusBroken && probe.Group != "" {
+				found[probe.Group] = true
+			}
+		}
+		for i, probe := range p.Probes {
+			h := results[i]
+			if h.Status == StatusBroken && found[probe.Group] {
+				h.Status = StatusAbsent
+			}
+			switch h.Status {
+			case StatusBroken:
+				log.Warn("Element broken", "page", p.Name, "element", probe.Name)
+			case StatusFallback:
+				log.Warn("Element only matched a fallback selector", "page", p.Name, "element", probe.Name, "selector", h.Selector)
+			}
+			d.Elements = append(d.Elements, h)
+		}
+	}
+	for _, name := range Unprobed {
+		d.Elements = append(d.Elements, ElementHealth{Page: "actions", Name: name, Status: StatusSkipped, Error: "only shown after an action"})
+	}
+	return d
+}
+
+// probeElement finds the first matching selector of the probe's chains
+func probeElement(b *browser.Browser, p Probe) ElementHealth {
+	h := ElementHealth{Name: p.Name, Status: StatusBroken}
+	if p.Selector != "" {
+		if present(b, p.Selector) {
+			h.Status, h.Selector = StatusOK, p.Selector
+		}
+		return h
+	}
+	for _, name := range p.Elements {
+		for i, sel := range b.Selectors(name) {
+			if !present(b, sel) {
+				continue
+			}
+			h.Status, h.Element, h.Selector = StatusOK, name, sel
+			if i > 0 {
+				h.Status = StatusFallback
+			}
+			return h
+		}
+	}
+	return h
+}
+
+// firstProfileLink returns the first member profile linked from the current page
+func firstProfileLink(b *browser.Browser) string {
+	links, err := b.Page.Elements("main a[href*='/in/']")
+	if err != nil {
+		return ""
+	}
+	for _, link := range links {
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		if p, err := profile.Parse(*href); err == nil {
+			return p.String()
+		}
+	}
+	return ""
+}
+
+func present(b *browser.Browser, sel string) bool {
+	var has bool
+	if selectors.IsXPath(sel) {
+		has, _, _ = b.Page.HasX(sel)
+	} else {
+		has, _, _ = b.Page.Has(sel)
+	}
+	return has
+}
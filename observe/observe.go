@@ -0,0 +1,202 @@
+package observe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/logger"
+	"linkedin-automation/selectors"
+	"linkedin-automation/stealth"
+)
+
+// Check is a named selector the workflows depend on. A check with Element
+// set tests each selector of that element's fallback chain instead.
+type Check struct {
+	Name     string `json:"name"`
+	Selector string `json:"selector"`
+	XPath    bool   `json:"xpath,omitempty"`
+	Element  string `json:"element,omitempty"`
+}
+
+// Target is a page to visit and the selectors expected on it
+type Target struct {
+	Name   string
+	URL    string
+	Checks []Check
+}
+
+// Finding is the result of a single selector check
+type Finding struct {
+	Check
+	Matched bool `json:"matched"`
+	Count   int  `json:"count"`
+}
+
+// PageReport lists which selectors resolved on a page plus heuristic alternatives
+type PageReport struct {
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	Resolved   []Finding `json:"resolved"`
+	Missing    []Finding `json:"missing"`
+	Candidates []string  `json:"candidates,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Report is the full observe-mode output
+type Report struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Pages       []PageReport `json:"pages"`
+}
+
+// DefaultTargets mirrors the selectors used by the search, connect and messaging flows
+func DefaultTargets(profileURL, searchURL string) []Target {
+	return []Target{
+		{
+			Name: "search",
+			URL:  searchURL,
+			Checks: []Check{
+				{Name: "global_nav", Selector: ".global-nav__content"},
+				{Name: "result_profile_links", Selector: "a[href*='/in/']"},
+				{Name: "next_page", Selector: `button[aria-label="Next"]`},
+			},
+		},
+		{
+			Name: "profile",
+			URL:  profileURL,
+			Checks: []Check{
+				{Name: "main", Selector: "main"},
+				{Name: "name_heading", Selector: "h1"},
+				{Name: "distance_badge", Selector: `//main//span[contains(@class, "dist-value")]`, XPath: true},
+				{Name: "primary_action", Selector: `//main//button[contains(@class, "artdeco-button--primary")]`, XPath: true},
+				{Name: "connect_button", Element: "connect_button"},
+				{Name: "more_actions", Element: "more_actions"},
+				{Name: "message_button", Element: "message_button"},
+				{Name: "related_sidebar", Selector: ".pv-browsemap-section a[href*='/in/'], aside a[href*='/in/']"},
+			},
+		},
+		{
+			Name: "connections",
+			URL:  "https://www.linkedin.com/mynetwork/invite-connect/connections/",
+			Checks: []Check{
+				{Name: "connection_link", Element: "connection_link"},
+			},
+		},
+		{
+			Name: "messaging",
+			URL:  "https://www.linkedin.com/messaging/",
+			Checks: []Check{
+				{Name: "message_box", Element: "message_box"},
+				{Name: "message_send", Element: "message_send"},
+			},
+		},
+	}
+}
+
+// Run visits each target and records which selectors resolve
+func Run(b *browser.Browser, log logger.Logger, targets []Target) Report {
+	report := Report{GeneratedAt: time.Now()}
+
+	for _, t := range targets {
+		log.Info("Observing page", "page", t.Name, "url", t.URL)
+		pr := PageReport{Name: t.Name, URL: t.URL}
+
+		if err := b.NavigateTo(t.URL); err != nil {
+			pr.Error = err.Error()
+			report.Pages = append(report.Pages, pr)
+			continue
+		}
+		stealth.SleepContextual(stealth.ActionTypeRead, 1.5)
+		b.HumanScroll(400)
+
+		for _, c := range expand(b, t.Checks) {
+			f := Finding{Check: c}
+			if c.XPath {
+				if els, err := b.Page.ElementsX(c.Selector); err == nil {
+					f.Count = len(els)
+				}
+			} else {
+				if els, err := b.Page.Elements(c.Selector); err == nil {
+					f.Count = len(els)
+				}
+			}
+			f.Matched = f.Count > 0
+			if f.Matched {
+				pr.Resolved = append(pr.Resolved, f)
+			} else {
+				pr.Missing = append(pr.Missing, f)
+			}
+		}
+
+		if len(pr.Missing) > 0 {
+			pr.Candidates = candidateButtons(b)
+		}
+
+		log.Info("Page observed", "page", t.Name, "resolved", len(pr.Resolved), "missing", len(pr.Missing))
+		report.Pages = append(report.Pages, pr)
+	}
+
+	return report
+}
+
+// expand replaces element checks with one check per selector of the chain
+func expand(b *browser.Browser, checks []Check) []Check {
+	var out []Check
+	for _, c := range checks {
+		if c.Element == "" {
+			out = append(out, c)
+			continue
+		}
+		for i, sel := range b.Selectors(c.Element) {
+			out = append(out, Check{
+				Name:     fmt.Sprintf("%s[%d]", c.Name, i),
+				Selector: sel,
+				XPath:    selectors.IsXPath(sel),
+				Element:  c.Element,
+			})
+		}
+	}
+	return out
+}
+
+// candidateButtons lists the labels of visible buttons so missing selectors
+// can be remapped by hand
+func candidateButtons(b *browser.Browser) []string {
+	els, err := b.Page.Elements("main button, [role='dialog'] button")
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var out []string
+	for _, el := range els {
+		if vis, _ := el.Visible(); !vis {
+			continue
+		}
+		label := ""
+		if aria, err := el.Attribute("aria-label"); err == nil && aria != nil {
+			label = *aria
+		} else if text, err := el.Text(); err == nil {
+			label = text
+		}
+		label = strings.Join(strings.Fields(label), " ")
+		if label == "" || len(label) > 80 || seen[label] {
+			continue
+		}
+		seen[label] = true
+		out = append(out, label)
+	}
+	return out
+}
+
+// WriteReport saves a report or diagnosis as indented JSON
+func WriteReport(r any, path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
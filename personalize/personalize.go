@@ -0,0 +1,192 @@
+package personalize
+
+import (
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/go-rod/rod"
+)
+
+// Fields are the variables scraped from a profile page
+var Fields = []string{"company", "title", "location", "mutual", "school"}
+
+// Fallbacks replace a field that couldn't be scraped, so a note never reads
+// "I see you work at ." Use {{if has "company"}} to leave a sentence out instead.
+var Fallbacks = map[string]string{
+	"company":  "your company",
+	"title":    "your role",
+	"location": "your area",
+	"mutual":   "a few",
+	"school":   "your school",
+}
+
+// Top card parts of a profile page; the aria-label buttons read
+// "Current company: Acme Corp. Click to skip to experience card" and
+// "Education: MIT. Click to skip to education card"
+const (
+	headlineSelector = "main .text-body-medium.break-words, main [data-generated-suggestion-target]"
+	locationSelector = "main .text-body-small.inline.t-black--light.break-words"
+	companySelector  = `main button[aria-label^="Current company"], main a[aria-label^="Current company"]`
+	schoolSelector   = `main button[aria-label^="Education"], main a[aria-label^="Education"]`
+	mutualSelector   = `main a[href*="facetNetwork"], main a[href*="mutual"]`
+)
+
+// aboutXPath is the text of the About card, anchored by its #about div
+const aboutXPath = `//section[.//div[@id="about"]]//div[contains(@class, "inline-show-more-text")]//span[@aria-hidden="true"]`
+
+// maxAboutLength caps the about text handed on, it can run to 2,600 characters
+const maxAboutLength = 1000
+
+// atMarker separates title and company in headlines: "Engineer at Acme"
+const atMarker = " at "
+
+var mutualPattern = regexp.MustCompile(`(\d+)\s+(?:other\s+)?mutual connection`)
+
+// identifierPattern matches the names text/template accepts as functions
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Execute renders text as a Go text/template in which every allowed variable
+// is a function, so the {{name}} placeholders keep working alongside
+// conditionals such as {{if has "company"}}...{{end}}. Variables whose names
+// aren't identifiers (an "e-mail" CSV column) are read as {{index . "e-mail"}}.
+// Variables in allowed but missing from vars render as their fallback.
+func Execute(text string, vars map[string]string, allowed []string) (string, error) {
+	t, err := parse(text, vars, allowed)
+	if err != nil {
+		return "", err
+	}
+	data := make(map[string]string, len(allowed))
+	for _, name := range allowed {
+		data[name] = value(vars, name)()
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Check reports syntax errors and placeholders outside allowed
+func Check(text string, allowed []string) error {
+	_, err := parse(text, nil, allowed)
+	return err
+}
+
+func parse(text string, vars map[string]string, allowed []string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"has": func(name string) bool { return vars[name] != "" },
+	}
+	for _, name := range allowed {
+		// Funcs panics on a name that isn't an identifier
+		if identifierPattern.MatchString(name) && name != "has" {
+			funcs[name] = value(vars, name)
+		}
+	}
+	return template.New("template").Funcs(funcs).Parse(text)
+}
+
+// value returns the template function for one variable
+func value(vars map[string]string, name string) func() string {
+	return func() string {
+		if v := vars[name]; v != "" {
+			return v
+		}
+		return Fallbacks[name]
+	}
+}
+
+// Scrape reads the personalization fields from the profile page currently open.
+// Fields that can't be found are left out. It doesn't wait for elements.
+func Scrape(page *rod.Page) map[string]string {
+	vars := make(map[string]string)
+	headline := text(page, headlineSelector)
+	if headline != "" {
+		vars["title"] = headline
+		if i := strings.LastIndex(headline, atMarker); i >= 0 {
+			vars["title"] = strings.TrimSpace(headline[:i])
+			vars["company"] = trimTail(headline[i+len(atMarker):])
+		}
+	}
+	if company := ariaValue(page, companySelector); company != "" {
+		vars["company"] = company
+	}
+	if school := ariaValue(page, schoolSelector); school != "" {
+		vars["school"] = school
+	}
+	if location := text(page, locationSelector); location != "" {
+		vars["location"] = location
+	}
+	if m := mutualPattern.FindStringSubmatch(text(page, mutualSelector)); m != nil {
+		vars["mutual"] = m[1]
+	}
+
+	for k, v := range vars {
+		if v == "" {
+			delete(vars, k)
+		}
+	}
+	return vars
+}
+
+// Headline returns the headline under the name on the profile page currently open
+func Headline(page *rod.Page) string {
+	return text(page, headlineSelector)
+}
+
+// About returns the About section of the profile page currently open, shortened
+// to maxAboutLength. It is empty when the profile has none.
+func About(page *rod.Page) string {
+	els, err := page.ElementsX(aboutXPath)
+	if err != nil || len(els) == 0 {
+		return ""
+	}
+	t, err := els.First().Text()
+	if err != nil {
+		return ""
+	}
+	about := []rune(strings.Join(strings.Fields(t), " "))
+	if len(about) > maxAboutLength {
+		about = about[:maxAboutLength]
+	}
+	return string(about)
+}
+
+// text returns the whitespace-normalised text of the first element matching selector
+func text(page *rod.Page, selector string) string {
+	els, err := page.Elements(selector)
+	if err != nil || len(els) == 0 {
+		return ""
+	}
+	t, err := els.First().Text()
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(t), " ")
+}
+
+// ariaValue returns the "Label: value. Click ..." value of the first element matching selector
+func ariaValue(page *rod.Page, selector string) string {
+	els, err := page.Elements(selector)
+	if err != nil || len(els) == 0 {
+		return ""
+	}
+	aria, err := els.First().Attribute("aria-label")
+	if err != nil || aria == nil {
+		return ""
+	}
+	_, rest, ok := strings.Cut(*aria, ":")
+	if !ok {
+		return ""
+	}
+	value, _, _ := strings.Cut(rest, ". Click")
+	return strings.TrimSpace(value)
+}
+
+// trimTail cuts what headlines add after the company: "Acme | Speaker"
+func trimTail(s string) string {
+	if i := strings.IndexAny(s, "|·•,"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,34 @@
+package personalize
+
+import "testing"
+
+func TestExecuteNonIdentifierVariables(t *testing.T) {
+	allowed := []string{"firstname", "company", "e-mail", "first.name", "2nd"}
+	vars := map[string]string{"firstname": "Ada", "e-mail": "ada@example.com", "first.name": "Ada L."}
+
+	tests := []struct {
+		text, want string
+	}{
+		{"Hi {{firstname}}", "Hi Ada"},
+		{"At {{company}}", "At your company"},
+		{`Mail {{index . "e-mail"}}`, "Mail ada@example.com"},
+		{`{{if has "first.name"}}{{index . "first.name"}}{{end}}`, "Ada L."},
+		{`[{{index . "2nd"}}]`, "[]"},
+	}
+	for _, tt := range tests {
+		got, err := Execute(tt.text, vars, allowed)
+		if err != nil {
+			t.Errorf("Execute(%q): %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Execute(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCheckNonIdentifierVariables(t *testing.T) {
+	if err := Check(`Hi {{index . "e-mail"}}`, []string{"e-mail", "first.name"}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package preflight
+
+import (
+	"strings"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/stealth"
+)
+
+// Standing is the account state detected before any outreach
+type Standing int
+
+const (
+	OK Standing = iota
+	Warned
+	Restricted
+)
+
+func (s Standing) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Warned:
+		return "warned"
+	case Restricted:
+		return "restricted"
+	}
+	return "unknown"
+}
+
+// feedURL is where standing is checked; restricted accounts are redirected away from it
+const feedURL = "https://www.linkedin.com/feed/"
+
+// navSelector is the primary nav, missing on restricted or interstitial pages
+const navSelector = ".global-nav__content"
+
+// restrictedPhrases appear on the account restriction interstitial
+var restrictedPhrases = []string{
+	"your account has been restricted",
+	"your account is restricted",
+	"temporarily restricted",
+	"account has been temporarily limited",
+}
+
+// warningPhrases appear in banners and dialogs shown to accounts close to a restriction
+var warningPhrases = []string{
+	"unusual activity",
+	"weekly invitation limit",
+	"reached the weekly limit",
+	"we've noticed some",
+	"please verify your account",
+}
+
+// CheckAccountStanding visits the feed and looks for restriction pages,
+// warning banners or a missing primary nav. The reason describes what was found.
+func CheckAccountStanding(b *browser.Browser) (Standing, string, error) {
+	if err := b.NavigateTo(feedURL); err != nil {
+		return OK, "", err
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	if info, err := b.Page.Info(); err == nil {
+		if strings.Contains(info.URL, "/checkpoint/") || strings.Contains(info.URL, "restricted") {
+			return Restricted, "redirected to " + info.URL, nil
+		}
+	}
+
+	text := ""
+	if body, err := b.Page.Element("body"); err == nil {
+		if t, err := body.Text(); err == nil {
+			text = strings.ToLower(t)
+		}
+	}
+	// Normalise curly apostrophes so phrases match either form
+	text = strings.ReplaceAll(text, "’", "'")
+
+	for _, p := range restrictedPhrases {
+		if strings.Contains(text, p) {
+			return Restricted, "page says \"" + p + "\"", nil
+		}
+	}
+
+	if _, err := b.Page.Timeout(15 * time.Second).Element(navSelector); err != nil {
+		return Warned, "primary navigation missing", nil
+	}
+
+	for _, p := range warningPhrases {
+		if strings.Contains(text, p) {
+			return Warned, "page says \"" + p + "\"", nil
+		}
+	}
+	return OK, "", nil
+}
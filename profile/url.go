@@ -0,0 +1,71 @@
+package profile
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrNotAProfile is returned when a URL is not a LinkedIn member profile
+var ErrNotAProfile = errors.New("not a linkedin profile URL")
+
+// baseURL is the canonical scheme and host for profile URLs
+const baseURL = "https://www.linkedin.com"
+
+// URL is a parsed LinkedIn member profile URL (linkedin.com/in/<public-id>)
+type URL struct {
+	// PublicID is the vanity identifier after /in/, e.g. "jane-doe-1a2b3c"
+	PublicID string
+}
+
+// Parse validates an absolute or relative profile href and extracts its public identifier.
+// The scheme may be left out ("linkedin.com/in/jane-doe"). Company pages, posts,
+// mini-profiles and other non-/in/ links are rejected.
+func Parse(raw string) (URL, error) {
+	raw = strings.TrimSpace(raw)
+	// Without a scheme the host would be read as the first path segment
+	if first, _, _ := strings.Cut(raw, "/"); !strings.Contains(raw, "://") && strings.Contains(first, ".") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return URL{}, ErrNotAProfile
+	}
+
+	// Relative hrefs are resolved against linkedin.com, absolute ones must point there
+	if u.Host != "" {
+		host := strings.ToLower(u.Hostname())
+		if host != "linkedin.com" && !strings.HasSuffix(host, ".linkedin.com") {
+			return URL{}, ErrNotAProfile
+		}
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "in" || segments[1] == "" {
+		return URL{}, ErrNotAProfile
+	}
+	// Sub-pages like /in/<id>/recent-activity/ still identify the same profile
+
+	return URL{PublicID: segments[1]}, nil
+}
+
+// String returns the canonical form, e.g. https://www.linkedin.com/in/jane-doe
+func (p URL) String() string {
+	return baseURL + "/in/" + p.PublicID
+}
+
+// IsProfile reports whether raw is a valid profile URL
+func IsProfile(raw string) bool {
+	_, err := Parse(raw)
+	return err == nil
+}
+
+// Canonical returns the canonical form of raw, or raw unchanged if it is not a profile URL.
+// Use it to normalise keys so the same person is never stored twice.
+func Canonical(raw string) string {
+	p, err := Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return p.String()
+}
@@ -0,0 +1,83 @@
+package profile
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"canonical", "https://www.linkedin.com/in/jane-doe", "jane-doe"},
+		{"trailing slash", "https://www.linkedin.com/in/jane-doe/", "jane-doe"},
+		{"query string", "https://www.linkedin.com/in/jane-doe/?miniProfileUrn=urn%3Ali%3Afs_miniProfile", "jane-doe"},
+		{"fragment", "https://www.linkedin.com/in/jane-doe#experience", "jane-doe"},
+		{"no www", "https://linkedin.com/in/jane-doe", "jane-doe"},
+		{"http", "http://www.linkedin.com/in/jane-doe", "jane-doe"},
+		{"locale subdomain", "https://de.linkedin.com/in/jane-doe/", "jane-doe"},
+		{"upper case host", "https://WWW.LinkedIn.com/in/jane-doe", "jane-doe"},
+		{"port", "https://www.linkedin.com:443/in/jane-doe", "jane-doe"},
+		{"sub-page", "https://www.linkedin.com/in/jane-doe/recent-activity/all/", "jane-doe"},
+		{"relative", "/in/jane-doe/", "jane-doe"},
+		{"protocol relative", "//www.linkedin.com/in/jane-doe", "jane-doe"},
+		{"missing scheme", "linkedin.com/in/jane-doe", "jane-doe"},
+		{"missing scheme with www", "www.linkedin.com/in/jane-doe/?trk=public", "jane-doe"},
+		{"missing scheme locale", "fr.linkedin.com/in/jane-doe", "jane-doe"},
+		{"surrounding space", "  https://www.linkedin.com/in/jane-doe \n", "jane-doe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.raw, err)
+			}
+			if got.PublicID != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.raw, got.PublicID, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"company page", "https://www.linkedin.com/company/acme/"},
+		{"post", "https://www.linkedin.com/feed/update/urn:li:activity:123/"},
+		{"no public id", "https://www.linkedin.com/in/"},
+		{"other host", "https://example.com/in/jane-doe"},
+		{"lookalike host", "https://notlinkedin.com/in/jane-doe"},
+		{"other host without scheme", "example.com/in/jane-doe"},
+		{"plain name", "jane-doe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.raw); !errors.Is(err, ErrNotAProfile) {
+				t.Errorf("Parse(%q) error = %v, want ErrNotAProfile", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://www.linkedin.com/in/jane-doe/", "https://www.linkedin.com/in/jane-doe"},
+		{"linkedin.com/in/jane-doe", "https://www.linkedin.com/in/jane-doe"},
+		{"https://uk.linkedin.com/in/jane-doe?trk=people-guest", "https://www.linkedin.com/in/jane-doe"},
+		{"/in/jane-doe/details/experience/", "https://www.linkedin.com/in/jane-doe"},
+		{"https://www.linkedin.com/company/acme/", "https://www.linkedin.com/company/acme/"},
+	}
+	for _, tt := range tests {
+		if got := Canonical(tt.raw); got != tt.want {
+			t.Errorf("Canonical(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
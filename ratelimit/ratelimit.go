@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/logger"
+	"linkedin-automation/storage"
+)
+
+// ErrLimitReached is returned when an action type's hourly, daily or weekly budget is used up
+var ErrLimitReached = errors.New("rate limit reached")
+
+// Action types with their own budgets
+const (
+	Connect     = "connect"
+	Message     = "message"
+	ProfileView = "profile_view"
+)
+
+// Limiter enforces the rate_limits budgets. Services consult it before acting
+// and record each action afterwards. A nil Limiter allows everything.
+type Limiter struct {
+	Store storage.DataStore
+	Log   logger.Logger
+
+	budgets map[string]config.RateLimit
+}
+
+// New builds a Limiter from the config. Connect and message budgets without
+// Daily/Weekly take them from the limits section.
+func New(cfg *config.Config, store storage.DataStore, l logger.Logger) *Limiter {
+	connect := cfg.RateLimits.Connect
+	if connect.Daily == 0 {
+		connect.Daily = cfg.Limits.DailyConnections
+	}
+	if connect.Weekly == 0 {
+		connect.Weekly = cfg.Limits.WeeklyConnections
+	}
+	message := cfg.RateLimits.Message
+	if message.Daily == 0 {
+		message.Daily = cfg.Limits.DailyMessages
+	}
+
+	return &Limiter{
+		Store: store,
+		Log:   l,
+		budgets: map[string]config.RateLimit{
+			Connect:     connect,
+			Message:     message,
+			ProfileView: cfg.RateLimits.ProfileView,
+		},
+	}
+}
+
+// Budget returns the effective budget of an action type
+func (l *Limiter) Budget(action string) config.RateLimit {
+	if l == nil {
+		return config.RateLimit{}
+	}
+	return l.budgets[action]
+}
+
+// Check returns ErrLimitReached when any of the actions has no budget left
+func (l *Limiter) Check(actions ...string) error {
+	if l == nil {
+		return nil
+	}
+	now := time.Now()
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	for _, a := range actions {
+		b := l.budgets[a]
+		windows := []struct {
+			name  string
+			limit int
+			since time.Time
+		}{
+			{"in the last hour", b.Hourly, now.Add(-time.Hour)},
+			{"today", b.Daily, today},
+			{"in the last 7 days", b.Weekly, now.Add(-7 * 24 * time.Hour)},
+		}
+		for _, w := range windows {
+			if w.limit <= 0 {
+				continue
+			}
+			if n := l.Store.ActionsSince(a, w.since); n >= w.limit {
+				return fmt.Errorf("%w: %s %d/%d %s", ErrLimitReached, a, n, w.limit, w.name)
+			}
+		}
+	}
+	return nil
+}
+
+// Wait checks the budgets, then sleeps until every action's pacing gap since
+// its last occurrence has passed. It returns early with ctx's error.
+func (l *Limiter) Wait(ctx context.Context, actions ...string) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.Check(actions...); err != nil {
+		return err
+	}
+
+	var until time.Time
+	var slowest string
+	for _, a := range actions {
+		gap := l.gap(a)
+		last := l.Store.LastAction(a)
+		if gap <= 0 || last.IsZero() {
+			continue
+		}
+		// Up to a quarter extra so paced actions don't land on a fixed beat
+		next := last.Add(gap + time.Duration(rand.Int63n(int64(gap)/4+1)))
+		if next.After(until) {
+			until, slowest = next, a
+		}
+	}
+
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		return nil
+	}
+	l.Log.Info("Pacing action", "action", slowest, "wait", remaining.Round(time.Second))
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Record counts one action against its budgets
+func (l *Limiter) Record(action string) {
+	if l == nil {
+		return
+	}
+	if err := l.Store.RecordAction(action); err != nil {
+		l.Log.Warn("Failed to record action for rate limits", "action", action, "error", err)
+	}
+}
+
+// gap is the pacing between two actions: MinGap, or Spread/Daily when larger
+func (l *Limiter) gap(action string) time.Duration {
+	b := l.budgets[action]
+	gap := b.MinGap
+	if b.Spread > 0 && b.Daily > 0 {
+		if spread := b.Spread / time.Duration(b.Daily); spread > gap {
+			gap = spread
+		}
+	}
+	return gap
+}
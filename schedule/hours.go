@@ -0,0 +1,163 @@
+package schedule
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidHours is returned for business hours ParseHours can't read
+var ErrInvalidHours = errors.New("invalid business hours")
+
+// Hours is when an account is at work: From to To (time since midnight) in
+// Location, on the allowed weekdays, except holidays
+type Hours struct {
+	Location *time.Location
+	From, To time.Duration
+
+	days     uint64 // bit i set = weekday i allowed
+	holidays map[string]bool
+}
+
+// DefaultHours is from to to o'clock on every day, in the machine's timezone
+func DefaultHours(from, to int) *Hours {
+	return &Hours{
+		Location: time.Local,
+		From:     time.Duration(from) * time.Hour,
+		To:       time.Duration(to) * time.Hour,
+		days:     1<<7 - 1,
+	}
+}
+
+// ParseHours reads business hours: an IANA timezone ("" = the machine's),
+// "HH:MM" from and to, a cron day-of-week field such as "mon-fri" ("" or *
+// = every day) and holidays as "2006-01-02" dates
+func ParseHours(timezone, from, to, weekdays string, holidays []string) (*Hours, error) {
+	h := &Hours{Location: time.Local, holidays: make(map[string]bool)}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: timezone %q: %v", ErrInvalidHours, timezone, err)
+		}
+		h.Location = loc
+	}
+
+	var err error
+	if h.From, err = clock(from); err != nil {
+		return nil, err
+	}
+	if h.To, err = clock(to); err != nil {
+		return nil, err
+	}
+	if h.To <= h.From {
+		return nil, fmt.Errorf("%w: %s-%s ends before it starts", ErrInvalidHours, from, to)
+	}
+
+	if weekdays == "" {
+		weekdays = "*"
+	}
+	if h.days, err = parseField(weekdays, dowField); err != nil {
+		return nil, fmt.Errorf("%w: weekdays %q: %v", ErrInvalidHours, weekdays, err)
+	}
+	if h.days&(1<<7) != 0 {
+		h.days |= 1
+	}
+
+	for _, d := range holidays {
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			return nil, fmt.Errorf("%w: holiday %q: want YYYY-MM-DD", ErrInvalidHours, d)
+		}
+		h.holidays[d] = true
+	}
+	return h, nil
+}
+
+// LoadHolidays reads holiday dates from a file: one YYYY-MM-DD per line
+// (# starts a comment), or an iCalendar export whose all-day events'
+// DTSTART dates are taken
+func LoadHolidays(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var dates []string
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "DTSTART") {
+			_, v, _ := strings.Cut(line, ":")
+			if t, err := time.Parse("20060102", strings.TrimSpace(v)); err == nil {
+				dates = append(dates, t.Format(time.DateOnly))
+			}
+			continue
+		}
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.Contains(line, ":") {
+			// Blank, or another iCalendar property
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %q is not a YYYY-MM-DD date", path, n, line)
+		}
+		dates = append(dates, line)
+	}
+	return dates, sc.Err()
+}
+
+// Active reports whether t falls within the hours
+func (h *Hours) Active(t time.Time) bool {
+	t = t.In(h.Location)
+	if !h.WorkDay(t) {
+		return false
+	}
+	// Wall-clock time, so a DST change doesn't shift the hours
+	since := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	return since >= h.From && since < h.To
+}
+
+// WorkDay reports whether t's date (in the hours' timezone) is a working day
+func (h *Hours) WorkDay(t time.Time) bool {
+	t = t.In(h.Location)
+	return h.days&(1<<uint(t.Weekday())) != 0 && !h.holidays[t.Format(time.DateOnly)]
+}
+
+// NextStart returns when the hours next begin after t, or t itself while
+// they are on. It returns the zero time when no day within a year is a
+// working day.
+func (h *Hours) NextStart(t time.Time) time.Time {
+	if h.Active(t) {
+		return t
+	}
+	local := t.In(h.Location)
+	from := int(h.From / time.Minute)
+	for i := 0; i <= 366; i++ {
+		start := time.Date(local.Year(), local.Month(), local.Day()+i, from/60, from%60, 0, 0, h.Location)
+		if start.After(t) && h.WorkDay(start) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// String describes the hours, e.g. "09:00-17:30 Europe/Berlin"
+func (h *Hours) String() string {
+	hm := func(d time.Duration) string { return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60) }
+	return hm(h.From) + "-" + hm(h.To) + " " + h.Location.String()
+}
+
+// clock reads "HH:MM" as the time since midnight
+func clock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not HH:MM", ErrInvalidHours, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/hooks"
+	"linkedin-automation/stealth"
+)
+
+// ErrNotACompany is returned for a URL that isn't a LinkedIn company page
+var ErrNotACompany = errors.New("not a LinkedIn company URL")
+
+// companyPattern matches the slug or id of linkedin.com/company/<slug>/ URLs
+var companyPattern = regexp.MustCompile(`linkedin\.com/company/([^/?#]+)`)
+
+// Company "People" tab parts. Like group members the list grows as it is scrolled.
+const (
+	employeeCardSelector = "li.org-people-profile-card__profile-card-spacing, .org-people-profile-card"
+	employeeNameSelector = ".org-people-profile-card__profile-title, .artdeco-entity-lockup__title"
+	employeeRoleSelector = ".artdeco-entity-lockup__subtitle"
+	employeeDegSelector  = ".artdeco-entity-lockup__degree"
+	companyNameSelector  = "h1"
+)
+
+// CompanyFilters narrow a company's People tab. Title is matched as keywords;
+// Location is a name, id or URN like Criteria.Location.
+type CompanyFilters struct {
+	Title    string
+	Location string
+}
+
+// CompanyPeopleURL returns the People tab URL of a company URL with filters applied.
+// A location without a known geo id is added to the keywords.
+func CompanyPeopleURL(companyURL string, f CompanyFilters) (string, error) {
+	m := companyPattern.FindStringSubmatch(companyURL)
+	if m == nil {
+		return "", fmt.Errorf("%w: %s", ErrNotACompany, companyURL)
+	}
+	params := url.Values{}
+	keywords := f.Title
+	if geo, ok := ResolveGeo(f.Location); ok {
+		params.Set("facetGeoRegion", geo)
+	} else if f.Location != "" {
+		keywords = strings.TrimSpace(keywords + " " + f.Location)
+	}
+	if keywords != "" {
+		params.Set("keywords", keywords)
+	}
+	peopleURL := "https://www.linkedin.com/company/" + m[1] + "/people/"
+	if len(params) > 0 {
+		peopleURL += "?" + params.Encode()
+	}
+	return peopleURL, nil
+}
+
+// CompanyEmployees lists the people of a company's People tab matching
+// filters, loading the list up to maxPages times. Each profile's Headline is
+// their role and Company the company's name.
+func (s *Service) CompanyEmployees(ctx context.Context, companyURL string, filters CompanyFilters, maxPages int) ([]Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	peopleURL, err := CompanyPeopleURL(companyURL, filters)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ResolveGeo(filters.Location); !ok && filters.Location != "" {
+		s.Log.Warn("Unknown location, searching it as a keyword (add it to search.geo_urns)", "location", filters.Location)
+	}
+	results, err := s.companyEmployees(ctx, peopleURL, maxPages)
+
+	herr := err
+	if errors.Is(err, ErrNoResults) {
+		herr = nil
+	}
+	result := hooks.NewResult(peopleURL, hooks.ActionSearch, herr)
+	result.Metadata["found"] = fmt.Sprint(len(results))
+	s.OnResult(result)
+	return results, err
+}
+
+func (s *Service) companyEmployees(ctx context.Context, peopleURL string, maxPages int) ([]Profile, error) {
+	s.Log.Info("Navigating to company people", "url", peopleURL)
+	if err := s.Browser.NavigateTo(peopleURL); err != nil {
+		return nil, fmt.Errorf("failed to navigate to company people: %w", err)
+	}
+	company := ""
+	if el, err := s.Browser.Page.Timeout(10 * time.Second).Element(companyNameSelector); err == nil {
+		if text, err := el.Text(); err == nil {
+			company = strings.TrimSpace(text)
+		}
+	}
+	if _, err := s.Browser.Page.Timeout(20 * time.Second).Element(employeeCardSelector); err != nil {
+		if has, _, _ := s.Browser.Page.HasX(noResultsSelector); has {
+			return nil, ErrNoResults
+		}
+		return nil, fmt.Errorf("company people list not found: %w", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	seen := make(map[string]bool)
+	var results []Profile
+	scraped := 0
+	for page := 1; page <= maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			s.Log.Info("Company people scrape interrupted", "loads", page-1, "profiles", len(results))
+			return results, err
+		}
+
+		cards, err := s.Browser.Page.Elements(employeeCardSelector)
+		if err != nil {
+			break
+		}
+		if scraped > len(cards) {
+			scraped = 0
+		}
+		for _, card := range cards[scraped:] {
+			// Out-of-network employees show as "LinkedIn Member" without a profile link
+			p, ok := scrapeCard(card)
+			if !ok || seen[p.URL] {
+				continue
+			}
+			p.Name = cardText(card, employeeNameSelector)
+			p.Headline = cardText(card, employeeRoleSelector)
+			p.Degree = parseDegree(cardText(card, employeeDegSelector))
+			p.Company = company
+			if p.Company == "" {
+				p.Company = CompanyFromHeadline(p.Headline)
+			}
+			seen[p.URL] = true
+			results = append(results, p)
+			s.Log.Debug("Found employee", "url", p.URL, "name", p.Name, "role", p.Headline)
+		}
+		s.Log.Info("Employees found", "company", company, "total_unique", len(results))
+		if page == maxPages {
+			break
+		}
+
+		if !s.loadMoreCards(employeeCardSelector, len(cards)) {
+			s.Log.Info("End of company people list")
+			break
+		}
+		scraped = len(cards)
+	}
+	return results, nil
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/hooks"
+	"linkedin-automation/stealth"
+)
+
+// ErrNotAnEvent is returned for a URL that isn't a LinkedIn event
+var ErrNotAnEvent = errors.New("not a LinkedIn event URL")
+
+// eventPattern matches the numeric id of linkedin.com/events/<id>/ and
+// linkedin.com/events/<slug>-<id>/ URLs
+var eventPattern = regexp.MustCompile(`linkedin\.com/events/(?:[^/?#]*-)?(\d{6,})`)
+
+// Event page parts: its title and the "attendees" link into people search
+const (
+	eventNameSelector      = "h1"
+	eventAttendeesSelector = "a[href*='eventAttending']"
+)
+
+// EventAttendeesURL returns the people search of an event's attendees
+func EventAttendeesURL(eventURL string) (string, error) {
+	m := eventPattern.FindStringSubmatch(eventURL)
+	if m == nil {
+		return "", fmt.Errorf("%w: %s", ErrNotAnEvent, eventURL)
+	}
+	return "https://www.linkedin.com/search/results/people/?eventAttending=" + url.QueryEscape(`["`+m[1]+`"]`) + "&origin=EVENT_PAGE_CANONICAL", nil
+}
+
+// EventAttendees opens an event and scrapes up to maxPages pages of its
+// attendee list. Each profile's Event is the event's title, for {{event}}.
+func (s *Service) EventAttendees(ctx context.Context, eventURL string, maxPages int) ([]Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	attendeesURL, err := EventAttendeesURL(eventURL)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.eventAttendees(ctx, eventURL, attendeesURL, maxPages)
+
+	result := hooks.NewResult(attendeesURL, hooks.ActionSearch, err)
+	result.Metadata["found"] = fmt.Sprint(len(results))
+	s.OnResult(result)
+	return results, err
+}
+
+func (s *Service) eventAttendees(ctx context.Context, eventURL, attendeesURL string, maxPages int) ([]Profile, error) {
+	s.Log.Info("Opening event", "url", eventURL)
+	if err := s.Browser.NavigateTo(eventURL); err != nil {
+		return nil, fmt.Errorf("failed to navigate to event: %w", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+	name := ""
+	if el, err := s.Browser.Page.Timeout(10 * time.Second).Element(eventNameSelector); err == nil {
+		if text, err := el.Text(); err == nil {
+			name = strings.TrimSpace(text)
+		}
+	}
+	if name == "" {
+		s.Log.Warn("Event title not found, {{event}} will be empty", "url", eventURL)
+	}
+
+	// Follow the page's own attendees link when it shows one
+	clicked := false
+	if link, err := s.Browser.Page.Timeout(3 * time.Second).Element(eventAttendeesSelector); err == nil {
+		if err := s.Browser.HumanClick(link); err == nil {
+			s.Browser.Page.Timeout(15 * time.Second).WaitLoad()
+			clicked = true
+		}
+	}
+	if !clicked {
+		s.Log.Info("Navigating to event attendees", "url", attendeesURL)
+		if err := s.Browser.NavigateTo(attendeesURL); err != nil {
+			return nil, fmt.Errorf("failed to navigate to event attendees: %w", err)
+		}
+	}
+	results, err := s.scrapeResults(ctx, name, maxPages)
+	for i := range results {
+		results[i].Event = name
+	}
+	return results, err
+}
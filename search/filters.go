@@ -0,0 +1,177 @@
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// peopleSearchURL is the base of people search result pages
+const peopleSearchURL = "https://www.linkedin.com/search/results/people/"
+
+// geoURNs maps lower-case place names to LinkedIn geo ids. Extend it with
+// search.geo_urns in config; the id is the number in a search URL's geoUrn.
+var geoURNs = map[string]string{
+	"united states":          "103644278",
+	"usa":                    "103644278",
+	"united kingdom":         "101165590",
+	"uk":                     "101165590",
+	"germany":                "101282230",
+	"india":                  "102713980",
+	"canada":                 "101174742",
+	"france":                 "105015875",
+	"netherlands":            "102890719",
+	"australia":              "101452733",
+	"spain":                  "105646813",
+	"ireland":                "104738515",
+	"switzerland":            "106693272",
+	"sweden":                 "105117694",
+	"brazil":                 "106057199",
+	"singapore":              "102454443",
+	"united arab emirates":   "104305776",
+	"berlin":                 "103035651",
+	"london":                 "90009496",
+	"san francisco bay area": "90000084",
+	"new york city":          "90000070",
+	"new york":               "90000070",
+	"bengaluru":              "105214831",
+	"bangalore":              "105214831",
+	"toronto":                "100025096",
+}
+
+// industryURNs maps lower-case industry names to LinkedIn industry ids.
+// Extend it with search.industry_urns in config.
+var industryURNs = map[string]string{
+	"software development":                 "4",
+	"computer software":                    "4",
+	"it services and it consulting":        "96",
+	"technology, information and internet": "6",
+	"internet":                             "6",
+	"financial services":                   "43",
+	"banking":                              "41",
+	"hospitals and health care":            "14",
+	"staffing and recruiting":              "104",
+	"human resources services":             "137",
+	"real estate":                          "44",
+	"retail":                               "27",
+	"accounting":                           "47",
+	"business consulting and services":     "11",
+	"management consulting":                "11",
+	"higher education":                     "68",
+	"biotechnology research":               "12",
+	"pharmaceutical manufacturing":         "15",
+	"construction":                         "48",
+}
+
+// networkCodes maps connection degrees to the network facet codes
+var networkCodes = map[string]string{
+	"1": "F", "1st": "F", "f": "F",
+	"2": "S", "2nd": "S", "s": "S",
+	"3": "O", "3rd": "O", "3rd+": "O", "o": "O",
+}
+
+// RegisterGeo adds or overrides a place name for location lookups
+func RegisterGeo(name, id string) {
+	geoURNs[strings.ToLower(strings.TrimSpace(name))] = urnID(id)
+}
+
+// RegisterIndustry adds or overrides an industry name for industry lookups
+func RegisterIndustry(name, id string) {
+	industryURNs[strings.ToLower(strings.TrimSpace(name))] = urnID(id)
+}
+
+// urnID strips an "urn:li:geo:" style prefix
+func urnID(id string) string {
+	id = strings.TrimSpace(id)
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		id = id[i+1:]
+	}
+	return id
+}
+
+// resolve looks a name up in table; a plain id or URN is used as it is
+func resolve(table map[string]string, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", false
+	}
+	if id := urnID(name); isDigits(id) {
+		return id, true
+	}
+	id, ok := table[strings.ToLower(name)]
+	return id, ok
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveGeo returns the geo id for a place name, id or URN
+func ResolveGeo(name string) (string, bool) { return resolve(geoURNs, name) }
+
+// ResolveIndustry returns the industry id for an industry name, id or URN
+func ResolveIndustry(name string) (string, bool) { return resolve(industryURNs, name) }
+
+// NetworkCodes converts degrees such as "2nd", "S" or "1,2" to facet codes
+func NetworkCodes(degrees []string) ([]string, error) {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, d := range degrees {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		code, ok := networkCodes[d]
+		if !ok {
+			return nil, fmt.Errorf("unknown network degree %q (want 1st, 2nd or 3rd)", d)
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// facetList encodes values as LinkedIn's list parameters: ["a","b"]
+func facetList(values ...string) string {
+	return `["` + strings.Join(values, `","`) + `"]`
+}
+
+// BuildURL returns the people search URL for the criteria. Title and company
+// use the free-text filters, location and industry the URN facets; names the
+// resolver doesn't know are searched as keywords instead.
+func BuildURL(c Criteria) string {
+	q := url.Values{}
+	if kw := c.Query(); kw != "" {
+		q.Set("keywords", kw)
+	}
+	if c.Title != "" {
+		q.Set("titleFreeText", c.Title)
+	}
+	if c.Company != "" {
+		q.Set("company", c.Company)
+	}
+	if id, ok := ResolveGeo(c.Location); ok {
+		q.Set("geoUrn", facetList(id))
+	}
+	if id, ok := ResolveIndustry(c.Industry); ok {
+		q.Set("industry", facetList(id))
+	}
+	// Invalid degrees are rejected when the flags are parsed
+	if codes, _ := NetworkCodes(c.Network); len(codes) > 0 {
+		q.Set("network", facetList(codes...))
+	}
+	if facets := len(q); facets > 1 || facets == 1 && !q.Has("keywords") {
+		q.Set("origin", "FACETED_SEARCH")
+	}
+	return peopleSearchURL + "?" + q.Encode()
+}
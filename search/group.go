@@ -0,0 +1,138 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"linkedin-automation/hooks"
+	"linkedin-automation/stealth"
+)
+
+// ErrNotAGroup is returned for a URL that isn't a LinkedIn group
+var ErrNotAGroup = errors.New("not a LinkedIn group URL")
+
+// ErrNotGroupMember is returned when the members list is hidden because the account hasn't joined the group
+var ErrNotGroupMember = errors.New("members list needs group membership")
+
+// groupPattern matches the numeric id of linkedin.com/groups/<id>/ URLs
+var groupPattern = regexp.MustCompile(`linkedin\.com/groups/(\d+)`)
+
+// Group members page parts. Unlike people search the list has no pages: it
+// grows as it is scrolled, with a "Show more results" button now and then.
+const (
+	groupMemberSelector = "li.groups-members-list__typeahead-result, .groups-members-list li.artdeco-list__item"
+	groupNameSelector   = ".artdeco-entity-lockup__title"
+	groupHeadSelector   = ".artdeco-entity-lockup__subtitle"
+	groupDegreeSelector = ".artdeco-entity-lockup__degree"
+	groupJoinXPath      = `//button[contains(., "Request to join") or contains(., "Join")]`
+)
+
+// loadMoreSelector is the "Show more results" button of lists that grow as they are scrolled
+const loadMoreSelector = "button.scaffold-finite-scroll__load-button"
+
+// GroupMembersURL returns the members list URL of a group URL
+func GroupMembersURL(groupURL string) (string, error) {
+	m := groupPattern.FindStringSubmatch(groupURL)
+	if m == nil {
+		return "", fmt.Errorf("%w: %s", ErrNotAGroup, groupURL)
+	}
+	return "https://www.linkedin.com/groups/" + m[1] + "/members/", nil
+}
+
+// GroupMembers lists the members of a group the account belongs to. maxPages
+// counts loads of the growing list, each about a search page worth of members.
+func (s *Service) GroupMembers(ctx context.Context, groupURL string, maxPages int) ([]Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	membersURL, err := GroupMembersURL(groupURL)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.groupMembers(ctx, membersURL, maxPages)
+
+	result := hooks.NewResult(membersURL, hooks.ActionSearch, err)
+	result.Metadata["found"] = fmt.Sprint(len(results))
+	s.OnResult(result)
+	return results, err
+}
+
+func (s *Service) groupMembers(ctx context.Context, membersURL string, maxPages int) ([]Profile, error) {
+	s.Log.Info("Navigating to group members", "url", membersURL)
+	if err := s.Browser.NavigateTo(membersURL); err != nil {
+		return nil, fmt.Errorf("failed to navigate to group members: %w", err)
+	}
+	if _, err := s.Browser.Page.Timeout(20 * time.Second).Element(groupMemberSelector); err != nil {
+		if has, _, _ := s.Browser.Page.HasX(groupJoinXPath); has {
+			return nil, ErrNotGroupMember
+		}
+		return nil, fmt.Errorf("group members list not found: %w", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	seen := make(map[string]bool)
+	var results []Profile
+	scraped := 0
+	for page := 1; page <= maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			s.Log.Info("Group member scrape interrupted", "loads", page-1, "profiles", len(results))
+			return results, err
+		}
+
+		cards, err := s.Browser.Page.Elements(groupMemberSelector)
+		if err != nil {
+			break
+		}
+		// Earlier cards normally stay in the list, only the new ones need reading
+		if scraped > len(cards) {
+			scraped = 0
+		}
+		for _, card := range cards[scraped:] {
+			p, ok := scrapeCard(card)
+			if !ok || seen[p.URL] {
+				continue
+			}
+			p.Name = cardText(card, groupNameSelector)
+			p.Headline = cardText(card, groupHeadSelector)
+			p.Degree = parseDegree(cardText(card, groupDegreeSelector))
+			p.Company = CompanyFromHeadline(p.Headline)
+			seen[p.URL] = true
+			results = append(results, p)
+			s.Log.Debug("Found group member", "url", p.URL, "name", p.Name, "degree", p.Degree)
+		}
+		s.Log.Info("Group members found", "total_unique", len(results))
+		if page == maxPages {
+			break
+		}
+
+		if !s.loadMoreCards(groupMemberSelector, len(cards)) {
+			s.Log.Info("End of group members list")
+			break
+		}
+		scraped = len(cards)
+	}
+	return results, nil
+}
+
+// loadMoreCards scrolls or presses "Show more results" until a growing list
+// has more than have cardSelector cards. Returns false when it doesn't.
+func (s *Service) loadMoreCards(cardSelector string, have int) bool {
+	for i := 0; i < 4; i++ {
+		if btn, err := s.Browser.Page.Timeout(time.Second).Element(loadMoreSelector); err == nil {
+			if visible, _ := btn.Visible(); visible {
+				s.Browser.HumanClick(btn)
+			}
+		} else {
+			s.Browser.HumanScroll(600)
+		}
+		stealth.SleepContextual(stealth.ActionTypeScroll, 1.5)
+
+		if cards, err := s.Browser.Page.Elements(cardSelector); err == nil && len(cards) > have {
+			return true
+		}
+	}
+	return false
+}
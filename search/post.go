@@ -0,0 +1,250 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+
+	"linkedin-automation/hooks"
+	"linkedin-automation/stealth"
+)
+
+// ErrNotAPost is returned for a URL that isn't a LinkedIn post
+var ErrNotAPost = errors.New("not a LinkedIn post URL")
+
+// postPattern matches feed/update/urn:li:activity:<id> and posts/<slug>-activity-<id> URLs
+var postPattern = regexp.MustCompile(`linkedin\.com/(?:feed/update/urn:li:(?:activity|share|ugcPost):\d+|posts/[^/?#]+)`)
+
+// Engagement kinds of a post engager
+const (
+	EngagementReaction = "reaction"
+	EngagementComment  = "comment"
+)
+
+// Post page parts. Reactions open in a modal that grows as it is scrolled;
+// comments load a batch at a time with a "Load more comments" button.
+const (
+	postTextSelector       = ".feed-shared-update-v2__description, .update-components-text, .feed-shared-text"
+	reactionsCountSelector = "button.social-details-social-counts__count-value, button[aria-label*='reaction'], .social-details-social-counts__reactions-count"
+	reactorItemSelector    = ".social-details-reactors-modal li.artdeco-list__item, .social-details-reactors-tab-body-list-item"
+	reactorsLoadSelector   = ".social-details-reactors-modal button.scaffold-finite-scroll__load-button"
+	modalDismissSelector   = "button.artdeco-modal__dismiss"
+	commentItemSelector    = "article.comments-comment-entity, article.comments-comment-item"
+	commentActorSelector   = "a.comments-post-meta__actor-link, .comments-comment-meta__actor a, a.comments-comment-meta__image-link"
+	commentHeadSelector    = ".comments-post-meta__headline, .comments-comment-meta__description-subtitle"
+	commentsLoadSelector   = "button.comments-comments-list__load-more-comments-button"
+	lockupTitleSelector    = ".artdeco-entity-lockup__title"
+	lockupCaptionSelector  = ".artdeco-entity-lockup__caption, .artdeco-entity-lockup__subtitle"
+)
+
+// postSnippetLength is the most characters of the post's text kept for {{post}}
+const postSnippetLength = 60
+
+// PostEngagers opens a post and scrapes the profiles in its reactions list
+// and comments, each loaded up to maxPages times. Each profile's Post is the
+// opening of the post's text, for {{post}}, and Engagement how they engaged;
+// someone who both reacted and commented is listed once, as a commenter.
+func (s *Service) PostEngagers(ctx context.Context, postURL string, maxPages int) ([]Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !postPattern.MatchString(postURL) {
+		return nil, fmt.Errorf("%w: %s", ErrNotAPost, postURL)
+	}
+	results, err := s.postEngagers(ctx, postURL, maxPages)
+
+	result := hooks.NewResult(postURL, hooks.ActionSearch, err)
+	result.Metadata["found"] = fmt.Sprint(len(results))
+	s.OnResult(result)
+	return results, err
+}
+
+func (s *Service) postEngagers(ctx context.Context, postURL string, maxPages int) ([]Profile, error) {
+	s.Log.Info("Opening post", "url", postURL)
+	if err := s.Browser.NavigateTo(postURL); err != nil {
+		return nil, fmt.Errorf("failed to navigate to post: %w", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.5)
+
+	snippet := ""
+	if el, err := s.Browser.Page.Timeout(10 * time.Second).Element(postTextSelector); err == nil {
+		if text, err := el.Text(); err == nil {
+			snippet = PostSnippet(text)
+		}
+	}
+	if snippet == "" {
+		s.Log.Warn("Post text not found, {{post}} will be empty", "url", postURL)
+	}
+
+	seen := make(map[string]int)
+	var results []Profile
+	add := func(p Profile) {
+		p.Post = snippet
+		if i, ok := seen[p.URL]; ok {
+			if p.Engagement == EngagementComment {
+				results[i].Engagement = EngagementComment
+			}
+			return
+		}
+		seen[p.URL] = len(results)
+		results = append(results, p)
+	}
+
+	for _, p := range s.commenters(ctx, maxPages) {
+		add(p)
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	reactors, err := s.reactors(ctx, maxPages)
+	if err != nil {
+		s.Log.Warn("Failed to read the reactions list", "error", err)
+	}
+	for _, p := range reactors {
+		add(p)
+	}
+	s.Log.Info("Post engagers found", "total_unique", len(results))
+	if len(results) == 0 && err == nil {
+		return nil, ErrNoResults
+	}
+	return results, ctx.Err()
+}
+
+// commenters loads the post's comments up to maxPages times and reads their authors
+func (s *Service) commenters(ctx context.Context, maxPages int) []Profile {
+	for page := 1; page < maxPages && ctx.Err() == nil; page++ {
+		btn, err := s.Browser.Page.Timeout(2 * time.Second).Element(commentsLoadSelector)
+		if err != nil {
+			break
+		}
+		if err := s.Browser.HumanClick(btn); err != nil {
+			break
+		}
+		stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+	}
+
+	items, err := s.Browser.Page.Elements(commentItemSelector)
+	if err != nil {
+		return nil
+	}
+	var out []Profile
+	for _, item := range items {
+		link, err := item.Element(commentActorSelector)
+		if err != nil {
+			continue
+		}
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		url, ok := cleanProfileURL(*href)
+		if !ok {
+			continue // company pages comment too
+		}
+		p := Profile{URL: url, Engagement: EngagementComment}
+		p.Name = cardText(item, lockupTitleSelector)
+		if p.Name == "" {
+			if text, err := link.Text(); err == nil {
+				p.Name = strings.TrimSpace(strings.Split(text, "\n")[0])
+			}
+		}
+		p.Headline = cardText(item, commentHeadSelector)
+		p.Company = CompanyFromHeadline(p.Headline)
+		out = append(out, p)
+	}
+	s.Log.Info("Commenters found", "count", len(out))
+	return out
+}
+
+// reactors opens the reactions modal, grows its list up to maxPages times,
+// reads it and closes the modal again
+func (s *Service) reactors(ctx context.Context, maxPages int) ([]Profile, error) {
+	btn, err := s.Browser.Page.Timeout(5 * time.Second).Element(reactionsCountSelector)
+	if err != nil {
+		return nil, nil // no reactions yet
+	}
+	if err := s.Browser.HumanClick(btn); err != nil {
+		return nil, err
+	}
+	defer s.closeModal()
+	if _, err := s.Browser.Page.Timeout(10 * time.Second).Element(reactorItemSelector); err != nil {
+		return nil, fmt.Errorf("reactions list not found: %w", err)
+	}
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	for page := 1; page < maxPages && ctx.Err() == nil; page++ {
+		items, err := s.Browser.Page.Elements(reactorItemSelector)
+		if err != nil || len(items) == 0 || !s.growList(items, reactorItemSelector, reactorsLoadSelector) {
+			break
+		}
+	}
+
+	items, err := s.Browser.Page.Elements(reactorItemSelector)
+	if err != nil {
+		return nil, err
+	}
+	var out []Profile
+	for _, item := range items {
+		p, ok := scrapeCard(item)
+		if !ok {
+			continue
+		}
+		p.Name = cardText(item, lockupTitleSelector)
+		p.Headline = cardText(item, lockupCaptionSelector)
+		p.Company = CompanyFromHeadline(p.Headline)
+		p.Engagement = EngagementReaction
+		out = append(out, p)
+	}
+	s.Log.Info("Reactors found", "count", len(out))
+	return out, nil
+}
+
+// growList scrolls to the last of a modal list's items, pressing loadMore
+// when it shows, and reports whether more itemSelector items loaded
+func (s *Service) growList(items rod.Elements, itemSelector, loadMore string) bool {
+	have := len(items)
+	if btn, err := s.Browser.Page.Timeout(time.Second).Element(loadMore); err == nil {
+		s.Browser.HumanClick(btn)
+	} else {
+		items.Last().ScrollIntoView()
+		s.Browser.HumanScroll(300)
+	}
+	stealth.SleepContextual(stealth.ActionTypeScroll, 1.5)
+	more, err := s.Browser.Page.Elements(itemSelector)
+	return err == nil && len(more) > have
+}
+
+// closeModal dismisses the open modal, with Escape as the fallback
+func (s *Service) closeModal() {
+	if btn, err := s.Browser.Page.Timeout(2 * time.Second).Element(modalDismissSelector); err == nil {
+		if err := s.Browser.HumanClick(btn); err == nil {
+			return
+		}
+	}
+	s.Browser.Page.Keyboard.Type(input.Escape)
+}
+
+// PostSnippet returns the opening of a post's text, cut at a word boundary
+// to at most postSnippetLength characters
+func PostSnippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.TrimSuffix(text, "…see more")
+	if i := strings.IndexAny(text, ".!?"); i > 0 {
+		text = text[:i]
+	}
+	runes := []rune(text)
+	if len(runes) <= postSnippetLength {
+		return text
+	}
+	cut := string(runes[:postSnippetLength])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, ",;:-") + "..."
+}
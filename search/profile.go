@@ -0,0 +1,144 @@
+package search
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// Profile is a search result with the details shown on its card
+type Profile struct {
+	URL         string `json:"url"`
+	Name        string `json:"name,omitempty"`
+	Headline    string `json:"headline,omitempty"`
+	Company     string `json:"company,omitempty"`
+	Location    string `json:"location,omitempty"`
+	MutualCount int    `json:"mutual_connections,omitempty"`
+	Degree      int    `json:"degree,omitempty"` // 1, 2 or 3 (3rd+), 0 if unknown
+
+	// Event is the title of the event the profile was found attending
+	Event string `json:"event,omitempty"`
+
+	// Post is the opening of the post the profile engaged with, Engagement
+	// whether they reacted or commented
+	Post       string `json:"post,omitempty"`
+	Engagement string `json:"engagement,omitempty"`
+}
+
+// Result card parts; the newer layouts drop the entity-result classes, hence the fallbacks
+const (
+	resultCardSelector = "li.reusable-search__result-container, div.entity-result, [data-chameleon-result-urn]"
+	cardNameSelector   = ".entity-result__title-text a span[aria-hidden='true'], .entity-result__title-text a"
+	cardHeadSelector   = ".entity-result__primary-subtitle"
+	cardLocSelector    = ".entity-result__secondary-subtitle"
+	cardDegreeSelector = ".entity-result__badge-text, .dist-value"
+	cardMutualSelector = ".entity-result__simple-insight-text, .reusable-search-simple-insight__text"
+	cardSummarySel     = ".entity-result__summary"
+)
+
+var (
+	degreePattern   = regexp.MustCompile(`(\d)(st|nd|rd)`)
+	mutualNPattern  = regexp.MustCompile(`(\d+)\s+(other\s+)?mutual connection`)
+	currentPattern  = regexp.MustCompile(`(?i)current:\s*(.+)`)
+	atCompanyMarker = " at "
+)
+
+// scrapeCard reads the result card details, returns false if the card has no profile link
+func scrapeCard(card *rod.Element) (Profile, bool) {
+	links, err := card.Elements("a[href*='/in/']")
+	if err != nil {
+		return Profile{}, false
+	}
+	var p Profile
+	for _, link := range links {
+		href, err := link.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		if url, ok := cleanProfileURL(*href); ok {
+			p.URL = url
+			break
+		}
+	}
+	if p.URL == "" {
+		return Profile{}, false
+	}
+
+	p.Name = cardText(card, cardNameSelector)
+	p.Headline = cardText(card, cardHeadSelector)
+	p.Location = cardText(card, cardLocSelector)
+	p.Degree = parseDegree(cardText(card, cardDegreeSelector))
+	p.MutualCount = parseMutual(cardText(card, cardMutualSelector))
+	p.Company = parseCompany(cardText(card, cardSummarySel), p.Headline)
+	return p, true
+}
+
+// cardText returns the trimmed text of the first match in card, empty if none
+func cardText(card *rod.Element, selector string) string {
+	has, el, err := card.Has(selector)
+	if err != nil || !has {
+		return ""
+	}
+	text, err := el.Text()
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// parseDegree turns "• 2nd" / "3rd+" into 2 / 3
+func parseDegree(text string) int {
+	m := degreePattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// parseMutual counts mutual connections from the card insight:
+// "Jane is a mutual connection", "Jane and John are mutual connections",
+// "Jane and 12 other mutual connections"
+func parseMutual(text string) int {
+	if text == "" || !strings.Contains(text, "mutual connection") {
+		return 0
+	}
+	if m := mutualNPattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] != "" {
+			// "Jane, John and 12 other ..." names plus the others
+			n += strings.Count(text[:strings.Index(text, m[0])], ",") + 1
+		}
+		return n
+	}
+	if strings.Contains(text, " and ") {
+		return strings.Count(text, ",") + 2
+	}
+	return 1
+}
+
+// CompanyFromHeadline returns the "... at Company" part of a headline, "" when it has none
+func CompanyFromHeadline(headline string) string {
+	return parseCompany("", headline)
+}
+
+// parseCompany takes the company from "Current: Title at Company" in the summary,
+// falling back to the "... at Company" part of the headline
+func parseCompany(summary, headline string) string {
+	if m := currentPattern.FindStringSubmatch(summary); m != nil {
+		if i := strings.LastIndex(m[1], atCompanyMarker); i >= 0 {
+			return strings.TrimSpace(m[1][i+len(atCompanyMarker):])
+		}
+	}
+	if i := strings.LastIndex(headline, atCompanyMarker); i >= 0 {
+		company := headline[i+len(atCompanyMarker):]
+		// Headlines often continue after the company: "Engineer at Acme | Speaker"
+		if j := strings.IndexAny(company, "|·•,"); j >= 0 {
+			company = company[:j]
+		}
+		return strings.TrimSpace(company)
+	}
+	return ""
+}
@@ -1,75 +1,131 @@
 package search
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
-	"github.com/go-rod/rod/lib/proto"
-
 	"linkedin-automation/browser"
+	"linkedin-automation/diagnostics"
+	"linkedin-automation/hooks"
 	"linkedin-automation/logger"
+	"linkedin-automation/profile"
 	"linkedin-automation/stealth"
+	"linkedin-automation/storage"
 )
 
+// ErrNoResults is returned when LinkedIn reports that the search matched no one
+var ErrNoResults = errors.New("search returned no results")
+
+// noResultsSelector matches the "No results found" empty state
+const noResultsSelector = `//*[contains(@class, "search-reusables__no-results") or contains(@class, "artdeco-empty-state")] | //h2[contains(., "No results found")]`
+
 // Criteria defines the search filters
 type Criteria struct {
 	Keywords string
 	Title    string
 	Company  string
+	// Location and Industry are names (resolved to URNs), ids or URNs
 	Location string
+	Industry string
+	// Network limits results to connection degrees, e.g. ["2nd"] or ["S", "O"]
+	Network []string
 }
 
 // Finder defines the interface for searching
 type Finder interface {
-	SearchPeople(criteria Criteria, maxPages int) ([]string, error)
+	SearchPeople(ctx context.Context, criteria Criteria, maxPages int) ([]Profile, error)
+	ScrapeRelated(ctx context.Context, profileURL string) ([]string, error)
+	GroupMembers(ctx context.Context, groupURL string, maxPages int) ([]Profile, error)
+	EventAttendees(ctx context.Context, eventURL string, maxPages int) ([]Profile, error)
+	PostEngagers(ctx context.Context, postURL string, maxPages int) ([]Profile, error)
+	CompanyEmployees(ctx context.Context, companyURL string, filters CompanyFilters, maxPages int) ([]Profile, error)
 }
 
 // Service implements Finder and handles search operations
 type Service struct {
 	Browser *browser.Browser
 	Log     logger.Logger
+	Store   storage.DataStore
+
+	// OnResult is invoked after every search, with the search URL as ProfileURL
+	OnResult hooks.ResultHook
+
+	// Diagnostics captures pages whose results didn't load, nil disables it
+	Diagnostics *diagnostics.Recorder
 }
 
 // New creates a new Search Service
-func New(b *browser.Browser, l logger.Logger) *Service {
+func New(b *browser.Browser, l logger.Logger, store storage.DataStore) *Service {
 	return &Service{
-		Browser: b,
-		Log:     l,
+		Browser:  b,
+		Log:      l,
+		Store:    store,
+		OnResult: hooks.Noop,
 	}
 }
 
-// SearchPeople performs a search and scrapes profile URLs
-func (s *Service) SearchPeople(criteria Criteria, maxPages int) ([]string, error) {
-	// 1. Navigate to Search Page
-	// Construct the query string based on criteria
-	// We use the "keywords" parameter with boolean operators for simplicity: "Keywords AND Title AND Company..."
-	// Or we can just join them with spaces which implies AND/OR depending on LI logic, usually good enough.
-	// A better approach for specific fields is using the advanced filters if possible, but URL params for that are complex (e.g. &title=... is not always standard, often encoded filters).
-	// For robust "v1" implementation, we'll build a rich keywords string.
-
+// Query is the keywords parameter: the keywords plus any location or industry
+// that has no known URN and so can't be a facet
+func (c Criteria) Query() string {
 	var parts []string
-	if criteria.Keywords != "" {
-		parts = append(parts, criteria.Keywords)
+	if c.Keywords != "" {
+		parts = append(parts, c.Keywords)
+	}
+	if _, ok := ResolveGeo(c.Location); !ok && c.Location != "" {
+		parts = append(parts, c.Location)
 	}
-	if criteria.Title != "" {
-		parts = append(parts, criteria.Title)
+	if _, ok := ResolveIndustry(c.Industry); !ok && c.Industry != "" {
+		parts = append(parts, c.Industry)
 	}
-	if criteria.Company != "" {
-		parts = append(parts, criteria.Company)
+	return strings.Join(parts, " ")
+}
+
+// SearchPeople performs a search and scrapes each result card's profile details.
+// On cancellation the profiles scraped so far are returned with the context's error.
+func (s *Service) SearchPeople(ctx context.Context, criteria Criteria, maxPages int) ([]Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	results, err := s.searchPeople(ctx, criteria, maxPages)
+
+	// An empty search still worked
+	herr := err
+	if errors.Is(err, ErrNoResults) {
+		herr = nil
+	}
+	result := hooks.NewResult(BuildURL(criteria), hooks.ActionSearch, herr)
+	result.Metadata["found"] = fmt.Sprint(len(results))
+	s.OnResult(result)
+	return results, err
+}
+
+func (s *Service) searchPeople(ctx context.Context, criteria Criteria, maxPages int) ([]Profile, error) {
+
+	// 1. Navigate to Search Page
+	// Title/company are free-text filters, location/industry URN facets
+	if _, ok := ResolveGeo(criteria.Location); !ok && criteria.Location != "" {
+		s.Log.Warn("Unknown location, searching it as a keyword (add it to search.geo_urns)", "location", criteria.Location)
 	}
-	if criteria.Location != "" {
-		parts = append(parts, criteria.Location)
+	if _, ok := ResolveIndustry(criteria.Industry); !ok && criteria.Industry != "" {
+		s.Log.Warn("Unknown industry, searching it as a keyword (add it to search.industry_urns)", "industry", criteria.Industry)
 	}
 
-	fullQuery := strings.Join(parts, " ")
-	safeQuery := strings.ReplaceAll(fullQuery, " ", "%20")
-	searchURL := fmt.Sprintf("https://www.linkedin.com/search/results/people/?keywords=%s", safeQuery)
+	fullQuery := criteria.Query()
+	searchURL := BuildURL(criteria)
 
 	s.Log.Info("Navigating to search", "url", searchURL)
 	if err := s.Browser.NavigateTo(searchURL); err != nil {
 		return nil, fmt.Errorf("failed to navigate to search: %w", err)
 	}
+	return s.scrapeResults(ctx, fullQuery, maxPages)
+}
+
+// scrapeResults scrapes up to maxPages pages of the people search results on
+// the current page; fullQuery is only logged
+func (s *Service) scrapeResults(ctx context.Context, fullQuery string, maxPages int) ([]Profile, error) {
 
 	// Wait for results to load
 	// Selector for result list container: .reusable-search__result-container
@@ -88,15 +144,28 @@ func (s *Service) SearchPeople(criteria Criteria, maxPages int) ([]string, error
 	// This is generic and works regardless of container class changes
 	err := s.Browser.Page.Timeout(30*time.Second).WaitElementsMoreThan("a[href*='/in/']", 2)
 	if err != nil {
+		// Distinguish a genuinely empty search from a slow/broken page
+		if has, _, _ := s.Browser.Page.HasX(noResultsSelector); has {
+			s.Log.Info("LinkedIn reports no results for this search", "query", fullQuery)
+			return nil, ErrNoResults
+		}
 		s.Log.Warn("Search results selector timed out or not found, attempting to scrape anyway...", "error", err)
-		s.Browser.Page.MustScreenshot("search_warning.png")
+		if s.Diagnostics != nil {
+			if _, err := s.Diagnostics.Capture("search_warning", err); err != nil {
+				s.Log.Warn("Failed to capture search page", "error", err)
+			}
+		}
 		// Do not return error, proceed to scraping logic which handles empty lists
 	}
 
 	uniqueURLs := make(map[string]bool)
-	var results []string
+	var results []Profile
 
 	for page := 1; page <= maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			s.Log.Info("Search interrupted", "pages_scraped", page-1, "profiles", len(results))
+			return results, err
+		}
 		s.Log.Info("Scraping page", "page", page)
 
 		// Human Scroll to load all lazy-loaded elements on the page
@@ -106,35 +175,31 @@ func (s *Service) SearchPeople(criteria Criteria, maxPages int) ([]string, error
 			stealth.SleepRandom(500*time.Millisecond, 1500*time.Millisecond)
 		}
 
+		// Result cards first, they carry the name, headline, degree etc.
+		if cards, err := s.Browser.Page.Elements(resultCardSelector); err == nil {
+			for _, card := range cards {
+				p, ok := scrapeCard(card)
+				if !ok || uniqueURLs[p.URL] {
+					continue
+				}
+				uniqueURLs[p.URL] = true
+				results = append(results, p)
+				s.Log.Debug("Found profile", "url", p.URL, "name", p.Name, "degree", p.Degree)
+			}
+		}
+
 		// Extract Links
-		// Select all anchor tags with /in/
+		// Any profile link outside a recognised card (layout changes) is still collected, URL only
 		// Common selector: .app-aware-link
 		elements, err := s.Browser.Page.Elements("a")
 		if err == nil {
 			for _, el := range elements {
 				href, err := el.Attribute("href")
 				if err == nil && href != nil {
-					val := *href
-					// Filter for profile links
-					// We only check for /in/ and ensure it's not a mini-profile
-					// We DO NOT filter out "linkedin.com/in/" because absolute URLs are valid common returns
-					if strings.Contains(val, "/in/") && !strings.Contains(val, "/mini-profile/") {
-						// linkedin.com/in/ check is to avoid dupes if href is absolute vs relative, usually it's relative or absolute.
-						// Use simple check for /in/ standard pattern.
-
-						// Clean URL (remove query params)
-						cleanURL := strings.Split(val, "?")[0]
-
-						// Ensure it's a full URL if relative
-						if !strings.HasPrefix(cleanURL, "http") {
-							cleanURL = "https://www.linkedin.com" + cleanURL
-						}
-
-						if !uniqueURLs[cleanURL] {
-							uniqueURLs[cleanURL] = true
-							results = append(results, cleanURL)
-							s.Log.Debug("Found profile", "url", cleanURL)
-						}
+					if cleanURL, ok := cleanProfileURL(*href); ok && !uniqueURLs[cleanURL] {
+						uniqueURLs[cleanURL] = true
+						results = append(results, Profile{URL: cleanURL})
+						s.Log.Debug("Found profile", "url", cleanURL)
 					}
 				}
 			}
@@ -162,23 +227,11 @@ func (s *Service) SearchPeople(criteria Criteria, maxPages int) ([]string, error
 				break
 			}
 
-			// Scroll element into view if needed (HumanScroll logic handles generic scroll, but we need button visible)
-			// Rod's ScrollIntoView works, but we want to be stealthy.
-			// Ideally, we calculated Y pose. For now we assume bottom of page has the button.
-			// We already scrolled down.
+			// HumanClick scrolls the button into view first
 
 			s.Log.Info("Clicking next page")
 
-			err = s.Browser.HumanMove(nextBtn)
-			if err != nil {
-				// Fallback
-				nextBtn.ScrollIntoView()
-				nextBtn.Click(proto.InputMouseButtonLeft, 1)
-			} else {
-				// Click with delay
-				time.Sleep(time.Millisecond * 200)
-				nextBtn.Click(proto.InputMouseButtonLeft, 1)
-			}
+			s.Browser.Click(nextBtn)
 
 			stealth.SleepContextual(stealth.ActionTypeRead, 1.5) // Wait for page load
 		}
@@ -186,3 +239,73 @@ func (s *Service) SearchPeople(criteria Criteria, maxPages int) ([]string, error
 
 	return results, nil
 }
+
+// ScrapeRelated visits a seed profile and collects the "People also viewed" /
+// "More profiles for you" sidebar links, skipping profiles already in the store
+func (s *Service) ScrapeRelated(ctx context.Context, profileURL string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := profile.Parse(profileURL); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, profileURL)
+	}
+
+	s.Log.Info("Scraping related profiles", "seed", profileURL)
+	if err := s.Browser.NavigateTo(profileURL); err != nil {
+		return nil, fmt.Errorf("failed to navigate to seed profile: %w", err)
+	}
+
+	stealth.SleepContextual(stealth.ActionTypeRead, 1.0)
+
+	if !s.Browser.OnPersonProfile() {
+		return nil, fmt.Errorf("%w: %s", profile.ErrNotAProfile, profileURL)
+	}
+
+	// The sidebar is lazy-loaded, scroll a bit to trigger it
+	for i := 0; i < 3; i++ {
+		s.Browser.HumanScroll(400)
+		stealth.SleepRandom(500*time.Millisecond, 1200*time.Millisecond)
+	}
+
+	elements, err := s.Browser.Page.Timeout(10 * time.Second).Elements(".pv-browsemap-section a[href*='/in/'], aside a[href*='/in/']")
+	if err != nil {
+		return nil, fmt.Errorf("related profiles sidebar not found: %w", err)
+	}
+
+	seed, _ := cleanProfileURL(profileURL)
+	uniqueURLs := map[string]bool{seed: true}
+	var results []string
+
+	for _, el := range elements {
+		href, err := el.Attribute("href")
+		if err != nil || href == nil {
+			continue
+		}
+		cleanURL, ok := cleanProfileURL(*href)
+		if !ok || uniqueURLs[cleanURL] {
+			continue
+		}
+		uniqueURLs[cleanURL] = true
+
+		if s.Store != nil && (s.Store.IsRequestSent(cleanURL) || s.Store.IsConnected(cleanURL)) {
+			s.Log.Debug("Skipping known related profile", "url", cleanURL)
+			continue
+		}
+
+		results = append(results, cleanURL)
+		s.Log.Debug("Found related profile", "url", cleanURL)
+	}
+
+	s.Log.Info("Related profiles found", "count", len(results))
+	return results, nil
+}
+
+// cleanProfileURL normalises a profile href to its canonical URL.
+// Returns false if the href is not a profile link (company pages, posts, mini-profiles).
+func cleanProfileURL(href string) (string, bool) {
+	p, err := profile.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	return p.String(), true
+}
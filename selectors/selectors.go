@@ -0,0 +1,169 @@
+package selectors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version of the built-in selectors. It goes up whenever a default chain
+// changes, so a selectors file written for older defaults can be spotted.
+const Version = 1
+
+// ErrUnknownElement is returned for a selectors file naming an element the bot never looks up
+var ErrUnknownElement = errors.New("unknown selector element")
+
+// Defaults are the built-in fallback chains, tried in order. Entries starting
+// with "/", "./" or "(" are XPath, the rest CSS. In XPath, {contains:EXPR:ACTION}
+// and {equals:EXPR:ACTION} expand to a predicate matching EXPR against the
+// button labels of ACTION (see button_labels).
+var Defaults = map[string][]string{
+	// Profile top card
+	"connect_button": {
+		`//main//button[contains(@class, "artdeco-button--primary")][{contains:.:connect}][not({contains:@aria-label:cta})]`,
+		`//button[{contains:@aria-label:connect}][not(contains(@aria-label, "Invite"))][not({contains:@aria-label:cta})]`,
+	},
+	"more_actions": {
+		`//main//button[contains(@aria-label, "More actions")]`,
+		`button[aria-label="More actions"]`,
+	},
+	"more_menu_connect": {
+		`//div[contains(@class, "artdeco-dropdown")]//span[{equals:text():connect}]`,
+		`//div[contains(@class, "artdeco-dropdown")]//span[text()="Add"]`,
+		`//div[contains(@class, "artdeco-dropdown")]//span[contains(text(), "Invite")]`,
+		`//div[@role="button"]//span[{equals:text():connect}]`,
+		`//div[@role="button"]//span[text()="Add"]`,
+	},
+	"pending_button": {
+		`//main//button[contains(., "Pending") or contains(@aria-label, "Pending")]`,
+	},
+	"message_button": {
+		`//button[{contains:@aria-label:message}]`,
+		`//main//button[{contains:.:message}]`,
+		`//button[{contains:.:message}]`,
+	},
+	"follow_button": {
+		`//button[{contains:@aria-label:follow}]`,
+		`//button//span[{equals:text():follow}]`,
+		`//div[contains(@class, "artdeco-dropdown")]//span[{equals:text():follow}]`,
+		`//div[@role="button"]//span[{equals:text():follow}]`,
+	},
+	"see_more": {
+		`main button.inline-show-more-text__button`,
+		`//main//section//button[contains(., "see more")]`,
+	},
+
+	// Invitation modal
+	"add_note": {
+		`//button[contains(@aria-label, "Add a note") or contains(., "Add a note")]`,
+	},
+	"note_textarea": {
+		`textarea[name='message']`,
+		`//div[@role="dialog"]//textarea`,
+	},
+	"send_in_modal": {
+		`button[aria-label="Send now"]`,
+		`//div[@role="dialog"]//button[{contains:.:send}]`,
+	},
+	"withdraw_confirm": {
+		`//div[@role="alertdialog" or @role="dialog"]//button[contains(., "Withdraw")]`,
+	},
+
+	// Chat composer
+	"message_box": {
+		`div[role="textbox"][aria-label^="Write a message"]`,
+		`.msg-form__contenteditable`,
+		`//div[@role="textbox"][@contenteditable="true"]`,
+	},
+	"message_send": {
+		`button[type="submit"]`,
+		`//button[{contains:.:send}]`,
+	},
+
+	// My Network
+	"connection_link": {
+		`.mn-connection-card__link`,
+		`li.mn-connection-card a[href*='/in/']`,
+	},
+}
+
+// Set holds the fallback chains in use: the defaults with a selectors
+// file's chains replacing them element by element
+type Set struct {
+	Version  int                 `yaml:"version"`
+	Elements map[string][]string `yaml:"elements"`
+}
+
+// Load reads a selectors file. A missing file (or empty path) leaves every
+// element on its default chain.
+func Load(path string) (*Set, error) {
+	s := &Set{Version: Version}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.Version = 0
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid selectors file %s: %w", path, err)
+	}
+	for name, chain := range s.Elements {
+		if _, ok := Defaults[name]; !ok {
+			return nil, fmt.Errorf("%s: %w %q (known: %s)", path, ErrUnknownElement, name, strings.Join(Names(), ", "))
+		}
+		if len(chain) == 0 {
+			return nil, fmt.Errorf("%s: element %q has no selectors", path, name)
+		}
+	}
+	return s, nil
+}
+
+// Chain returns the selectors for an element, most preferred first. A nil
+// Set uses the defaults.
+func (s *Set) Chain(name string) []string {
+	if s != nil {
+		if chain := s.Elements[name]; len(chain) > 0 {
+			return chain
+		}
+	}
+	return Defaults[name]
+}
+
+// Overridden lists the elements whose chain differs from the built-in one
+func (s *Set) Overridden() []string {
+	if s == nil {
+		return nil
+	}
+	var names []string
+	for name, chain := range s.Elements {
+		if !slices.Equal(chain, Defaults[name]) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Names lists the logical elements, sorted
+func Names() []string {
+	names := make([]string, 0, len(Defaults))
+	for name := range Defaults {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// IsXPath reports whether a selector is XPath rather than CSS
+func IsXPath(sel string) bool {
+	return strings.HasPrefix(sel, "/") || strings.HasPrefix(sel, "./") || strings.HasPrefix(sel, "(")
+}
@@ -0,0 +1,250 @@
+package sheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotASheet is returned for a URL that isn't a Google Sheet
+var ErrNotASheet = errors.New("not a Google Sheets URL")
+
+// ErrNoCredentials is returned when a sheet is used without a service account key
+var ErrNoCredentials = errors.New("google sheets need a service account key (sheets.credentials_file or LINKEDIN_GOOGLE_CREDENTIALS)")
+
+// apiBase is the Sheets API v4 endpoint
+const apiBase = "https://sheets.googleapis.com/v4/spreadsheets/"
+
+var (
+	sheetPattern = regexp.MustCompile(`docs\.google\.com/spreadsheets/d/([a-zA-Z0-9_-]+)`)
+	gidPattern   = regexp.MustCompile(`[#&?]gid=(\d+)`)
+)
+
+// Ref points at one tab of a spreadsheet. HasGID is false for a URL without
+// a gid, which means the first tab.
+type Ref struct {
+	ID     string
+	GID    int
+	HasGID bool
+}
+
+// IsURL reports whether s looks like a Google Sheets URL
+func IsURL(s string) bool {
+	return sheetPattern.MatchString(s)
+}
+
+// Parse reads the spreadsheet id and tab of a Google Sheets URL
+func Parse(sheetURL string) (Ref, error) {
+	m := sheetPattern.FindStringSubmatch(sheetURL)
+	if m == nil {
+		return Ref{}, fmt.Errorf("%w: %s", ErrNotASheet, sheetURL)
+	}
+	r := Ref{ID: m[1]}
+	if g := gidPattern.FindStringSubmatch(sheetURL); g != nil {
+		r.GID, _ = strconv.Atoi(g[1])
+		r.HasGID = true
+	}
+	return r, nil
+}
+
+// Sheet is one tab's cells, header row included
+type Sheet struct {
+	Ref   Ref
+	Title string
+	Rows  [][]string
+}
+
+// Client talks to the Sheets API as a service account. Share the sheet with
+// the account's client_email as an editor.
+type Client struct {
+	http *http.Client
+	key  *serviceAccount
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// New creates a Client from a service account key file
+func New(credentialsFile string, timeout time.Duration) (*Client, error) {
+	if credentialsFile == "" {
+		return nil, ErrNoCredentials
+	}
+	key, err := loadServiceAccount(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{http: &http.Client{Timeout: timeout}, key: key}, nil
+}
+
+// Read returns every row of the tab sheetURL points at
+func (c *Client) Read(ctx context.Context, sheetURL string) (*Sheet, error) {
+	ref, err := Parse(sheetURL)
+	if err != nil {
+		return nil, err
+	}
+	title, err := c.title(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Values [][]string `json:"values"`
+	}
+	if err := c.do(ctx, http.MethodGet, ref.ID+"/values/"+url.PathEscape(quote(title)), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &Sheet{Ref: ref, Title: title, Rows: resp.Values}, nil
+}
+
+// headerlessColumn is where a sheet without a header gets its first written
+// column, after the URL and first name
+const headerlessColumn = 2
+
+// WriteColumns writes whole columns below the header row, keyed by header
+// name. Columns missing from the header are appended to it; cells of other
+// columns are left alone. values[i] is the cell of the (i+1)th data row.
+// Without a header (hasHeader false) row 1 is data: the columns are written
+// from row 1 in order, starting after the URL and first name columns.
+func (c *Client) WriteColumns(ctx context.Context, s *Sheet, columns []string, values map[string][]string, hasHeader bool) error {
+	var data []map[string]any
+	if !hasHeader {
+		for i, name := range columns {
+			if cells := column(values[name]); len(cells) > 0 {
+				data = append(data, valueRange(s.Title, headerlessColumn+i, 1, cells))
+			}
+		}
+		return c.batchUpdate(ctx, s, data)
+	}
+
+	if len(s.Rows) == 0 {
+		s.Rows = [][]string{{}}
+	}
+	header := s.Rows[0]
+	for _, name := range columns {
+		col := -1
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				col = i
+				break
+			}
+		}
+		if col < 0 {
+			col = len(header)
+			header = append(header, name)
+			data = append(data, valueRange(s.Title, col, 1, [][]string{{name}}))
+		}
+		if cells := column(values[name]); len(cells) > 0 {
+			data = append(data, valueRange(s.Title, col, 2, cells))
+		}
+	}
+	s.Rows[0] = header
+	return c.batchUpdate(ctx, s, data)
+}
+
+// batchUpdate writes the value ranges of data into the sheet
+func (c *Client) batchUpdate(ctx context.Context, s *Sheet, data []map[string]any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	body := map[string]any{"valueInputOption": "RAW", "data": data}
+	return c.do(ctx, http.MethodPost, s.Ref.ID+"/values:batchUpdate", body, nil)
+}
+
+// column turns values into the cells of one column
+func column(values []string) [][]string {
+	cells := make([][]string, len(values))
+	for i, v := range values {
+		cells[i] = []string{v}
+	}
+	return cells
+}
+
+// title finds the name of the tab ref points at
+func (c *Client) title(ctx context.Context, ref Ref) (string, error) {
+	var resp struct {
+		Sheets []struct {
+			Properties struct {
+				SheetID int    `json:"sheetId"`
+				Title   string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	if err := c.do(ctx, http.MethodGet, ref.ID+"?fields=sheets.properties(sheetId,title)", nil, &resp); err != nil {
+		return "", err
+	}
+	for _, s := range resp.Sheets {
+		if !ref.HasGID || s.Properties.SheetID == ref.GID {
+			return s.Properties.Title, nil
+		}
+	}
+	return "", fmt.Errorf("spreadsheet %s has no tab with gid %d", ref.ID, ref.GID)
+}
+
+// do calls the API, encoding body and decoding the answer into out when set
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiBase+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sheets api: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// valueRange is one block of cells starting at column col (0-based), row row (1-based)
+func valueRange(title string, col, row int, cells [][]string) map[string]any {
+	return map[string]any{
+		"range":  fmt.Sprintf("%s!%s%d", quote(title), ColumnName(col), row),
+		"values": cells,
+	}
+}
+
+// quote wraps a tab title for A1 notation
+func quote(title string) string {
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
+
+// ColumnName returns the A1 letters of a 0-based column index: A, B, ... Z, AA
+func ColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
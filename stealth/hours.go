@@ -0,0 +1,36 @@
+package stealth
+
+import (
+	"sync"
+	"time"
+
+	"linkedin-automation/schedule"
+)
+
+var (
+	hoursMu sync.RWMutex
+	hours   *schedule.Hours
+)
+
+// UseHours makes h the business hours every workflow and the daemon consult
+func UseHours(h *schedule.Hours) {
+	hoursMu.Lock()
+	defer hoursMu.Unlock()
+	hours = h
+}
+
+// BusinessHours returns the hours in use: those set by UseHours, else the
+// current persona's active hours
+func BusinessHours() *schedule.Hours {
+	hoursMu.RLock()
+	defer hoursMu.RUnlock()
+	if hours != nil {
+		return hours
+	}
+	return Current().Hours()
+}
+
+// IsBusinessHours reports whether now is within the business hours in use
+func IsBusinessHours() bool {
+	return BusinessHours().Active(time.Now())
+}
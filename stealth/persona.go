@@ -0,0 +1,148 @@
+package stealth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"linkedin-automation/schedule"
+)
+
+// Persona is how one account's "user" behaves: how fast they type, how hard
+// they scroll, how long they read and when they're at their desk. It is
+// generated once per account and reused, so the same account doesn't turn
+// from a fast skimmer into a slow reader between runs.
+type Persona struct {
+	// TypingSpeed and TypoFactor scale the configured typing profile's WPM
+	// and typo rate
+	TypingSpeed float64 `json:"typing_speed"`
+	TypoFactor  float64 `json:"typo_factor"`
+	// ScrollSpeed scales scroll flick sizes (above 1 scrolls harder)
+	ScrollSpeed float64 `json:"scroll_speed"`
+	// ReadPace and ThinkPace scale read and think pauses (above 1 is slower)
+	ReadPace  float64 `json:"read_pace"`
+	ThinkPace float64 `json:"think_pace"`
+	// ActiveFrom and ActiveTo are the local hours the persona works, [from, to)
+	ActiveFrom int       `json:"active_from"`
+	ActiveTo   int       `json:"active_to"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// neutral is the persona in use until Use is called: no scaling, 9 to 6
+var neutral = Persona{TypingSpeed: 1, TypoFactor: 1, ScrollSpeed: 1, ReadPace: 1, ThinkPace: 1, ActiveFrom: 9, ActiveTo: 18}
+
+var (
+	personaMu sync.RWMutex
+	current   = neutral
+)
+
+// GeneratePersona creates a random persona within believable ranges
+func GeneratePersona() Persona {
+	between := func(min, max float64) float64 { return min + rand.Float64()*(max-min) }
+	from := 7 + rand.Intn(4) // 7-10
+	return Persona{
+		TypingSpeed: between(0.8, 1.2),
+		TypoFactor:  between(0.6, 1.5),
+		ScrollSpeed: between(0.75, 1.3),
+		ReadPace:    between(0.7, 1.4),
+		ThinkPace:   between(0.8, 1.3),
+		ActiveFrom:  from,
+		ActiveTo:    from + 8 + rand.Intn(3), // 8-10 hours
+		CreatedAt:   time.Now(),
+	}
+}
+
+// LoadPersona reads the persona saved at path, generating and saving a new
+// one the first time; created reports the latter. An empty path generates
+// one for this session only.
+func LoadPersona(path string) (p Persona, created bool, err error) {
+	if path == "" {
+		return GeneratePersona(), false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &p); err != nil {
+			return Persona{}, false, fmt.Errorf("invalid persona file %s: %w", path, err)
+		}
+		return p, false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return Persona{}, false, err
+	}
+
+	p = GeneratePersona()
+	data, err = json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return Persona{}, false, err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return Persona{}, false, err
+		}
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return Persona{}, false, fmt.Errorf("failed to save persona: %w", err)
+	}
+	return p, true, nil
+}
+
+// Use makes p the persona behind SleepContextual, typing and scrolling
+func Use(p Persona) {
+	personaMu.Lock()
+	defer personaMu.Unlock()
+	current = p
+}
+
+// Current returns the persona in use
+func Current() Persona {
+	personaMu.RLock()
+	defer personaMu.RUnlock()
+	return current
+}
+
+// Typing applies the persona's speed and accuracy to a typing profile
+func (p Persona) Typing(base TypingProfile) TypingProfile {
+	if p.TypingSpeed > 0 {
+		base.WPM = max(1, int(float64(base.WPM)*p.TypingSpeed))
+	}
+	if p.TypoFactor > 0 {
+		base.TypoRate *= p.TypoFactor
+	}
+	return base
+}
+
+// Pace returns the persona's multiplier for pauses of an action type
+func (p Persona) Pace(action ActionType) float64 {
+	pace := 1.0
+	switch action {
+	case ActionTypeRead:
+		pace = p.ReadPace
+	case ActionTypeThink:
+		pace = p.ThinkPace
+	case ActionTypeScroll:
+		// Harder scrollers also pause less between flicks
+		if p.ScrollSpeed > 0 {
+			pace = 1 / p.ScrollSpeed
+		}
+	}
+	if pace <= 0 {
+		return 1
+	}
+	return pace
+}
+
+// Active reports whether t falls within the persona's working hours
+func (p Persona) Active(t time.Time) bool {
+	h := t.Hour()
+	return h >= p.ActiveFrom && h < p.ActiveTo
+}
+
+// Hours returns the persona's active hours every day in the machine's timezone
+func (p Persona) Hours() *schedule.Hours {
+	return schedule.DefaultHours(p.ActiveFrom, p.ActiveTo)
+}
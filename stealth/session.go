@@ -0,0 +1,120 @@
+package stealth
+
+import (
+	"sync"
+	"time"
+
+	"linkedin-automation/schedule"
+)
+
+// Why a SessionManager holds activity back
+const (
+	ReasonBreak    = "break"
+	ReasonLunch    = "lunch"
+	ReasonOffHours = "end of day"
+)
+
+// SessionManager models how a person uses LinkedIn over a day: sessions of
+// MinSession-MaxSession with breaks of MinBreak-MaxBreak between them, a
+// lunch gap and nothing outside business hours. A nil manager never holds
+// anything back.
+type SessionManager struct {
+	Hours *schedule.Hours
+
+	MinSession time.Duration
+	MaxSession time.Duration
+	MinBreak   time.Duration
+	MaxBreak   time.Duration
+
+	// LunchAt is the time of day lunch starts around (12h30m is 12:30), give
+	// or take a quarter hour; it lasts about Lunch. Zero Lunch skips it.
+	LunchAt time.Duration
+	Lunch   time.Duration
+
+	mu         sync.Mutex
+	sessionEnd time.Time
+	breakEnd   time.Time
+	// lunchStart and lunchEnd are the lunch of the day lunchDay, picked once a day
+	lunchDay   string
+	lunchStart time.Time
+	lunchEnd   time.Time
+}
+
+// NewSessionManager creates a manager within hours with 10-30 minute
+// sessions, 30-120 minute breaks and a 45 minute lunch around 12:30
+func NewSessionManager(hours *schedule.Hours) *SessionManager {
+	return &SessionManager{
+		Hours:      hours,
+		MinSession: 10 * time.Minute,
+		MaxSession: 30 * time.Minute,
+		MinBreak:   30 * time.Minute,
+		MaxBreak:   2 * time.Hour,
+		LunchAt:    12*time.Hour + 30*time.Minute,
+		Lunch:      45 * time.Minute,
+	}
+}
+
+// Next reports when activity may resume and why it is held back, or the
+// zero time while a session is on. The first call after a break starts a new
+// session; a session running out starts a break.
+func (m *SessionManager) Next(now time.Time) (time.Time, string) {
+	if m == nil {
+		return time.Time{}, ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.Hours.Active(now) {
+		m.sessionEnd = time.Time{}
+		return m.dayStart(now), ReasonOffHours
+	}
+	lunchStart, lunchEnd := m.lunch(now)
+	if m.Lunch > 0 && !now.Before(lunchStart) && now.Before(lunchEnd) {
+		m.sessionEnd = time.Time{}
+		return lunchEnd, ReasonLunch
+	}
+	if now.Before(m.breakEnd) {
+		return m.breakEnd, ReasonBreak
+	}
+
+	if m.sessionEnd.IsZero() {
+		end := now.Add(RandomDuration(m.MinSession, m.MaxSession))
+		// A session running into lunch ends at lunch
+		if m.Lunch > 0 && now.Before(lunchStart) && end.After(lunchStart) {
+			end = lunchStart
+		}
+		m.sessionEnd = end
+		return time.Time{}, ""
+	}
+	if now.Before(m.sessionEnd) {
+		return time.Time{}, ""
+	}
+
+	m.sessionEnd = time.Time{}
+	m.breakEnd = now.Add(RandomDuration(m.MinBreak, m.MaxBreak))
+	return m.breakEnd, ReasonBreak
+}
+
+// lunch returns the lunch window of now's day, in the hours' timezone
+func (m *SessionManager) lunch(now time.Time) (time.Time, time.Time) {
+	now = now.In(m.Hours.Location)
+	day := now.Format(time.DateOnly)
+	if m.lunchDay != day {
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		m.lunchDay = day
+		m.lunchStart = midnight.Add(m.LunchAt + RandomDuration(-15*time.Minute, 15*time.Minute))
+		m.lunchEnd = m.lunchStart.Add(RandomDuration(m.Lunch*4/5, m.Lunch*6/5))
+	}
+	return m.lunchStart, m.lunchEnd
+}
+
+// dayStart is when the next working day begins, a few minutes after the
+// hours open
+func (m *SessionManager) dayStart(now time.Time) time.Time {
+	start := m.Hours.NextStart(now)
+	if start.IsZero() {
+		// No working day ahead, check again tomorrow
+		return now.Add(24 * time.Hour)
+	}
+	return start.Add(RandomDuration(0, 20*time.Minute))
+}
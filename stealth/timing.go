@@ -73,6 +73,9 @@ func SleepContextual(action ActionType, intensity float64) {
 		config = TimingConfig{Min: 500 * time.Millisecond, Max: 1000 * time.Millisecond}
 	}
 
+	// The persona reads and thinks at its own pace
+	intensity *= Current().Pace(action)
+
 	min := time.Duration(float64(config.Min) * intensity)
 	max := time.Duration(float64(config.Max) * intensity)
 
@@ -0,0 +1,145 @@
+package stealth
+
+import (
+	"math"
+	"math/rand"
+	"time"
+	"unicode"
+)
+
+// TypingProfile is a typist's speed and accuracy
+type TypingProfile struct {
+	Name string
+	// WPM is the average speed in words (5 characters) per minute
+	WPM int
+	// TypoRate is the chance of a typo per character
+	TypoRate float64
+}
+
+// TypingProfiles are the selectable typing profiles by name
+var TypingProfiles = map[string]TypingProfile{
+	"slow":    {Name: "slow", WPM: 25, TypoRate: 0.06},
+	"average": {Name: "average", WPM: 45, TypoRate: 0.04},
+	"fast":    {Name: "fast", WPM: 75, TypoRate: 0.025},
+}
+
+// Typing returns the named profile, "average" for unknown names
+func Typing(name string) TypingProfile {
+	if p, ok := TypingProfiles[name]; ok {
+		return p
+	}
+	return TypingProfiles["average"]
+}
+
+// KeystrokeDelay returns a randomised delay for one keystroke at the
+// profile's speed, ±40% around the mean
+func (p TypingProfile) KeystrokeDelay() time.Duration {
+	mean := time.Minute / time.Duration(p.WPM*5)
+	return RandomDuration(mean*6/10, mean*14/10)
+}
+
+// TypoKind is a kind of typing mistake
+type TypoKind int
+
+const (
+	// TypoNone means the character is typed correctly
+	TypoNone TypoKind = iota
+	// TypoAdjacent hits a neighbouring key instead
+	TypoAdjacent
+	// TypoDouble types the character twice
+	TypoDouble
+	// TypoTranspose types the next character before this one
+	TypoTranspose
+)
+
+// NextTypo decides whether typing cur (followed by next, 0 at the end) goes
+// wrong and how. Transpositions need a following letter and adjacent-key
+// errors a key with neighbours on the layout.
+func (p TypingProfile) NextTypo(layout string, cur, next rune) TypoKind {
+	if rand.Float64() >= p.TypoRate {
+		return TypoNone
+	}
+	r := rand.Float64()
+	switch {
+	case r < 0.2:
+		if unicode.IsLetter(cur) {
+			return TypoDouble
+		}
+	case r < 0.4:
+		if unicode.IsLetter(cur) && unicode.IsLetter(next) && cur != next {
+			return TypoTranspose
+		}
+	}
+	if _, ok := AdjacentKey(layout, cur); ok {
+		return TypoAdjacent
+	}
+	return TypoNone
+}
+
+// Keyboard rows of the supported layouts, with each row's horizontal offset
+// in key widths so diagonal neighbours come out right
+var layouts = map[string][]struct {
+	keys   string
+	offset float64
+}{
+	"qwerty": {
+		{"1234567890-=", 0},
+		{"qwertyuiop[]", 0.5},
+		{"asdfghjkl;'", 0.75},
+		{"zxcvbnm,./", 1.25},
+	},
+	"azerty": {
+		{"1234567890", 0},
+		{"azertyuiop", 0.5},
+		{"qsdfghjklm", 0.75},
+		{"wxcvbn,;:!", 1.25},
+	},
+}
+
+// adjacency maps layout -> key -> neighbouring keys, built from layouts
+var adjacency = make(map[string]map[rune][]rune)
+
+func init() {
+	for name, rows := range layouts {
+		adj := make(map[rune][]rune)
+		for ri, row := range rows {
+			for ki, k := range []rune(row.keys) {
+				x := float64(ki) + row.offset
+				for rj := ri - 1; rj <= ri+1; rj++ {
+					if rj < 0 || rj >= len(rows) {
+						continue
+					}
+					for kj, n := range []rune(rows[rj].keys) {
+						if n == k {
+							continue
+						}
+						dx := math.Abs(float64(kj) + rows[rj].offset - x)
+						if (rj == ri && dx <= 1) || (rj != ri && dx < 1) {
+							adj[k] = append(adj[k], n)
+						}
+					}
+				}
+			}
+		}
+		adjacency[name] = adj
+	}
+}
+
+// AdjacentKey returns a random key next to c on the layout ("qwerty" or
+// "azerty", unknown names use qwerty), keeping c's case. ok is false for
+// characters not on the layout.
+func AdjacentKey(layout string, c rune) (r rune, ok bool) {
+	adj, found := adjacency[layout]
+	if !found {
+		adj = adjacency["qwerty"]
+	}
+	neighbours := adj[unicode.ToLower(c)]
+	if len(neighbours) == 0 {
+		return c, false
+	}
+	r = neighbours[rand.Intn(len(neighbours))]
+	if unicode.IsUpper(c) {
+		r = unicode.ToUpper(r)
+	}
+	return r, true
+}
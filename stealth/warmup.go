@@ -0,0 +1,47 @@
+package stealth
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// feedURL is where a session warms up
+const feedURL = "https://www.linkedin.com/feed/"
+
+// feedPostSelector matches a post in the feed
+const feedPostSelector = "div.feed-shared-update-v2, [data-urn^='urn:li:activity']"
+
+// Surfer is the browser behaviour WarmUp needs; *browser.Browser implements it
+type Surfer interface {
+	NavigateTo(url string) error
+	HumanScroll(deltaY float64) error
+	HoverRandom(selector string) error
+}
+
+// WarmUp browses the feed for a random duration between min and max before the
+// real workflow starts: scrolling, pausing to read and hovering posts. Nothing
+// is liked or clicked. It returns early when ctx is cancelled.
+func WarmUp(ctx context.Context, s Surfer, min, max time.Duration) error {
+	if err := s.NavigateTo(feedURL); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(RandomDuration(min, max))
+
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		// Mostly reading downwards, now and then back up to something passed over
+		delta := 300 + rand.Float64()*600
+		if rand.Float64() < 0.15 {
+			delta = -(150 + rand.Float64()*250)
+		}
+		s.HumanScroll(delta)
+		SleepContextual(ActionTypeRead, 1.0)
+
+		if rand.Float64() < 0.4 {
+			// A missing post is fine, the feed may still be loading
+			s.HoverRandom(feedPostSelector)
+			SleepContextual(ActionTypeThink, 1.0)
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,49 @@
+package storage
+
+import "time"
+
+// actionWindow is how long action times are kept, the longest rate limit window
+const actionWindow = 7 * 24 * time.Hour
+
+// RecordAction logs one action of the given type, dropping entries outside the window
+func (s *MemoryStore) RecordAction(action string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	times := s.Data.Actions[action]
+	kept := times[:0]
+	for _, t := range times {
+		if now.Sub(t) < actionWindow {
+			kept = append(kept, t)
+		}
+	}
+	s.Data.Actions[action] = append(kept, now)
+	return s.persist()
+}
+
+// ActionsSince counts actions of the type at or after t (at most 7 days back)
+func (s *MemoryStore) ActionsSince(action string, t time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, at := range s.Data.Actions[action] {
+		if !at.Before(t) {
+			count++
+		}
+	}
+	return count
+}
+
+// LastAction returns the time of the latest action of the type, zero if none
+func (s *MemoryStore) LastAction(action string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	times := s.Data.Actions[action]
+	if len(times) == 0 {
+		return time.Time{}
+	}
+	return times[len(times)-1]
+}
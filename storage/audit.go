@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"linkedin-automation/profile"
+)
+
+// AuditEntry is one action in the append-only audit log: who did what to
+// whom, with which template, and how it ended
+type AuditEntry struct {
+	Time       time.Time         `json:"time"`
+	Account    string            `json:"account,omitempty"`
+	Action     string            `json:"action"`
+	ProfileURL string            `json:"profile_url"`
+	Template   string            `json:"template,omitempty"`
+	Variant    string            `json:"variant,omitempty"`
+	Result     string            `json:"result"`
+	Error      string            `json:"error,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// EnableAudit appends every Audit entry to path as JSON Lines. With a
+// Postgres backend entries go to the bot_audit table instead.
+func (s *MemoryStore) EnableAudit(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditFile = path
+}
+
+// Audit appends an entry to the audit log; a no-op when none is enabled.
+// Entries are never rewritten or pruned.
+func (s *MemoryStore) Audit(e AuditEntry) error {
+	e.ProfileURL = profile.Canonical(e.ProfileURL)
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if s.backend != nil {
+		return s.backend.audit(e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.auditFile == "" {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// AuditTrail returns the logged entries for a profile, oldest first, or all
+// of them for an empty URL
+func (s *MemoryStore) AuditTrail(profileURL string) ([]AuditEntry, error) {
+	key := ""
+	if profileURL != "" {
+		key = profile.Canonical(profileURL)
+	}
+	if s.backend != nil {
+		return s.backend.auditTrail(key)
+	}
+
+	s.mu.RLock()
+	path := s.auditFile
+	s.mu.RUnlock()
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// A line cut short by a crash doesn't hide the rest
+			continue
+		}
+		if key == "" || e.ProfileURL == key {
+			entries = append(entries, e)
+		}
+	}
+	return entries, sc.Err()
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat sorts lexically in chronological order
+const backupTimeFormat = "20060102-150405"
+
+// EnableBackups turns on rotating backups of the state file into dir.
+// A backup is written on Close and, if every > 0, after every N saves.
+// Only the newest keep backups are retained (keep <= 0 keeps all).
+func (s *MemoryStore) EnableBackups(dir string, keep, every int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backupDir = dir
+	s.backupKeep = keep
+	s.backupEvery = every
+	return nil
+}
+
+// backup copies the current state file into the backup dir and prunes old copies.
+// Caller must hold the lock.
+func (s *MemoryStore) backup() error {
+	if s.backupDir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.File)
+	if err != nil {
+		return fmt.Errorf("failed to read state for backup: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(s.File), filepath.Ext(s.File))
+	stamp := time.Now().Format(backupTimeFormat)
+	path := filepath.Join(s.backupDir, fmt.Sprintf("%s-%s.json", base, stamp))
+	// Another backup in the same second gets a suffix, which sorts after it
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		path = filepath.Join(s.backupDir, fmt.Sprintf("%s-%s_%02d.json", base, stamp, n))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return s.pruneBackups(base)
+}
+
+func (s *MemoryStore) pruneBackups(base string) error {
+	if s.backupKeep <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(s.backupDir, base+"-*.json"))
+	if err != nil {
+		return err
+	}
+	if len(matches) <= s.backupKeep {
+		return nil
+	}
+
+	sort.Strings(matches)
+	for _, old := range matches[:len(matches)-s.backupKeep] {
+		if err := os.Remove(old); err != nil {
+			return fmt.Errorf("failed to prune backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// RestoreBackup replaces the state file at statePath with the given backup.
+// Must be called before the store is opened.
+func RestoreBackup(backupPath, statePath string) error {
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	// Refuse to restore something that isn't a state file
+	var probe StateData
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return fmt.Errorf("invalid backup: %w", err)
+	}
+
+	return os.WriteFile(statePath, data, 0644)
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"linkedin-automation/profile"
+)
+
+// canonicalize rekeys state written before profile URLs were canonicalised
+// (trailing slashes, query strings, locale paths...), so old entries match
+// the canonical URLs lookups use and nobody is contacted twice. Entries that
+// collapse onto one key are merged: first events keep the earliest time,
+// last events the latest.
+func (d *StateData) canonicalize() {
+	d.Requests = rekey(d.Requests, earliest)
+	d.Messages = rekey(d.Messages, earliest)
+	d.Connections = rekey(d.Connections, earliest)
+	d.Replies = rekey(d.Replies, earliest)
+	d.Withdrawn = rekey(d.Withdrawn, latest)
+	d.Endorsements = rekey(d.Endorsements, latest)
+	d.Views = rekey(d.Views, latest)
+	d.Profiles = rekey(d.Profiles, nil)
+	d.Visits = rekey(d.Visits, mergeTimes)
+	d.Congrats = rekey(d.Congrats, mergeTimes)
+	d.Attempts = rekey(d.Attempts, func(a, b []Attempt) []Attempt {
+		all := append(a, b...)
+		slices.SortFunc(all, func(x, y Attempt) int { return x.SentAt.Compare(y.SentAt) })
+		return all
+	})
+	d.PendingMessages = rekey(d.PendingMessages, nil)
+	for key, m := range d.PendingMessages {
+		m.ProfileURL = key
+		d.PendingMessages[key] = m
+	}
+	for name, seq := range d.Sequences {
+		d.Sequences[name] = rekey(seq, func(a, b SequenceProgress) SequenceProgress {
+			if b.Step > a.Step {
+				return b
+			}
+			return a
+		})
+	}
+	for name, cs := range d.Campaigns {
+		for action, m := range cs.Actions {
+			cs.Actions[action] = rekey(m, earliest)
+		}
+		for kind, m := range cs.Variants {
+			cs.Variants[kind] = rekey(m, nil)
+		}
+		d.Campaigns[name] = cs
+	}
+	for workflow, q := range d.PendingTargets {
+		for i := range q.Targets {
+			q.Targets[i].URL = profile.Canonical(q.Targets[i].URL)
+		}
+		d.PendingTargets[workflow] = q
+	}
+}
+
+// rekey returns m keyed by canonical profile URL. merge combines two values
+// landing on the same key; nil keeps the one already there.
+func rekey[V any](m map[string]V, merge func(a, b V) V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]V, len(m))
+	// Keys already canonical go first so they win without a merge
+	for _, pass := range []bool{true, false} {
+		for k, v := range m {
+			key := profile.Canonical(k)
+			if (key == k) != pass {
+				continue
+			}
+			if old, ok := out[key]; ok {
+				if merge != nil {
+					out[key] = merge(old, v)
+				}
+				continue
+			}
+			out[key] = v
+		}
+	}
+	return out
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// mergeTimes merges per-kind times, keeping the latest of each
+func mergeTimes(a, b map[string]time.Time) map[string]time.Time {
+	if a == nil {
+		a = make(map[string]time.Time)
+	}
+	for k, t := range b {
+		a[k] = latest(a[k], t)
+	}
+	return a
+}
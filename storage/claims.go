@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"linkedin-automation/profile"
+)
+
+// claimLockWait is how long a claim waits for another account holding the claims file
+const claimLockWait = 30 * time.Second
+
+// defaultClaimCampaign holds the claims of runs without a campaign
+const defaultClaimCampaign = "default"
+
+// Claim records which account took a profile in a campaign
+type Claim struct {
+	Account string    `json:"account"`
+	At      time.Time `json:"at"`
+}
+
+// claimer keeps the claims: a Claims file shared by one machine's accounts,
+// or the shared Postgres database. claim and holder return the other account
+// holding the profile, "" when it is free or ours.
+type claimer interface {
+	claim(campaign, key string) (string, error)
+	release(campaign, key string) error
+	holder(campaign, key string) (string, error)
+}
+
+// Claims is a file shared by the accounts of one machine, so accounts running
+// at the same time never target the same profile in the same campaign. Each
+// read-modify-write holds the file's lock.
+type Claims struct {
+	path    string
+	account string
+}
+
+// EnableClaims makes the store claim profiles for account in the claims file at path
+func (s *MemoryStore) EnableClaims(path, account string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = &Claims{path: path, account: account}
+}
+
+// Claim takes the profile for this account in campaign. It returns the other
+// account that already holds it, "" when the claim is ours. Without a claims
+// file or shared database every claim succeeds.
+func (s *MemoryStore) Claim(campaign, profileURL string) (string, error) {
+	if s.claims == nil {
+		return "", nil
+	}
+	return s.claims.claim(campaign, profile.Canonical(profileURL))
+}
+
+// Release gives up this account's claim on the profile in campaign, for an
+// attempt that sent nothing, so another account may take it
+func (s *MemoryStore) Release(campaign, profileURL string) error {
+	if s.claims == nil {
+		return nil
+	}
+	return s.claims.release(campaign, profile.Canonical(profileURL))
+}
+
+// ClaimedBy returns the other account holding the profile in campaign, "" if none
+func (s *MemoryStore) ClaimedBy(campaign, profileURL string) string {
+	if s.claims == nil {
+		return ""
+	}
+	other, err := s.claims.holder(campaign, profile.Canonical(profileURL))
+	if err != nil {
+		return ""
+	}
+	return other
+}
+
+func (c *Claims) holder(campaign, key string) (string, error) {
+	all, err := c.load()
+	if err != nil {
+		return "", err
+	}
+	held, ok := all[claimCampaign(campaign)][key]
+	if !ok || held.Account == c.account {
+		return "", nil
+	}
+	return held.Account, nil
+}
+
+func (c *Claims) claim(campaign, key string) (string, error) {
+	l, err := acquireLock(c.path, claimLockWait)
+	if err != nil {
+		return "", err
+	}
+	defer l.release()
+
+	all, err := c.load()
+	if err != nil {
+		return "", err
+	}
+	campaign = claimCampaign(campaign)
+	if held, ok := all[campaign][key]; ok {
+		if held.Account != c.account {
+			return held.Account, nil
+		}
+		return "", nil
+	}
+	if all[campaign] == nil {
+		all[campaign] = make(map[string]Claim)
+	}
+	all[campaign][key] = Claim{Account: c.account, At: time.Now()}
+	return "", c.save(all)
+}
+
+func (c *Claims) release(campaign, key string) error {
+	l, err := acquireLock(c.path, claimLockWait)
+	if err != nil {
+		return err
+	}
+	defer l.release()
+
+	all, err := c.load()
+	if err != nil {
+		return err
+	}
+	campaign = claimCampaign(campaign)
+	if held, ok := all[campaign][key]; !ok || held.Account != c.account {
+		return nil
+	}
+	delete(all[campaign], key)
+	return c.save(all)
+}
+
+// save writes the claims through a temporary file; the caller holds the lock
+func (c *Claims) save(all map[string]map[string]Claim) error {
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// load reads the claims by campaign and profile URL; a missing file has none
+func (c *Claims) load() (map[string]map[string]Claim, error) {
+	all := make(map[string]map[string]Claim)
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func claimCampaign(campaign string) string {
+	if campaign == "" {
+		return defaultClaimCampaign
+	}
+	return campaign
+}
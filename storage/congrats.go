@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"linkedin-automation/profile"
+)
+
+// MarkCongratulated records that a connection was congratulated on event,
+// a key naming the occasion such as "job_change:acme"
+func (s *MemoryStore) MarkCongratulated(profileURL, event string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, now := profile.Canonical(profileURL), time.Now()
+	if s.Data.Congrats[key] == nil {
+		s.Data.Congrats[key] = make(map[string]time.Time)
+	}
+	s.Data.Congrats[key][event] = now
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgCongrats, key, "", now)
+}
+
+// CongratulatedAt returns when the profile was congratulated on event, zero if never
+func (s *MemoryStore) CongratulatedAt(profileURL, event string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Congrats[profile.Canonical(profileURL)][event]
+}
+
+// CongratsSince counts congratulations on occasions of kind (the event key's
+// prefix, "" for all) sent after t
+func (s *MemoryStore) CongratsSince(kind string, t time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, events := range s.Data.Congrats {
+		for event, at := range events {
+			if at.After(t) && (kind == "" || strings.HasPrefix(event, kind+":")) {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"linkedin-automation/profile"
+)
+
+// ErrExcluded is returned when a profile matches the exclusion list
+var ErrExcluded = errors.New("profile is on the exclusion list")
+
+// ErrUnknownExclusion is returned by Exclude for a kind other than profile, company or keyword
+var ErrUnknownExclusion = errors.New("unknown exclusion kind")
+
+// Kinds of exclusions
+const (
+	ExcludeProfile = "profile"
+	ExcludeCompany = "company"
+	ExcludeKeyword = "keyword"
+)
+
+// Exclusions are profiles, companies and headline keywords never to contact.
+// Companies and keywords match case-insensitively.
+type Exclusions struct {
+	Profiles  []string `json:"profiles,omitempty"`
+	Companies []string `json:"companies,omitempty"`
+	Keywords  []string `json:"keywords,omitempty"`
+}
+
+// Merge returns the union of e and o
+func (e Exclusions) Merge(o Exclusions) Exclusions {
+	return Exclusions{
+		Profiles:  append(slices.Clip(e.Profiles), o.Profiles...),
+		Companies: append(slices.Clip(e.Companies), o.Companies...),
+		Keywords:  append(slices.Clip(e.Keywords), o.Keywords...),
+	}
+}
+
+// Match reports why a profile is excluded, "" if it isn't. company and
+// headline may be empty when they aren't known yet; a company also matches
+// when the headline reads "... at <company>".
+func (e Exclusions) Match(profileURL, company, headline string) string {
+	url := profile.Canonical(profileURL)
+	for _, p := range e.Profiles {
+		if profile.Canonical(p) == url {
+			return "profile " + p
+		}
+	}
+
+	lowerHeadline := strings.ToLower(headline)
+	for _, c := range e.Companies {
+		lc := strings.ToLower(strings.TrimSpace(c))
+		if lc == "" {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(company), lc) || strings.Contains(lowerHeadline, " at "+lc) {
+			return fmt.Sprintf("company %q", c)
+		}
+	}
+	for _, k := range e.Keywords {
+		if lk := strings.ToLower(strings.TrimSpace(k)); lk != "" && strings.Contains(lowerHeadline, lk) {
+			return fmt.Sprintf("keyword %q", k)
+		}
+	}
+	return ""
+}
+
+// Exclude adds value to the stored exclusion list of the given kind
+func (s *MemoryStore) Exclude(kind, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("empty %s exclusion", kind)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list *[]string
+	switch kind {
+	case ExcludeProfile:
+		if _, err := profile.Parse(value); err != nil {
+			return fmt.Errorf("%w: %s", err, value)
+		}
+		value = profile.Canonical(value)
+		list = &s.Data.Exclusions.Profiles
+	case ExcludeCompany:
+		list = &s.Data.Exclusions.Companies
+	case ExcludeKeyword:
+		list = &s.Data.Exclusions.Keywords
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownExclusion, kind)
+	}
+	if slices.ContainsFunc(*list, func(v string) bool { return strings.EqualFold(v, value) }) {
+		return nil
+	}
+	*list = append(*list, value)
+	return s.persist()
+}
+
+// Exclusions returns a copy of the stored exclusion list
+func (s *MemoryStore) Exclusions() Exclusions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Exclusions{}.Merge(s.Data.Exclusions)
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"linkedin-automation/profile"
+)
+
+// Attempt is one invitation to a profile. It is open while pending; an
+// acceptance or a withdrawal closes it.
+type Attempt struct {
+	SentAt      time.Time `json:"sent_at,omitzero"`
+	AcceptedAt  time.Time `json:"accepted_at,omitzero"`
+	WithdrawnAt time.Time `json:"withdrawn_at,omitzero"`
+
+	// Note is the ID of the note template it was sent with, see NoteID
+	Note string `json:"note,omitempty"`
+}
+
+// pending reports whether the attempt is neither accepted nor withdrawn
+func (a Attempt) pending() bool {
+	return a.AcceptedAt.IsZero() && a.WithdrawnAt.IsZero()
+}
+
+// NoteID identifies a note template by its text, so acceptance can be
+// compared between templates without naming them
+func NoteID(template string) string {
+	sum := sha256.Sum256([]byte(template))
+	return hex.EncodeToString(sum[:4])
+}
+
+// openAttempt appends an attempt sent at t unless the last one is still
+// pending. The caller holds the lock.
+func (s *MemoryStore) openAttempt(key string, t time.Time) {
+	attempts := s.Data.Attempts[key]
+	if len(attempts) == 0 && !s.Data.Withdrawn[key].IsZero() {
+		// Withdrawn before attempts were recorded
+		attempts = []Attempt{{WithdrawnAt: s.Data.Withdrawn[key]}}
+	}
+	if n := len(attempts); n > 0 && attempts[n-1].pending() {
+		return
+	}
+	s.Data.Attempts[key] = append(attempts, Attempt{SentAt: t})
+}
+
+// closeAttempt marks the pending attempt withdrawn at t, recording one with an
+// unknown send time for invitations sent outside the bot. The caller holds the lock.
+func (s *MemoryStore) closeAttempt(key string, t time.Time) {
+	attempts := s.Data.Attempts[key]
+	if n := len(attempts); n > 0 && attempts[n-1].pending() {
+		attempts[n-1].WithdrawnAt = t
+		return
+	}
+	s.Data.Attempts[key] = append(attempts, Attempt{WithdrawnAt: t})
+}
+
+// acceptAttempt marks the pending attempt accepted at t. The caller holds the lock.
+func (s *MemoryStore) acceptAttempt(key string, t time.Time) {
+	if sent, ok := s.Data.Requests[key]; ok {
+		// Pending before attempts were recorded
+		s.openAttempt(key, sent)
+	}
+	attempts := s.Data.Attempts[key]
+	if n := len(attempts); n > 0 && attempts[n-1].pending() {
+		attempts[n-1].AcceptedAt = t
+	}
+}
+
+// SetInviteNote records the note template the pending invitation to a profile
+// was sent with
+func (s *MemoryStore) SetInviteNote(profileURL, template string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := profile.Canonical(profileURL)
+	attempts := s.Data.Attempts[key]
+	n := len(attempts)
+	if n == 0 || !attempts[n-1].pending() {
+		return nil
+	}
+	id := NoteID(template)
+	attempts[n-1].Note = id
+	s.Data.Notes[id] = template
+	return s.persist()
+}
+
+// UndoInvite records an invitation withdrawn in the undo window right after
+// it was sent: it is kept as a withdrawn attempt, so the re-invite cooldown
+// applies, but no longer counts toward the invitation limits
+func (s *MemoryStore) UndoInvite(profileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, now := profile.Canonical(profileURL), time.Now()
+	sent := now
+	if n := len(s.Data.SentInvites); n > 0 {
+		sent = s.Data.SentInvites[n-1]
+		s.Data.SentInvites = s.Data.SentInvites[:n-1]
+	}
+	s.openAttempt(key, sent)
+	s.closeAttempt(key, now)
+	s.Data.Withdrawn[key] = now
+	return s.persist()
+}
+
+// Attempts returns the invitations sent to a profile, oldest first. Profiles
+// invited before attempts were recorded get one rebuilt from the request and
+// withdrawal times.
+func (s *MemoryStore) Attempts(profileURL string) []Attempt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := profile.Canonical(profileURL)
+	if attempts := s.Data.Attempts[key]; len(attempts) > 0 {
+		return append([]Attempt(nil), attempts...)
+	}
+	a := Attempt{SentAt: s.Data.Requests[key], WithdrawnAt: s.Data.Withdrawn[key]}
+	if a.SentAt.IsZero() && a.WithdrawnAt.IsZero() {
+		return nil
+	}
+	return []Attempt{a}
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLocked is returned when another instance holds the state file lock
+var ErrLocked = errors.New("another instance is running on this state file")
+
+// acquireLock takes the lock for statePath, retrying until wait elapses (0 = fail immediately)
+func acquireLock(statePath string, wait time.Duration) (*fileLock, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		l, err := tryLock(statePath + ".lock")
+		if err == nil {
+			return l, nil
+		}
+		if !errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("failed to lock state file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(time.Second)
+	}
+}
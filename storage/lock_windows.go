@@ -0,0 +1,55 @@
+//go:build windows
+
+package storage
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fileLock is an exclusive PID file. A lock left behind by a dead process is reclaimed.
+type fileLock struct {
+	path string
+}
+
+func tryLock(path string) (*fileLock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			f.Close()
+			return &fileLock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if !staleLock(path) {
+			return nil, ErrLocked
+		}
+		os.Remove(path)
+	}
+	return nil, ErrLocked
+}
+
+// staleLock reports whether the PID in the lock file no longer exists
+func staleLock(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return true
+	}
+	p.Release()
+	return false
+}
+
+func (l *fileLock) release() error {
+	return os.Remove(l.path)
+}
@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrContactedElsewhere is returned when another account sharing the claims
+// file or Postgres database already holds the profile
+var ErrContactedElsewhere = errors.New("profile already contacted by another account")
+
+// ErrNoPostgresDriver is returned by OpenPostgres in builds without the driver
+var ErrNoPostgresDriver = errors.New("postgres support not built in, rebuild with -tags postgres")
+
+// postgresSchema is applied on every open. bot_state holds each account's
+// state document (queues, timestamps, settings); bot_profiles holds the
+// account that claimed each profile, so accounts never contact the same
+// person twice; bot_actions logs every action with its campaign,
+// bot_campaigns tracks which accounts work each campaign and bot_counters
+// counts the actions outside campaigns per account and day. bot_audit is the
+// append-only audit log. Databases from before bot_counters was a table have
+// the view replaced, filled from bot_actions.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS bot_state (
+	account    TEXT PRIMARY KEY,
+	data       JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS bot_profiles (
+	profile_url      TEXT PRIMARY KEY,
+	account          TEXT NOT NULL,
+	first_contact_at TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE bot_profiles ADD COLUMN IF NOT EXISTS campaign TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS bot_actions (
+	id          BIGSERIAL PRIMARY KEY,
+	account     TEXT NOT NULL,
+	action      TEXT NOT NULL,
+	profile_url TEXT NOT NULL,
+	campaign    TEXT NOT NULL DEFAULT '',
+	at          TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS bot_actions_profile ON bot_actions (profile_url);
+CREATE INDEX IF NOT EXISTS bot_actions_account_at ON bot_actions (account, at);
+CREATE TABLE IF NOT EXISTS bot_audit (
+	id          BIGSERIAL PRIMARY KEY,
+	at          TIMESTAMPTZ NOT NULL,
+	account     TEXT NOT NULL,
+	action      TEXT NOT NULL,
+	profile_url TEXT NOT NULL,
+	template    TEXT NOT NULL DEFAULT '',
+	variant     TEXT NOT NULL DEFAULT '',
+	result      TEXT NOT NULL,
+	error       TEXT NOT NULL DEFAULT '',
+	metadata    JSONB
+);
+CREATE INDEX IF NOT EXISTS bot_audit_profile ON bot_audit (profile_url);
+CREATE TABLE IF NOT EXISTS bot_campaigns (
+	campaign        TEXT NOT NULL,
+	account         TEXT NOT NULL,
+	actions         BIGINT NOT NULL,
+	first_action_at TIMESTAMPTZ NOT NULL,
+	last_action_at  TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (campaign, account)
+);
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_views WHERE viewname = 'bot_counters') THEN
+		DROP VIEW bot_counters;
+		CREATE TABLE bot_counters (
+			account TEXT NOT NULL,
+			action  TEXT NOT NULL,
+			day     DATE NOT NULL,
+			total   BIGINT NOT NULL,
+			PRIMARY KEY (account, action, day)
+		);
+		INSERT INTO bot_counters (account, action, day, total)
+			SELECT account, action, at::date, count(*) FROM bot_actions
+			WHERE campaign = '' GROUP BY account, action, at::date;
+	END IF;
+END $$;
+CREATE TABLE IF NOT EXISTS bot_counters (
+	account TEXT NOT NULL,
+	action  TEXT NOT NULL,
+	day     DATE NOT NULL,
+	total   BIGINT NOT NULL,
+	PRIMARY KEY (account, action, day)
+);
+`
+
+// Actions logged to bot_actions
+const (
+	pgConnect   = "connect"
+	pgMessage   = "message"
+	pgConnected = "connected"
+	pgWithdraw  = "withdraw"
+	pgEndorse   = "endorse"
+	pgView      = "view"
+	pgCongrats  = "congrats"
+)
+
+// postgres keeps a MemoryStore's state in PostgreSQL instead of a file. The
+// state document is written by a background writer, so a slow database
+// doesn't hold the store's mutex; only the newest pending document is kept.
+type postgres struct {
+	db      *sql.DB
+	conn    *sql.Conn // holds the account's advisory lock
+	account string
+
+	pending chan []byte
+	written chan struct{} // closed when the writer has stopped
+	errMu   sync.Mutex
+	err     error // the last failed write, returned by the next save
+}
+
+// OpenPostgres opens the account's state in the PostgreSQL database at dsn,
+// creating the tables on first use. Like Open it fails with ErrLocked when
+// another instance runs the same account, after waiting up to lockWait.
+func OpenPostgres(dsn, account string, lockWait time.Duration) (*MemoryStore, error) {
+	pg, err := connectPostgres(dsn, account)
+	if err != nil {
+		return nil, err
+	}
+	if err := pg.lock(lockWait); err != nil {
+		pg.db.Close()
+		return nil, err
+	}
+	s, err := pg.load()
+	if err != nil {
+		pg.close()
+		return nil, err
+	}
+	pg.startWriter()
+	s.backend = pg
+	s.claims = pg
+	return s, nil
+}
+
+// PostgresSnapshot loads the account's state read-only, without taking the lock
+func PostgresSnapshot(dsn, account string) (*MemoryStore, error) {
+	pg, err := connectPostgres(dsn, account)
+	if err != nil {
+		return nil, err
+	}
+	defer pg.db.Close()
+	return pg.load()
+}
+
+func connectPostgres(dsn, account string) (*postgres, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		if !driverRegistered("postgres") {
+			return nil, ErrNoPostgresDriver
+		}
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := db.ExecContext(ctx, postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare postgres schema: %w", err)
+	}
+	return &postgres{db: db, account: account}, nil
+}
+
+func driverRegistered(name string) bool {
+	for _, d := range sql.Drivers() {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lock takes a session advisory lock on the account, retrying until wait elapses
+func (p *postgres) lock(wait time.Duration) error {
+	conn, err := p.db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		var ok bool
+		err := conn.QueryRowContext(context.Background(), `SELECT pg_try_advisory_lock(hashtext('linkedin-bot:' || $1))`, p.account).Scan(&ok)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+		if ok {
+			p.conn = conn
+			return nil
+		}
+		if time.Now().After(deadline) {
+			conn.Close()
+			return ErrLocked
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (p *postgres) load() (*MemoryStore, error) {
+	s := newMemoryStore("")
+	var data []byte
+	err := p.db.QueryRow(`SELECT data FROM bot_state WHERE account = $1`, p.account).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decode(data); err != nil {
+		return nil, fmt.Errorf("invalid state for account %q: %w", p.account, err)
+	}
+	return s, nil
+}
+
+// startWriter starts the goroutine writing the state documents save queues
+func (p *postgres) startWriter() {
+	p.pending = make(chan []byte, 1)
+	p.written = make(chan struct{})
+	go func() {
+		defer close(p.written)
+		for data := range p.pending {
+			_, err := p.db.Exec(`INSERT INTO bot_state (account, data, updated_at) VALUES ($1, $2, now())
+				ON CONFLICT (account) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`, p.account, data)
+			p.errMu.Lock()
+			p.err = err
+			p.errMu.Unlock()
+		}
+	}()
+}
+
+// save queues the state document for the writer, replacing one not yet
+// written. It returns the error of the last write. The caller holds the
+// store's lock, so there is one sender.
+func (p *postgres) save(data []byte) error {
+	if p.pending == nil {
+		return errors.New("postgres state is closed")
+	}
+	select {
+	case <-p.pending:
+	default:
+	}
+	p.pending <- data
+
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	return p.err
+}
+
+// record logs an action and counts it towards its campaign or the account's
+// daily counters
+func (p *postgres) record(action, profileURL, campaign string, at time.Time) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO bot_actions (account, action, profile_url, campaign, at) VALUES ($1, $2, $3, $4, $5)`,
+		p.account, action, profileURL, campaign, at); err != nil {
+		return err
+	}
+	if campaign != "" {
+		_, err = tx.Exec(`INSERT INTO bot_campaigns (campaign, account, actions, first_action_at, last_action_at) VALUES ($1, $2, 1, $3, $3)
+			ON CONFLICT (campaign, account) DO UPDATE SET actions = bot_campaigns.actions + 1, last_action_at = EXCLUDED.last_action_at`,
+			campaign, p.account, at)
+	} else {
+		_, err = tx.Exec(`INSERT INTO bot_counters (account, action, day, total) VALUES ($1, $2, $3::timestamptz::date, 1)
+			ON CONFLICT (account, action, day) DO UPDATE SET total = bot_counters.total + 1`,
+			p.account, action, at)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// claim takes the profile for the account in one statement, so two machines
+// can't both win it. The shared database holds one claim per profile,
+// whatever the campaign: accounts never contact the same person twice.
+func (p *postgres) claim(campaign, key string) (string, error) {
+	var holder string
+	// The no-op update locks and returns an existing claim instead of skipping it
+	err := p.db.QueryRow(`INSERT INTO bot_profiles (profile_url, account, campaign, first_contact_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (profile_url) DO UPDATE SET profile_url = EXCLUDED.profile_url
+		RETURNING account`, key, p.account, campaign).Scan(&holder)
+	if err != nil || holder == p.account {
+		return "", err
+	}
+	return holder, nil
+}
+
+func (p *postgres) release(campaign, key string) error {
+	_, err := p.db.Exec(`DELETE FROM bot_profiles WHERE profile_url = $1 AND account = $2`, key, p.account)
+	return err
+}
+
+func (p *postgres) holder(campaign, key string) (string, error) {
+	var holder string
+	err := p.db.QueryRow(`SELECT account FROM bot_profiles WHERE profile_url = $1`, key).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) || holder == p.account {
+		return "", nil
+	}
+	return holder, err
+}
+
+func (p *postgres) audit(e AuditEntry) error {
+	if e.Account == "" {
+		e.Account = p.account
+	}
+	var meta []byte
+	if len(e.Metadata) > 0 {
+		var err error
+		if meta, err = json.Marshal(e.Metadata); err != nil {
+			return err
+		}
+	}
+	_, err := p.db.Exec(`INSERT INTO bot_audit (at, account, action, profile_url, template, variant, result, error, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.Time, e.Account, e.Action, e.ProfileURL, e.Template, e.Variant, e.Result, e.Error, meta)
+	return err
+}
+
+// auditTrail returns the audit entries for a profile ("" for all), oldest first
+func (p *postgres) auditTrail(profileURL string) ([]AuditEntry, error) {
+	rows, err := p.db.Query(`SELECT at, account, action, profile_url, template, variant, result, error, metadata
+		FROM bot_audit WHERE $1 = '' OR profile_url = $1 ORDER BY id`, profileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var meta []byte
+		if err := rows.Scan(&e.Time, &e.Account, &e.Action, &e.ProfileURL, &e.Template, &e.Variant, &e.Result, &e.Error, &meta); err != nil {
+			return nil, err
+		}
+		if len(meta) > 0 {
+			json.Unmarshal(meta, &e.Metadata)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (p *postgres) close() error {
+	var err error
+	if p.pending != nil {
+		// Let the writer finish the last document before unlocking
+		close(p.pending)
+		<-p.written
+		p.pending = nil
+		err = p.err
+	}
+	if p.conn != nil {
+		p.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext('linkedin-bot:' || $1))`, p.account)
+		p.conn.Close()
+		p.conn = nil
+	}
+	return errors.Join(err, p.db.Close())
+}
+
+// record logs an action to the shared database; a no-op for file state.
+// Caller must hold the lock.
+func (s *MemoryStore) record(action, key, campaign string, at time.Time) error {
+	if s.backend == nil {
+		return nil
+	}
+	return s.backend.record(action, key, campaign, at)
+}
+
+// persistPostgres saves the state document; caller must hold the lock
+func (s *MemoryStore) persistPostgres() error {
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return err
+	}
+	return s.backend.save(data)
+}
@@ -0,0 +1,7 @@
+//go:build postgres
+
+package storage
+
+// The Postgres backend's driver, only linked into builds with -tags postgres
+// (after go get github.com/lib/pq) so the default build stays dependency-free
+import _ "github.com/lib/pq"
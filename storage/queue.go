@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"time"
+
+	"linkedin-automation/profile"
+)
+
+// Work queue target statuses
+const (
+	TargetPending = "pending"
+	TargetDone    = "done"
+	TargetSkipped = "skipped"
+	TargetFailed  = "failed"
+)
+
+// PendingTarget is one profile a workflow queued to act on
+type PendingTarget struct {
+	URL    string `json:"url"`
+	Status string `json:"status"`
+	// Keyword is the search that found the profile, Vars its extra template variables
+	Keyword   string            `json:"keyword,omitempty"`
+	Vars      map[string]string `json:"vars,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at,omitzero"`
+}
+
+// WorkQueue is the persisted target list of one workflow. Key identifies
+// what filled it (the search, campaign...), so a run with different inputs
+// starts a new queue instead of resuming this one.
+type WorkQueue struct {
+	Key       string          `json:"key"`
+	CreatedAt time.Time       `json:"created_at"`
+	Targets   []PendingTarget `json:"targets"`
+}
+
+// Pending counts the targets not yet handled
+func (q WorkQueue) Pending() int {
+	n := 0
+	for _, t := range q.Targets {
+		if t.Status == TargetPending {
+			n++
+		}
+	}
+	return n
+}
+
+// SetQueue replaces the work queue of a workflow with targets, all pending
+func (s *MemoryStore) SetQueue(workflow, key string, targets []PendingTarget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := WorkQueue{Key: key, CreatedAt: time.Now(), Targets: make([]PendingTarget, len(targets))}
+	for i, t := range targets {
+		t.URL = profile.Canonical(t.URL)
+		t.Status = TargetPending
+		q.Targets[i] = t
+	}
+	s.Data.PendingTargets[workflow] = q
+	return s.persist()
+}
+
+// Queue returns a copy of the work queue of a workflow
+func (s *MemoryStore) Queue(workflow string) (WorkQueue, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q, ok := s.Data.PendingTargets[workflow]
+	if !ok {
+		return WorkQueue{}, false
+	}
+	q.Targets = append([]PendingTarget(nil), q.Targets...)
+	return q, true
+}
+
+// ResumableQueue returns the work queue of a workflow when it was filled with
+// key no longer than maxAge ago (0 = any age) and still has pending targets
+func (s *MemoryStore) ResumableQueue(workflow, key string, maxAge time.Duration) (WorkQueue, bool) {
+	q, ok := s.Queue(workflow)
+	if !ok || q.Key != key || q.Pending() == 0 {
+		return WorkQueue{}, false
+	}
+	if maxAge > 0 && time.Since(q.CreatedAt) > maxAge {
+		return WorkQueue{}, false
+	}
+	return q, true
+}
+
+// SetTargetStatus records how a queued target was handled; errMsg explains a
+// skip or failure. Targets not in the queue are ignored.
+func (s *MemoryStore) SetTargetStatus(workflow, profileURL, status, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.Data.PendingTargets[workflow]
+	if !ok {
+		return nil
+	}
+	key := profile.Canonical(profileURL)
+	for i := range q.Targets {
+		if q.Targets[i].URL == key {
+			q.Targets[i].Status = status
+			q.Targets[i].Error = errMsg
+			q.Targets[i].UpdatedAt = time.Now()
+			return s.persist()
+		}
+	}
+	return nil
+}
+
+// ClearQueue drops the work queue of a workflow
+func (s *MemoryStore) ClearQueue(workflow string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Data.PendingTargets[workflow]; !ok {
+		return nil
+	}
+	delete(s.Data.PendingTargets, workflow)
+	return s.persist()
+}
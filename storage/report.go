@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// maxErrors is how many recent failures the state file keeps
+const maxErrors = 50
+
+// ErrorEntry is one failed action or run, for the dashboard
+type ErrorEntry struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	ProfileURL string    `json:"profile_url,omitempty"`
+	Error      string    `json:"error"`
+}
+
+// Funnel counts profiles through the outreach stages. Each stage only counts
+// profiles that passed the previous one: a connection the bot never invited
+// is not "accepted".
+type Funnel struct {
+	Sent     int `json:"sent"`
+	Accepted int `json:"accepted"`
+	Messaged int `json:"messaged"`
+	Replied  int `json:"replied"`
+}
+
+// Snapshot loads the state file without locking it, for read-only viewers
+// running next to the bot. It must not be written to.
+func Snapshot(path string) (*MemoryStore, error) {
+	return load(path)
+}
+
+// RecordError logs a failure, keeping the newest maxErrors
+func (s *MemoryStore) RecordError(action, profileURL, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Data.Errors = append(s.Data.Errors, ErrorEntry{
+		Time:       time.Now(),
+		Action:     action,
+		ProfileURL: profileURL,
+		Error:      message,
+	})
+	if len(s.Data.Errors) > maxErrors {
+		s.Data.Errors = s.Data.Errors[len(s.Data.Errors)-maxErrors:]
+	}
+	return s.persist()
+}
+
+// RecentErrors returns up to n logged failures, newest first
+func (s *MemoryStore) RecentErrors(n int) []ErrorEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]ErrorEntry, 0, n)
+	for i := len(s.Data.Errors) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, s.Data.Errors[i])
+	}
+	return out
+}
+
+// Funnel counts the outreach stages of the profiles invited in campaign, or
+// of every invited profile when campaign is "". Withdrawn invitations still
+// count as sent.
+func (s *MemoryStore) Funnel(campaign string) Funnel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invited := make(map[string]bool)
+	if campaign != "" {
+		for url := range s.Data.Campaigns[campaign].Actions["connect"] {
+			invited[url] = true
+		}
+	} else {
+		for url := range s.Data.Requests {
+			invited[url] = true
+		}
+		for url := range s.Data.Withdrawn {
+			invited[url] = true
+		}
+	}
+
+	var f Funnel
+	for url := range invited {
+		f.Sent++
+		if _, ok := s.Data.Connections[url]; !ok {
+			continue
+		}
+		f.Accepted++
+		if _, ok := s.Data.Messages[url]; !ok {
+			continue
+		}
+		f.Messaged++
+		if _, ok := s.Data.Replies[url]; ok {
+			f.Replied++
+		}
+	}
+	return f
+}
+
+// NoteAcceptance is how the invitations sent with one note template fared
+type NoteAcceptance struct {
+	ID       string `json:"id"`
+	Template string `json:"template"`
+	Sent     int    `json:"sent"`
+	Accepted int    `json:"accepted"`
+	Pending  int    `json:"pending"`
+}
+
+// AcceptanceByNote counts the invitations of each note template, most sent
+// first. Invitations sent before notes were recorded are not included.
+func (s *MemoryStore) AcceptanceByNote() []NoteAcceptance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byID := make(map[string]*NoteAcceptance)
+	for _, attempts := range s.Data.Attempts {
+		for _, a := range attempts {
+			if a.Note == "" {
+				continue
+			}
+			n, ok := byID[a.Note]
+			if !ok {
+				n = &NoteAcceptance{ID: a.Note, Template: s.Data.Notes[a.Note]}
+				byID[a.Note] = n
+			}
+			n.Sent++
+			if !a.AcceptedAt.IsZero() {
+				n.Accepted++
+			} else if a.pending() {
+				n.Pending++
+			}
+		}
+	}
+
+	out := make([]NoteAcceptance, 0, len(byID))
+	for _, n := range byID {
+		out = append(out, *n)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Sent != out[j].Sent {
+			return out[i].Sent > out[j].Sent
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// CampaignNames lists the campaigns with recorded actions, sorted
+func (s *MemoryStore) CampaignNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.Data.Campaigns))
+	for name := range s.Data.Campaigns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
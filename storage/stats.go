@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats summarises the stored state for the status command
+type Stats struct {
+	FirstRun           time.Time `json:"first_run"`
+	Requests           int       `json:"requests"`
+	RequestsToday      int       `json:"requests_today"`
+	RequestsThisWeek   int       `json:"requests_this_week"`
+	Connections        int       `json:"connections"`
+	Messages           int       `json:"messages"`
+	MessagesToday      int       `json:"messages_today"`
+	QueuedMessages     int       `json:"queued_messages"`
+	PendingTargets     int       `json:"pending_targets"`
+	Withdrawn          int       `json:"withdrawn"`
+	Endorsed           int       `json:"endorsed"`
+	EndorsedToday      int       `json:"endorsed_today"`
+	Viewed             int       `json:"viewed"`
+	ViewedToday        int       `json:"viewed_today"`
+	LoginFailuresToday int       `json:"login_failures_today"`
+
+	// Campaigns counts each campaign's actions by type
+	Campaigns map[string]map[string]int `json:"campaigns"`
+}
+
+// Record is everything stored about one profile, for exports
+type Record struct {
+	ProfileURL  string    `json:"profile_url"`
+	RequestedAt time.Time `json:"requested_at,omitzero"`
+	ConnectedAt time.Time `json:"connected_at,omitzero"`
+	MessagedAt  time.Time `json:"messaged_at,omitzero"`
+	WithdrawnAt time.Time `json:"withdrawn_at,omitzero"`
+	EndorsedAt  time.Time `json:"endorsed_at,omitzero"`
+	ViewedAt    time.Time `json:"viewed_at,omitzero"`
+	RepliedAt   time.Time `json:"replied_at,omitzero"`
+	Campaigns   []string  `json:"campaigns,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Keyword     string    `json:"keyword,omitempty"`
+}
+
+// Stats counts requests, messages and queue entries; "this week" is the last 7 days
+func (s *MemoryStore) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+
+	st := Stats{
+		FirstRun:           s.Data.FirstRun,
+		Requests:           len(s.Data.Requests),
+		Connections:        len(s.Data.Connections),
+		Messages:           len(s.Data.Messages),
+		QueuedMessages:     len(s.Data.PendingMessages),
+		Withdrawn:          len(s.Data.Withdrawn),
+		Endorsed:           len(s.Data.Endorsements),
+		Viewed:             len(s.Data.Views),
+		LoginFailuresToday: s.Data.LoginFailures[today],
+		Campaigns:          make(map[string]map[string]int),
+	}
+	for _, q := range s.Data.PendingTargets {
+		st.PendingTargets += q.Pending()
+	}
+	for name, cs := range s.Data.Campaigns {
+		counts := make(map[string]int)
+		for action, urls := range cs.Actions {
+			counts[action] = len(urls)
+		}
+		st.Campaigns[name] = counts
+	}
+	for _, t := range s.Data.Requests {
+		if t.Format("2006-01-02") == today {
+			st.RequestsToday++
+		}
+		if t.After(weekAgo) {
+			st.RequestsThisWeek++
+		}
+	}
+	for _, t := range s.Data.Messages {
+		if t.Format("2006-01-02") == today {
+			st.MessagesToday++
+		}
+	}
+	for _, t := range s.Data.Endorsements {
+		if t.Format("2006-01-02") == today {
+			st.EndorsedToday++
+		}
+	}
+	for _, t := range s.Data.Views {
+		if t.Format("2006-01-02") == today {
+			st.ViewedToday++
+		}
+	}
+	return st
+}
+
+// Records returns one Record per known profile, sorted by URL
+func (s *MemoryStore) Records() []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byURL := make(map[string]*Record)
+	get := func(url string) *Record {
+		r, ok := byURL[url]
+		if !ok {
+			r = &Record{ProfileURL: url}
+			byURL[url] = r
+		}
+		return r
+	}
+	for url, t := range s.Data.Requests {
+		get(url).RequestedAt = t
+	}
+	for url, t := range s.Data.Connections {
+		get(url).ConnectedAt = t
+	}
+	for url, t := range s.Data.Messages {
+		get(url).MessagedAt = t
+	}
+	for url, t := range s.Data.Withdrawn {
+		get(url).WithdrawnAt = t
+	}
+	for url, t := range s.Data.Endorsements {
+		get(url).EndorsedAt = t
+	}
+	for url, t := range s.Data.Views {
+		get(url).ViewedAt = t
+	}
+	for url, t := range s.Data.Replies {
+		get(url).RepliedAt = t
+	}
+	for url, meta := range s.Data.Profiles {
+		r := get(url)
+		r.Campaigns = meta.Campaigns
+		r.Tags = meta.Tags
+		r.Keyword = meta.Keyword
+	}
+
+	records := make([]Record, 0, len(byURL))
+	for _, r := range byURL {
+		records = append(records, *r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ProfileURL < records[j].ProfileURL })
+	return records
+}
@@ -2,22 +2,88 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"sort"
 	"sync"
 	"time"
+
+	"linkedin-automation/profile"
 )
 
 // DataStore defines the interface for persistence
 type DataStore interface {
 	SaveRequest(profileURL string) error
 	IsRequestSent(profileURL string) bool
+	RequestTime(profileURL string) time.Time
+	RecordInvite() error
+	InvitesSince(t time.Time) int
+	MarkWithdrawn(profileURL string) error
+	UndoInvite(profileURL string) error
+	WithdrawnAt(profileURL string) time.Time
+	Attempts(profileURL string) []Attempt
+	SetInviteNote(profileURL, template string) error
+	MarkEndorsed(profileURL string) error
+	EndorsedAt(profileURL string) time.Time
+	EndorsementsSince(t time.Time) int
+	MarkViewed(profileURL string) error
+	ViewedAt(profileURL string) time.Time
+	ViewsSince(t time.Time) int
+	MarkCongratulated(profileURL, event string) error
+	CongratulatedAt(profileURL, event string) time.Time
+	CongratsSince(kind string, t time.Time) int
 
 	SaveMessage(profileURL string) error
 	IsMessaged(profileURL string) bool
+	MarkReplied(profileURL string) error
+	SequenceProgressFor(sequence, profileURL string) SequenceProgress
+	SetSequenceProgress(sequence, profileURL string, p SequenceProgress) error
+	HasReplied(profileURL string) bool
 
 	SaveConnection(profileURL string) error
 	IsConnected(profileURL string) bool
 
+	FirstRun() (time.Time, error)
+	LastMessageTime() time.Time
+
+	RecordVisit(profileURL, action string) error
+	LastVisit(profileURL, action string) time.Time
+
+	QueueMessage(profileURL, template string) error
+	QueuedMessages() []QueuedMessage
+	RemoveQueuedMessage(profileURL string) error
+
+	SetQueue(workflow, key string, targets []PendingTarget) error
+	Queue(workflow string) (WorkQueue, bool)
+	ResumableQueue(workflow, key string, maxAge time.Duration) (WorkQueue, bool)
+	SetTargetStatus(workflow, profileURL, status, errMsg string) error
+	ClearQueue(workflow string) error
+
+	RecordLoginFailure() (int, error)
+	LoginFailuresToday() int
+
+	TagProfile(profileURL, campaign string, tags ...string) error
+	GetProfileMeta(profileURL string) (ProfileMeta, bool)
+	InCampaign(profileURL, campaign string) bool
+	SetKeyword(profileURL, keyword string) error
+	RecordCampaignAction(campaign, action, profileURL string) error
+	CampaignActionsSince(campaign, action string, since time.Time) int
+	KeywordRequestsToday(keyword string) int
+	AssignVariant(campaign, kind, profileURL, variant string) error
+	VariantOf(campaign, kind, profileURL string) string
+
+	Exclude(kind, value string) error
+	Exclusions() Exclusions
+
+	RecordAction(action string) error
+	ActionsSince(action string, t time.Time) int
+	LastAction(action string) time.Time
+
+	RecordError(action, profileURL, message string) error
+	Claim(campaign, profileURL string) (string, error)
+	Release(campaign, profileURL string) error
+	ClaimedBy(campaign, profileURL string) string
+
 	Close() error
 }
 
@@ -26,24 +92,151 @@ type MemoryStore struct {
 	mu   sync.RWMutex
 	File string
 	Data StateData
+
+	backupDir   string
+	backupKeep  int
+	backupEvery int
+	saves       int
+
+	lock *fileLock
+
+	// backend is set when the state lives in Postgres instead of File
+	backend *postgres
+
+	// auditFile receives the audit log when set, see EnableAudit
+	auditFile string
+
+	// claims is set when accounts share a claims file (see EnableClaims) or
+	// a Postgres database
+	claims claimer
+
+	// OnAccepted, when set, is called when a profile with a recorded
+	// invitation becomes a connection, with the time it was sent
+	OnAccepted func(profileURL string, sent time.Time)
+	// OnReplied, when set, is called when a connection is first seen replying.
+	// Both run under the store's lock and must not call back into it.
+	OnReplied func(profileURL string)
+}
+
+// ProfileMeta holds segmentation info for a stored profile
+type ProfileMeta struct {
+	Campaigns []string `json:"campaigns,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+
+	// Keyword is the search that surfaced the profile
+	Keyword string `json:"keyword,omitempty"`
+}
+
+// QueuedMessage is a follow-up waiting for a flush-messages run
+type QueuedMessage struct {
+	ProfileURL string    `json:"profile_url"`
+	Template   string    `json:"template"`
+	QueuedAt   time.Time `json:"queued_at"`
 }
 
 type StateData struct {
 	Requests    map[string]time.Time `json:"requests"`
 	Messages    map[string]time.Time `json:"messages"`
 	Connections map[string]time.Time `json:"connections"`
+	FirstRun    time.Time            `json:"first_run"`
+
+	// LastMessageAt is the time of the most recent message to anyone
+	LastMessageAt time.Time              `json:"last_message_at"`
+	Profiles      map[string]ProfileMeta `json:"profiles"`
+
+	// Visits holds the last visit time per profile and action type
+	Visits map[string]map[string]time.Time `json:"visits"`
+
+	// PendingMessages are deferred follow-ups keyed by profile URL
+	PendingMessages map[string]QueuedMessage `json:"pending_messages"`
+
+	// LoginFailures counts failed logins keyed by local date (2006-01-02)
+	LoginFailures map[string]int `json:"login_failures"`
+
+	// SentInvites are the send times of invitations in the last 7 days, for the
+	// rolling daily/weekly limits. Unlike Requests, withdrawals don't remove entries.
+	SentInvites []time.Time `json:"sent_invites"`
+
+	// Withdrawn holds when a pending request to a profile was withdrawn
+	Withdrawn map[string]time.Time `json:"withdrawn"`
+
+	// Attempts is each profile's invitation history, for withdraw-then-retry
+	Attempts map[string][]Attempt `json:"attempts"`
+
+	// Notes maps the note template IDs used in Attempts to their text
+	Notes map[string]string `json:"notes"`
+
+	// Sequences tracks drip progress: sequence name -> profile URL -> progress
+	Sequences map[string]map[string]SequenceProgress `json:"sequences"`
+
+	// Replies holds when a connection was first seen to have replied
+	Replies map[string]time.Time `json:"replies"`
+
+	// Campaigns partitions actions per campaign for independent limits and progress
+	Campaigns map[string]CampaignState `json:"campaigns"`
+
+	// Endorsements holds when a connection's skills were last endorsed
+	Endorsements map[string]time.Time `json:"endorsements"`
+
+	// Views holds when a target's profile was viewed by the view command
+	Views map[string]time.Time `json:"views"`
+
+	// Congrats holds when a connection was congratulated, per occasion
+	Congrats map[string]map[string]time.Time `json:"congrats"`
+
+	// PendingTargets are the work queues of the connect and message
+	// workflows, by workflow, so an interrupted run resumes where it stopped
+	PendingTargets map[string]WorkQueue `json:"pending_targets"`
+
+	// Exclusions are added with the exclude command, on top of config's blacklist
+	Exclusions Exclusions `json:"exclusions"`
+
+	// Actions are the times of rate-limited actions in the last 7 days, by action type
+	Actions map[string][]time.Time `json:"actions"`
+
+	// Errors are the most recent failed actions and runs, oldest first
+	Errors []ErrorEntry `json:"errors"`
+}
+
+// SequenceProgress is how far a connection is through a drip sequence.
+// Step is the number of steps already sent.
+type SequenceProgress struct {
+	Step       int       `json:"step"`
+	LastSentAt time.Time `json:"last_sent_at"`
 }
 
-// NewJSONStore creates a new store backed by a JSON file
+// CampaignState holds a campaign's actions: action type -> profile URL -> time
+type CampaignState struct {
+	Actions map[string]map[string]time.Time `json:"actions"`
+
+	// Variants holds the A/B template variant per kind and profile URL
+	Variants map[string]map[string]string `json:"variants,omitempty"`
+}
+
+// NewJSONStore creates a new store backed by a JSON file.
+// It fails with ErrLocked if another instance already has the file open.
 func NewJSONStore(filepath string) (*MemoryStore, error) {
-	s := &MemoryStore{
-		File: filepath,
-		Data: StateData{
-			Requests:    make(map[string]time.Time),
-			Messages:    make(map[string]time.Time),
-			Connections: make(map[string]time.Time),
-		},
+	return Open(filepath, 0)
+}
+
+// Open is NewJSONStore, waiting up to lockWait for another instance to release the file
+func Open(filepath string, lockWait time.Duration) (*MemoryStore, error) {
+	lock, err := acquireLock(filepath, lockWait)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := load(filepath)
+	if err != nil {
+		lock.release()
+		return nil, err
 	}
+	s.lock = lock
+	return s, nil
+}
+
+func load(filepath string) (*MemoryStore, error) {
+	s := newMemoryStore(filepath)
 
 	// Load if exists
 	if _, err := os.Stat(filepath); err == nil {
@@ -51,7 +244,7 @@ func NewJSONStore(filepath string) (*MemoryStore, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := json.Unmarshal(content, &s.Data); err != nil {
+		if err := s.decode(content); err != nil {
 			return nil, err
 		}
 	}
@@ -59,12 +252,130 @@ func NewJSONStore(filepath string) (*MemoryStore, error) {
 	return s, nil
 }
 
+// newMemoryStore creates an empty store saving to file
+func newMemoryStore(file string) *MemoryStore {
+	return &MemoryStore{
+		File: file,
+		Data: StateData{
+			Requests:    make(map[string]time.Time),
+			Messages:    make(map[string]time.Time),
+			Connections: make(map[string]time.Time),
+			Profiles:    make(map[string]ProfileMeta),
+
+			LoginFailures:   make(map[string]int),
+			PendingMessages: make(map[string]QueuedMessage),
+			Visits:          make(map[string]map[string]time.Time),
+			Withdrawn:       make(map[string]time.Time),
+			Attempts:        make(map[string][]Attempt),
+			Notes:           make(map[string]string),
+			Campaigns:       make(map[string]CampaignState),
+			Replies:         make(map[string]time.Time),
+			Sequences:       make(map[string]map[string]SequenceProgress),
+			Endorsements:    make(map[string]time.Time),
+			Views:           make(map[string]time.Time),
+			Congrats:        make(map[string]map[string]time.Time),
+			PendingTargets:  make(map[string]WorkQueue),
+			Actions:         make(map[string][]time.Time),
+		},
+	}
+}
+
+// decode replaces the state with saved JSON state
+func (s *MemoryStore) decode(content []byte) error {
+	if err := json.Unmarshal(content, &s.Data); err != nil {
+		return err
+	}
+	// Older state files predate profile metadata
+	if s.Data.Profiles == nil {
+		s.Data.Profiles = make(map[string]ProfileMeta)
+	}
+	if s.Data.LoginFailures == nil {
+		s.Data.LoginFailures = make(map[string]int)
+	}
+	if s.Data.PendingMessages == nil {
+		s.Data.PendingMessages = make(map[string]QueuedMessage)
+	}
+	if s.Data.Visits == nil {
+		s.Data.Visits = make(map[string]map[string]time.Time)
+	}
+	if s.Data.Withdrawn == nil {
+		s.Data.Withdrawn = make(map[string]time.Time)
+	}
+	if s.Data.Attempts == nil {
+		s.Data.Attempts = make(map[string][]Attempt)
+	}
+	if s.Data.Notes == nil {
+		s.Data.Notes = make(map[string]string)
+	}
+	if s.Data.Campaigns == nil {
+		s.Data.Campaigns = make(map[string]CampaignState)
+	}
+	if s.Data.Replies == nil {
+		s.Data.Replies = make(map[string]time.Time)
+	}
+	if s.Data.Sequences == nil {
+		s.Data.Sequences = make(map[string]map[string]SequenceProgress)
+	}
+	if s.Data.Endorsements == nil {
+		s.Data.Endorsements = make(map[string]time.Time)
+	}
+	if s.Data.Views == nil {
+		s.Data.Views = make(map[string]time.Time)
+	}
+	if s.Data.Congrats == nil {
+		s.Data.Congrats = make(map[string]map[string]time.Time)
+	}
+	if s.Data.PendingTargets == nil {
+		s.Data.PendingTargets = make(map[string]WorkQueue)
+	}
+	if s.Data.Actions == nil {
+		s.Data.Actions = make(map[string][]time.Time)
+	}
+	if s.Data.SentInvites == nil {
+		s.Data.SentInvites = seedInvites(s.Data.Requests)
+	}
+	s.Data.canonicalize()
+	return nil
+}
+
+// seedInvites rebuilds the last week's invitation send times from the
+// requests, for state files from before they were recorded
+func seedInvites(requests map[string]time.Time) []time.Time {
+	since := time.Now().Add(-inviteWindow)
+	invites := make([]time.Time, 0)
+	for _, t := range requests {
+		if t.After(since) {
+			invites = append(invites, t)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].Before(invites[j]) })
+	return invites
+}
+
 func (s *MemoryStore) persist() error {
+	if s.backend != nil {
+		return s.persistPostgres()
+	}
 	data, err := json.MarshalIndent(s.Data, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(s.File, data, 0644)
+	// Write a temp file and rename it over the state so an interrupted save
+	// never leaves a truncated state.json behind
+	tmp := s.File + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.File); err != nil {
+		return err
+	}
+
+	// Periodic backups are best-effort, the save itself succeeded
+	s.saves++
+	if s.backupEvery > 0 && s.saves%s.backupEvery == 0 {
+		s.backup()
+	}
+	return nil
 }
 
 // SaveRequest records a sent connection request
@@ -72,51 +383,500 @@ func (s *MemoryStore) SaveRequest(profileURL string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Data.Requests[profileURL] = time.Now()
-	return s.persist()
+	key, now := profile.Canonical(profileURL), time.Now()
+	s.Data.Requests[key] = now
+	s.openAttempt(key, now)
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgConnect, key, "", now)
 }
 
 func (s *MemoryStore) IsRequestSent(profileURL string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	_, exists := s.Data.Requests[profileURL]
+	_, exists := s.Data.Requests[profile.Canonical(profileURL)]
 	return exists
 }
 
+// RequestTime returns when a request was sent, zero if it wasn't
+func (s *MemoryStore) RequestTime(profileURL string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Requests[profile.Canonical(profileURL)]
+}
+
+// inviteWindow is how long invite send times are kept
+const inviteWindow = 7 * 24 * time.Hour
+
+// RecordInvite logs an invitation send, dropping entries outside the rolling window
+func (s *MemoryStore) RecordInvite() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	kept := s.Data.SentInvites[:0]
+	for _, t := range s.Data.SentInvites {
+		if now.Sub(t) < inviteWindow {
+			kept = append(kept, t)
+		}
+	}
+	s.Data.SentInvites = append(kept, now)
+	return s.persist()
+}
+
+// InvitesSince counts invitations sent at or after t (at most 7 days back)
+func (s *MemoryStore) InvitesSince(t time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, sent := range s.Data.SentInvites {
+		if !sent.Before(t) {
+			count++
+		}
+	}
+	return count
+}
+
+// MarkWithdrawn removes a pending request and records when it was withdrawn
+func (s *MemoryStore) MarkWithdrawn(profileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, now := profile.Canonical(profileURL), time.Now()
+	if sent, ok := s.Data.Requests[key]; ok {
+		// Pending before attempts were recorded
+		s.openAttempt(key, sent)
+	}
+	delete(s.Data.Requests, key)
+	s.Data.Withdrawn[key] = now
+	s.closeAttempt(key, now)
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgWithdraw, key, "", now)
+}
+
+// WithdrawnAt returns when a request to the profile was withdrawn, zero if never
+func (s *MemoryStore) WithdrawnAt(profileURL string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Withdrawn[profile.Canonical(profileURL)]
+}
+
+// MarkEndorsed records that a connection's skills were endorsed
+func (s *MemoryStore) MarkEndorsed(profileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, now := profile.Canonical(profileURL), time.Now()
+	s.Data.Endorsements[key] = now
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgEndorse, key, "", now)
+}
+
+// EndorsedAt returns when the profile was endorsed, zero if never
+func (s *MemoryStore) EndorsedAt(profileURL string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Endorsements[profile.Canonical(profileURL)]
+}
+
+// EndorsementsSince counts profiles endorsed after t
+func (s *MemoryStore) EndorsementsSince(t time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, at := range s.Data.Endorsements {
+		if at.After(t) {
+			n++
+		}
+	}
+	return n
+}
+
+// MarkViewed records that a target's profile was viewed
+func (s *MemoryStore) MarkViewed(profileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, now := profile.Canonical(profileURL), time.Now()
+	s.Data.Views[key] = now
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgView, key, "", now)
+}
+
+// ViewedAt returns when the profile was viewed, zero if never
+func (s *MemoryStore) ViewedAt(profileURL string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Views[profile.Canonical(profileURL)]
+}
+
+// ViewsSince counts profiles viewed after t
+func (s *MemoryStore) ViewsSince(t time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, at := range s.Data.Views {
+		if at.After(t) {
+			n++
+		}
+	}
+	return n
+}
+
 // SaveMessage records a sent message
 func (s *MemoryStore) SaveMessage(profileURL string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Data.Messages[profileURL] = time.Now()
+	key, now := profile.Canonical(profileURL), time.Now()
+	s.Data.Messages[key] = now
+	s.Data.LastMessageAt = now
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgMessage, key, "", now)
+}
+
+// MarkReplied records that the connection has replied, keeping the first time seen
+func (s *MemoryStore) MarkReplied(profileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := profile.Canonical(profileURL)
+	if _, exists := s.Data.Replies[key]; exists {
+		return nil
+	}
+	s.Data.Replies[key] = time.Now()
+	if s.OnReplied != nil {
+		s.OnReplied(key)
+	}
+	return s.persist()
+}
+
+// HasReplied reports whether the connection was seen replying
+func (s *MemoryStore) HasReplied(profileURL string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.Data.Replies[profile.Canonical(profileURL)]
+	return exists
+}
+
+// SequenceProgressFor returns a connection's progress through a sequence, zero if not started
+func (s *MemoryStore) SequenceProgressFor(sequence, profileURL string) SequenceProgress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Sequences[sequence][profile.Canonical(profileURL)]
+}
+
+// SetSequenceProgress records a connection's progress through a sequence
+func (s *MemoryStore) SetSequenceProgress(sequence, profileURL string, p SequenceProgress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Data.Sequences[sequence] == nil {
+		s.Data.Sequences[sequence] = make(map[string]SequenceProgress)
+	}
+	s.Data.Sequences[sequence][profile.Canonical(profileURL)] = p
 	return s.persist()
 }
 
+// LastMessageTime returns when the last message was sent to anyone
+func (s *MemoryStore) LastMessageTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.LastMessageAt
+}
+
 func (s *MemoryStore) IsMessaged(profileURL string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	_, exists := s.Data.Messages[profileURL]
+	_, exists := s.Data.Messages[profile.Canonical(profileURL)]
 	return exists
 }
 
-// SaveConnection records a confirmed connection
+// SaveConnection records a confirmed connection, first time seen only. A
+// pending invitation to the profile is marked accepted.
 func (s *MemoryStore) SaveConnection(profileURL string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Data.Connections[profileURL] = time.Now()
-	return s.persist()
+	key, now := profile.Canonical(profileURL), time.Now()
+	if _, ok := s.Data.Connections[key]; ok {
+		return nil
+	}
+	s.Data.Connections[key] = now
+	s.acceptAttempt(key, now)
+	if sent, ok := s.Data.Requests[key]; ok && s.OnAccepted != nil {
+		s.OnAccepted(key, sent)
+	}
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(pgConnected, key, "", now)
 }
 
 func (s *MemoryStore) IsConnected(profileURL string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	_, exists := s.Data.Connections[profileURL]
+	_, exists := s.Data.Connections[profile.Canonical(profileURL)]
 	return exists
 }
 
-func (s *MemoryStore) Close() error {
+// FirstRun returns the date the account was first used with the bot,
+// recording now if it has not been set yet
+func (s *MemoryStore) FirstRun() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Data.FirstRun.IsZero() {
+		s.Data.FirstRun = time.Now()
+		if err := s.persist(); err != nil {
+			return s.Data.FirstRun, err
+		}
+	}
+	return s.Data.FirstRun, nil
+}
+
+// RecordVisit records that a profile was visited for the given action
+func (s *MemoryStore) RecordVisit(profileURL, action string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := profile.Canonical(profileURL)
+	if s.Data.Visits[key] == nil {
+		s.Data.Visits[key] = make(map[string]time.Time)
+	}
+	s.Data.Visits[key][action] = time.Now()
+	return s.persist()
+}
+
+// LastVisit returns the last visit time for the action, or for any action if action is empty.
+// Zero time means never visited.
+func (s *MemoryStore) LastVisit(profileURL, action string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	visits := s.Data.Visits[profile.Canonical(profileURL)]
+	if action != "" {
+		return visits[action]
+	}
+	var last time.Time
+	for _, t := range visits {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// QueueMessage stores a deferred follow-up, replacing any earlier entry for the profile
+func (s *MemoryStore) QueueMessage(profileURL, template string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+
+	key := profile.Canonical(profileURL)
+	s.Data.PendingMessages[key] = QueuedMessage{
+		ProfileURL: key,
+		Template:   template,
+		QueuedAt:   time.Now(),
+	}
 	return s.persist()
 }
+
+// QueuedMessages returns pending follow-ups, oldest first
+func (s *MemoryStore) QueuedMessages() []QueuedMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	queue := make([]QueuedMessage, 0, len(s.Data.PendingMessages))
+	for _, m := range s.Data.PendingMessages {
+		queue = append(queue, m)
+	}
+	sort.Slice(queue, func(i, j int) bool { return queue[i].QueuedAt.Before(queue[j].QueuedAt) })
+	return queue
+}
+
+// RemoveQueuedMessage drops a follow-up from the queue
+func (s *MemoryStore) RemoveQueuedMessage(profileURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Data.PendingMessages, profile.Canonical(profileURL))
+	return s.persist()
+}
+
+// RecordLoginFailure increments today's failed login count and returns it.
+// Older days are dropped so the count resets at midnight.
+func (s *MemoryStore) RecordLoginFailure() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	for day := range s.Data.LoginFailures {
+		if day != today {
+			delete(s.Data.LoginFailures, day)
+		}
+	}
+	s.Data.LoginFailures[today]++
+	return s.Data.LoginFailures[today], s.persist()
+}
+
+// LoginFailuresToday returns the number of failed logins recorded today
+func (s *MemoryStore) LoginFailuresToday() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.LoginFailures[time.Now().Format("2006-01-02")]
+}
+
+// TagProfile records that a profile belongs to a campaign and adds tags.
+// Empty campaign or tags are ignored; existing values are not duplicated.
+func (s *MemoryStore) TagProfile(profileURL, campaign string, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := profile.Canonical(profileURL)
+	meta := s.Data.Profiles[key]
+	if campaign != "" {
+		meta.Campaigns = appendUnique(meta.Campaigns, campaign)
+	}
+	for _, t := range tags {
+		if t != "" {
+			meta.Tags = appendUnique(meta.Tags, t)
+		}
+	}
+	s.Data.Profiles[key] = meta
+	return s.persist()
+}
+
+// GetProfileMeta returns the segmentation info for a profile
+func (s *MemoryStore) GetProfileMeta(profileURL string) (ProfileMeta, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	meta, exists := s.Data.Profiles[profile.Canonical(profileURL)]
+	return meta, exists
+}
+
+// InCampaign reports whether a profile was already actioned in the given campaign
+func (s *MemoryStore) InCampaign(profileURL, campaign string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.Data.Profiles[profile.Canonical(profileURL)].Campaigns {
+		if c == campaign {
+			return true
+		}
+	}
+	return false
+}
+
+// SetKeyword records the search keyword that surfaced a profile
+func (s *MemoryStore) SetKeyword(profileURL, keyword string) error {
+	if keyword == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := profile.Canonical(profileURL)
+	meta := s.Data.Profiles[key]
+	meta.Keyword = keyword
+	s.Data.Profiles[key] = meta
+	return s.persist()
+}
+
+// KeywordRequestsToday counts connection requests sent today to profiles surfaced by keyword
+func (s *MemoryStore) KeywordRequestsToday(keyword string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	y, m, d := time.Now().Date()
+	count := 0
+	for url, sent := range s.Data.Requests {
+		if sy, sm, sd := sent.Date(); sy != y || sm != m || sd != d {
+			continue
+		}
+		if s.Data.Profiles[url].Keyword == keyword {
+			count++
+		}
+	}
+	return count
+}
+
+// RecordCampaignAction records an action on a profile in the campaign's partition
+func (s *MemoryStore) RecordCampaignAction(campaign, action, profileURL string) error {
+	if campaign == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs := s.Data.Campaigns[campaign]
+	if cs.Actions == nil {
+		cs.Actions = make(map[string]map[string]time.Time)
+	}
+	if cs.Actions[action] == nil {
+		cs.Actions[action] = make(map[string]time.Time)
+	}
+	key, now := profile.Canonical(profileURL), time.Now()
+	cs.Actions[action][key] = now
+	s.Data.Campaigns[campaign] = cs
+	if err := s.persist(); err != nil {
+		return err
+	}
+	return s.record(action, key, campaign, now)
+}
+
+// CampaignActionsSince counts the campaign's actions of a type at or after since
+func (s *MemoryStore) CampaignActionsSince(campaign, action string, since time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, t := range s.Data.Campaigns[campaign].Actions[action] {
+		if !t.Before(since) {
+			count++
+		}
+	}
+	return count
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, existing := range list {
+		if existing == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.unlock()
+	err := s.persist()
+	if s.backend != nil {
+		// Closing flushes the document just queued and releases the account
+		return errors.Join(err, s.backend.close())
+	}
+	if err != nil {
+		return err
+	}
+	return s.backup()
+}
+
+// unlock releases the instance lock; safe to call more than once
+func (s *MemoryStore) unlock() {
+	if s.lock != nil {
+		s.lock.release()
+		s.lock = nil
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"sort"
+
+	"linkedin-automation/profile"
+)
+
+// VariantStats is how one template variant of a campaign performed. Notes
+// count Sent and Accepted, messages Sent and Replied.
+type VariantStats struct {
+	Kind     string `json:"kind"`
+	Variant  string `json:"variant"`
+	Assigned int    `json:"assigned"`
+	Sent     int    `json:"sent"`
+	Accepted int    `json:"accepted,omitempty"`
+	Replied  int    `json:"replied,omitempty"`
+}
+
+// AssignVariant records the variant of a template kind ("note" or "message")
+// a profile gets in a campaign
+func (s *MemoryStore) AssignVariant(campaign, kind, profileURL, variant string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs := s.Data.Campaigns[campaign]
+	if cs.Variants == nil {
+		cs.Variants = make(map[string]map[string]string)
+	}
+	if cs.Variants[kind] == nil {
+		cs.Variants[kind] = make(map[string]string)
+	}
+	cs.Variants[kind][profile.Canonical(profileURL)] = variant
+	s.Data.Campaigns[campaign] = cs
+	return s.persist()
+}
+
+// VariantOf returns the variant assigned to a profile, "" if none
+func (s *MemoryStore) VariantOf(campaign, kind, profileURL string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Data.Campaigns[campaign].Variants[kind][profile.Canonical(profileURL)]
+}
+
+// VariantReport counts each variant's profiles through the campaign: a note
+// is sent with the campaign's connection request and accepted when the
+// profile connects; a message is sent and replied to. Sorted by kind and name.
+func (s *MemoryStore) VariantReport(campaign string) []VariantStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cs := s.Data.Campaigns[campaign]
+	var out []VariantStats
+	for kind, assigned := range cs.Variants {
+		byName := make(map[string]*VariantStats)
+		for url, name := range assigned {
+			v, ok := byName[name]
+			if !ok {
+				v = &VariantStats{Kind: kind, Variant: name}
+				byName[name] = v
+			}
+			v.Assigned++
+			switch kind {
+			case "note":
+				if _, ok := cs.Actions["connect"][url]; ok {
+					v.Sent++
+					if _, ok := s.Data.Connections[url]; ok {
+						v.Accepted++
+					}
+				}
+			case "message":
+				if _, ok := cs.Actions["message"][url]; ok {
+					v.Sent++
+					if _, ok := s.Data.Replies[url]; ok {
+						v.Replied++
+					}
+				}
+			}
+		}
+		for _, v := range byName {
+			out = append(out, *v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind > out[j].Kind // notes before messages
+		}
+		return out[i].Variant < out[j].Variant
+	})
+	return out
+}
@@ -0,0 +1,200 @@
+package summary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"linkedin-automation/hooks"
+)
+
+// maxErrors bounds the error messages kept in a report
+const maxErrors = 50
+
+// Report is the accounting of one run: what was searched, sent and skipped
+type Report struct {
+	Command    string        `json:"command"`
+	Account    string        `json:"account,omitempty"`
+	Status     string        `json:"status"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Duration   time.Duration `json:"-"`
+
+	Searches      int `json:"searches"`
+	ProfilesFound int `json:"profiles_found"`
+	// Candidates are the distinct profiles the run acted on, after filters
+	Candidates   int `json:"candidates"`
+	Invites      int `json:"invites_sent"`
+	Follows      int `json:"follows"`
+	Messages     int `json:"messages_sent"`
+	Endorsements int `json:"endorsements"`
+	Views        int `json:"profile_views"`
+
+	// Skips counts expected non-sends (already connected, limits...) by reason
+	Skips  map[string]int `json:"skips"`
+	Errors []Error        `json:"errors"`
+	// ErrorCount includes errors beyond the ones listed
+	ErrorCount int `json:"error_count"`
+}
+
+// Error is one unexpected failure
+type Error struct {
+	Action     string    `json:"action"`
+	ProfileURL string    `json:"profile_url,omitempty"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+}
+
+// MarshalJSON adds the duration in seconds and as text
+func (r Report) MarshalJSON() ([]byte, error) {
+	type plain Report
+	return json.Marshal(struct {
+		plain
+		DurationSeconds float64 `json:"duration_seconds"`
+		DurationText    string  `json:"duration"`
+	}{plain(r), r.Duration.Seconds(), r.Duration.Round(time.Second).String()})
+}
+
+// Collector builds a Report from action results. Start begins a run and
+// Finish ends it; results between them are counted.
+type Collector struct {
+	Account string
+
+	// Skip lists expected outcomes; failures matching one count as a skip
+	// named after it instead of an error
+	Skip []error
+
+	mu       sync.Mutex
+	report   Report
+	profiles map[string]bool
+}
+
+// New creates a Collector for the account ("" when there is only one)
+func New(account string, skip []error) *Collector {
+	return &Collector{Account: account, Skip: skip}
+}
+
+// Start resets the counters for a new run of command
+func (c *Collector) Start(command string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.report = Report{
+		Command:   command,
+		Account:   c.Account,
+		StartedAt: time.Now(),
+		Skips:     make(map[string]int),
+		Errors:    []Error{},
+	}
+	c.profiles = make(map[string]bool)
+}
+
+// Observe is a hooks.ResultHook counting the run's actions
+func (c *Collector) Observe(res hooks.ActionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.profiles == nil {
+		return
+	}
+	r := &c.report
+
+	if res.Action == hooks.ActionSearch {
+		r.Searches++
+		if n, err := strconv.Atoi(res.Metadata["found"]); err == nil {
+			r.ProfilesFound += n
+		}
+	} else if res.ProfileURL != "" && !c.profiles[res.ProfileURL] {
+		c.profiles[res.ProfileURL] = true
+		r.Candidates++
+	}
+
+	if res.Error != nil {
+		for _, e := range c.Skip {
+			if errors.Is(res.Error, e) {
+				r.Skips[e.Error()]++
+				return
+			}
+		}
+		r.ErrorCount++
+		if len(r.Errors) < maxErrors {
+			r.Errors = append(r.Errors, Error{Action: string(res.Action), ProfileURL: res.ProfileURL, Message: res.Error.Error(), Time: res.Time})
+		}
+		return
+	}
+
+	switch res.Action {
+	case hooks.ActionConnect:
+		r.Invites++
+	case hooks.ActionFollow:
+		r.Follows++
+	case hooks.ActionMessage:
+		r.Messages++
+	case hooks.ActionEndorse:
+		r.Endorsements++
+	case hooks.ActionView:
+		r.Views++
+	}
+}
+
+// Finish ends the run with its status and returns the report
+func (c *Collector) Finish(status string) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.report.Status = status
+	c.report.FinishedAt = time.Now()
+	c.report.Duration = c.report.FinishedAt.Sub(c.report.StartedAt)
+	return c.report
+}
+
+// Write saves the report as JSON into dir, named after its start time and
+// command, and returns the path
+func (r Report) Write(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := r.StartedAt.Format("20060102-150405") + "_" + r.Command
+	if r.Account != "" {
+		name += "_" + r.Account
+	}
+	path := filepath.Join(dir, name+".json")
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// Print writes the report as a table
+func (r Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\n=== Run summary: %s (%s) ===\n", r.Command, r.Status)
+	if r.Account != "" {
+		fmt.Fprintf(tw, "Account\t%s\n", r.Account)
+	}
+	fmt.Fprintf(tw, "Duration\t%s\n", r.Duration.Round(time.Second))
+	fmt.Fprintf(tw, "Searches\t%d (%d profiles found)\n", r.Searches, r.ProfilesFound)
+	fmt.Fprintf(tw, "Candidates\t%d\n", r.Candidates)
+	fmt.Fprintf(tw, "Invites sent\t%d\n", r.Invites)
+	fmt.Fprintf(tw, "Follows\t%d\n", r.Follows)
+	fmt.Fprintf(tw, "Messages sent\t%d\n", r.Messages)
+	fmt.Fprintf(tw, "Endorsements\t%d\n", r.Endorsements)
+	fmt.Fprintf(tw, "Profile views\t%d\n", r.Views)
+
+	reasons := make([]string, 0, len(r.Skips))
+	for reason := range r.Skips {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(tw, "Skipped: %s\t%d\n", reason, r.Skips[reason])
+	}
+	fmt.Fprintf(tw, "Errors\t%d\n", r.ErrorCount)
+	tw.Flush()
+}
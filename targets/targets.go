@@ -0,0 +1,164 @@
+package targets
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"linkedin-automation/profile"
+)
+
+// ErrNoURLColumn is returned when a CSV header has no recognisable profile URL column
+var ErrNoURLColumn = errors.New("no profile URL column (profile_url, url, linkedin_url) in header")
+
+// Target is a profile to contact with optional per-row template variables
+type Target struct {
+	URL  string
+	Vars map[string]string
+}
+
+// urlColumns and firstNameColumns are the accepted header names
+var (
+	urlColumns       = []string{"profile_url", "url", "linkedin_url", "linkedin"}
+	firstNameColumns = []string{"first_name", "firstname"}
+)
+
+// LoadCSV reads targets from a CSV file. With a header row, the URL column is
+// found by name, first_name fills {{firstname}} and every other column becomes
+// a template variable named after its header. Without a header, the columns are
+// URL and first name. Rows without a valid profile URL are skipped.
+func LoadCSV(path string) ([]Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSV(f)
+}
+
+// ReadCSV is LoadCSV on a reader
+func ReadCSV(r io.Reader) ([]Target, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return FromRows(rows)
+}
+
+// FromRows reads targets from spreadsheet rows the way LoadCSV reads a file
+func FromRows(rows [][]string) ([]Target, error) {
+	urlCol, headers, rows, err := splitHeader(rows)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	var targets []Target
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if urlCol >= len(row) {
+			continue
+		}
+		p, err := profile.Parse(strings.TrimSpace(row[urlCol]))
+		if err != nil || seen[p.String()] {
+			continue
+		}
+		seen[p.String()] = true
+
+		t := Target{URL: p.String(), Vars: make(map[string]string)}
+		for i, v := range row {
+			if i == urlCol || i >= len(headers) || headers[i] == "" {
+				continue
+			}
+			if v = strings.TrimSpace(v); v != "" {
+				t.Vars[headers[i]] = v
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// RowURLs returns the canonical profile URL of each data row (header
+// excluded), "" for rows without a valid one, so results can be written
+// back next to the rows they came from
+func RowURLs(rows [][]string) ([]string, error) {
+	urlCol, _, rows, err := splitHeader(rows)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, len(rows))
+	for i, row := range rows {
+		if urlCol >= len(row) {
+			continue
+		}
+		if p, err := profile.Parse(strings.TrimSpace(row[urlCol])); err == nil {
+			urls[i] = p.String()
+		}
+	}
+	return urls, nil
+}
+
+// HasHeader reports whether the first row is a header rather than a target
+func HasHeader(rows [][]string) bool {
+	return len(rows) > 0 && len(rows[0]) > 0 && !profile.IsProfile(rows[0][0])
+}
+
+// splitHeader finds the URL column and the variable names of rows, and
+// returns the data rows without the header. Without a header, the columns
+// are URL and first name.
+func splitHeader(rows [][]string) (int, []string, [][]string, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return 0, nil, nil, nil
+	}
+	urlCol, headers := 0, []string{"url", "firstname"}
+	if !HasHeader(rows) {
+		return urlCol, headers, rows, nil
+	}
+	headers = make([]string, len(rows[0]))
+	urlCol = -1
+	for i, h := range rows[0] {
+		h = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(h), " ", "_"))
+		if contains(firstNameColumns, h) {
+			h = "firstname"
+		}
+		headers[i] = h
+		if urlCol < 0 && contains(urlColumns, h) {
+			urlCol = i
+		}
+	}
+	if urlCol < 0 {
+		return 0, nil, nil, ErrNoURLColumn
+	}
+	return urlCol, headers, rows[1:], nil
+}
+
+// VarNames returns the variable names used across targets
+func VarNames(list []Target) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, t := range list {
+		for k := range t.Vars {
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+	}
+	return names
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package templates
+
+import (
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// Name styles for the {{name}} placeholder in notes
+const (
+	NameStyleFirst = "first"
+	NameStyleFull  = "full"
+	NameStyleMixed = "mixed"
+)
+
+// GenericName is used when no usable name could be extracted
+const GenericName = "there"
+
+// honorifics are dropped from the start of scraped names
+var honorifics = map[string]bool{
+	"dr": true, "mr": true, "mrs": true, "ms": true, "miss": true,
+	"prof": true, "sir": true, "eng": true, "er": true, "adv": true,
+}
+
+// Names cleans a scraped display name ("Dr. Jane Doe, PhD 🚀") and returns
+// the first and full name ("Jane", "Jane Doe"). Both are empty if nothing usable remains.
+func Names(raw string) (first, full string) {
+	// Credentials usually follow a comma or sit in parentheses
+	if i := strings.IndexAny(raw, ",|("); i >= 0 {
+		raw = raw[:i]
+	}
+
+	var tokens []string
+	for _, tok := range strings.Fields(raw) {
+		tok = strings.TrimFunc(tok, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		if tok == "" || !isNameToken(tok) {
+			continue
+		}
+		if len(tokens) == 0 && honorifics[strings.ToLower(tok)] {
+			continue
+		}
+		tokens = append(tokens, tok)
+	}
+
+	if len(tokens) == 0 {
+		return "", ""
+	}
+	return tokens[0], strings.Join(tokens, " ")
+}
+
+// isNameToken rejects tokens containing emoji or symbols
+func isNameToken(tok string) bool {
+	for _, r := range tok {
+		if !unicode.IsLetter(r) && r != '-' && r != '\'' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// DisplayName resolves the name to greet someone with according to style.
+// "mixed" varies per call between first name, full name and GenericName.
+func DisplayName(raw, style string) string {
+	first, full := Names(raw)
+	if first == "" {
+		return GenericName
+	}
+
+	switch style {
+	case NameStyleFull:
+		return full
+	case NameStyleMixed:
+		switch p := rand.Float64(); {
+		case p < 0.6:
+			return first
+		case p < 0.85:
+			return full
+		default:
+			return GenericName
+		}
+	default:
+		return first
+	}
+}
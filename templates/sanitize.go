@@ -0,0 +1,50 @@
+package templates
+
+import (
+	"strings"
+	"unicode"
+)
+
+// asciiReplacer maps typographic punctuation to plain ASCII
+var asciiReplacer = strings.NewReplacer(
+	"\u2018", "'", "\u2019", "'", "\u201A", "'", "\u2032", "'",
+	"\u201C", `"`, "\u201D", `"`, "\u201E", `"`, "\u2033", `"`,
+	"\u2013", "-", "\u2014", "-", "\u2212", "-",
+	"\u2026", "...",
+	"\u00A0", " ", "\u2009", " ", "\u202F", " ",
+)
+
+// Sanitize strips emoji and pictographs and normalises smart quotes, dashes and
+// ellipses to ASCII. Letters (including accented ones) are kept.
+func Sanitize(text string) string {
+	text = asciiReplacer.Replace(text)
+
+	var b strings.Builder
+	for _, r := range text {
+		if isEmoji(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	// Removing emoji can leave doubled spaces behind
+	lines := strings.Split(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r == '\u200D', r == '\uFE0F', r == '\uFE0E': // joiners and variation selectors
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF: // emoji, pictographs, flags
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols and dingbats
+		return true
+	case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators
+		return true
+	}
+	return unicode.Is(unicode.So, r) || unicode.Is(unicode.Cs, r)
+}
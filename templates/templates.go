@@ -0,0 +1,229 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"linkedin-automation/logger"
+	"linkedin-automation/personalize"
+)
+
+// Kinds of templates
+const (
+	KindNote    = "note"
+	KindMessage = "message"
+)
+
+// MaxNoteLength is LinkedIn's limit for connection notes
+const MaxNoteLength = 300
+
+// Rule is a single template as served by the template service
+type Rule struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+	// NoFooter disables the configured note/message footer for this template
+	NoFooter bool `json:"no_footer,omitempty"`
+}
+
+// knownPlaceholders are the variables the workflows substitute
+var knownPlaceholders = map[string]bool{
+	"name":      true,
+	"firstname": true,
+
+	// Set by the congrats workflow from catch-up notifications
+	"newcompany": true,
+	"newtitle":   true,
+	"years":      true,
+
+	// Set for targets found with --event and --post
+	"event": true,
+	"post":  true,
+}
+
+func init() {
+	AllowPlaceholders(personalize.Fields...)
+}
+
+// placeholderNames lists knownPlaceholders for the template engine
+func placeholderNames() []string {
+	names := make([]string, 0, len(knownPlaceholders))
+	for n := range knownPlaceholders {
+		names = append(names, n)
+	}
+	return names
+}
+
+// AllowPlaceholders adds variables supplied by the caller (e.g. import file
+// columns) to the placeholders Lint accepts
+func AllowPlaceholders(names ...string) {
+	for _, n := range names {
+		knownPlaceholders[n] = true
+	}
+}
+
+var placeholderRe = regexp.MustCompile(`{{\s*([a-zA-Z_]+)\s*}}`)
+
+// templateKeywords are the bare {{...}} actions that aren't placeholders
+var templateKeywords = map[string]bool{"end": true, "else": true}
+
+// Lint validates a template rule
+func Lint(r Rule) error {
+	if r.Text == "" {
+		return fmt.Errorf("template %q: empty text", r.Name)
+	}
+	if r.Kind != KindNote && r.Kind != KindMessage {
+		return fmt.Errorf("template %q: unknown kind %q", r.Name, r.Kind)
+	}
+	if r.Kind == KindNote && len([]rune(r.Text)) > MaxNoteLength {
+		return fmt.Errorf("template %q: note exceeds %d characters", r.Name, MaxNoteLength)
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(r.Text, -1) {
+		if !knownPlaceholders[m[1]] && !templateKeywords[m[1]] {
+			return fmt.Errorf("template %q: unknown placeholder %q", r.Name, m[0])
+		}
+	}
+	if err := personalize.Check(r.Text, placeholderNames()); err != nil {
+		return fmt.Errorf("template %q: %w", r.Name, err)
+	}
+	return nil
+}
+
+// LintAll validates every rule, returning the first error
+func LintAll(rules []Rule) error {
+	if len(rules) == 0 {
+		return errors.New("no templates")
+	}
+	for _, r := range rules {
+		if err := Lint(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fetch downloads template rules from url, validates them and caches them at cachePath.
+// On any fetch or validation failure it falls back to the last good cache.
+func Fetch(url, cachePath string, log logger.Logger) ([]Rule, error) {
+	rules, err := fetchRemote(url)
+	if err == nil {
+		err = LintAll(rules)
+	}
+	if err == nil {
+		if data, mErr := json.MarshalIndent(rules, "", "  "); mErr == nil {
+			if wErr := os.WriteFile(cachePath, data, 0644); wErr != nil {
+				log.Warn("Failed to cache templates", "path", cachePath, "error", wErr)
+			}
+		}
+		log.Info("Loaded remote templates", "count", len(rules), "url", url)
+		return rules, nil
+	}
+
+	log.Warn("Remote templates unavailable, using cache", "url", url, "error", err)
+	return LoadCache(cachePath)
+}
+
+// LoadCache reads and validates previously cached rules
+func LoadCache(cachePath string) ([]Rule, error) {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("no template cache: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid template cache: %w", err)
+	}
+	if err := LintAll(rules); err != nil {
+		return nil, fmt.Errorf("invalid template cache: %w", err)
+	}
+	return rules, nil
+}
+
+func fetchRemote(url string) ([]Rule, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return nil, fmt.Errorf("invalid template JSON: %w", err)
+	}
+	return rules, nil
+}
+
+// Pick returns a random template of the given kind
+func Pick(rules []Rule, kind string) (Rule, bool) {
+	var matches []Rule
+	for _, r := range rules {
+		if r.Kind == kind {
+			matches = append(matches, r)
+		}
+	}
+	if len(matches) == 0 {
+		return Rule{}, false
+	}
+	return matches[rand.Intn(len(matches))], true
+}
+
+// footerSeparator goes between the rendered body and the footer
+const footerSeparator = "\n\n"
+
+// Render fills in the placeholders (see personalize.Execute) and appends footer.
+// A template the engine rejects falls back to plain {{var}} substitution.
+// When maxLen > 0 the result is kept within maxLen characters by truncating
+// the body, never the footer.
+func Render(text string, vars map[string]string, footer string, maxLen int) string {
+	body, err := personalize.Execute(text, vars, placeholderNames())
+	if err != nil {
+		body = placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+			key := placeholderRe.FindStringSubmatch(m)[1]
+			if v, ok := vars[key]; ok {
+				return v
+			}
+			return m
+		})
+	}
+
+	return AppendFooter(body, footer, maxLen)
+}
+
+// AppendFooter appends footer to body. When maxLen > 0 the body is truncated
+// so the result stays within maxLen characters, the footer never is.
+func AppendFooter(body, footer string, maxLen int) string {
+	tail := ""
+	if footer != "" {
+		tail = footerSeparator + footer
+	}
+
+	if maxLen > 0 {
+		budget := maxLen - len([]rune(tail))
+		if budget < 0 {
+			budget = 0
+		}
+		if runes := []rune(body); len(runes) > budget {
+			body = strings.TrimSpace(string(runes[:budget]))
+		}
+	}
+
+	return body + tail
+}
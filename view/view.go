@@ -0,0 +1,138 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"linkedin-automation/browser"
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+	"linkedin-automation/personalize"
+	"linkedin-automation/profile"
+	"linkedin-automation/ratelimit"
+	"linkedin-automation/stealth"
+	"linkedin-automation/storage"
+)
+
+// Service views target profiles without acting on them, so they see a
+// "viewed your profile" notification before any invitation arrives
+type Service struct {
+	Browser *browser.Browser
+	Log     logger.Logger
+	Store   storage.DataStore
+
+	// MinDwell and MaxDwell bound the time spent on each profile
+	MinDwell time.Duration
+	MaxDwell time.Duration
+
+	// Limiter paces profile views within their budget, nil disables it
+	Limiter *ratelimit.Limiter
+
+	// Blacklist is config's exclusion list, checked together with the stored one
+	Blacklist storage.Exclusions
+
+	// OnResult is invoked after every view
+	OnResult hooks.ResultHook
+}
+
+// New creates a new View Service
+func New(b *browser.Browser, l logger.Logger, store storage.DataStore) *Service {
+	return &Service{
+		Browser:  b,
+		Log:      l,
+		Store:    store,
+		MinDwell: 20 * time.Second,
+		MaxDwell: time.Minute,
+		OnResult: hooks.Noop,
+	}
+}
+
+// Exclusions returns the blacklist merged with the state file's exclusions
+func (s *Service) Exclusions() storage.Exclusions {
+	if s.Store == nil {
+		return s.Blacklist
+	}
+	return s.Blacklist.Merge(s.Store.Exclusions())
+}
+
+// View opens a profile, reads down it for a random dwell time and leaves
+func (s *Service) View(ctx context.Context, profileURL string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dwell, err := s.view(ctx, profileURL)
+
+	result := hooks.NewResult(profileURL, hooks.ActionView, err)
+	result.Metadata["dwell"] = dwell.Round(time.Second).String()
+	s.OnResult(result)
+	return err
+}
+
+func (s *Service) view(ctx context.Context, profileURL string) (time.Duration, error) {
+	if _, err := profile.Parse(profileURL); err != nil {
+		return 0, fmt.Errorf("%w: %s", err, profileURL)
+	}
+	// Hard safety rail: never touch profiles outside the allowlist
+	if err := s.Browser.Cfg.CheckAllowed(profileURL); err != nil {
+		s.Log.Error("Refusing to view profile outside safe allowlist", "url", profileURL)
+		return 0, fmt.Errorf("%w: %s", err, profileURL)
+	}
+
+	exclusions := s.Exclusions()
+	if reason := exclusions.Match(profileURL, "", ""); reason != "" {
+		s.Log.Info("Profile is excluded, not viewing", "url", profileURL, "reason", reason)
+		return 0, fmt.Errorf("%w: %s", storage.ErrExcluded, reason)
+	}
+
+	if err := s.Limiter.Wait(ctx, ratelimit.ProfileView); err != nil {
+		return 0, err
+	}
+
+	s.Log.Info("Viewing profile", "url", profileURL)
+	started := time.Now()
+	if err := s.Browser.NavigateTo(profileURL); err != nil {
+		return 0, err
+	}
+	s.Limiter.Record(ratelimit.ProfileView)
+	if err := s.Store.RecordVisit(profileURL, string(hooks.ActionView)); err != nil {
+		s.Log.Warn("Failed to record visit", "url", profileURL, "error", err)
+	}
+
+	// Search cards don't always show the company, leave before dwelling
+	if reason := exclusions.Match(profileURL, personalize.Scrape(s.Browser.Page)["company"], personalize.Headline(s.Browser.Page)); reason != "" {
+		s.Log.Info("Profile is excluded, leaving without reading", "url", profileURL, "reason", reason)
+		return time.Since(started), fmt.Errorf("%w: %s", storage.ErrExcluded, reason)
+	}
+	s.read(ctx, s.dwell())
+
+	dwell := time.Since(started)
+	if err := s.Store.MarkViewed(profileURL); err != nil {
+		s.Log.Warn("Failed to record profile view", "url", profileURL, "error", err)
+	}
+	s.Log.Info("Profile viewed", "url", profileURL, "dwell", dwell.Round(time.Second))
+	return dwell, nil
+}
+
+// dwell picks the time to spend on a profile
+func (s *Service) dwell() time.Duration {
+	if s.MaxDwell <= s.MinDwell {
+		return s.MinDwell
+	}
+	return s.MinDwell + time.Duration(rand.Int63n(int64(s.MaxDwell-s.MinDwell)))
+}
+
+// read scrolls down the profile in uneven steps, pausing on sections and now
+// and then scrolling back up, until d has passed or ctx is done
+func (s *Service) read(ctx context.Context, d time.Duration) {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		delta := 250 + rand.Float64()*450
+		if rand.Float64() < 0.15 {
+			delta = -delta / 2
+		}
+		s.Browser.HumanScroll(delta)
+		stealth.SleepContextual(stealth.ActionTypeRead, 0.6+rand.Float64()*0.6)
+	}
+}
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"linkedin-automation/config"
+	"linkedin-automation/hooks"
+	"linkedin-automation/logger"
+)
+
+// Lifecycle events
+const (
+	InviteSent         = "invite_sent"
+	InviteAccepted     = "invite_accepted"
+	MessageSent        = "message_sent"
+	ReplyDetected      = "reply_detected"
+	CheckpointDetected = "checkpoint_detected"
+)
+
+// Events are the lifecycle events endpoints can subscribe to
+var Events = []string{InviteSent, InviteAccepted, MessageSent, ReplyDetected, CheckpointDetected}
+
+// Request headers besides Content-Type
+const (
+	EventHeader     = "X-Event"
+	DeliveryHeader  = "X-Delivery-ID"
+	SignatureHeader = "X-Signature-256"
+)
+
+// retryDelay is the wait before the first retry, doubled for each further one
+const retryDelay = 2 * time.Second
+
+// errPermanent marks answers a retry can't fix (4xx other than 408 and 429)
+var errPermanent = errors.New("rejected")
+
+// Payload is the JSON body of every delivery
+type Payload struct {
+	ID         string            `json:"id"`
+	Event      string            `json:"event"`
+	Time       time.Time         `json:"time"`
+	Account    string            `json:"account,omitempty"`
+	ProfileURL string            `json:"profile_url,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+}
+
+// Sender delivers lifecycle events to the configured endpoints in the
+// background. A nil Sender does nothing.
+type Sender struct {
+	Log logger.Logger
+	// Account, when set, is sent with every event so multi-account deployments can be told apart
+	Account string
+	Retries int
+
+	client    *http.Client
+	endpoints []config.WebhookEndpoint
+	wg        sync.WaitGroup
+}
+
+// New builds a Sender from the webhooks config section.
+// It returns nil when no endpoints are configured.
+func New(cfg *config.Config, l logger.Logger) (*Sender, error) {
+	if len(cfg.Webhooks.Endpoints) == 0 {
+		return nil, nil
+	}
+	for i, e := range cfg.Webhooks.Endpoints {
+		if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhooks.endpoints[%d]: invalid url %q", i, e.URL)
+		}
+		for _, ev := range e.Events {
+			if !slices.Contains(Events, ev) {
+				return nil, fmt.Errorf("webhooks.endpoints[%d]: unknown event %q (want one of %s)", i, ev, strings.Join(Events, ", "))
+			}
+		}
+	}
+	return &Sender{
+		Log:       l,
+		Account:   cfg.Account,
+		Retries:   cfg.Webhooks.Retries,
+		client:    &http.Client{Timeout: cfg.Webhooks.Timeout},
+		endpoints: cfg.Webhooks.Endpoints,
+	}, nil
+}
+
+// Emit sends an event to every endpoint subscribed to it without waiting for
+// the deliveries; Wait does. Failures are only logged, a webhook must never
+// stop a run.
+func (s *Sender) Emit(event, profileURL string, data map[string]string) {
+	if s == nil {
+		return
+	}
+	p := Payload{
+		ID:         newID(),
+		Event:      event,
+		Time:       time.Now().UTC(),
+		Account:    s.Account,
+		ProfileURL: profileURL,
+		Data:       data,
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		s.Log.Warn("Failed to encode webhook", "event", event, "error", err)
+		return
+	}
+	for _, e := range s.endpoints {
+		if len(e.Events) > 0 && !slices.Contains(e.Events, event) {
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.deliver(e, p, body); err != nil {
+				s.Log.Warn("Webhook delivery failed", "url", e.URL, "event", event, "error", err)
+				return
+			}
+			s.Log.Debug("Webhook delivered", "url", e.URL, "event", event)
+		}()
+	}
+}
+
+// Observe is a result hook turning sent invitations and messages into events
+func (s *Sender) Observe(r hooks.ActionResult) {
+	if r.Outcome != hooks.OutcomeSuccess {
+		return
+	}
+	switch r.Action {
+	case hooks.ActionConnect:
+		s.Emit(InviteSent, r.ProfileURL, r.Metadata)
+	case hooks.ActionMessage:
+		s.Emit(MessageSent, r.ProfileURL, r.Metadata)
+	}
+}
+
+// Wait blocks until every emitted event was delivered or gave up
+func (s *Sender) Wait() {
+	if s == nil {
+		return
+	}
+	s.wg.Wait()
+}
+
+// deliver posts body to the endpoint, retrying network errors, 5xx, 408 and 429
+func (s *Sender) deliver(e config.WebhookEndpoint, p Payload, body []byte) error {
+	delay := retryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = s.post(e, p, body); err == nil || errors.Is(err, errPermanent) || attempt >= s.Retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (s *Sender) post(e config.WebhookEndpoint, p Payload, body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, p.Event)
+	req.Header.Set(DeliveryHeader, p.ID)
+	if e.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(e.Secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		err = fmt.Errorf("%w: %w", errPermanent, err)
+	}
+	return err
+}
+
+// Sign returns the X-Signature-256 value of body: "sha256=" and the hex
+// HMAC-SHA256 of the raw body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newID returns a random delivery id receivers can deduplicate retries by
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}